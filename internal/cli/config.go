@@ -0,0 +1,359 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"filippo.io/age"
+	"github.com/KashifKhn/kassie/internal/shared/config"
+	"github.com/spf13/cobra"
+)
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Check and inspect the config file",
+		// These commands must work on a config that fails to load, so they
+		// skip the loading every other command does first.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			resolveConfigFile()
+			return nil
+		},
+	}
+
+	cmd.AddCommand(newConfigValidateCmd())
+	cmd.AddCommand(newConfigShowCmd())
+	cmd.AddCommand(newConfigSchemaCmd())
+	cmd.AddCommand(newConfigEncryptSecretCmd())
+	cmd.AddCommand(newConfigTrustCmd())
+	return cmd
+}
+
+func newConfigTrustCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "trust [dir]",
+		Short: "Load the project file in a directory",
+		Long: `Trust a directory, by default the current one, so its .kassie file is
+merged into the config. Project files are ignored until trusted: one can
+point a profile at other hosts, add server users or run cmd: secrets, so a
+cloned repository must not be able to do that on its own.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) == 1 {
+				dir = args[0]
+			}
+			if err := config.NewLoader().TrustProject(dir); err != nil {
+				return err
+			}
+			abs, _ := filepath.Abs(dir)
+			fmt.Fprintf(cmd.OutOrStdout(), "Trusted %s\n", abs)
+			return nil
+		},
+	}
+}
+
+// warnUntrusted names the project files the stack leaves out.
+func warnUntrusted(out io.Writer, loader *config.Loader) {
+	for _, path := range loader.UntrustedProjectFiles() {
+		fmt.Fprintf(out, "warning: %s: ignored until trusted with kassie config trust %s\n", path, filepath.Dir(path))
+	}
+}
+
+func newConfigValidateCmd() *cobra.Command {
+	var strict bool
+
+	cmd := &cobra.Command{
+		Use:   "validate [file]",
+		Short: "Check a config file for errors",
+		Long: `Load a config file the way kassie does and report the first problem,
+naming the profile and field it is in. Keys that no setting reads, usually
+typos, are reported as warnings.
+
+Without a file, the whole stack of system, user and project files is
+checked, merged the way kassie merges it.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+
+			var (
+				paths   []string
+				loadErr error
+			)
+			if len(args) == 1 {
+				paths = args
+				_, loadErr = config.NewLoaderWithPath(args[0]).LoadFromPath(args[0])
+			} else {
+				loader := configLoader()
+				warnUntrusted(out, loader)
+				paths = loader.Layers()
+				_, loadErr = loader.Load()
+			}
+
+			var unknown []string
+			for _, path := range paths {
+				fields, err := config.UnknownFields(path)
+				if err != nil {
+					continue
+				}
+				for _, field := range fields {
+					fmt.Fprintf(out, "warning: %s: %s: unknown field\n", path, field)
+				}
+				unknown = append(unknown, fields...)
+			}
+
+			if loadErr != nil {
+				if len(args) == 1 {
+					return fmt.Errorf("%s: %w", args[0], loadErr)
+				}
+				return loadErr
+			}
+			if strict && len(unknown) > 0 {
+				return fmt.Errorf("%d unknown field(s)", len(unknown))
+			}
+			for _, path := range paths {
+				fmt.Fprintf(out, "%s is valid\n", path)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&strict, "strict", false, "treat unknown fields as errors")
+	return cmd
+}
+
+func newConfigShowCmd() *cobra.Command {
+	var (
+		resolved bool
+		origin   bool
+		output   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Print the config with secrets masked",
+		Long: `Print the config, merged from the system, user and project files, with
+passwords and other secrets masked.
+
+With --resolved, defaults are filled in and ${VAR} references are replaced
+with their values, showing the config exactly as kassie uses it. With
+--origin, each setting is listed with the file it came from.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format := config.FormatForPath(cfgFile)
+			switch config.Format(output) {
+			case "":
+			case config.FormatJSON, config.FormatYAML, config.FormatTOML:
+				format = config.Format(output)
+			default:
+				return fmt.Errorf("unsupported output format %q (want json, yaml or toml)", output)
+			}
+
+			var (
+				cfg     *config.Config
+				origins config.Origins
+				err     error
+			)
+			loader := configLoader()
+			warnUntrusted(cmd.ErrOrStderr(), loader)
+			if resolved {
+				cfg, origins, err = loader.LoadStack()
+			} else {
+				cfg, origins, err = loader.ReadStack()
+			}
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			cfg = config.MaskSecrets(cfg)
+
+			if origin {
+				return printOrigins(cmd.OutOrStdout(), cfg, origins)
+			}
+
+			data, err := config.Encode(format, cfg)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), strings.TrimRight(string(data), "\n"))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&resolved, "resolved", false, "apply defaults and environment variables")
+	cmd.Flags().BoolVar(&origin, "origin", false, "list each setting with the file it came from")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "output format: json, yaml or toml (default: the file's format)")
+	return cmd
+}
+
+func printOrigins(out io.Writer, cfg *config.Config, origins config.Origins) error {
+	settings, err := config.Settings(cfg)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SETTING\tVALUE\tORIGIN")
+	for _, s := range settings {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Path, s.Value, origins.Origin(s.Path))
+	}
+	return w.Flush()
+}
+
+func newConfigSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema for the config file",
+		Long: `Print the JSON Schema for the config file. Point an editor at it for
+completion and checking, or reference the published copy:
+
+  ` + config.SchemaURL,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schema, err := config.Schema()
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(schema)
+			return err
+		},
+	}
+}
+
+func newConfigEncryptSecretCmd() *cobra.Command {
+	var (
+		useIdentity bool
+		recipients  []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "encrypt-secret",
+		Short: "Encrypt a password for the config file",
+		Long: `Read a secret and print it as an enc: value for a password, passphrase or
+client secret in the config file. Kassie decrypts enc: values as the config
+loads.
+
+By default the secret is encrypted with a passphrase, taken from
+KASSIE_CONFIG_PASSPHRASE or asked for twice. Kassie asks for it once per run
+when a config holds passphrase-encrypted values.
+
+With --identity, the secret is encrypted to the age identity file in
+KASSIE_AGE_IDENTITY or ` + "`" + `~/.config/kassie/age-identity.txt` + "`" + `, which is created
+if it does not exist, and decrypted with it without asking. --recipient adds
+other age public keys, such as a server's.
+
+The secret is read from stdin when stdin is not a terminal. enc: values are
+base64 age files, so age -d can decrypt them too.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var to []age.Recipient
+			for _, r := range recipients {
+				recipient, err := age.ParseX25519Recipient(r)
+				if err != nil {
+					return fmt.Errorf("--recipient %s: %w", r, err)
+				}
+				to = append(to, recipient)
+			}
+			if useIdentity {
+				id, err := loadOrCreateIdentity(config.IdentityPath())
+				if err != nil {
+					return err
+				}
+				to = append(to, id.Recipient())
+			}
+
+			secret, err := readPassword("Secret to encrypt: ")
+			if err != nil {
+				return err
+			}
+			if secret == "" {
+				return errors.New("empty secret")
+			}
+
+			if len(to) == 0 {
+				passphrase, err := newConfigPassphrase()
+				if err != nil {
+					return err
+				}
+				recipient, err := age.NewScryptRecipient(passphrase)
+				if err != nil {
+					return err
+				}
+				to = append(to, recipient)
+			}
+
+			value, err := config.EncryptSecret(secret, to...)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), value)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&useIdentity, "identity", false, "encrypt to the age identity file instead of a passphrase")
+	cmd.Flags().StringSliceVar(&recipients, "recipient", nil, "age public key (age1...) to encrypt to, repeatable")
+	return cmd
+}
+
+// newConfigPassphrase reads a passphrase for encryption, asking twice on a
+// terminal so that a typo does not lock the secret away.
+func newConfigPassphrase() (string, error) {
+	if passphrase := os.Getenv("KASSIE_CONFIG_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+	passphrase, err := promptConfigPassphrase()
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("empty passphrase")
+	}
+	again, err := readPassword("Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if again != passphrase {
+		return "", errors.New("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// loadOrCreateIdentity reads the first identity in path, writing a new one
+// there when the file does not exist.
+func loadOrCreateIdentity(path string) (*age.X25519Identity, error) {
+	f, err := os.Open(path)
+	if err == nil {
+		defer f.Close()
+		ids, err := age.ParseIdentities(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		id, ok := ids[0].(*age.X25519Identity)
+		if !ok {
+			return nil, fmt.Errorf("%s: the first identity is not an X25519 age key", path)
+		}
+		return id, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	data := fmt.Sprintf("# public key: %s\n%s\n", id.Recipient(), id)
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "Created age identity %s (public key %s)\n", path, id.Recipient())
+	return id, nil
+}
@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KashifKhn/kassie/internal/shared/config"
+	"github.com/spf13/cobra"
+)
+
+// ephemeralProfileName names the profile made from connection flags when
+// there is no profile to apply them to.
+const ephemeralProfileName = "adhoc"
+
+var (
+	overrideHosts         []string
+	overridePort          int
+	overrideKeyspace      string
+	overrideUsername      string
+	overridePasswordStdin bool
+	overrideSSL           bool
+	overridePageSize      int
+	overrideTimeout       time.Duration
+
+	// connOverride and ephemeral are kept so that config reloads apply
+	// them again.
+	connOverride *config.Override
+	ephemeral    *config.Profile
+)
+
+// overrideFlags are the global connection flags added by addOverrideFlags.
+var overrideFlags = []string{"host", "port", "keyspace", "username", "password-stdin", "ssl", "page-size", "timeout"}
+
+func addOverrideFlags(cmd *cobra.Command) {
+	f := cmd.PersistentFlags()
+	f.StringSliceVar(&overrideHosts, "host", nil, "contact points for the profile, comma separated (KASSIE_HOST)")
+	f.IntVar(&overridePort, "port", 0, "CQL port for the profile (KASSIE_PORT)")
+	f.StringVar(&overrideKeyspace, "keyspace", "", "keyspace for the profile (KASSIE_KEYSPACE)")
+	f.StringVar(&overrideUsername, "username", "", "database username for the profile (KASSIE_DB_USERNAME)")
+	f.BoolVar(&overridePasswordStdin, "password-stdin", false, "read the database password from stdin (KASSIE_DB_PASSWORD)")
+	f.BoolVar(&overrideSSL, "ssl", false, "connect with TLS (KASSIE_SSL)")
+	f.IntVar(&overridePageSize, "page-size", 0, "rows per page (KASSIE_PAGE_SIZE)")
+	f.DurationVar(&overrideTimeout, "timeout", 0, "query timeout, such as 30s (KASSIE_TIMEOUT)")
+}
+
+// connectionOverride builds an override from the global flags, falling back
+// to their KASSIE_* variables. The root's flags are read rather than cmd's
+// so that subcommand flags of the same name, such as profile add --port,
+// keep their own meaning.
+func connectionOverride(cmd *cobra.Command) (*config.Override, error) {
+	root := cmd.Root()
+	o := &config.Override{
+		Hosts:    overrideHosts,
+		Port:     overridePort,
+		Keyspace: overrideKeyspace,
+		Username: overrideUsername,
+		PageSize: overridePageSize,
+	}
+	timeout := overrideTimeout
+	if root.PersistentFlags().Changed("ssl") {
+		o.SSLEnabled = &overrideSSL
+	}
+
+	if s := fromEnv(root, "host", "KASSIE_HOST"); s != "" {
+		o.Hosts = strings.Split(s, ",")
+	}
+	if s := fromEnv(root, "keyspace", "KASSIE_KEYSPACE"); s != "" {
+		o.Keyspace = s
+	}
+	if s := fromEnv(root, "username", "KASSIE_DB_USERNAME"); s != "" {
+		o.Username = s
+	}
+	var err error
+	if s := fromEnv(root, "port", "KASSIE_PORT"); s != "" {
+		if o.Port, err = strconv.Atoi(s); err != nil {
+			return nil, fmt.Errorf("invalid KASSIE_PORT %q: %w", s, err)
+		}
+	}
+	if s := fromEnv(root, "ssl", "KASSIE_SSL"); s != "" {
+		enabled, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid KASSIE_SSL %q: %w", s, err)
+		}
+		o.SSLEnabled = &enabled
+	}
+	if s := fromEnv(root, "page-size", "KASSIE_PAGE_SIZE"); s != "" {
+		if o.PageSize, err = strconv.Atoi(s); err != nil {
+			return nil, fmt.Errorf("invalid KASSIE_PAGE_SIZE %q: %w", s, err)
+		}
+	}
+	if s := fromEnv(root, "timeout", "KASSIE_TIMEOUT"); s != "" {
+		if timeout, err = time.ParseDuration(s); err != nil {
+			return nil, fmt.Errorf("invalid KASSIE_TIMEOUT %q: %w", s, err)
+		}
+	}
+	o.TimeoutMs = int(timeout / time.Millisecond)
+
+	var hosts []string
+	for _, h := range o.Hosts {
+		if h = strings.TrimSpace(h); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	o.Hosts = hosts
+
+	if overridePasswordStdin {
+		if o.Password, err = readPasswordStdin(); err != nil {
+			return nil, err
+		}
+	} else {
+		o.Password = os.Getenv("KASSIE_DB_PASSWORD")
+	}
+	return o, nil
+}
+
+// fromEnv returns the variable's value when the flag was not given.
+func fromEnv(root *cobra.Command, flag, env string) string {
+	if root.PersistentFlags().Changed(flag) {
+		return ""
+	}
+	return os.Getenv(env)
+}
+
+func readPasswordStdin() (string, error) {
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read password from stdin: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func hasProfileOverride(o *config.Override) bool {
+	return len(o.Hosts) > 0 || o.Port > 0 || o.Keyspace != "" || o.Username != "" ||
+		o.Password != "" || o.SSLEnabled != nil
+}
+
+// setupConnectionOverride decides which profile the connection flags apply
+// to: the --profile one, else the default profile. With no config file, or
+// no profile to apply them to, they make an ephemeral profile instead,
+// which is selected for the command and never written to a config file.
+func setupConnectionOverride(cmd *cobra.Command, cfg *config.Config, fileFound bool) error {
+	if isServerCmd(cmd) {
+		return rejectConnectionFlags(cmd)
+	}
+
+	o, err := connectionOverride(cmd)
+	if err != nil {
+		return err
+	}
+
+	if hasProfileOverride(o) {
+		profileFlag := cmd.Flags().Lookup("profile")
+		var target string
+		switch {
+		case profileFlag != nil && profileFlag.Changed:
+			target = profileFlag.Value.String()
+		case fileFound:
+			target = cfg.Defaults.DefaultProfile
+		}
+		if idx, _ := cfg.FindProfile(target); fileFound && target != "" && idx == -1 {
+			return fmt.Errorf("%w: %s", config.ErrProfileNotFound, target)
+		}
+
+		if !fileFound || target == "" {
+			if target == "" {
+				target = ephemeralProfileName
+			}
+			ephemeral = &config.Profile{
+				Name:      target,
+				Hosts:     []string{"127.0.0.1"},
+				Port:      config.DefaultCQLPort,
+				Ephemeral: true,
+			}
+			if !fileFound {
+				cfg.Profiles = nil
+			}
+			cfg.Defaults.DefaultProfile = target
+			if profileFlag != nil && !profileFlag.Changed {
+				if err := profileFlag.Value.Set(target); err != nil {
+					return err
+				}
+			}
+			appLogger.With().Str("profile", target).Logger().Info("using an ephemeral profile from the connection flags")
+		}
+		o.ProfileName = target
+	}
+
+	connOverride = o
+	return applyConnectionOverride(cfg)
+}
+
+// isServerCmd reports whether cmd runs the standalone server. It serves
+// every profile as configured, so the connection flags and their KASSIE_*
+// variables do not apply, and its own --host is the bind address.
+func isServerCmd(cmd *cobra.Command) bool {
+	return cmd.Name() == "server" && cmd.Parent() == cmd.Root()
+}
+
+func rejectConnectionFlags(cmd *cobra.Command) error {
+	root := cmd.Root()
+	for _, name := range overrideFlags {
+		if root.PersistentFlags().Changed(name) {
+			return fmt.Errorf("--%s does not apply to kassie server; set it in the profile instead", name)
+		}
+	}
+	return nil
+}
+
+// applyConnectionOverride applies the connection flags to cfg, adding the
+// ephemeral profile back when cfg was reloaded without it. Credentials
+// given on the command line replace a profile's prompt for them.
+func applyConnectionOverride(cfg *config.Config) error {
+	if connOverride == nil {
+		return nil
+	}
+	if ephemeral != nil {
+		if idx, _ := cfg.FindProfile(ephemeral.Name); idx == -1 {
+			p := ephemeral.Clone()
+			p.PageSize = cfg.Defaults.PageSize
+			cfg.Profiles = append(cfg.Profiles, *p)
+		}
+	}
+	if connOverride.Username != "" || connOverride.Password != "" {
+		if _, p := cfg.FindProfile(connOverride.ProfileName); p != nil && p.PromptsForCredentials() {
+			p.Auth.Prompt = false
+		}
+	}
+	if err := config.ApplyOverrides(cfg, connOverride); err != nil {
+		return fmt.Errorf("invalid connection flags: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KashifKhn/kassie/internal/shared/config"
+)
+
+var overrideEnv = []string{
+	"KASSIE_HOST", "KASSIE_PORT", "KASSIE_KEYSPACE", "KASSIE_DB_USERNAME", "KASSIE_DB_PASSWORD",
+	"KASSIE_SSL", "KASSIE_PAGE_SIZE", "KASSIE_TIMEOUT",
+}
+
+func TestConnectionOverride(t *testing.T) {
+	enabled, disabled := true, false
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		want    config.Override
+		wantErr string
+	}{
+		{name: "nothing set"},
+		{
+			name: "flags",
+			args: []string{"--host", "10.0.0.1, 10.0.0.2", "--port", "9043", "--keyspace", "app", "--ssl", "--page-size", "20", "--timeout", "2s"},
+			want: config.Override{Hosts: []string{"10.0.0.1", "10.0.0.2"}, Port: 9043, Keyspace: "app", SSLEnabled: &enabled, PageSize: 20, TimeoutMs: 2000},
+		},
+		{
+			name: "variables",
+			env:  map[string]string{"KASSIE_HOST": "db1,db2", "KASSIE_PORT": "9044", "KASSIE_DB_USERNAME": "app", "KASSIE_DB_PASSWORD": "secret", "KASSIE_SSL": "true", "KASSIE_TIMEOUT": "1m"},
+			want: config.Override{Hosts: []string{"db1", "db2"}, Port: 9044, Username: "app", Password: "secret", SSLEnabled: &enabled, TimeoutMs: 60000},
+		},
+		{
+			name: "flags win over variables",
+			args: []string{"--host", "flag-host", "--port", "9045", "--ssl=false", "--page-size", "10"},
+			env:  map[string]string{"KASSIE_HOST": "env-host", "KASSIE_PORT": "9046", "KASSIE_SSL": "true", "KASSIE_PAGE_SIZE": "99", "KASSIE_KEYSPACE": "env_ks"},
+			want: config.Override{Hosts: []string{"flag-host"}, Port: 9045, Keyspace: "env_ks", SSLEnabled: &disabled, PageSize: 10},
+		},
+		{name: "invalid port variable", env: map[string]string{"KASSIE_PORT": "cql"}, wantErr: "invalid KASSIE_PORT"},
+		{name: "invalid ssl variable", env: map[string]string{"KASSIE_SSL": "maybe"}, wantErr: "invalid KASSIE_SSL"},
+		{name: "invalid timeout variable", env: map[string]string{"KASSIE_TIMEOUT": "soon"}, wantErr: "invalid KASSIE_TIMEOUT"},
+		{name: "flag hides an invalid variable", args: []string{"--port", "9042"}, env: map[string]string{"KASSIE_PORT": "cql"}, want: config.Override{Port: 9042}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range overrideEnv {
+				t.Setenv(name, tt.env[name])
+			}
+			root := NewRootCmd()
+			if err := root.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags() error = %v", err)
+			}
+
+			got, err := connectionOverride(root)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("connectionOverride() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("connectionOverride() error = %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("connectionOverride() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerIgnoresConnectionOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "bind address", args: []string{"server", "--host", "127.0.0.1"}},
+		{name: "connection flag", args: []string{"server", "--port", "9043"}, wantErr: "--port does not apply to kassie server"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range overrideEnv {
+				t.Setenv(name, "")
+			}
+			t.Setenv("KASSIE_HOST", "10.0.0.9")
+			connOverride, ephemeral, bindHost = nil, nil, ""
+
+			root := NewRootCmd()
+			cmd, args, err := root.Find(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := cmd.ParseFlags(args); err != nil {
+				t.Fatalf("ParseFlags() error = %v", err)
+			}
+
+			cfg := &config.Config{Profiles: []config.Profile{{Name: "dev", Hosts: []string{"db"}, Port: 9042}}}
+			cfg.Defaults.DefaultProfile = "dev"
+			err = setupConnectionOverride(cmd, cfg, true)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("setupConnectionOverride() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("setupConnectionOverride() error = %v", err)
+			}
+			if connOverride != nil || !reflect.DeepEqual(cfg.Profiles[0].Hosts, []string{"db"}) {
+				t.Errorf("server applied connection overrides: hosts = %v", cfg.Profiles[0].Hosts)
+			}
+			if bindHost != "127.0.0.1" {
+				t.Errorf("bind address = %q, want 127.0.0.1", bindHost)
+			}
+		})
+	}
+}
@@ -0,0 +1,549 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/client"
+	"github.com/KashifKhn/kassie/internal/server"
+	"github.com/KashifKhn/kassie/internal/shared/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	profileServer string
+	profileUser   string
+	profileInline string
+
+	profileHosts         []string
+	profilePort          int
+	profileKeyspace      string
+	profileUsername      string
+	profilePasswordEnv   string
+	profilePrompt        bool
+	profileSSL           bool
+	profileSSLCert       string
+	profileSSLKey        string
+	profileSSLCA         string
+	profileSSLInsecure   bool
+	profileBundle        string
+	profileClearPassword bool
+
+	profileImportFrom   string
+	profileImportName   string
+	profileImportDryRun bool
+)
+
+func newProfileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Work with database profiles",
+	}
+
+	cmd.PersistentFlags().StringVar(&profileServer, "server", "", "remote server address (bypasses embedded server)")
+	cmd.PersistentFlags().StringVar(&profileUser, "user", "", "server username (password from KASSIE_PASSWORD or prompt)")
+
+	test := &cobra.Command{
+		Use:   "test [name]",
+		Short: "Check that a profile can reach its cluster",
+		Long: `Check a profile stage by stage: DNS resolution and TCP reach of each host,
+the TLS handshake, authentication, a read of system.local and that the
+profile's keyspace exists. No session is opened.
+
+Without a name the --profile flag or the default profile is tested. With
+--inline, a profile given as JSON is tested instead; remote servers only
+accept inline profiles when they have no users.
+
+Database credentials for profiles with auth.prompt are read from
+KASSIE_DB_USERNAME and KASSIE_DB_PASSWORD, or prompted for.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runProfileTest,
+	}
+	test.Flags().StringVar(&profileInline, "inline", "", `file holding a profile as JSON ("-" reads stdin)`)
+
+	add := &cobra.Command{
+		Use:   "add <name>",
+		Short: "Add a profile to the config file",
+		Long: `Add a profile to the config file, or to the server given with --server.
+
+Passwords are never taken on the command line: --password-env names the
+environment variable that holds it, and the profile stores a ${NAME}
+reference. The variable must be set where the server runs.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runProfileAdd,
+	}
+	edit := &cobra.Command{
+		Use:   "edit <name>",
+		Short: "Change a profile's settings",
+		Long: `Change the settings given as flags and keep the rest. Sessions on the
+profile are closed so that the next login uses the new settings.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runProfileEdit,
+	}
+	for _, c := range []*cobra.Command{add, edit} {
+		f := c.Flags()
+		f.StringSliceVar(&profileHosts, "hosts", nil, "contact points, comma separated")
+		f.IntVar(&profilePort, "port", 0, "CQL port (default 9042 unless --bundle is given)")
+		f.StringVar(&profileKeyspace, "keyspace", "", "default keyspace")
+		f.StringVar(&profileUsername, "username", "", "database username")
+		f.StringVar(&profilePasswordEnv, "password-env", "", "environment variable holding the database password")
+		f.BoolVar(&profilePrompt, "prompt", false, "ask each user for database credentials at login")
+		f.BoolVar(&profileSSL, "ssl", false, "connect with TLS")
+		f.StringVar(&profileSSLCert, "ssl-cert", "", "client certificate path")
+		f.StringVar(&profileSSLKey, "ssl-key", "", "client key path")
+		f.StringVar(&profileSSLCA, "ssl-ca", "", "CA certificate path")
+		f.BoolVar(&profileSSLInsecure, "ssl-insecure", false, "skip server certificate verification")
+		f.StringVar(&profileBundle, "bundle", "", "Astra secure connect bundle path")
+	}
+	edit.Flags().BoolVar(&profileClearPassword, "clear-password", false, "remove the stored password")
+
+	imp := &cobra.Command{
+		Use:   "import --from cqlshrc|datastax-conf [path]",
+		Short: "Create a profile from a cqlshrc or driver config",
+		Long: `Create a profile from a cqlsh config (default ~/.cassandra/cqlshrc) or
+from the datastax-java-driver block of a DataStax Java driver
+application.conf (default ./application.conf), and add it to the config
+file.
+
+Hosts, port, keyspace, username, TLS files and driver timeouts are carried
+over; anything else is listed as a warning. Passwords are not copied:
+--password-env names the environment variable that holds it, and the
+profile stores a ${NAME} reference. With --dry-run the profile is printed
+and nothing is written.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runProfileImport,
+	}
+	imp.Flags().StringVar(&profileImportFrom, "from", "", "source format: cqlshrc or datastax-conf")
+	imp.Flags().StringVar(&profileImportName, "name", "", "profile name (default: derived from the host)")
+	imp.Flags().StringVar(&profilePasswordEnv, "password-env", "", "environment variable holding the database password")
+	imp.Flags().BoolVar(&profileImportDryRun, "dry-run", false, "print the profile without saving it")
+	imp.MarkFlagRequired("from")
+
+	cmd.AddCommand(test)
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List profiles",
+		Args:  cobra.NoArgs,
+		RunE:  runProfileList,
+	})
+	cmd.AddCommand(add)
+	cmd.AddCommand(edit)
+	cmd.AddCommand(imp)
+	cmd.AddCommand(&cobra.Command{
+		Use:   "rm <name>",
+		Short: "Remove a profile",
+		Long: `Remove a profile, close its sessions and revoke its API tokens. Profiles
+still granted to server users cannot be removed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runProfileRemove,
+	})
+	return cmd
+}
+
+func runProfileTest(cmd *cobra.Command, args []string) error {
+	req := &pb.TestConnectionRequest{}
+	switch {
+	case profileInline != "" && len(args) > 0:
+		return fmt.Errorf("give either a profile name or --inline, not both")
+	case profileInline != "":
+		raw, err := readInlineProfile(profileInline)
+		if err != nil {
+			return err
+		}
+		req.InlineProfile = raw
+	case len(args) > 0:
+		req.Profile = args[0]
+	case profile != "":
+		req.Profile = profile
+	default:
+		p, err := appConfig.GetDefaultProfile()
+		if err != nil {
+			return err
+		}
+		req.Profile = p.Name
+	}
+
+	if req.Profile != "" && profileServer == "" {
+		if p, err := appConfig.GetProfile(req.Profile); err == nil && p.PromptsForCredentials() {
+			if err := promptDBCredentials(req, p.Auth.Username); err != nil {
+				return err
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// Inline profiles need an authenticated caller, so a remote server is
+	// logged into first; named profiles send the server credentials along.
+	if req.InlineProfile != "" && profileServer != "" {
+		c, err := connectServer(ctx, profileServer, profileUser)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = c.Close() }()
+		return printTestResult(ctx, c, req)
+	}
+
+	addr, localKey, stop, err := profileServerAddress()
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	c, err := client.New(addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to server: %w", err)
+	}
+	defer func() { _ = c.Close() }()
+	c.SetLocalKey(localKey)
+
+	if profileUser != "" {
+		password := os.Getenv("KASSIE_PASSWORD")
+		if password == "" {
+			password, err = readPassword(fmt.Sprintf("Password for %s: ", profileUser))
+			if err != nil {
+				return err
+			}
+		}
+		c.SetCredentials(profileUser, password)
+	}
+	if req.DbUsername != "" {
+		c.SetDatabaseCredentials(req.DbUsername, req.DbPassword)
+	}
+	return printTestResult(ctx, c, req)
+}
+
+func printTestResult(ctx context.Context, c *client.Client, req *pb.TestConnectionRequest) error {
+	resp, err := c.TestConnection(ctx, req.Profile, req.InlineProfile)
+	if err != nil {
+		return err
+	}
+
+	printChecks(os.Stdout, resp.Checks)
+	if !resp.Ok {
+		return fmt.Errorf("connection test failed")
+	}
+	fmt.Fprintln(os.Stderr, "All checks passed")
+	return nil
+}
+
+func runProfileList(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	c, done, err := profileClient(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	profiles, err := c.GetProfiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tHOSTS\tKEYSPACE\tSSL\tCREDENTIALS")
+	for _, p := range profiles {
+		hosts := "astra"
+		if len(p.Hosts) > 0 {
+			hosts = fmt.Sprintf("%s:%d", strings.Join(p.Hosts, ","), p.Port)
+		}
+		credentials := "stored"
+		if p.CredentialsRequired {
+			credentials = "prompt"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n", p.Name, hosts, p.Keyspace, p.SslEnabled, credentials)
+	}
+	return w.Flush()
+}
+
+func runProfileAdd(cmd *cobra.Command, args []string) error {
+	spec := &pb.ProfileSpec{Name: args[0]}
+	applyProfileFlags(cmd, spec)
+	if spec.Port == 0 && spec.SecureConnectBundle == "" {
+		spec.Port = int32(config.DefaultCQLPort)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	c, done, err := profileClient(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if err := c.CreateProfile(ctx, spec); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Added profile %s\n", spec.Name)
+	return nil
+}
+
+func runProfileEdit(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	c, done, err := profileClient(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	current, err := c.GetProfile(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	spec := current.Profile
+	// An empty password_env keeps the stored password.
+	spec.PasswordEnv = ""
+	applyProfileFlags(cmd, spec)
+
+	if err := c.UpdateProfile(ctx, args[0], spec, profileClearPassword); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Updated profile %s\n", args[0])
+	return nil
+}
+
+func runProfileRemove(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	c, done, err := profileClient(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if err := c.DeleteProfile(ctx, args[0]); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Removed profile %s\n", args[0])
+	return nil
+}
+
+// runProfileImport edits the config file directly, like the config
+// commands, so it needs no server.
+func runProfileImport(cmd *cobra.Command, args []string) error {
+	if profileServer != "" {
+		return fmt.Errorf("profile import writes the local config file and cannot be used with --server")
+	}
+	if profilePasswordEnv != "" {
+		if _, err := config.EnvReference(profilePasswordEnv); err != nil {
+			return err
+		}
+	}
+
+	var path string
+	if len(args) == 1 {
+		path = args[0]
+	}
+	var importer func([]byte, config.ImportOptions) (*config.ImportedProfile, error)
+	switch profileImportFrom {
+	case "cqlshrc":
+		importer = config.ImportCqlshrc
+		if path == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return err
+			}
+			path = filepath.Join(home, ".cassandra", "cqlshrc")
+		}
+	case "datastax-conf":
+		importer = config.ImportDataStaxConf
+		if path == "" {
+			path = "application.conf"
+		}
+	default:
+		return fmt.Errorf("unsupported --from %q (want cqlshrc or datastax-conf)", profileImportFrom)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	imported, err := importer(data, config.ImportOptions{Name: profileImportName, PasswordEnv: profilePasswordEnv})
+	if err != nil {
+		return err
+	}
+	for _, w := range imported.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	if idx, _ := appConfig.FindProfile(imported.Profile.Name); idx != -1 {
+		return fmt.Errorf("%w: %s (choose another with --name)", config.ErrDuplicateProfile, imported.Profile.Name)
+	}
+
+	if profileImportDryRun {
+		masked := config.MaskSecrets(&config.Config{Profiles: []config.Profile{imported.Profile}})
+		out, err := json.MarshalIndent(masked.Profiles[0], "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(out))
+		return nil
+	}
+
+	store := config.NewProfileStore(appConfig, configLoader())
+	if err := store.Create(imported.Profile); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Imported profile %s from %s\n", imported.Profile.Name, path)
+	return nil
+}
+
+// applyProfileFlags copies the flags given on the command line into spec.
+func applyProfileFlags(cmd *cobra.Command, spec *pb.ProfileSpec) {
+	f := cmd.Flags()
+	if f.Changed("hosts") {
+		spec.Hosts = profileHosts
+	}
+	if f.Changed("port") {
+		spec.Port = int32(profilePort)
+	}
+	if f.Changed("keyspace") {
+		spec.Keyspace = profileKeyspace
+	}
+	if f.Changed("username") {
+		spec.Username = profileUsername
+	}
+	if f.Changed("password-env") {
+		spec.PasswordEnv = profilePasswordEnv
+	}
+	if f.Changed("prompt") {
+		spec.Prompt = profilePrompt
+	}
+	if f.Changed("ssl") {
+		spec.SslEnabled = profileSSL
+	}
+	if f.Changed("ssl-cert") {
+		spec.SslCertPath = profileSSLCert
+	}
+	if f.Changed("ssl-key") {
+		spec.SslKeyPath = profileSSLKey
+	}
+	if f.Changed("ssl-ca") {
+		spec.SslCaPath = profileSSLCA
+	}
+	if f.Changed("ssl-insecure") {
+		spec.SslInsecureSkipVerify = profileSSLInsecure
+	}
+	if f.Changed("bundle") {
+		spec.SecureConnectBundle = profileBundle
+	}
+}
+
+// profileClient connects to --server or an embedded server. Remote servers
+// with users only let admins change profiles, so with --user and admin set
+// the client logs in first.
+func profileClient(ctx context.Context, admin bool) (*client.Client, func(), error) {
+	addr, localKey, stop, err := profileServerAddress()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var c *client.Client
+	if admin && profileUser != "" {
+		c, err = connectServer(ctx, addr, profileUser)
+	} else {
+		c, err = client.New(addr)
+		if err != nil {
+			err = fmt.Errorf("failed to connect to server: %w", err)
+		}
+	}
+	if err != nil {
+		stop()
+		return nil, nil, err
+	}
+	c.SetLocalKey(localKey)
+
+	return c, func() {
+		_ = c.Close()
+		stop()
+	}, nil
+}
+
+// profileServerAddress returns --server, or starts an embedded server that
+// saves profile edits to the config file, along with its local key.
+func profileServerAddress() (string, string, func(), error) {
+	if profileServer != "" {
+		return profileServer, "", func() {}, nil
+	}
+
+	embedded, err := server.NewEmbeddedServer(appConfig, &server.EmbeddedServerConfig{JWTSecret: generateSecret(), ConfigPath: cfgFile}, appLogger)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to create embedded server: %w", err)
+	}
+	if err := embedded.Start(); err != nil {
+		return "", "", nil, fmt.Errorf("failed to start embedded server: %w", err)
+	}
+	return embedded.GRPCAddress(), embedded.LocalKey(), func() { _ = embedded.Stop() }, nil
+}
+
+func readInlineProfile(path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read inline profile: %w", err)
+	}
+	return string(data), nil
+}
+
+func promptDBCredentials(req *pb.TestConnectionRequest, defaultUser string) error {
+	req.DbUsername = os.Getenv("KASSIE_DB_USERNAME")
+	if req.DbUsername == "" {
+		req.DbUsername = defaultUser
+	}
+	if req.DbUsername == "" {
+		return fmt.Errorf("profile %s prompts for database credentials; set KASSIE_DB_USERNAME", req.Profile)
+	}
+
+	req.DbPassword = os.Getenv("KASSIE_DB_PASSWORD")
+	if req.DbPassword == "" {
+		password, err := readPassword(fmt.Sprintf("Database password for %s: ", req.DbUsername))
+		if err != nil {
+			return err
+		}
+		req.DbPassword = password
+	}
+	return nil
+}
+
+func printChecks(out io.Writer, checks []*pb.ConnectionCheck) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STAGE\tHOST\tSTATUS\tLATENCY\tDETAIL")
+	for _, c := range checks {
+		host := c.Host
+		if host == "" {
+			host = "-"
+		}
+		latency := "-"
+		if c.Status != pb.CheckStatus_CHECK_STATUS_SKIPPED {
+			latency = fmt.Sprintf("%dms", c.LatencyMs)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.Stage, host, checkStatusLabel(c.Status), latency, c.Detail)
+		if c.Hint != "" {
+			fmt.Fprintf(w, "\t\t\t\t→ %s\n", c.Hint)
+		}
+	}
+	_ = w.Flush()
+}
+
+func checkStatusLabel(s pb.CheckStatus) string {
+	return strings.ToLower(strings.TrimPrefix(s.String(), "CHECK_STATUS_"))
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+func readPassword(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt)
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		return string(password), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// promptConfigPassphrase asks for the passphrase of enc: config values. It
+// only asks on a terminal, so stdin piped to a command is left alone.
+func promptConfigPassphrase() (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", errors.New("no terminal to ask for the passphrase; set KASSIE_CONFIG_PASSPHRASE")
+	}
+	return readPassword("Config passphrase: ")
+}
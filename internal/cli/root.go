@@ -3,9 +3,10 @@ package cli
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 
 	"github.com/KashifKhn/kassie/internal/shared/config"
 	"github.com/KashifKhn/kassie/internal/shared/logger"
@@ -15,6 +16,7 @@ import (
 
 var (
 	cfgFile   string
+	cfgGiven  bool
 	profile   string
 	logLevel  string
 	appConfig *config.Config
@@ -46,18 +48,23 @@ your Cassandra/ScyllaDB clusters with ease.`,
 			if showVersion {
 				return nil
 			}
-			return initConfig()
+			return initConfig(cmd)
 		},
 	}
 
-	cmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default: ~/.config/kassie/config.json)")
+	cmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default: ~/.config/kassie/config.{json,yaml,toml})")
 	cmd.PersistentFlags().StringVar(&profile, "profile", "", "database profile to use")
 	cmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "log level (debug, info, warn, error)")
 	cmd.PersistentFlags().BoolVarP(&showVersion, "version", "v", false, "print version information")
+	addOverrideFlags(cmd)
+	config.DefaultSecretDecrypter.Prompt = promptConfigPassphrase
 
 	cmd.AddCommand(newServerCmd())
 	cmd.AddCommand(newWebCmd())
 	cmd.AddCommand(newTUICmd())
+	cmd.AddCommand(newTokenCmd())
+	cmd.AddCommand(newProfileCmd())
+	cmd.AddCommand(newConfigCmd())
 	cmd.AddCommand(newVersionCmd())
 	cmd.AddCommand(newUpgradeCmd())
 
@@ -77,7 +84,7 @@ func newVersionCmd() *cobra.Command {
 	}
 }
 
-func initConfig() error {
+func initConfig(cmd *cobra.Command) error {
 	level, err := logger.ParseLevel(logLevel)
 	if err != nil {
 		return fmt.Errorf("invalid log level: %w", err)
@@ -92,23 +99,19 @@ func initConfig() error {
 		return fmt.Errorf("failed to initialize logger: %w", err)
 	}
 
-	if cfgFile == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
-		}
-		cfgFile = filepath.Join(homeDir, ".config", "kassie", "config.json")
-	}
+	resolveConfigFile()
 
-	if _, err := os.Stat(cfgFile); os.IsNotExist(err) {
+	loader := configLoader()
+	for _, path := range loader.UntrustedProjectFiles() {
+		appLogger.With().Str("file", path).Logger().Warn("ignoring untrusted project config; run kassie config trust to load it")
+	}
+	appConfig, err = loader.Load()
+	if errors.Is(err, config.ErrFileNotFound) {
 		appLogger.Warn("config file not found, using defaults")
 		appConfig = getDefaultConfig()
 		appConfig.SetDefaults()
-		return nil
+		return setupConnectionOverride(cmd, appConfig, false)
 	}
-
-	loader := config.NewLoaderWithPath(cfgFile)
-	appConfig, err = loader.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
@@ -117,8 +120,29 @@ func initConfig() error {
 		return fmt.Errorf("invalid config: %w", err)
 	}
 
-	appLogger.With().Str("config_file", cfgFile).Logger().Info("config loaded")
-	return nil
+	appLogger.With().Str("config_files", strings.Join(loader.Layers(), ",")).Logger().Info("config loaded")
+	return setupConnectionOverride(cmd, appConfig, true)
+}
+
+// resolveConfigFile picks the default config file when --config is not
+// given: config.json, config.yaml, config.yml or config.toml, whichever
+// exists in ~/.config/kassie.
+func resolveConfigFile() {
+	if cfgFile == "" {
+		cfgFile = config.DefaultConfigPath()
+	} else {
+		cfgGiven = true
+	}
+}
+
+// configLoader loads the config stack: the system, user and project files,
+// with the --config file on top when one was given. Profile edits still go
+// to cfgFile alone.
+func configLoader() *config.Loader {
+	if cfgGiven {
+		return config.NewLoaderWithPath(cfgFile)
+	}
+	return config.NewLoader()
 }
 
 func getDefaultConfig() *config.Config {
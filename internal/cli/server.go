@@ -3,22 +3,32 @@ package cli
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 
+	"github.com/KashifKhn/kassie/internal/server/apitoken"
 	"github.com/KashifKhn/kassie/internal/server/db"
 	"github.com/KashifKhn/kassie/internal/server/gateway"
 	"github.com/KashifKhn/kassie/internal/server/grpc"
+	"github.com/KashifKhn/kassie/internal/server/metrics"
+	"github.com/KashifKhn/kassie/internal/server/policy"
+	"github.com/KashifKhn/kassie/internal/server/service"
 	"github.com/KashifKhn/kassie/internal/server/state"
 	"github.com/KashifKhn/kassie/internal/shared/config"
 	"github.com/spf13/cobra"
 )
 
 var (
-	grpcPort int
-	httpPort int
-	bindHost string
+	grpcPort    int
+	httpPort    int
+	bindHost    string
+	metricsOn   bool
+	metricsPort int
+	metricsHost string
 )
 
 func newServerCmd() *cobra.Command {
@@ -28,13 +38,21 @@ func newServerCmd() *cobra.Command {
 		Long: `Run Kassie as a standalone server exposing both gRPC and HTTP/REST APIs.
 
 This mode is suitable for team environments where multiple clients need to connect
-to a shared Kassie instance.`,
+to a shared Kassie instance. Add users to the "server" block of the config file to
+require a username and password at login; use "kassie server hash-password" to
+generate password hashes.`,
 		RunE: runServer,
 	}
 
 	cmd.Flags().IntVar(&grpcPort, "grpc-port", config.DefaultGRPCPort, "gRPC server port")
 	cmd.Flags().IntVar(&httpPort, "http-port", config.DefaultHTTPPort, "HTTP gateway port")
 	cmd.Flags().StringVar(&bindHost, "host", config.DefaultServerHost, "bind address")
+	cmd.Flags().BoolVar(&metricsOn, "metrics", false, "serve Prometheus metrics at /metrics on the HTTP gateway")
+	cmd.Flags().IntVar(&metricsPort, "metrics-port", 0, "serve Prometheus metrics on this port instead of the gateway (implies --metrics)")
+	cmd.Flags().StringVar(&metricsHost, "metrics-host", "127.0.0.1", "bind address for --metrics-port")
+
+	cmd.AddCommand(newHashPasswordCmd())
+	cmd.AddCommand(newSessionsCmd())
 
 	return cmd
 }
@@ -55,13 +73,40 @@ func runServer(cmd *cobra.Command, args []string) error {
 		JWTSecret: jwtSecret,
 	}
 
-	pool := db.NewPool()
+	tokens, err := apitoken.NewStore(tokenFilePath())
+	if err != nil {
+		return fmt.Errorf("failed to load api tokens: %w", err)
+	}
+
+	pool := db.NewPool(db.DefaultIdleTimeout)
+	pool.StartHealthChecks(db.DefaultHealthInterval)
 	store := state.NewStore(config.DefaultSessionTTL)
 
+	var serverMetrics *metrics.Metrics
+	if metricsOn || metricsPort != 0 {
+		serverMetrics = metrics.New(store, pool)
+		pool.SetQueryObserver(serverMetrics)
+	}
+
+	profiles := config.NewProfileStore(appConfig, config.NewLoaderWithPath(cfgFile))
+	engine := policy.NewEngine(appConfig.Server)
 	grpcDeps := &grpc.ServerDeps{
-		Config: appConfig,
-		Pool:   pool,
-		Store:  store,
+		Config:   profiles,
+		Pool:     pool,
+		Store:    store,
+		Policy:   engine,
+		Tokens:   tokens,
+		Profiles: profiles,
+		Metrics:  serverMetrics,
+	}
+
+	if appConfig.HasUsers() {
+		appLogger.With().Int("users", len(appConfig.Server.Users)).Logger().Info("user authentication enabled")
+	}
+	if appConfig.Server != nil && appConfig.Server.OIDC != nil {
+		appLogger.With().Str("issuer", appConfig.Server.OIDC.Issuer).Logger().Info("OIDC single sign-on enabled")
+	} else if !appConfig.HasUsers() {
+		appLogger.Warn("no server users configured, any client that can reach the server can use every profile")
 	}
 
 	grpcServer, err := grpc.NewServer(grpcCfg, grpcDeps, appLogger)
@@ -96,6 +141,23 @@ func runServer(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to register services: %w", err)
 	}
 
+	if sso := grpcServer.OIDC(); sso != nil {
+		if err := httpGateway.RegisterOIDC(sso); err != nil {
+			return err
+		}
+	}
+
+	var metricsServer *http.Server
+	if serverMetrics != nil && metricsPort != 0 {
+		metricsServer = startMetricsServer(serverMetrics, cancel)
+	} else if serverMetrics != nil {
+		if err := httpGateway.RegisterMetrics(metrics.Path, serverMetrics.Handler()); err != nil {
+			return err
+		}
+		appLogger.With().Str("address", fmt.Sprintf("%s:%d%s", bindHost, httpPort, metrics.Path)).Logger().
+			Warn("serving metrics on the HTTP gateway without authentication; they name every profile in use, so prefer --metrics-port")
+	}
+
 	go func() {
 		if err := httpGateway.Start(); err != nil {
 			appLogger.With().Err(err).Logger().Error("HTTP gateway failed")
@@ -111,11 +173,25 @@ func runServer(cmd *cobra.Command, args []string) error {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
-	select {
-	case <-sigChan:
-		appLogger.Info("shutting down server")
-	case <-ctx.Done():
-		appLogger.Info("server context cancelled")
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+
+	changed := watchConfig(ctx)
+
+wait:
+	for {
+		select {
+		case <-hupChan:
+			reloadConfig(profiles, engine)
+		case <-changed:
+			reloadConfig(profiles, engine)
+		case <-sigChan:
+			appLogger.Info("shutting down server")
+			break wait
+		case <-ctx.Done():
+			appLogger.Info("server context cancelled")
+			break wait
+		}
 	}
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), config.DefaultShutdownTime)
@@ -125,6 +201,12 @@ func runServer(cmd *cobra.Command, args []string) error {
 		appLogger.With().Err(err).Logger().Warn("HTTP gateway shutdown error")
 	}
 
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+			appLogger.With().Err(err).Logger().Warn("metrics server shutdown error")
+		}
+	}
+
 	if err := grpcServer.Stop(); err != nil {
 		appLogger.With().Err(err).Logger().Warn("gRPC server shutdown error")
 	}
@@ -132,3 +214,107 @@ func runServer(cmd *cobra.Command, args []string) error {
 	appLogger.Info("server stopped")
 	return nil
 }
+
+// startMetricsServer serves metrics on their own port, on loopback unless
+// --metrics-host says otherwise, so that they stay off the network the API
+// is exposed on.
+func startMetricsServer(m *metrics.Metrics, cancel context.CancelFunc) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle(metrics.Path, m.Handler())
+
+	addr := fmt.Sprintf("%s:%d", metricsHost, metricsPort)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: config.DefaultReadTimeout,
+	}
+
+	go func() {
+		appLogger.With().Str("address", addr+metrics.Path).Logger().Info("serving metrics")
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			appLogger.With().Err(err).Logger().Error("metrics server failed")
+			cancel()
+		}
+	}()
+	return srv
+}
+
+// tokenFilePath keeps API tokens next to the config file unless the server
+// block says otherwise.
+func tokenFilePath() string {
+	if appConfig.Server != nil && appConfig.Server.TokenFile != "" {
+		return appConfig.Server.TokenFile
+	}
+	return filepath.Join(filepath.Dir(cfgFile), "tokens.json")
+}
+
+// watchConfig reports changes to the config files. Reloads are run by the
+// caller's select loop so that a SIGHUP and a file change never overlap.
+func watchConfig(ctx context.Context) <-chan struct{} {
+	changed := make(chan struct{}, 1)
+	go config.NewWatcher(configLoader().Layers, config.DefaultConfigPollInterval).Watch(ctx, func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	return changed
+}
+
+// reloadConfig loads the config files again and swaps them in. An edit that
+// does not load or validate is logged and the running config is kept.
+// Sessions already open keep the settings they logged in with.
+func reloadConfig(profiles *config.ProfileStore, engine *policy.Engine) {
+	cfg, err := configLoader().Load()
+	if err == nil {
+		err = applyConnectionOverride(cfg)
+	}
+	if err != nil {
+		appLogger.With().Err(err).Logger().Error("config reload failed, keeping current config")
+		return
+	}
+
+	diff := profiles.Reload(cfg)
+	if engine != nil {
+		engine.Reload(cfg.Server)
+	}
+
+	if diff.Empty() {
+		appLogger.Debug("config reloaded, profiles unchanged")
+		return
+	}
+	appLogger.With().
+		Str("added", strings.Join(diff.Added, ",")).
+		Str("changed", strings.Join(diff.Changed, ",")).
+		Str("removed", strings.Join(diff.Removed, ",")).
+		Logger().Info("config reloaded")
+}
+
+func newHashPasswordCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "hash-password",
+		Short: "Generate a password hash for a server user",
+		Long: `Read a password from the terminal (or stdin) and print a bcrypt hash suitable
+for the "password_hash" field of a user in the "server" config block.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			password, err := readPassword("Password: ")
+			if err != nil {
+				return err
+			}
+			if password == "" {
+				return fmt.Errorf("password must not be empty")
+			}
+
+			hash, err := service.HashPassword(password)
+			if err != nil {
+				return fmt.Errorf("failed to hash password: %w", err)
+			}
+
+			fmt.Println(hash)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/KashifKhn/kassie/internal/shared/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sessionsServer string
+	sessionsUser   string
+)
+
+func newSessionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "List sessions on a running server",
+		Long: `List the sessions open on a running kassie server, with their user, profile,
+open cursors and queries in flight. Requires the admin permission.
+
+The session used by this command is marked with "*".`,
+		Args: cobra.NoArgs,
+		RunE: runSessionsList,
+	}
+
+	cmd.PersistentFlags().StringVar(&sessionsServer, "server", fmt.Sprintf("%s:%d", config.DefaultHost, config.DefaultGRPCPort), "server gRPC address")
+	cmd.PersistentFlags().StringVar(&sessionsUser, "user", "", "server username (password from KASSIE_PASSWORD or prompt)")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "terminate <id>",
+		Short: "Close a session and revoke its tokens",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runSessionsTerminate,
+	})
+
+	return cmd
+}
+
+func runSessionsList(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	c, err := connectServer(ctx, sessionsServer, sessionsUser)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.Close() }()
+
+	sessions, err := c.ListSessions(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSER\tPROFILE\tCREATED\tLAST ACCESS\tCURSORS\tIN FLIGHT")
+	for _, s := range sessions {
+		id := s.Id
+		if s.Current {
+			id += " *"
+		}
+		user := s.User
+		if user == "" {
+			user = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\n",
+			id, user, s.Profile,
+			formatUnix(s.CreatedAt, ""), formatUnix(s.LastAccess, ""),
+			s.OpenCursors, s.InFlightQueries)
+	}
+	return w.Flush()
+}
+
+func runSessionsTerminate(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	c, err := connectServer(ctx, sessionsServer, sessionsUser)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.Close() }()
+
+	if err := c.TerminateSession(ctx, args[0]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Terminated session %s\n", args[0])
+	return nil
+}
@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/client"
+	"github.com/KashifKhn/kassie/internal/shared/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tokenServer   string
+	tokenUser     string
+	tokenName     string
+	tokenReadOnly bool
+	tokenTTL      time.Duration
+)
+
+func newTokenCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Manage personal API tokens",
+		Long: `Create, list and revoke personal API tokens on a running kassie server.
+
+API tokens are long-lived credentials for scripts and CI. Send them as
+"Authorization: Bearer kst_..." to the REST or gRPC API. Each token is bound to
+one profile and can be limited to read-only access.`,
+	}
+
+	cmd.PersistentFlags().StringVar(&tokenServer, "server", fmt.Sprintf("%s:%d", config.DefaultHost, config.DefaultGRPCPort), "server gRPC address")
+	cmd.PersistentFlags().StringVar(&tokenUser, "user", "", "server username (password from KASSIE_PASSWORD or prompt)")
+
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create a token bound to --profile",
+		Args:  cobra.NoArgs,
+		RunE:  runTokenCreate,
+	}
+	create.Flags().StringVar(&tokenName, "name", "", "token name")
+	create.Flags().BoolVar(&tokenReadOnly, "read-only", false, "only allow read operations")
+	create.Flags().DurationVar(&tokenTTL, "ttl", 0, "token lifetime, e.g. 720h (0 never expires)")
+	_ = create.MarkFlagRequired("name")
+
+	cmd.AddCommand(create)
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List your tokens",
+		Args:  cobra.NoArgs,
+		RunE:  runTokenList,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "revoke <id>",
+		Short: "Revoke a token",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runTokenRevoke,
+	})
+
+	return cmd
+}
+
+func runTokenCreate(cmd *cobra.Command, args []string) error {
+	if profile == "" {
+		return fmt.Errorf("--profile is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	c, err := connectServer(ctx, tokenServer, tokenUser)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.Close() }()
+
+	resp, err := c.CreateToken(ctx, &pb.CreateTokenRequest{
+		Name:       tokenName,
+		Profile:    profile,
+		ReadOnly:   tokenReadOnly,
+		TtlSeconds: int64(tokenTTL / time.Second),
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Created token %s (%s). It is shown only once:\n", resp.Info.Id, resp.Info.Name)
+	fmt.Println(resp.Token)
+	return nil
+}
+
+func runTokenList(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	c, err := connectServer(ctx, tokenServer, tokenUser)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.Close() }()
+
+	tokens, err := c.ListTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tPROFILE\tACCESS\tCREATED\tEXPIRES\tLAST USED")
+	for _, t := range tokens {
+		access := "read-write"
+		if t.ReadOnly {
+			access = "read-only"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			t.Id, t.Name, t.Profile, access,
+			formatUnix(t.CreatedAt, ""), formatUnix(t.ExpiresAt, "never"), formatUnix(t.LastUsedAt, "never"))
+	}
+	return w.Flush()
+}
+
+func runTokenRevoke(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	c, err := connectServer(ctx, tokenServer, tokenUser)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.Close() }()
+
+	if err := c.RevokeToken(ctx, args[0]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Revoked token %s\n", args[0])
+	return nil
+}
+
+// connectServer logs in to a running server as user with --profile, or the
+// first profile it offers, for commands that manage the server remotely.
+func connectServer(ctx context.Context, addr, user string) (*client.Client, error) {
+	c, err := client.New(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to server: %w", err)
+	}
+
+	if user != "" {
+		password := os.Getenv("KASSIE_PASSWORD")
+		if password == "" {
+			password, err = readPassword(fmt.Sprintf("Password for %s: ", user))
+			if err != nil {
+				_ = c.Close()
+				return nil, err
+			}
+		}
+		c.SetCredentials(user, password)
+	}
+
+	loginProfile := profile
+	if loginProfile == "" {
+		profiles, err := c.GetProfiles(ctx)
+		if err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+		if len(profiles) == 0 {
+			_ = c.Close()
+			return nil, fmt.Errorf("server has no profiles")
+		}
+		loginProfile = profiles[0].Name
+	}
+
+	if _, err := c.Login(ctx, loginProfile); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("failed to login with profile %s: %w", loginProfile, err)
+	}
+
+	return c, nil
+}
+
+func formatUnix(ts int64, zero string) string {
+	if ts == 0 {
+		return zero
+	}
+	return time.Unix(ts, 0).Format(time.RFC3339)
+}
@@ -18,6 +18,7 @@ import (
 var (
 	tuiProfile string
 	tuiServer  string
+	tuiUser    string
 )
 
 func newTUICmd() *cobra.Command {
@@ -33,6 +34,7 @@ Coming soon in Phase 4!`,
 
 	cmd.Flags().StringVar(&tuiProfile, "profile", "", "profile to connect to")
 	cmd.Flags().StringVar(&tuiServer, "server", "", "remote server address (bypasses embedded server)")
+	cmd.Flags().StringVar(&tuiUser, "user", "", "server username (password from KASSIE_PASSWORD or prompt)")
 
 	return cmd
 }
@@ -56,9 +58,10 @@ func runTUI(cmd *cobra.Command, args []string) error {
 		}
 
 		embeddedCfg := &server.EmbeddedServerConfig{
-			JWTSecret: jwtSecret,
-			GRPCPort:  0,
-			HTTPPort:  0,
+			JWTSecret:  jwtSecret,
+			GRPCPort:   0,
+			HTTPPort:   0,
+			ConfigPath: cfgFile,
 		}
 
 		var err error
@@ -83,6 +86,20 @@ func runTUI(cmd *cobra.Command, args []string) error {
 			appLogger.With().Err(err).Logger().Warn("failed to close client connection")
 		}
 	}()
+	if embedded != nil {
+		clientConn.SetLocalKey(embedded.LocalKey())
+	}
+
+	if tuiUser != "" {
+		password := os.Getenv("KASSIE_PASSWORD")
+		if password == "" {
+			password, err = readPassword(fmt.Sprintf("Password for %s: ", tuiUser))
+			if err != nil {
+				return err
+			}
+		}
+		clientConn.SetCredentials(tuiUser, password)
+	}
 
 	if tuiProfile != "" {
 		ctxLogin, cancelLogin := context.WithTimeout(context.Background(), 10*time.Second)
@@ -93,7 +110,12 @@ func runTUI(cmd *cobra.Command, args []string) error {
 		cancelLogin()
 	}
 
-	program := tea.NewProgram(tui.NewApp(clientConn), tea.WithAltScreen())
+	opts := []tea.ProgramOption{tea.WithAltScreen()}
+	if overridePasswordStdin {
+		// stdin held the password, so read keys from the terminal.
+		opts = append(opts, tea.WithInputTTY())
+	}
+	program := tea.NewProgram(tui.NewApp(clientConn), opts...)
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
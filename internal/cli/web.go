@@ -48,9 +48,13 @@ func runWeb(cmd *cobra.Command, args []string) error {
 	defer cancel()
 
 	if webProfile != "" {
-		if _, err := appConfig.GetProfile(webProfile); err != nil {
+		p, err := appConfig.GetProfile(webProfile)
+		if err != nil {
 			return fmt.Errorf("profile not found: %s", webProfile)
 		}
+		if p.Abstract {
+			return fmt.Errorf("profile %s is abstract and can only be extended", webProfile)
+		}
 	}
 
 	jwtSecret := os.Getenv("KASSIE_JWT_SECRET")
@@ -67,13 +71,16 @@ func runWeb(cmd *cobra.Command, args []string) error {
 		JWTSecret: jwtSecret,
 	}
 
-	pool := db.NewPool()
+	pool := db.NewPool(db.DefaultIdleTimeout)
+	pool.StartHealthChecks(db.DefaultHealthInterval)
 	store := state.NewStore(config.DefaultSessionTTL)
 
+	profiles := config.NewProfileStore(appConfig, config.NewLoaderWithPath(cfgFile))
 	grpcDeps := &grpc.ServerDeps{
-		Config: appConfig,
-		Pool:   pool,
-		Store:  store,
+		Config:   profiles,
+		Pool:     pool,
+		Store:    store,
+		Profiles: profiles,
 	}
 
 	grpcServer, err := grpc.NewServer(grpcCfg, grpcDeps, appLogger)
@@ -158,11 +165,20 @@ func runWeb(cmd *cobra.Command, args []string) error {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
-	select {
-	case <-sigChan:
-		fmt.Println("\n\nShutting down...")
-	case <-ctx.Done():
-		fmt.Println("\n\nServer error, shutting down...")
+	changed := watchConfig(ctx)
+
+wait:
+	for {
+		select {
+		case <-changed:
+			reloadConfig(profiles, nil)
+		case <-sigChan:
+			fmt.Println("\n\nShutting down...")
+			break wait
+		case <-ctx.Done():
+			fmt.Println("\n\nServer error, shutting down...")
+			break wait
+		}
 	}
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), config.DefaultShutdownTime)
@@ -12,16 +12,25 @@ import (
 )
 
 type Client struct {
-	conn    *grpc.ClientConn
-	session pb.SessionServiceClient
-	schema  pb.SchemaServiceClient
-	data    pb.DataServiceClient
+	conn     *grpc.ClientConn
+	session  pb.SessionServiceClient
+	schema   pb.SchemaServiceClient
+	data     pb.DataServiceClient
+	diag     pb.DiagnosticsServiceClient
+	tokens   pb.TokenServiceClient
+	admin    pb.AdminServiceClient
+	profiles pb.ProfileServiceClient
 
 	mu           sync.RWMutex
 	accessToken  string
 	refreshToken string
 	expiresAt    time.Time
 	profile      string
+	username     string
+	password     string
+	dbUsername   string
+	dbPassword   string
+	localKey     string
 }
 
 func New(addr string) (*Client, error) {
@@ -40,6 +49,10 @@ func New(addr string) (*Client, error) {
 	c.session = pb.NewSessionServiceClient(conn)
 	c.schema = pb.NewSchemaServiceClient(conn)
 	c.data = pb.NewDataServiceClient(conn)
+	c.diag = pb.NewDiagnosticsServiceClient(conn)
+	c.tokens = pb.NewTokenServiceClient(conn)
+	c.admin = pb.NewAdminServiceClient(conn)
+	c.profiles = pb.NewProfileServiceClient(conn)
 
 	return c, nil
 }
@@ -59,8 +72,71 @@ func (c *Client) GetProfiles(ctx context.Context) ([]*pb.ProfileInfo, error) {
 	return resp.Profiles, nil
 }
 
+// TestConnection runs the server's staged connection checks for a configured
+// profile or, with inline set, a profile given as JSON. The server and
+// database credentials set on the client are sent along.
+func (c *Client) TestConnection(ctx context.Context, profile, inline string) (*pb.TestConnectionResponse, error) {
+	c.mu.RLock()
+	req := &pb.TestConnectionRequest{
+		Profile:       profile,
+		InlineProfile: inline,
+		Username:      c.username,
+		Password:      c.password,
+		DbUsername:    c.dbUsername,
+		DbPassword:    c.dbPassword,
+	}
+	c.mu.RUnlock()
+
+	resp, err := c.session.TestConnection(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to test connection: %w", err)
+	}
+	return resp, nil
+}
+
+// SetLocalKey sends an embedded server's local key with every call, so
+// profiles can be managed before logging in.
+func (c *Client) SetLocalKey(key string) {
+	c.mu.Lock()
+	c.localKey = key
+	c.mu.Unlock()
+}
+
+func (c *Client) SetCredentials(username, password string) {
+	c.mu.Lock()
+	c.username = username
+	c.password = password
+	c.mu.Unlock()
+}
+
+// SetDatabaseCredentials sets the database credentials sent with the next
+// login, for profiles that prompt for them. They are dropped once the login
+// succeeds.
+func (c *Client) SetDatabaseCredentials(username, password string) {
+	c.mu.Lock()
+	c.dbUsername = username
+	c.dbPassword = password
+	c.mu.Unlock()
+}
+
+func (c *Client) HasCredentials() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.username != ""
+}
+
 func (c *Client) Login(ctx context.Context, profile string) (*pb.ProfileInfo, error) {
-	resp, err := c.session.Login(ctx, &pb.LoginRequest{Profile: profile})
+	c.mu.RLock()
+	req := &pb.LoginRequest{
+		Profile:    profile,
+		Username:   c.username,
+		Password:   c.password,
+		DbUsername: c.dbUsername,
+		DbPassword: c.dbPassword,
+	}
+	c.mu.RUnlock()
+
+	resp, err := c.session.Login(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to login: %w", err)
 	}
@@ -70,6 +146,8 @@ func (c *Client) Login(ctx context.Context, profile string) (*pb.ProfileInfo, er
 	c.refreshToken = resp.RefreshToken
 	c.expiresAt = time.Unix(resp.ExpiresAt, 0)
 	c.profile = profile
+	c.dbUsername = ""
+	c.dbPassword = ""
 	c.mu.Unlock()
 
 	return resp.Profile, nil
@@ -107,6 +185,9 @@ func (c *Client) Refresh(ctx context.Context) error {
 
 	c.mu.Lock()
 	c.accessToken = resp.AccessToken
+	if resp.RefreshToken != "" {
+		c.refreshToken = resp.RefreshToken
+	}
 	c.expiresAt = time.Unix(resp.ExpiresAt, 0)
 	c.mu.Unlock()
 
@@ -173,6 +254,110 @@ func (c *Client) FilterRows(ctx context.Context, keyspace, table, where string,
 	return resp, nil
 }
 
+func (c *Client) GetConnectionHealth(ctx context.Context) (*pb.ConnectionHealth, error) {
+	resp, err := c.diag.GetConnectionHealth(ctx, &pb.GetConnectionHealthRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection health: %w", err)
+	}
+	return resp.Health, nil
+}
+
+func (c *Client) GetLargeDataReport(ctx context.Context, keyspace, table string, limit int32) (*pb.GetLargeDataReportResponse, error) {
+	resp, err := c.diag.GetLargeDataReport(ctx, &pb.GetLargeDataReportRequest{
+		Keyspace: keyspace,
+		Table:    table,
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get large data report: %w", err)
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateToken(ctx context.Context, req *pb.CreateTokenRequest) (*pb.CreateTokenResponse, error) {
+	resp, err := c.tokens.CreateToken(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token: %w", err)
+	}
+	return resp, nil
+}
+
+func (c *Client) ListTokens(ctx context.Context) ([]*pb.ApiToken, error) {
+	resp, err := c.tokens.ListTokens(ctx, &pb.ListTokensRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+	return resp.Tokens, nil
+}
+
+func (c *Client) RevokeToken(ctx context.Context, id string) error {
+	if _, err := c.tokens.RevokeToken(ctx, &pb.RevokeTokenRequest{Id: id}); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) RevokeTokens(ctx context.Context, user, profile string) (*pb.RevokeTokensResponse, error) {
+	resp, err := c.admin.RevokeTokens(ctx, &pb.RevokeTokensRequest{User: user, Profile: profile})
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke tokens: %w", err)
+	}
+	return resp, nil
+}
+
+func (c *Client) ListSessions(ctx context.Context) ([]*pb.SessionInfo, error) {
+	resp, err := c.admin.ListSessions(ctx, &pb.ListSessionsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return resp.Sessions, nil
+}
+
+func (c *Client) TerminateSession(ctx context.Context, id string) error {
+	if _, err := c.admin.TerminateSession(ctx, &pb.TerminateSessionRequest{Id: id}); err != nil {
+		return fmt.Errorf("failed to terminate session: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) ListConnections(ctx context.Context) ([]*pb.ConnectionInfo, error) {
+	resp, err := c.admin.ListConnections(ctx, &pb.ListConnectionsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list connections: %w", err)
+	}
+	return resp.Connections, nil
+}
+
+func (c *Client) GetProfile(ctx context.Context, name string) (*pb.GetProfileResponse, error) {
+	resp, err := c.profiles.GetProfile(ctx, &pb.GetProfileRequest{Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateProfile(ctx context.Context, spec *pb.ProfileSpec) error {
+	if _, err := c.profiles.CreateProfile(ctx, &pb.CreateProfileRequest{Profile: spec}); err != nil {
+		return fmt.Errorf("failed to create profile: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) UpdateProfile(ctx context.Context, name string, spec *pb.ProfileSpec, clearPassword bool) error {
+	req := &pb.UpdateProfileRequest{Name: name, Profile: spec, ClearPassword: clearPassword}
+	if _, err := c.profiles.UpdateProfile(ctx, req); err != nil {
+		return fmt.Errorf("failed to update profile: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) DeleteProfile(ctx context.Context, name string) error {
+	if _, err := c.profiles.DeleteProfile(ctx, &pb.DeleteProfileRequest{Name: name}); err != nil {
+		return fmt.Errorf("failed to delete profile: %w", err)
+	}
+	return nil
+}
+
 func (c *Client) Profile() string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -27,6 +27,9 @@ func TestNewClient(t *testing.T) {
 	if c.data == nil {
 		t.Fatal("expected data client to be set")
 	}
+	if c.diag == nil {
+		t.Fatal("expected diagnostics client to be set")
+	}
 }
 
 func TestIsAuthenticated(t *testing.T) {
@@ -69,6 +72,27 @@ func TestProfile(t *testing.T) {
 	}
 }
 
+func TestSetCredentials(t *testing.T) {
+	c, err := New("localhost:0")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	if c.HasCredentials() {
+		t.Fatal("expected no credentials initially")
+	}
+
+	c.SetCredentials("alice", "secret")
+
+	if !c.HasCredentials() {
+		t.Fatal("expected credentials after SetCredentials")
+	}
+	if c.username != "alice" || c.password != "secret" {
+		t.Fatalf("unexpected credentials %q/%q", c.username, c.password)
+	}
+}
+
 func TestNeedsRefresh(t *testing.T) {
 	c, err := New("localhost:0")
 	if err != nil {
@@ -152,6 +176,7 @@ func TestPublicMethods(t *testing.T) {
 
 	private := []string{
 		"/kassie.v1.SessionService/Logout",
+		"/kassie.v1.SessionService/TestConnection",
 		"/kassie.v1.SchemaService/ListKeyspaces",
 		"/kassie.v1.DataService/QueryRows",
 	}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/KashifKhn/kassie/internal/shared/ctxutil"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -30,6 +31,7 @@ func (c *Client) authInterceptor() grpc.UnaryClientInterceptor {
 			return invoker(ctx, method, req, reply, cc, opts...)
 		}
 
+		ctx = c.attachLocalKey(ctx)
 		ctx = c.attachToken(ctx)
 
 		err := invoker(ctx, method, req, reply, cc, opts...)
@@ -50,6 +52,18 @@ func (c *Client) authInterceptor() grpc.UnaryClientInterceptor {
 	}
 }
 
+func (c *Client) attachLocalKey(ctx context.Context) context.Context {
+	c.mu.RLock()
+	key := c.localKey
+	c.mu.RUnlock()
+
+	if key == "" {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, ctxutil.LocalKeyMetadata, key)
+}
+
 func (c *Client) attachToken(ctx context.Context) context.Context {
 	c.mu.RLock()
 	token := c.accessToken
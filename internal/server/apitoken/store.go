@@ -0,0 +1,271 @@
+package apitoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prefix marks API tokens so they can be told apart from JWTs in the
+// Authorization header.
+const Prefix = "kst_"
+
+var (
+	ErrTokenNotFound = errors.New("api token not found")
+	ErrInvalidToken  = errors.New("invalid api token")
+	ErrExpiredToken  = errors.New("api token expired")
+	ErrInvalidName   = errors.New("api token name is required")
+)
+
+type Token struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Hash       string    `json:"hash"`
+	User       string    `json:"user,omitempty"`
+	Profile    string    `json:"profile"`
+	ReadOnly   bool      `json:"read_only,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+func (t *Token) Expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && now.After(t.ExpiresAt)
+}
+
+type fileFormat struct {
+	Tokens []*Token `json:"tokens"`
+}
+
+// Store keeps API tokens by the SHA-256 of their secret. Only the hash is
+// persisted; the plaintext token is returned once by Create. With an empty
+// path the store lives in memory only.
+type Store struct {
+	path string
+
+	mu     sync.RWMutex
+	byID   map[string]*Token
+	byHash map[string]*Token
+}
+
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path:   path,
+		byID:   make(map[string]*Token),
+		byHash: make(map[string]*Token),
+	}
+
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	var f fileFormat
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse token file: %w", err)
+	}
+	for _, t := range f.Tokens {
+		s.byID[t.ID] = t
+		s.byHash[t.Hash] = t
+	}
+
+	return s, nil
+}
+
+// Create issues a new token. A zero ttl creates a token that never expires.
+func (s *Store) Create(name, user, profile string, readOnly bool, ttl time.Duration) (string, *Token, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", nil, ErrInvalidName
+	}
+
+	secret, err := randomString(32)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	id, err := randomString(9)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate token id: %w", err)
+	}
+
+	raw := Prefix + secret
+	now := time.Now().UTC()
+	t := &Token{
+		ID:        id,
+		Name:      name,
+		Hash:      hashToken(raw),
+		User:      user,
+		Profile:   profile,
+		ReadOnly:  readOnly,
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		t.ExpiresAt = now.Add(ttl)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.byID[t.ID] = t
+	s.byHash[t.Hash] = t
+	if err := s.saveLocked(); err != nil {
+		delete(s.byID, t.ID)
+		delete(s.byHash, t.Hash)
+		return "", nil, err
+	}
+
+	clone := *t
+	return raw, &clone, nil
+}
+
+// Verify returns the token matching raw and records the time it was used.
+func (s *Store) Verify(raw string) (*Token, error) {
+	if !strings.HasPrefix(raw, Prefix) {
+		return nil, ErrInvalidToken
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.byHash[hashToken(raw)]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	now := time.Now().UTC()
+	if t.Expired(now) {
+		return nil, ErrExpiredToken
+	}
+	t.LastUsedAt = now
+
+	clone := *t
+	return &clone, nil
+}
+
+// List returns the tokens owned by user, oldest first.
+func (s *Store) List(user string) []Token {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokens := make([]Token, 0)
+	for _, t := range s.byID {
+		if t.User == user {
+			tokens = append(tokens, *t)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt.Before(tokens[j].CreatedAt)
+	})
+	return tokens
+}
+
+// Revoke deletes the token with id if it belongs to user.
+func (s *Store) Revoke(id, user string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.byID[id]
+	if !ok || t.User != user {
+		return ErrTokenNotFound
+	}
+
+	delete(s.byID, id)
+	delete(s.byHash, t.Hash)
+	if err := s.saveLocked(); err != nil {
+		s.byID[id] = t
+		s.byHash[t.Hash] = t
+		return err
+	}
+	return nil
+}
+
+// RevokeMatching deletes every token owned by user or bound to profile. An
+// empty user or profile matches nothing, so both may be combined.
+func (s *Store) RevokeMatching(user, profile string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := make([]*Token, 0)
+	for id, t := range s.byID {
+		if (user != "" && t.User == user) || (profile != "" && t.Profile == profile) {
+			removed = append(removed, t)
+			delete(s.byID, id)
+			delete(s.byHash, t.Hash)
+		}
+	}
+	if len(removed) == 0 {
+		return 0, nil
+	}
+
+	if err := s.saveLocked(); err != nil {
+		for _, t := range removed {
+			s.byID[t.ID] = t
+			s.byHash[t.Hash] = t
+		}
+		return 0, err
+	}
+	return len(removed), nil
+}
+
+// saveLocked writes the token file atomically. Last-used times are only
+// persisted alongside other changes to avoid a write on every request.
+func (s *Store) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	f := fileFormat{Tokens: make([]*Token, 0, len(s.byID))}
+	for _, t := range s.byID {
+		f.Tokens = append(f.Tokens, t)
+	}
+	sort.Slice(f.Tokens, func(i, j int) bool {
+		return f.Tokens[i].CreatedAt.Before(f.Tokens[j].CreatedAt)
+	})
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode tokens: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create token directory: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace token file: %w", err)
+	}
+	return nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
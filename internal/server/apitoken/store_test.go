@@ -0,0 +1,170 @@
+package apitoken
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore_CreateAndVerify(t *testing.T) {
+	s, err := NewStore("")
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	raw, tok, err := s.Create("cron", "alice", "dev", true, 0)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !strings.HasPrefix(raw, Prefix) {
+		t.Errorf("expected token to start with %s, got %s", Prefix, raw)
+	}
+	if tok.Hash == raw || strings.Contains(tok.Hash, raw) {
+		t.Error("expected only the hash of the token to be stored")
+	}
+
+	got, err := s.Verify(raw)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if got.ID != tok.ID || got.Profile != "dev" || !got.ReadOnly {
+		t.Errorf("unexpected token %+v", got)
+	}
+	if got.LastUsedAt.IsZero() {
+		t.Error("expected last used time to be recorded")
+	}
+
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{name: "unknown token", raw: Prefix + "nope"},
+		{name: "missing prefix", raw: strings.TrimPrefix(raw, Prefix)},
+		{name: "jwt", raw: "eyJhbGciOiJIUzI1NiJ9.e30.sig"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Verify(tt.raw); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStore_Expiry(t *testing.T) {
+	s, _ := NewStore("")
+
+	raw, _, err := s.Create("short", "alice", "dev", false, time.Nanosecond)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	if _, err := s.Verify(raw); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("expected ErrExpiredToken, got %v", err)
+	}
+}
+
+func TestStore_ListAndRevoke(t *testing.T) {
+	s, _ := NewStore("")
+
+	raw, alice, _ := s.Create("one", "alice", "dev", false, 0)
+	_, _, _ = s.Create("two", "alice", "prod", false, 0)
+	_, bob, _ := s.Create("three", "bob", "dev", false, 0)
+
+	if got := s.List("alice"); len(got) != 2 || got[0].Name != "one" {
+		t.Errorf("expected alice's two tokens in creation order, got %+v", got)
+	}
+
+	if err := s.Revoke(bob.ID, "alice"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("expected ErrTokenNotFound revoking another user's token, got %v", err)
+	}
+
+	if err := s.Revoke(alice.ID, "alice"); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if _, err := s.Verify(raw); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected revoked token to be rejected, got %v", err)
+	}
+}
+
+func TestStore_RevokeMatching(t *testing.T) {
+	s, _ := NewStore("")
+
+	_, _, _ = s.Create("one", "alice", "dev", false, 0)
+	_, _, _ = s.Create("two", "alice", "prod", false, 0)
+	_, _, _ = s.Create("three", "bob", "prod", false, 0)
+	bobDev, _, _ := s.Create("four", "bob", "dev", false, 0)
+
+	tests := []struct {
+		name    string
+		user    string
+		profile string
+		want    int
+	}{
+		{name: "nothing", want: 0},
+		{name: "unknown user", user: "carol", want: 0},
+		{name: "by user", user: "alice", want: 2},
+		{name: "by profile", profile: "prod", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := s.RevokeMatching(tt.user, tt.profile)
+			if err != nil {
+				t.Fatalf("RevokeMatching() error = %v", err)
+			}
+			if n != tt.want {
+				t.Errorf("expected %d tokens revoked, got %d", tt.want, n)
+			}
+		})
+	}
+
+	if _, err := s.Verify(bobDev); err != nil {
+		t.Errorf("expected unrelated token to survive, got %v", err)
+	}
+}
+
+func TestStore_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	raw, _, err := s.Create("ci", "", "dev", true, 0)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("expected token file, got %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), raw) {
+		t.Error("token file must not contain the plaintext token")
+	}
+
+	reloaded, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore() reload error = %v", err)
+	}
+	if _, err := reloaded.Verify(raw); err != nil {
+		t.Errorf("expected token to survive reload, got %v", err)
+	}
+}
+
+func TestStore_CreateRequiresName(t *testing.T) {
+	s, _ := NewStore("")
+	if _, _, err := s.Create(" ", "alice", "dev", false, 0); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("expected ErrInvalidName, got %v", err)
+	}
+}
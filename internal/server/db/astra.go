@@ -0,0 +1,297 @@
+package db
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	// astraMetadataTTL is how long the proxy address and contact points
+	// fetched from the bundle's metadata service are reused.
+	astraMetadataTTL = 5 * time.Minute
+	// astraPlaceholderHost stands in for the initial contact point; the
+	// dialer ignores addresses and routes by host ID.
+	astraPlaceholderHost  = "0.0.0.0"
+	astraDefaultProxyPort = 29042
+)
+
+// astraBundle is the content of a DataStax Astra secure connect bundle.
+type astraBundle struct {
+	host     string
+	port     int
+	keyspace string
+	roots    *x509.CertPool
+	cert     tls.Certificate
+}
+
+type astraBundleConfig struct {
+	Host           string `json:"host"`
+	Port           int    `json:"port"`
+	Keyspace       string `json:"keyspace"`
+	CACertLocation string `json:"caCertLocation"`
+	CertLocation   string `json:"certLocation"`
+	KeyLocation    string `json:"keyLocation"`
+}
+
+type astraContactInfo struct {
+	LocalDC         string   `json:"local_dc"`
+	ContactPoints   []string `json:"contact_points"`
+	SNIProxyAddress string   `json:"sni_proxy_address"`
+}
+
+func loadAstraBundle(bundlePath string) (*astraBundle, error) {
+	archive, err := zip.OpenReader(expandHome(bundlePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open secure connect bundle: %w", err)
+	}
+	defer func() { _ = archive.Close() }()
+
+	files := make(map[string][]byte)
+	for _, f := range archive.File {
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from secure connect bundle: %w", f.Name, err)
+		}
+		files[path.Clean(f.Name)] = data
+	}
+
+	raw, ok := files["config.json"]
+	if !ok {
+		return nil, fmt.Errorf("secure connect bundle has no config.json")
+	}
+	var cfg astraBundleConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse secure connect bundle config.json: %w", err)
+	}
+	if cfg.Host == "" || cfg.Port == 0 {
+		return nil, fmt.Errorf("secure connect bundle config.json has no metadata host")
+	}
+
+	lookup := func(location, fallback string) ([]byte, error) {
+		name := fallback
+		if location != "" {
+			name = path.Clean(location)
+		}
+		data, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("secure connect bundle has no %s", name)
+		}
+		return data, nil
+	}
+
+	caPEM, err := lookup(cfg.CACertLocation, "ca.crt")
+	if err != nil {
+		return nil, err
+	}
+	certPEM, err := lookup(cfg.CertLocation, "cert")
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := lookup(cfg.KeyLocation, "key")
+	if err != nil {
+		return nil, err
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("failed to parse secure connect bundle CA certificate")
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load secure connect bundle client certificate: %w", err)
+	}
+
+	return &astraBundle{
+		host:     cfg.Host,
+		port:     cfg.Port,
+		keyspace: cfg.Keyspace,
+		roots:    roots,
+		cert:     cert,
+	}, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+	return io.ReadAll(io.LimitReader(rc, 1<<20))
+}
+
+// tlsConfig returns mutual TLS settings for serverName. Astra's SNI proxy
+// presents its own certificate whatever node is requested, so the chain is
+// verified against the proxy host rather than the SNI value.
+func (b *astraBundle) tlsConfig(serverName, verifyHost string) *tls.Config {
+	return &tls.Config{
+		ServerName:         serverName,
+		Certificates:       []tls.Certificate{b.cert},
+		RootCAs:            b.roots,
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return fmt.Errorf("astra: no server certificate")
+			}
+			intermediates := x509.NewCertPool()
+			for _, cert := range cs.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+			_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+				DNSName:       verifyHost,
+				Roots:         b.roots,
+				Intermediates: intermediates,
+			})
+			return err
+		},
+	}
+}
+
+// configureAstra points cluster at the bundle's SNI proxy. The local
+// datacenter and keyspace come from the bundle unless the profile sets them.
+func configureAstra(cluster *gocql.ClusterConfig, cfg *ConnectionConfig) error {
+	bundle, err := loadAstraBundle(cfg.SecureConnectBundle)
+	if err != nil {
+		return err
+	}
+
+	timeout := dialTimeout(cfg)
+	dialer := newAstraDialer(bundle, timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	contact, err := dialer.contactInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConnectionFailed, err)
+	}
+
+	cluster.Hosts = []string{astraPlaceholderHost}
+	cluster.Port = astraDefaultProxyPort
+	if _, port, err := net.SplitHostPort(contact.SNIProxyAddress); err == nil {
+		if n, err := strconv.Atoi(port); err == nil {
+			cluster.Port = n
+		}
+	}
+	cluster.HostDialer = dialer
+
+	if cluster.Keyspace == "" {
+		cluster.Keyspace = bundle.keyspace
+	}
+	localDC := cfg.LocalDC
+	if localDC == "" {
+		localDC = contact.LocalDC
+	}
+	cluster.PoolConfig.HostSelectionPolicy = hostSelectionPolicy(localDC, cfg.TokenAware)
+
+	return nil
+}
+
+// astraDialer is a gocql.HostDialer that routes every node through the
+// bundle's SNI proxy, naming the node by host ID in the TLS handshake.
+type astraDialer struct {
+	bundle  *astraBundle
+	timeout time.Duration
+	client  *http.Client
+
+	mu        sync.Mutex
+	contact   *astraContactInfo
+	fetchedAt time.Time
+}
+
+func newAstraDialer(bundle *astraBundle, timeout time.Duration) *astraDialer {
+	return &astraDialer{
+		bundle:  bundle,
+		timeout: timeout,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{TLSClientConfig: bundle.tlsConfig(bundle.host, bundle.host)},
+		},
+	}
+}
+
+// contactInfo returns the proxy address and contact points, fetching them
+// from the metadata service when missing or stale.
+func (d *astraDialer) contactInfo(ctx context.Context) (*astraContactInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.contact != nil && time.Since(d.fetchedAt) < astraMetadataTTL {
+		return d.contact, nil
+	}
+
+	url := "https://" + net.JoinHostPort(d.bundle.host, strconv.Itoa(d.bundle.port)) + "/metadata"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch astra metadata: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch astra metadata: %s", resp.Status)
+	}
+
+	var metadata struct {
+		ContactInfo astraContactInfo `json:"contact_info"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse astra metadata: %w", err)
+	}
+	contact := metadata.ContactInfo
+	if contact.SNIProxyAddress == "" || len(contact.ContactPoints) == 0 {
+		return nil, fmt.Errorf("astra metadata has no proxy address or contact points")
+	}
+
+	d.contact = &contact
+	d.fetchedAt = time.Now()
+	return d.contact, nil
+}
+
+func (d *astraDialer) DialHost(ctx context.Context, host *gocql.HostInfo) (*gocql.DialedHost, error) {
+	contact, err := d.contactInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The initial contact point is a placeholder without a host ID; any
+	// node the metadata service lists will do.
+	hostID := host.HostID()
+	if hostID == "" {
+		hostID = contact.ContactPoints[rand.Intn(len(contact.ContactPoints))]
+	}
+
+	proxyHost, _, err := net.SplitHostPort(contact.SNIProxyAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid astra proxy address %q: %w", contact.SNIProxyAddress, err)
+	}
+
+	dialer := net.Dialer{Timeout: d.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", contact.SNIProxyAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConn := tls.Client(conn, d.bundle.tlsConfig(hostID, proxyHost))
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return &gocql.DialedHost{Conn: tlsConn, DisableCoalesce: true}, nil
+}
@@ -0,0 +1,308 @@
+package db
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate CA key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kassie test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create CA: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse CA: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pool: pool,
+	}
+}
+
+// issue returns a PEM certificate and key signed by the CA. Server
+// certificates are valid for 127.0.0.1.
+func (ca *testCA) issue(t *testing.T, serial int64, server bool) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "kassie test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if server {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		tmpl.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func (ca *testCA) serverTLS(t *testing.T) *tls.Config {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, 2, true)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("server key pair: %v", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    ca.pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+}
+
+func writeBundle(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "secure-connect-test.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create bundle: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("add %s: %v", name, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close bundle: %v", err)
+	}
+	_ = f.Close()
+	return path
+}
+
+func bundleFiles(t *testing.T, ca *testCA, host string, port int) map[string][]byte {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, 3, false)
+	config, err := json.Marshal(map[string]interface{}{
+		"host":           host,
+		"port":           port,
+		"keyspace":       "app",
+		"caCertLocation": "./ca.crt",
+		"certLocation":   "./cert",
+		"keyLocation":    "./key",
+	})
+	if err != nil {
+		t.Fatalf("marshal config.json: %v", err)
+	}
+	return map[string][]byte{
+		"config.json": config,
+		"ca.crt":      ca.pem,
+		"cert":        certPEM,
+		"key":         keyPEM,
+	}
+}
+
+func TestLoadAstraBundle(t *testing.T) {
+	ca := newTestCA(t)
+
+	bundle, err := loadAstraBundle(writeBundle(t, bundleFiles(t, ca, "db.astra.example.com", 29080)))
+	if err != nil {
+		t.Fatalf("loadAstraBundle() error = %v", err)
+	}
+	if bundle.host != "db.astra.example.com" || bundle.port != 29080 || bundle.keyspace != "app" {
+		t.Errorf("unexpected bundle %+v", bundle)
+	}
+	if len(bundle.cert.Certificate) == 0 {
+		t.Error("expected the client certificate to be loaded")
+	}
+
+	tests := []struct {
+		name    string
+		mod     func(map[string][]byte)
+		wantErr string
+	}{
+		{name: "no config", mod: func(f map[string][]byte) { delete(f, "config.json") }, wantErr: "config.json"},
+		{name: "no ca", mod: func(f map[string][]byte) { delete(f, "ca.crt") }, wantErr: "ca.crt"},
+		{name: "no key", mod: func(f map[string][]byte) { delete(f, "key") }, wantErr: "key"},
+		{name: "bad ca", mod: func(f map[string][]byte) { f["ca.crt"] = []byte("nope") }, wantErr: "CA certificate"},
+		{name: "bad config", mod: func(f map[string][]byte) { f["config.json"] = []byte("{") }, wantErr: "config.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := bundleFiles(t, ca, "db.astra.example.com", 29080)
+			tt.mod(files)
+			_, err := loadAstraBundle(writeBundle(t, files))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("loadAstraBundle() error = %v, want mention of %s", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := loadAstraBundle(filepath.Join(t.TempDir(), "missing.zip")); err == nil {
+		t.Error("expected an error for a missing bundle")
+	}
+}
+
+func TestAstraDialerRoutesBySNI(t *testing.T) {
+	ca := newTestCA(t)
+
+	// The SNI proxy records the server name of each handshake.
+	serverNames := make(chan string, 4)
+	proxyTLS := ca.serverTLS(t)
+	proxyTLS.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		serverNames <- hello.ServerName
+		return nil, nil
+	}
+	proxy, err := tls.Listen("tcp", "127.0.0.1:0", proxyTLS)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = proxy.Close() })
+	go func() {
+		for {
+			conn, err := proxy.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_ = conn.(*tls.Conn).Handshake()
+				_ = conn.Close()
+			}()
+		}
+	}()
+
+	metadata := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metadata" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"contact_info": map[string]interface{}{
+				"local_dc":          "us-east1",
+				"contact_points":    []string{"node-1"},
+				"sni_proxy_address": proxy.Addr().String(),
+			},
+		})
+	}))
+	metadata.TLS = ca.serverTLS(t)
+	metadata.StartTLS()
+	t.Cleanup(metadata.Close)
+
+	_, port, _ := net.SplitHostPort(metadata.Listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	bundle, err := loadAstraBundle(writeBundle(t, bundleFiles(t, ca, "127.0.0.1", portNum)))
+	if err != nil {
+		t.Fatalf("loadAstraBundle() error = %v", err)
+	}
+	dialer := newAstraDialer(bundle, 5*time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	contact, err := dialer.contactInfo(ctx)
+	if err != nil {
+		t.Fatalf("contactInfo() error = %v", err)
+	}
+	if contact.LocalDC != "us-east1" {
+		t.Errorf("LocalDC = %q, want us-east1", contact.LocalDC)
+	}
+
+	host := &gocql.HostInfo{}
+	host.SetHostID("4f5c2b1e-host")
+	dialed, err := dialer.DialHost(ctx, host)
+	if err != nil {
+		t.Fatalf("DialHost() error = %v", err)
+	}
+	_ = dialed.Conn.Close()
+	if !dialed.DisableCoalesce {
+		t.Error("expected coalescing to be disabled for TLS connections")
+	}
+	if got := <-serverNames; got != "4f5c2b1e-host" {
+		t.Errorf("SNI = %q, want the host ID", got)
+	}
+
+	dialed, err = dialer.DialHost(ctx, &gocql.HostInfo{})
+	if err != nil {
+		t.Fatalf("DialHost() error = %v", err)
+	}
+	_ = dialed.Conn.Close()
+	if got := <-serverNames; got != "node-1" {
+		t.Errorf("SNI = %q, want a contact point for the initial host", got)
+	}
+}
+
+func TestAstraDialerRejectsUntrustedProxy(t *testing.T) {
+	ca := newTestCA(t)
+	other := newTestCA(t)
+
+	metadata := httptest.NewUnstartedServer(http.NotFoundHandler())
+	metadata.TLS = other.serverTLS(t)
+	metadata.StartTLS()
+	t.Cleanup(metadata.Close)
+
+	_, port, _ := net.SplitHostPort(metadata.Listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	bundle, err := loadAstraBundle(writeBundle(t, bundleFiles(t, ca, "127.0.0.1", portNum)))
+	if err != nil {
+		t.Fatalf("loadAstraBundle() error = %v", err)
+	}
+
+	if _, err := newAstraDialer(bundle, 5*time.Second).contactInfo(context.Background()); err == nil {
+		t.Fatal("expected a certificate from another CA to be rejected")
+	}
+}
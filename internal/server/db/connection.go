@@ -1,11 +1,16 @@
 package db
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
 	"os"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/KashifKhn/kassie/internal/shared/config"
@@ -33,62 +38,255 @@ type ConnectionConfig struct {
 	SSLKeyPath    string
 	SSLCAPath     string
 	SSLSkipVerify bool
+
+	LocalDC                  string
+	TokenAware               bool
+	ConnectTimeout           time.Duration
+	ProtocolVersion          int
+	Compressor               gocql.Compressor
+	DisableInitialHostLookup bool
+	RetryPolicy              gocql.RetryPolicy
+	Speculative              gocql.SpeculativeExecutionPolicy
+
+	Tunnel              *config.TunnelConfig
+	SOCKS5              *config.SOCKS5Config
+	SecureConnectBundle string
+}
+
+// DefaultIdleTimeout is how long a pooled connection stays open after the
+// last session using it is released.
+const DefaultIdleTimeout = 5 * time.Minute
+
+const (
+	// DefaultHealthInterval is how often pooled connections are probed.
+	DefaultHealthInterval = 15 * time.Second
+	// degradedLatency marks a connection that answers slowly as degraded.
+	degradedLatency = time.Second
+	// downAfterFailures is how many failed probes in a row mark a connection
+	// down and trigger a reconnect.
+	downAfterFailures  = 2
+	healthCheckTimeout = 5 * time.Second
+)
+
+type HealthState string
+
+const (
+	HealthHealthy  HealthState = "healthy"
+	HealthDegraded HealthState = "degraded"
+	HealthDown     HealthState = "down"
+)
+
+type Health struct {
+	State      HealthState
+	LastError  string
+	Latency    time.Duration
+	CheckedAt  time.Time
+	Reconnects int
+}
+
+type PoolStats struct {
+	Profile  string
+	Refs     int
+	OpenedAt time.Time
+	LastUsed time.Time
+	Health   Health
+}
+
+// poolEntry is a profile's shared connection. conn is swapped under Pool.mu
+// when the connection is recreated and read without it by sessions, so they
+// follow a reconnect transparently.
+type poolEntry struct {
+	conn     atomic.Pointer[gocql.Session]
+	health   atomic.Pointer[Health]
+	cfg      *ConnectionConfig
+	refs     int
+	failures int
+	openedAt time.Time
+	lastUsed time.Time
+}
+
+func newPoolEntry(session *gocql.Session, cfg *ConnectionConfig, now time.Time) *poolEntry {
+	entry := &poolEntry{cfg: cfg, openedAt: now}
+	entry.conn.Store(session)
+	entry.health.Store(&Health{State: HealthHealthy, CheckedAt: now})
+	return entry
 }
 
+// Pool shares one cluster connection per profile between sessions. Sessions
+// acquire a reference and release it when they close; the connection is
+// closed once it has had no references for the idle timeout. When health
+// checks are started, dead connections are recreated in place.
 type Pool struct {
-	mu          sync.RWMutex
-	connections map[string]*gocql.Session
-	configs     map[string]*ConnectionConfig
+	mu          sync.Mutex
+	entries     map[string]*poolEntry
+	idleTimeout time.Duration
+	dial        func(*ConnectionConfig) (*gocql.Session, error)
+	ping        func(*gocql.Session) error
+	observer    QueryObserver
 	closed      bool
+	done        chan struct{}
+	closeOnce   sync.Once
 }
 
-func NewPool() *Pool {
-	return &Pool{
-		connections: make(map[string]*gocql.Session),
-		configs:     make(map[string]*ConnectionConfig),
-		closed:      false,
+// NewPool creates a pool. An idle timeout of zero closes connections as soon
+// as their last reference is released.
+func NewPool(idleTimeout time.Duration) *Pool {
+	p := &Pool{
+		entries:     make(map[string]*poolEntry),
+		idleTimeout: idleTimeout,
+		dial:        createSession,
+		ping:        pingSession,
+		done:        make(chan struct{}),
 	}
+	if idleTimeout > 0 {
+		go p.reaper()
+	}
+	return p
 }
 
-func (p *Pool) GetOrCreate(profileName string, cfg *ConnectionConfig) (*gocql.Session, error) {
+// SetQueryObserver reports the queries run on sessions handed out from now
+// on to o.
+func (p *Pool) SetQueryObserver(o QueryObserver) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	p.observer = o
+}
 
-	if p.closed {
-		return nil, ErrPoolClosed
-	}
+// Acquire returns a session on the profile's shared connection, opening it if
+// needed. Closing the returned session releases the reference.
+func (p *Pool) Acquire(profileName string, cfg *ConnectionConfig) (*Session, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for {
+		if p.closed {
+			return nil, ErrPoolClosed
+		}
+
+		entry := p.entries[profileName]
+		sameConfig := entry != nil && reflect.DeepEqual(entry.cfg, cfg)
+		if sameConfig && !entry.conn.Load().Closed() {
+			return p.acquireLocked(profileName, entry), nil
+		}
 
-	if session, exists := p.connections[profileName]; exists {
-		if !session.Closed() {
-			return session, nil
+		// Dial without the lock, so a slow or unreachable cluster does
+		// not hold up other profiles, releases, stats or health checks.
+		p.mu.Unlock()
+		fresh, err := p.dial(cfg)
+		p.mu.Lock()
+		if err != nil {
+			return nil, err
 		}
-		delete(p.connections, profileName)
+
+		// Another caller may have opened, replaced or closed the entry
+		// while we dialed; start over with what it left.
+		if p.closed || p.entries[profileName] != entry {
+			fresh.Close()
+			continue
+		}
+
+		now := time.Now()
+		switch {
+		case sameConfig && entry.conn.Load().Closed():
+			// Reconnect in place so sessions already holding the
+			// entry recover too.
+			p.replaceLocked(entry, fresh, now)
+		case sameConfig:
+			// A health check reconnected it in the meantime.
+			fresh.Close()
+		default:
+			// A profile changed by a config reload gets a new
+			// connection. Sessions on the old one keep it until they
+			// are released.
+			if entry != nil && entry.refs == 0 {
+				entry.conn.Load().Close()
+			}
+			entry = newPoolEntry(fresh, cfg, now)
+			p.entries[profileName] = entry
+		}
+		return p.acquireLocked(profileName, entry), nil
 	}
+}
 
-	session, err := createSession(cfg)
-	if err != nil {
-		return nil, err
+func (p *Pool) acquireLocked(profileName string, entry *poolEntry) *Session {
+	entry.refs++
+	entry.lastUsed = time.Now()
+
+	session := newPooledSession(entry, func() { p.release(profileName, entry) })
+	session.speculative = entry.cfg.Speculative
+	session.observe(profileName, p.observer)
+	return session
+}
+
+func (p *Pool) replaceLocked(entry *poolEntry, session *gocql.Session, now time.Time) {
+	old := entry.conn.Swap(session)
+	old.Close()
+
+	health := *entry.health.Load()
+	health.State = HealthHealthy
+	health.CheckedAt = now
+	health.Reconnects++
+	entry.health.Store(&health)
+	entry.failures = 0
+}
+
+func (p *Pool) release(profileName string, entry *poolEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry.refs--
+	entry.lastUsed = time.Now()
+	if entry.refs > 0 {
+		return
+	}
+
+	// A connection that was replaced or force-closed is no longer in the
+	// map and has nobody left to reap it.
+	current := p.entries[profileName] == entry
+	if current && p.idleTimeout > 0 && !p.closed {
+		return
 	}
+	if current {
+		delete(p.entries, profileName)
+	}
+	entry.conn.Load().Close()
+}
+
+// Open creates a connection that is not shared through the pool. It is used
+// for per-user credentials; closing the returned session closes it.
+func (p *Pool) Open(profileName string, cfg *ConnectionConfig) (*Session, error) {
+	p.mu.Lock()
+	closed, observer := p.closed, p.observer
+	p.mu.Unlock()
 
-	p.connections[profileName] = session
-	p.configs[profileName] = cfg
+	if closed {
+		return nil, ErrPoolClosed
+	}
 
+	conn, err := p.dial(cfg)
+	if err != nil {
+		return nil, err
+	}
+	session := NewSession(conn)
+	session.speculative = cfg.Speculative
+	session.observe(profileName, observer)
 	return session, nil
 }
 
 func (p *Pool) Get(profileName string) (*gocql.Session, error) {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
 	if p.closed {
 		return nil, ErrPoolClosed
 	}
 
-	session, exists := p.connections[profileName]
+	entry, exists := p.entries[profileName]
 	if !exists {
 		return nil, fmt.Errorf("connection not found for profile: %s", profileName)
 	}
 
+	session := entry.conn.Load()
 	if session.Closed() {
 		return nil, fmt.Errorf("connection closed for profile: %s", profileName)
 	}
@@ -96,47 +294,205 @@ func (p *Pool) Get(profileName string) (*gocql.Session, error) {
 	return session, nil
 }
 
+// Close closes the profile's connection regardless of references.
 func (p *Pool) Close(profileName string) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	session, exists := p.connections[profileName]
+	entry, exists := p.entries[profileName]
 	if !exists {
 		return nil
 	}
 
-	session.Close()
-	delete(p.connections, profileName)
-	delete(p.configs, profileName)
+	entry.conn.Load().Close()
+	delete(p.entries, profileName)
 
 	return nil
 }
 
 func (p *Pool) CloseAll() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+	})
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	for _, session := range p.connections {
-		session.Close()
+	for _, entry := range p.entries {
+		entry.conn.Load().Close()
 	}
 
-	p.connections = make(map[string]*gocql.Session)
-	p.configs = make(map[string]*ConnectionConfig)
+	p.entries = make(map[string]*poolEntry)
 	p.closed = true
 }
 
 func (p *Pool) ListProfiles() []string {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	profiles := make([]string, 0, len(p.connections))
-	for name := range p.connections {
+	profiles := make([]string, 0, len(p.entries))
+	for name := range p.entries {
 		profiles = append(profiles, name)
 	}
 
 	return profiles
 }
 
+// Stats reports the open shared connections, sorted by profile.
+func (p *Pool) Stats() []PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]PoolStats, 0, len(p.entries))
+	for name, entry := range p.entries {
+		stats = append(stats, PoolStats{
+			Profile:  name,
+			Refs:     entry.refs,
+			OpenedAt: entry.openedAt,
+			LastUsed: entry.lastUsed,
+			Health:   *entry.health.Load(),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Profile < stats[j].Profile
+	})
+	return stats
+}
+
+func (p *Pool) reaper() {
+	interval := p.idleTimeout
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			p.reap(now)
+		}
+	}
+}
+
+// reap closes connections that have been unreferenced for the idle timeout.
+func (p *Pool) reap(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for name, entry := range p.entries {
+		if entry.refs == 0 && now.Sub(entry.lastUsed) >= p.idleTimeout {
+			entry.conn.Load().Close()
+			delete(p.entries, name)
+		}
+	}
+}
+
+// StartHealthChecks probes every pooled connection at interval until the
+// pool is closed.
+func (p *Pool) StartHealthChecks(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-ticker.C:
+				p.checkHealth()
+			}
+		}
+	}()
+}
+
+// checkHealth probes each pooled connection outside the pool lock. A
+// connection that is closed or fails downAfterFailures probes in a row is
+// marked down and redialed under the same profile.
+func (p *Pool) checkHealth() {
+	p.mu.Lock()
+	entries := make(map[string]*poolEntry, len(p.entries))
+	for name, entry := range p.entries {
+		entries[name] = entry
+	}
+	p.mu.Unlock()
+
+	for name, entry := range entries {
+		p.checkEntry(name, entry)
+	}
+}
+
+func (p *Pool) checkEntry(profileName string, entry *poolEntry) {
+	session := entry.conn.Load()
+	start := time.Now()
+
+	var err error
+	if session.Closed() {
+		err = fmt.Errorf("connection closed")
+	} else {
+		err = p.ping(session)
+	}
+
+	now := time.Now()
+	health := *entry.health.Load()
+	health.CheckedAt = now
+
+	p.mu.Lock()
+	if err == nil {
+		entry.failures = 0
+		health.Latency = now.Sub(start)
+		health.State = HealthHealthy
+		if health.Latency > degradedLatency {
+			health.State = HealthDegraded
+		}
+		entry.health.Store(&health)
+		p.mu.Unlock()
+		return
+	}
+
+	entry.failures++
+	health.LastError = err.Error()
+	health.State = HealthDegraded
+	if entry.failures >= downAfterFailures || session.Closed() {
+		health.State = HealthDown
+	}
+	entry.health.Store(&health)
+	p.mu.Unlock()
+
+	if health.State != HealthDown {
+		return
+	}
+
+	fresh, err := p.dial(entry.cfg)
+	if err != nil {
+		health.LastError = err.Error()
+		entry.health.Store(&health)
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// The entry may have been reaped or replaced while dialing.
+	if p.closed || p.entries[profileName] != entry {
+		fresh.Close()
+		return
+	}
+	p.replaceLocked(entry, fresh, time.Now())
+}
+
+func pingSession(session *gocql.Session) error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	return session.Query(`SELECT release_version FROM system.local`).WithContext(ctx).Exec()
+}
+
 func createSession(cfg *ConnectionConfig) (*gocql.Session, error) {
 	if err := validateConfig(cfg); err != nil {
 		return nil, err
@@ -148,6 +504,30 @@ func createSession(cfg *ConnectionConfig) (*gocql.Session, error) {
 	cluster.Consistency = cfg.Consistency
 	cluster.Timeout = cfg.Timeout
 	cluster.NumConns = cfg.PoolSize
+	cluster.ProtoVersion = cfg.ProtocolVersion
+	cluster.Compressor = cfg.Compressor
+	cluster.DisableInitialHostLookup = cfg.DisableInitialHostLookup
+	if cfg.ConnectTimeout > 0 {
+		cluster.ConnectTimeout = cfg.ConnectTimeout
+	}
+	if cfg.RetryPolicy != nil {
+		cluster.RetryPolicy = cfg.RetryPolicy
+	}
+	cluster.PoolConfig.HostSelectionPolicy = hostSelectionPolicy(cfg.LocalDC, cfg.TokenAware)
+
+	dialer, err := newDialer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if dialer != nil {
+		cluster.Dialer = dialer
+	}
+
+	if cfg.SecureConnectBundle != "" {
+		if err := configureAstra(cluster, cfg); err != nil {
+			return nil, err
+		}
+	}
 
 	if cfg.Username != "" && cfg.Password != "" {
 		cluster.Authenticator = gocql.PasswordAuthenticator{
@@ -157,30 +537,10 @@ func createSession(cfg *ConnectionConfig) (*gocql.Session, error) {
 	}
 
 	if cfg.SSLEnabled {
-		tlsConfig := &tls.Config{
-			InsecureSkipVerify: cfg.SSLSkipVerify,
-		}
-
-		if cfg.SSLCertPath != "" && cfg.SSLKeyPath != "" {
-			cert, err := tls.LoadX509KeyPair(cfg.SSLCertPath, cfg.SSLKeyPath)
-			if err != nil {
-				return nil, fmt.Errorf("failed to load SSL cert/key: %w", err)
-			}
-			tlsConfig.Certificates = []tls.Certificate{cert}
+		tlsConfig, err := clientTLSConfig(cfg)
+		if err != nil {
+			return nil, err
 		}
-
-		if cfg.SSLCAPath != "" {
-			caCert, err := os.ReadFile(cfg.SSLCAPath)
-			if err != nil {
-				return nil, fmt.Errorf("failed to read CA cert: %w", err)
-			}
-			caCertPool := x509.NewCertPool()
-			if !caCertPool.AppendCertsFromPEM(caCert) {
-				return nil, fmt.Errorf("failed to parse CA certificate")
-			}
-			tlsConfig.RootCAs = caCertPool
-		}
-
 		cluster.SslOpts = &gocql.SslOptions{
 			Config: tlsConfig,
 		}
@@ -194,17 +554,72 @@ func createSession(cfg *ConnectionConfig) (*gocql.Session, error) {
 	return session, nil
 }
 
+func clientTLSConfig(cfg *ConnectionConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.SSLSkipVerify,
+	}
+
+	if cfg.SSLCertPath != "" && cfg.SSLKeyPath != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.SSLCertPath, cfg.SSLKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SSL cert/key: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.SSLCAPath != "" {
+		caCert, err := os.ReadFile(cfg.SSLCAPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA cert: %w", err)
+		}
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse CA certificate")
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	return tlsConfig, nil
+}
+
+// dialTimeout bounds establishing a single connection.
+func dialTimeout(cfg *ConnectionConfig) time.Duration {
+	if cfg.ConnectTimeout > 0 {
+		return cfg.ConnectTimeout
+	}
+	if cfg.Timeout > 0 {
+		return cfg.Timeout
+	}
+	return tunnelDialTimeout
+}
+
+// hostSelectionPolicy routes queries to the local datacenter when one is set
+// and, unless disabled, to the replicas owning the partition.
+func hostSelectionPolicy(localDC string, tokenAware bool) gocql.HostSelectionPolicy {
+	policy := gocql.RoundRobinHostPolicy()
+	if localDC != "" {
+		policy = gocql.DCAwareRoundRobinPolicy(localDC)
+	}
+	if tokenAware {
+		policy = gocql.TokenAwareHostPolicy(policy)
+	}
+	return policy
+}
+
 func validateConfig(cfg *ConnectionConfig) error {
 	if cfg == nil {
 		return fmt.Errorf("config is nil")
 	}
 
-	if len(cfg.Hosts) == 0 {
-		return ErrNoHosts
-	}
+	// A secure connect bundle supplies the hosts and port.
+	if cfg.SecureConnectBundle == "" {
+		if len(cfg.Hosts) == 0 {
+			return ErrNoHosts
+		}
 
-	if cfg.Port < 1 || cfg.Port > 65535 {
-		return ErrInvalidPort
+		if cfg.Port < 1 || cfg.Port > 65535 {
+			return ErrInvalidPort
+		}
 	}
 
 	if cfg.Timeout <= 0 {
@@ -222,7 +637,15 @@ func validateConfig(cfg *ConnectionConfig) error {
 	return nil
 }
 
-func ProfileToConnectionConfig(profile *config.Profile) *ConnectionConfig {
+// ProfileToConnectionConfig maps a profile onto driver settings. Secret
+// references in its credentials are resolved here, when the profile is
+// connected to, rather than when the config is loaded.
+func ProfileToConnectionConfig(profile *config.Profile) (*ConnectionConfig, error) {
+	profile, err := profile.ResolveSecrets()
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &ConnectionConfig{
 		Hosts:       profile.Hosts,
 		Port:        profile.Port,
@@ -230,6 +653,7 @@ func ProfileToConnectionConfig(profile *config.Profile) *ConnectionConfig {
 		Consistency: gocql.Quorum,
 		Timeout:     10 * time.Second,
 		PoolSize:    5,
+		TokenAware:  true,
 	}
 
 	if profile.Auth != nil {
@@ -245,5 +669,60 @@ func ProfileToConnectionConfig(profile *config.Profile) *ConnectionConfig {
 		cfg.SSLSkipVerify = profile.SSL.InsecureSkipVerify
 	}
 
-	return cfg
+	if profile.Driver != nil {
+		applyDriverConfig(cfg, profile.Driver)
+	}
+
+	cfg.Tunnel = profile.Tunnel
+	cfg.SOCKS5 = profile.SOCKS5
+	cfg.SecureConnectBundle = profile.SecureConnectBundle
+
+	return cfg, nil
+}
+
+// applyDriverConfig maps a validated driver block onto cfg.
+func applyDriverConfig(cfg *ConnectionConfig, d *config.DriverConfig) {
+	cfg.LocalDC = d.LocalDC
+	cfg.TokenAware = !d.DisableTokenAware
+	cfg.ProtocolVersion = d.ProtocolVersion
+	cfg.DisableInitialHostLookup = d.DisableInitialHostLookup
+
+	if d.Consistency != "" {
+		if consistency, err := gocql.ParseConsistencyWrapper(strings.ToUpper(d.Consistency)); err == nil {
+			cfg.Consistency = consistency
+		}
+	}
+	if d.ConnectTimeoutMs > 0 {
+		cfg.ConnectTimeout = time.Duration(d.ConnectTimeoutMs) * time.Millisecond
+	}
+	if d.TimeoutMs > 0 {
+		cfg.Timeout = time.Duration(d.TimeoutMs) * time.Millisecond
+	}
+
+	switch d.Compression {
+	case config.CompressionSnappy:
+		cfg.Compressor = gocql.SnappyCompressor{}
+	case config.CompressionLZ4:
+		cfg.Compressor = LZ4Compressor{}
+	}
+
+	if r := d.Retry; r != nil {
+		switch r.Policy {
+		case config.RetryPolicySimple:
+			cfg.RetryPolicy = &gocql.SimpleRetryPolicy{NumRetries: r.MaxRetries}
+		case config.RetryPolicyExponential:
+			cfg.RetryPolicy = &gocql.ExponentialBackoffRetryPolicy{
+				NumRetries: r.MaxRetries,
+				Min:        time.Duration(r.GetMinBackoffMs()) * time.Millisecond,
+				Max:        time.Duration(r.GetMaxBackoffMs()) * time.Millisecond,
+			}
+		}
+	}
+
+	if s := d.SpeculativeExecution; s != nil {
+		cfg.Speculative = &gocql.SimpleSpeculativeExecution{
+			NumAttempts:  s.Attempts,
+			TimeoutDelay: time.Duration(s.DelayMs) * time.Millisecond,
+		}
+	}
 }
@@ -1,6 +1,8 @@
 package db
 
 import (
+	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -151,6 +153,52 @@ func TestProfileToConnectionConfig(t *testing.T) {
 					cfg.SSLSkipVerify
 			},
 		},
+		{
+			name: "profile with driver settings",
+			profile: &config.Profile{
+				Name:  "test",
+				Hosts: []string{"localhost"},
+				Port:  9042,
+				Driver: &config.DriverConfig{
+					LocalDC:                  "dc1",
+					Consistency:              "local_quorum",
+					ConnectTimeoutMs:         2000,
+					TimeoutMs:                3000,
+					ProtocolVersion:          4,
+					Compression:              config.CompressionLZ4,
+					DisableInitialHostLookup: true,
+					Retry:                    &config.RetryConfig{Policy: config.RetryPolicyExponential, MaxRetries: 3},
+					SpeculativeExecution:     &config.SpeculativeConfig{Attempts: 2, DelayMs: 50},
+				},
+			},
+			check: func(cfg *ConnectionConfig) bool {
+				retry, ok := cfg.RetryPolicy.(*gocql.ExponentialBackoffRetryPolicy)
+				_, lz4 := cfg.Compressor.(LZ4Compressor)
+				return cfg.LocalDC == "dc1" &&
+					cfg.TokenAware &&
+					cfg.Consistency == gocql.LocalQuorum &&
+					cfg.ConnectTimeout == 2*time.Second &&
+					cfg.Timeout == 3*time.Second &&
+					cfg.ProtocolVersion == 4 &&
+					lz4 &&
+					cfg.DisableInitialHostLookup &&
+					ok && retry.NumRetries == 3 && retry.Min == 100*time.Millisecond &&
+					cfg.Speculative != nil && cfg.Speculative.Attempts() == 2
+			},
+		},
+		{
+			name: "profile with token awareness disabled",
+			profile: &config.Profile{
+				Name:   "test",
+				Hosts:  []string{"localhost"},
+				Port:   9042,
+				Driver: &config.DriverConfig{DisableTokenAware: true, Compression: config.CompressionSnappy},
+			},
+			check: func(cfg *ConnectionConfig) bool {
+				_, snappy := cfg.Compressor.(gocql.SnappyCompressor)
+				return !cfg.TokenAware && snappy && cfg.RetryPolicy == nil
+			},
+		},
 		{
 			name: "profile with keyspace",
 			profile: &config.Profile{
@@ -167,7 +215,10 @@ func TestProfileToConnectionConfig(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := ProfileToConnectionConfig(tt.profile)
+			cfg, err := ProfileToConnectionConfig(tt.profile)
+			if err != nil {
+				t.Fatalf("ProfileToConnectionConfig() error = %v", err)
+			}
 
 			if cfg == nil {
 				t.Fatal("ProfileToConnectionConfig() returned nil")
@@ -181,18 +232,15 @@ func TestProfileToConnectionConfig(t *testing.T) {
 }
 
 func TestNewPool(t *testing.T) {
-	pool := NewPool()
+	pool := NewPool(DefaultIdleTimeout)
+	defer pool.CloseAll()
 
 	if pool == nil {
 		t.Fatal("NewPool() returned nil")
 	}
 
-	if pool.connections == nil {
-		t.Error("connections map not initialized")
-	}
-
-	if pool.configs == nil {
-		t.Error("configs map not initialized")
+	if pool.entries == nil {
+		t.Error("entries map not initialized")
 	}
 
 	if pool.closed {
@@ -201,7 +249,7 @@ func TestNewPool(t *testing.T) {
 }
 
 func TestPoolListProfiles(t *testing.T) {
-	pool := NewPool()
+	pool := NewPool(0)
 
 	profiles := pool.ListProfiles()
 	if len(profiles) != 0 {
@@ -210,7 +258,7 @@ func TestPoolListProfiles(t *testing.T) {
 }
 
 func TestPoolClosedState(t *testing.T) {
-	pool := NewPool()
+	pool := NewPool(0)
 	pool.CloseAll()
 
 	if !pool.closed {
@@ -222,9 +270,14 @@ func TestPoolClosedState(t *testing.T) {
 		Port:  9042,
 	}
 
-	_, err := pool.GetOrCreate("test", cfg)
+	_, err := pool.Acquire("test", cfg)
 	if err != ErrPoolClosed {
-		t.Errorf("GetOrCreate() on closed pool error = %v, want %v", err, ErrPoolClosed)
+		t.Errorf("Acquire() on closed pool error = %v, want %v", err, ErrPoolClosed)
+	}
+
+	_, err = pool.Open("test", cfg)
+	if err != ErrPoolClosed {
+		t.Errorf("Open() on closed pool error = %v, want %v", err, ErrPoolClosed)
 	}
 
 	_, err = pool.Get("test")
@@ -234,10 +287,345 @@ func TestPoolClosedState(t *testing.T) {
 }
 
 func TestPoolClose(t *testing.T) {
-	pool := NewPool()
+	pool := NewPool(0)
 
 	err := pool.Close("nonexistent")
 	if err != nil {
 		t.Errorf("Close() on nonexistent profile error = %v, want nil", err)
 	}
 }
+
+func newTestPool(idleTimeout time.Duration) (*Pool, *int) {
+	pool := NewPool(idleTimeout)
+	dials := 0
+	pool.dial = func(cfg *ConnectionConfig) (*gocql.Session, error) {
+		dials++
+		return &gocql.Session{}, nil
+	}
+	return pool, &dials
+}
+
+type recordedQuery struct {
+	profile string
+	rows    int
+	err     error
+}
+
+type recordingObserver struct {
+	queries []recordedQuery
+}
+
+func (o *recordingObserver) ObserveQuery(profile string, elapsed time.Duration, rows int, err error) {
+	o.queries = append(o.queries, recordedQuery{profile: profile, rows: rows, err: err})
+}
+
+func TestPoolQueryObserver(t *testing.T) {
+	pool, _ := newTestPool(0)
+	cfg := &ConnectionConfig{Hosts: []string{"localhost"}, Port: 9042}
+
+	before, err := pool.Acquire("dev", cfg)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	before.report(time.Now(), 3, nil)
+
+	observer := &recordingObserver{}
+	pool.SetQueryObserver(observer)
+
+	shared, err := pool.Acquire("dev", cfg)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	dedicated, err := pool.Open("prod", cfg)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	failed := errors.New("timeout")
+	shared.report(time.Now(), 2, nil)
+	dedicated.report(time.Now(), 0, failed)
+
+	want := []recordedQuery{{profile: "dev", rows: 2}, {profile: "prod", err: failed}}
+	if len(observer.queries) != len(want) {
+		t.Fatalf("observed %+v, want %+v", observer.queries, want)
+	}
+	for i, q := range observer.queries {
+		if q != want[i] {
+			t.Errorf("query %d = %+v, want %+v", i, q, want[i])
+		}
+	}
+}
+
+func TestPoolAcquireRelease(t *testing.T) {
+	pool, dials := newTestPool(0)
+	cfg := &ConnectionConfig{Hosts: []string{"localhost"}, Port: 9042}
+
+	first, err := pool.Acquire("dev", cfg)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	second, err := pool.Acquire("dev", cfg)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if *dials != 1 {
+		t.Fatalf("expected one shared connection, dialed %d", *dials)
+	}
+	if stats := pool.Stats(); len(stats) != 1 || stats[0].Refs != 2 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	shared := first.conn()
+	first.Close()
+	first.Close()
+	if !first.Closed() {
+		t.Error("expected released session to report closed")
+	}
+	if shared.Closed() {
+		t.Fatal("connection closed while another session still uses it")
+	}
+	if second.Closed() {
+		t.Error("expected the other session to stay open")
+	}
+
+	second.Close()
+	if !shared.Closed() {
+		t.Error("expected connection to close with the last reference")
+	}
+	if len(pool.Stats()) != 0 {
+		t.Errorf("expected no pooled connections, got %+v", pool.Stats())
+	}
+}
+
+func TestPoolIdleTimeout(t *testing.T) {
+	pool, dials := newTestPool(time.Minute)
+	defer pool.CloseAll()
+	cfg := &ConnectionConfig{Hosts: []string{"localhost"}, Port: 9042}
+
+	session, err := pool.Acquire("dev", cfg)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	shared := session.conn()
+	session.Close()
+
+	if shared.Closed() {
+		t.Fatal("expected idle connection to stay open until the timeout")
+	}
+
+	again, err := pool.Acquire("dev", cfg)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if *dials != 1 {
+		t.Errorf("expected idle connection to be reused, dialed %d", *dials)
+	}
+
+	pool.reap(time.Now().Add(time.Hour))
+	if shared.Closed() {
+		t.Fatal("reaped a connection that is still referenced")
+	}
+
+	again.Close()
+	pool.reap(time.Now().Add(30 * time.Second))
+	if shared.Closed() {
+		t.Fatal("reaped a connection before the idle timeout")
+	}
+	pool.reap(time.Now().Add(2 * time.Minute))
+	if !shared.Closed() {
+		t.Error("expected idle connection to be closed after the timeout")
+	}
+}
+
+func TestPoolReplacesClosedConnection(t *testing.T) {
+	pool, dials := newTestPool(time.Minute)
+	defer pool.CloseAll()
+	cfg := &ConnectionConfig{Hosts: []string{"localhost"}, Port: 9042}
+
+	old, _ := pool.Acquire("dev", cfg)
+	stale := old.conn()
+	stale.Close()
+
+	fresh, err := pool.Acquire("dev", cfg)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if *dials != 2 || fresh.conn() == stale {
+		t.Fatal("expected a closed connection to be replaced")
+	}
+	if old.conn() != fresh.conn() || old.Closed() {
+		t.Fatal("expected existing sessions to follow the new connection")
+	}
+
+	old.Close()
+	if fresh.Closed() {
+		t.Error("releasing the stale session must not affect the new connection")
+	}
+	if stats := pool.Stats(); len(stats) != 1 || stats[0].Refs != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestPoolRedialsChangedConfig(t *testing.T) {
+	pool, dials := newTestPool(time.Minute)
+	defer pool.CloseAll()
+
+	old, err := pool.Acquire("dev", &ConnectionConfig{Hosts: []string{"localhost"}, Port: 9042})
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	other, err := pool.Acquire("dev", &ConnectionConfig{Hosts: []string{"localhost"}, Port: 9042})
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if *dials != 1 {
+		t.Fatalf("expected an equal config to share the connection, dialed %d", *dials)
+	}
+
+	fresh, err := pool.Acquire("dev", &ConnectionConfig{Hosts: []string{"otherhost"}, Port: 9042})
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if *dials != 2 || fresh.conn() == old.conn() {
+		t.Fatal("expected a changed config to get a new connection")
+	}
+	if old.Closed() || old.conn().Closed() {
+		t.Fatal("expected sessions on the old config to keep their connection")
+	}
+
+	stale := old.conn()
+	old.Close()
+	other.Close()
+	if !stale.Closed() {
+		t.Error("expected the old connection to close with its last session")
+	}
+	if fresh.Closed() || fresh.conn().Closed() {
+		t.Error("releasing old sessions must not affect the new connection")
+	}
+	if stats := pool.Stats(); len(stats) != 1 || stats[0].Refs != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestPoolDialsWithoutLock(t *testing.T) {
+	pool := NewPool(time.Minute)
+	defer pool.CloseAll()
+
+	dialing := make(chan struct{})
+	unblock := make(chan struct{})
+	var dials atomic.Int32
+	pool.dial = func(cfg *ConnectionConfig) (*gocql.Session, error) {
+		dials.Add(1)
+		if cfg.Hosts[0] == "slow" {
+			dialing <- struct{}{}
+			<-unblock
+		}
+		return &gocql.Session{}, nil
+	}
+	slow := &ConnectionConfig{Hosts: []string{"slow"}, Port: 9042}
+
+	results := make(chan *Session, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			session, err := pool.Acquire("slow", slow)
+			if err != nil {
+				t.Errorf("Acquire() error = %v", err)
+			}
+			results <- session
+		}()
+		<-dialing
+	}
+
+	fast, err := pool.Acquire("fast", &ConnectionConfig{Hosts: []string{"fast"}, Port: 9042})
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	defer fast.Close()
+	if stats := pool.Stats(); len(stats) != 1 || stats[0].Profile != "fast" {
+		t.Errorf("stats while dialing = %+v", stats)
+	}
+
+	close(unblock)
+	first, second := <-results, <-results
+	defer first.Close()
+	defer second.Close()
+	if first.conn() != second.conn() {
+		t.Error("concurrent acquires of one profile opened separate connections")
+	}
+	if got := dials.Load(); got != 3 {
+		t.Errorf("dialed %d times, want 3", got)
+	}
+	if stats := pool.Stats(); len(stats) != 2 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestPoolHealthCheck(t *testing.T) {
+	pool, dials := newTestPool(time.Minute)
+	defer pool.CloseAll()
+	cfg := &ConnectionConfig{Hosts: []string{"localhost"}, Port: 9042}
+
+	var pingErr error
+	pool.ping = func(*gocql.Session) error { return pingErr }
+
+	session, err := pool.Acquire("dev", cfg)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	defer session.Close()
+	original := session.conn()
+
+	steps := []struct {
+		name       string
+		err        error
+		state      HealthState
+		reconnects int
+	}{
+		{name: "healthy", state: HealthHealthy},
+		{name: "first failure", err: errors.New("timeout"), state: HealthDegraded},
+		{name: "second failure reconnects", err: errors.New("timeout"), state: HealthHealthy, reconnects: 1},
+		{name: "recovered", state: HealthHealthy, reconnects: 1},
+	}
+
+	for _, step := range steps {
+		pingErr = step.err
+		pool.checkHealth()
+
+		health := session.Health()
+		if health.State != step.state || health.Reconnects != step.reconnects {
+			t.Fatalf("%s: got %+v", step.name, health)
+		}
+	}
+
+	if *dials != 2 {
+		t.Errorf("expected one reconnect, dialed %d", *dials)
+	}
+	if !original.Closed() || session.conn() == original {
+		t.Error("expected the dead connection to be replaced")
+	}
+	if session.Health().LastError != "timeout" {
+		t.Errorf("expected last error to be kept, got %+v", session.Health())
+	}
+}
+
+func TestPoolHealthCheckReconnectFails(t *testing.T) {
+	pool, _ := newTestPool(time.Minute)
+	defer pool.CloseAll()
+	cfg := &ConnectionConfig{Hosts: []string{"localhost"}, Port: 9042}
+
+	session, _ := pool.Acquire("dev", cfg)
+	defer session.Close()
+
+	session.conn().Close()
+	pool.dial = func(*ConnectionConfig) (*gocql.Session, error) {
+		return nil, ErrConnectionFailed
+	}
+	pool.checkHealth()
+
+	health := session.Health()
+	if health.State != HealthDown || health.LastError != ErrConnectionFailed.Error() {
+		t.Errorf("unexpected health %+v", health)
+	}
+	if stats := pool.Stats(); len(stats) != 1 || stats[0].Health.State != HealthDown {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
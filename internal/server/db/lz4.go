@@ -0,0 +1,53 @@
+package db
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	lz4 "github.com/bkaradzic/go-lz4"
+)
+
+// lz4MaxFrameSize bounds the declared uncompressed size, matching the native
+// protocol's 256MB frame limit.
+const lz4MaxFrameSize = 256 << 20
+
+var errLZ4Corrupt = errors.New("lz4: corrupt input")
+
+// LZ4Compressor implements gocql.Compressor using the native protocol's lz4
+// framing: a big-endian uncompressed length followed by one LZ4 block.
+// go-lz4 writes the same block behind a little-endian length, so only the
+// prefix is rewritten.
+type LZ4Compressor struct{}
+
+func (LZ4Compressor) Name() string {
+	return "lz4"
+}
+
+func (LZ4Compressor) Encode(data []byte) ([]byte, error) {
+	out, err := lz4.Encode(nil, data)
+	if err != nil {
+		return nil, fmt.Errorf("lz4: %w", err)
+	}
+	binary.BigEndian.PutUint32(out, uint32(len(data)))
+	return out, nil
+}
+
+func (LZ4Compressor) Decode(data []byte) ([]byte, error) {
+	if len(data) < 4 {
+		return nil, errLZ4Corrupt
+	}
+	size := binary.BigEndian.Uint32(data)
+	if size > lz4MaxFrameSize {
+		return nil, fmt.Errorf("lz4: uncompressed size %d exceeds limit", size)
+	}
+
+	frame := make([]byte, len(data))
+	copy(frame, data)
+	binary.LittleEndian.PutUint32(frame, size)
+	out, err := lz4.Decode(nil, frame)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errLZ4Corrupt, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"testing"
+)
+
+func TestLZ4CompressorRoundTrip(t *testing.T) {
+	random := make([]byte, 70000)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "short", data: []byte("hello")},
+		{name: "repeated", data: bytes.Repeat([]byte("kassie "), 1000)},
+		{name: "run", data: bytes.Repeat([]byte{0}, 5000)},
+		{name: "random", data: random},
+		{name: "mixed", data: append(append([]byte{}, random[:300]...), bytes.Repeat(random[:300], 300)...)},
+	}
+
+	var c LZ4Compressor
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := c.Encode(tt.data)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			decoded, err := c.Decode(encoded)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !bytes.Equal(decoded, tt.data) {
+				t.Fatal("round trip changed the data")
+			}
+		})
+	}
+
+	encoded, _ := c.Encode(bytes.Repeat([]byte("kassie "), 1000))
+	if len(encoded) > 200 {
+		t.Errorf("expected repetitive data to compress, got %d bytes", len(encoded))
+	}
+}
+
+func TestLZ4DecodeOverlappingMatch(t *testing.T) {
+	// "abcabcabcabcabc" as three literals followed by an overlapping 12 byte
+	// match at offset 3, then the five literals every block ends with.
+	block := []byte{0x38, 'a', 'b', 'c', 0x03, 0x00, 0x50, 'x', 'y', 'z', 'z', 'y'}
+	data := make([]byte, 4, 4+len(block))
+	binary.BigEndian.PutUint32(data, 20)
+	data = append(data, block...)
+
+	decoded, err := LZ4Compressor{}.Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if string(decoded) != "abcabcabcabcabcxyzzy" {
+		t.Errorf("Decode() = %q", decoded)
+	}
+}
+
+func TestLZ4DecodeCorrupt(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "missing length", data: []byte{0, 0}},
+		{name: "truncated literals", data: []byte{0, 0, 0, 5, 0x50, 'a'}},
+		{name: "zero offset", data: []byte{0, 0, 0, 8, 0x10, 'a', 0, 0}},
+		{name: "offset past start", data: []byte{0, 0, 0, 8, 0x10, 'a', 2, 0}},
+		{name: "oversized", data: []byte{0xff, 0xff, 0xff, 0xff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := (LZ4Compressor{}).Decode(tt.data); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,404 @@
+package db
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Stages run by TestConnection, in order.
+const (
+	StageDNS      = "dns"
+	StageTCP      = "tcp"
+	StageTLS      = "tls"
+	StageAuth     = "auth"
+	StageQuery    = "query"
+	StageKeyspace = "keyspace"
+)
+
+type CheckStatus int
+
+const (
+	CheckPassed CheckStatus = iota + 1
+	CheckFailed
+	CheckSkipped
+)
+
+// Check is the outcome of one stage of TestConnection. The network stages
+// report one check per host.
+type Check struct {
+	Stage   string
+	Host    string
+	Status  CheckStatus
+	Latency time.Duration
+	Detail  string
+	Hint    string
+}
+
+// TestConnection checks stage by stage that cfg can reach and use its
+// cluster. The network stages go on with the hosts that passed the previous
+// one; once a stage has no host left, or a cluster stage fails, the remaining
+// stages are skipped. The driver session opened for the auth stage is closed
+// before returning.
+func TestConnection(ctx context.Context, cfg *ConnectionConfig) []Check {
+	if err := validateConfig(cfg); err != nil {
+		return []Check{{Stage: StageDNS, Status: CheckFailed, Detail: err.Error(), Hint: "check the profile's hosts and port"}}
+	}
+
+	p := &prober{cfg: cfg, timeout: dialTimeout(cfg)}
+
+	if cfg.SecureConnectBundle != "" {
+		// The bundle's metadata service names the nodes and its proxy
+		// terminates mutual TLS, so only the driver can check them.
+		for _, stage := range []string{StageDNS, StageTCP, StageTLS} {
+			p.add(Check{Stage: stage, Status: CheckSkipped, Detail: "handled by the secure connect bundle"})
+		}
+	} else {
+		hosts := p.addresses()
+		for _, stage := range []func(context.Context, []string) []string{p.resolve, p.reach, p.handshake} {
+			hosts = stage(ctx, hosts)
+			if len(hosts) == 0 {
+				return append(p.checks, skipFrom(StageAuth, "no host passed the previous stage")...)
+			}
+		}
+	}
+
+	p.cluster(ctx)
+	return p.checks
+}
+
+type prober struct {
+	cfg     *ConnectionConfig
+	timeout time.Duration
+	checks  []Check
+}
+
+func (p *prober) add(c Check) {
+	p.checks = append(p.checks, c)
+}
+
+// addresses lists the configured hosts as host:port.
+func (p *prober) addresses() []string {
+	addrs := make([]string, 0, len(p.cfg.Hosts))
+	for _, host := range p.cfg.Hosts {
+		if _, _, err := net.SplitHostPort(host); err == nil {
+			addrs = append(addrs, host)
+			continue
+		}
+		addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(p.cfg.Port)))
+	}
+	return addrs
+}
+
+func (p *prober) resolve(ctx context.Context, addrs []string) []string {
+	if p.cfg.Tunnel != nil || p.cfg.SOCKS5 != nil {
+		p.add(Check{Stage: StageDNS, Status: CheckSkipped, Detail: "host names are resolved by the tunnel or proxy"})
+		return addrs
+	}
+
+	var passed []string
+	for _, addr := range addrs {
+		host, _, _ := net.SplitHostPort(addr)
+		if net.ParseIP(host) != nil {
+			p.add(Check{Stage: StageDNS, Host: addr, Status: CheckPassed, Detail: "IP address"})
+			passed = append(passed, addr)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, p.timeout)
+		start := time.Now()
+		ips, err := net.DefaultResolver.LookupHost(ctx, host)
+		cancel()
+		check := Check{Stage: StageDNS, Host: addr, Latency: time.Since(start)}
+		if err != nil {
+			check.Status = CheckFailed
+			check.Detail = err.Error()
+			check.Hint = hint(StageDNS, err, p.cfg)
+		} else {
+			check.Status = CheckPassed
+			check.Detail = "resolved to " + strings.Join(ips, ", ")
+			passed = append(passed, addr)
+		}
+		p.add(check)
+	}
+	return passed
+}
+
+func (p *prober) dial(ctx context.Context, addr string) (net.Conn, error) {
+	dialer, err := newDialer(p.cfg)
+	if err != nil {
+		return nil, err
+	}
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+func (p *prober) reach(ctx context.Context, addrs []string) []string {
+	var passed []string
+	for _, addr := range addrs {
+		start := time.Now()
+		conn, err := p.dial(ctx, addr)
+		check := Check{Stage: StageTCP, Host: addr, Latency: time.Since(start)}
+		if err != nil {
+			check.Status = CheckFailed
+			check.Detail = err.Error()
+			check.Hint = hint(StageTCP, err, p.cfg)
+		} else {
+			_ = conn.Close()
+			check.Status = CheckPassed
+			check.Detail = "port open"
+			passed = append(passed, addr)
+		}
+		p.add(check)
+	}
+	return passed
+}
+
+func (p *prober) handshake(ctx context.Context, addrs []string) []string {
+	if !p.cfg.SSLEnabled {
+		p.add(Check{Stage: StageTLS, Status: CheckSkipped, Detail: "ssl is not enabled"})
+		return addrs
+	}
+
+	tlsConfig, err := clientTLSConfig(p.cfg)
+	if err != nil {
+		p.add(Check{Stage: StageTLS, Status: CheckFailed, Detail: err.Error(), Hint: "check ssl.cert_path, ssl.key_path and ssl.ca_path"})
+		return nil
+	}
+
+	var passed []string
+	for _, addr := range addrs {
+		start := time.Now()
+		check := Check{Stage: StageTLS, Host: addr}
+		err := p.tlsHandshake(ctx, addr, tlsConfig)
+		check.Latency = time.Since(start)
+		if err != nil {
+			check.Status = CheckFailed
+			check.Detail = err.Error()
+			check.Hint = hint(StageTLS, err, p.cfg)
+		} else {
+			check.Status = CheckPassed
+			check.Detail = "handshake completed"
+			passed = append(passed, addr)
+		}
+		p.add(check)
+	}
+	return passed
+}
+
+func (p *prober) tlsHandshake(ctx context.Context, addr string, tlsConfig *tls.Config) error {
+	conn, err := p.dial(ctx, addr)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	cfg := tlsConfig.Clone()
+	if cfg.ServerName == "" {
+		cfg.ServerName, _, _ = net.SplitHostPort(addr)
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	return tls.Client(conn, cfg).HandshakeContext(ctx)
+}
+
+// cluster runs the stages that need a driver session: authentication, a
+// read of system.local and the keyspace lookup.
+func (p *prober) cluster(ctx context.Context) {
+	probeCfg := *p.cfg
+	probeCfg.Keyspace = ""
+	probeCfg.PoolSize = 1
+
+	start := time.Now()
+	session, err := createSession(&probeCfg)
+	auth := Check{Stage: StageAuth, Latency: time.Since(start)}
+	if err != nil {
+		auth.Status = CheckFailed
+		auth.Detail = err.Error()
+		auth.Hint = hint(StageAuth, err, p.cfg)
+		p.add(auth)
+		p.checks = append(p.checks, skipFrom(StageQuery, "could not open a session")...)
+		return
+	}
+	defer session.Close()
+
+	auth.Status = CheckPassed
+	auth.Detail = "connected without authentication"
+	if p.cfg.Username != "" {
+		auth.Detail = "authenticated as " + p.cfg.Username
+	}
+	p.add(auth)
+
+	queryCtx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	start = time.Now()
+	var release, clusterName, dc string
+	err = session.Query(`SELECT release_version, cluster_name, data_center FROM system.local`).
+		WithContext(queryCtx).Scan(&release, &clusterName, &dc)
+	query := Check{Stage: StageQuery, Latency: time.Since(start)}
+	if err != nil {
+		query.Status = CheckFailed
+		query.Detail = err.Error()
+		query.Hint = hint(StageQuery, err, p.cfg)
+		p.add(query)
+		p.checks = append(p.checks, skipFrom(StageKeyspace, "system.local could not be read")...)
+		return
+	}
+	query.Status = CheckPassed
+	query.Detail = fmt.Sprintf("cluster %q, datacenter %s, release %s", clusterName, dc, release)
+	p.add(query)
+
+	if p.cfg.Keyspace == "" {
+		p.add(Check{Stage: StageKeyspace, Status: CheckSkipped, Detail: "no keyspace configured"})
+		return
+	}
+
+	start = time.Now()
+	var keyspaces []string
+	iter := session.Query(`SELECT keyspace_name FROM system_schema.keyspaces`).WithContext(queryCtx).Iter()
+	var name string
+	for iter.Scan(&name) {
+		keyspaces = append(keyspaces, name)
+	}
+	err = iter.Close()
+	keyspace := Check{Stage: StageKeyspace, Latency: time.Since(start)}
+	switch {
+	case err != nil:
+		keyspace.Status = CheckFailed
+		keyspace.Detail = err.Error()
+		keyspace.Hint = hint(StageKeyspace, err, p.cfg)
+	case containsString(keyspaces, p.cfg.Keyspace):
+		keyspace.Status = CheckPassed
+		keyspace.Detail = fmt.Sprintf("keyspace %s exists", p.cfg.Keyspace)
+	default:
+		keyspace.Status = CheckFailed
+		keyspace.Detail = fmt.Sprintf("keyspace %s does not exist", p.cfg.Keyspace)
+		keyspace.Hint = "keyspace names are case-sensitive; check the profile's keyspace"
+		for _, ks := range keyspaces {
+			if strings.EqualFold(ks, p.cfg.Keyspace) {
+				keyspace.Hint = fmt.Sprintf("did you mean %s? keyspace names are case-sensitive", ks)
+				break
+			}
+		}
+	}
+	p.add(keyspace)
+}
+
+// skipFrom returns skipped checks for from and every later stage.
+func skipFrom(from, reason string) []Check {
+	stages := []string{StageDNS, StageTCP, StageTLS, StageAuth, StageQuery, StageKeyspace}
+	var checks []Check
+	skipping := false
+	for _, stage := range stages {
+		if stage == from {
+			skipping = true
+		}
+		if skipping {
+			checks = append(checks, Check{Stage: stage, Status: CheckSkipped, Detail: reason})
+		}
+	}
+	return checks
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// hint suggests a fix for err at stage, or returns "" when there is nothing
+// more specific to say than the error itself.
+func hint(stage string, err error, cfg *ConnectionConfig) string {
+	msg := err.Error()
+
+	var netErr net.Error
+	timeout := errors.As(err, &netErr) && netErr.Timeout() || errors.Is(err, context.DeadlineExceeded)
+
+	switch stage {
+	case StageDNS:
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return "the host name does not exist; check its spelling or use an IP address"
+		}
+		return "check the DNS resolver configured on the server"
+
+	case StageTCP:
+		switch {
+		case cfg.Tunnel != nil && strings.Contains(msg, "ssh tunnel:"):
+			return "the SSH bastion could not be reached or rejected the login; check the tunnel settings"
+		case cfg.SOCKS5 != nil && strings.Contains(msg, "socks"):
+			return "the SOCKS5 proxy refused the connection; check socks5.address and its credentials"
+		case errors.Is(err, syscall.ECONNREFUSED) || strings.Contains(msg, "connection refused"):
+			return "nothing is listening on this port; check the port and that the node is running"
+		case timeout:
+			return "the host did not answer; check firewalls and security groups"
+		case errors.Is(err, syscall.EHOSTUNREACH) || errors.Is(err, syscall.ENETUNREACH):
+			return "the host is unreachable from the server; check routing or use a tunnel"
+		}
+		return ""
+
+	case StageTLS:
+		var unknownCA x509.UnknownAuthorityError
+		var hostErr x509.HostnameError
+		var invalid x509.CertificateInvalidError
+		var recordErr tls.RecordHeaderError
+		switch {
+		case errors.As(err, &unknownCA):
+			return "the node's certificate is not signed by a trusted CA; set ssl.ca_path"
+		case errors.As(err, &hostErr):
+			return "the node's certificate does not name this host; connect by the name it lists or set ssl.insecure_skip_verify"
+		case errors.As(err, &invalid):
+			return "the node's certificate is expired or not yet valid"
+		case errors.As(err, &recordErr):
+			return "the node does not speak TLS on this port; disable ssl or use the TLS port"
+		case strings.Contains(msg, "certificate required") || strings.Contains(msg, "bad certificate"):
+			return "the node requires a client certificate; set ssl.cert_path and ssl.key_path"
+		case timeout:
+			return "the handshake timed out; the node may not expect TLS on this port"
+		}
+		return ""
+
+	case StageAuth:
+		switch {
+		case strings.Contains(msg, "authentication required"):
+			return "the cluster requires authentication; set auth.username and auth.password"
+		case strings.Contains(msg, "Provided username") || strings.Contains(msg, "Bad credentials") ||
+			strings.Contains(msg, "authentication") || strings.Contains(msg, "Unauthorized"):
+			return "the cluster rejected the credentials; check auth.username and auth.password"
+		case strings.Contains(msg, "unable to discover protocol version"):
+			if !cfg.SSLEnabled && cfg.SecureConnectBundle == "" {
+				return "the node did not answer the CQL handshake; check that this is the CQL port and whether the node requires TLS"
+			}
+			return "the node did not answer the CQL handshake; check driver.protocol_version and that this is the CQL port"
+		case strings.Contains(msg, "EOF") && !cfg.SSLEnabled && cfg.SecureConnectBundle == "":
+			return "the node closed the connection; it may require TLS (ssl.enabled)"
+		case timeout || strings.Contains(msg, "timeout"):
+			return "the node is slow to respond; raise driver.connect_timeout_ms"
+		}
+		return ""
+
+	default:
+		switch {
+		case strings.Contains(msg, "Unauthorized") || strings.Contains(msg, "permission"):
+			return "the database user may not read system tables; grant SELECT on the system keyspaces"
+		case timeout || strings.Contains(msg, "timeout"):
+			return "the node is slow to respond; raise driver.timeout_ms"
+		}
+		return ""
+	}
+}
@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func closedPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+	return port
+}
+
+// startTLSServer accepts TLS connections with a certificate for 127.0.0.1
+// issued by ca, completes the handshake and hangs up.
+func startTLSServer(t *testing.T, ca *testCA) int {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, 2, true)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("key pair: %v", err)
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func stageStatuses(checks []Check) map[string]CheckStatus {
+	statuses := make(map[string]CheckStatus)
+	for _, c := range checks {
+		if prev, ok := statuses[c.Stage]; !ok || prev != CheckFailed {
+			statuses[c.Stage] = c.Status
+		}
+	}
+	return statuses
+}
+
+func findCheck(t *testing.T, checks []Check, stage string) Check {
+	t.Helper()
+	for _, c := range checks {
+		if c.Stage == stage {
+			return c
+		}
+	}
+	t.Fatalf("no %s check in %+v", stage, checks)
+	return Check{}
+}
+
+func TestTestConnectionRefused(t *testing.T) {
+	checks := TestConnection(context.Background(), &ConnectionConfig{
+		Hosts:   []string{"127.0.0.1"},
+		Port:    closedPort(t),
+		Timeout: time.Second,
+	})
+
+	want := map[string]CheckStatus{
+		StageDNS:      CheckPassed,
+		StageTCP:      CheckFailed,
+		StageAuth:     CheckSkipped,
+		StageQuery:    CheckSkipped,
+		StageKeyspace: CheckSkipped,
+	}
+	got := stageStatuses(checks)
+	for stage, status := range want {
+		if got[stage] != status {
+			t.Errorf("%s status = %v, want %v", stage, got[stage], status)
+		}
+	}
+
+	tcp := findCheck(t, checks, StageTCP)
+	if !strings.Contains(tcp.Hint, "nothing is listening") {
+		t.Errorf("tcp hint = %q", tcp.Hint)
+	}
+	if !strings.HasPrefix(tcp.Host, "127.0.0.1:") {
+		t.Errorf("tcp host = %q", tcp.Host)
+	}
+}
+
+func TestTestConnectionContinuesWithReachableHosts(t *testing.T) {
+	ca := newTestCA(t)
+	port := startTLSServer(t, ca)
+
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.crt")
+	if err := os.WriteFile(caPath, ca.pem, 0o600); err != nil {
+		t.Fatalf("write CA: %v", err)
+	}
+
+	checks := TestConnection(context.Background(), &ConnectionConfig{
+		Hosts:          []string{"127.0.0.1:" + strconv.Itoa(port), "127.0.0.1:" + strconv.Itoa(closedPort(t))},
+		Port:           9042,
+		Timeout:        time.Second,
+		ConnectTimeout: time.Second,
+		SSLEnabled:     true,
+		SSLCAPath:      caPath,
+	})
+
+	var tcpPassed, tcpFailed, tlsPassed int
+	for _, c := range checks {
+		switch {
+		case c.Stage == StageTCP && c.Status == CheckPassed:
+			tcpPassed++
+		case c.Stage == StageTCP && c.Status == CheckFailed:
+			tcpFailed++
+		case c.Stage == StageTLS && c.Status == CheckPassed:
+			tlsPassed++
+		}
+	}
+	if tcpPassed != 1 || tcpFailed != 1 || tlsPassed != 1 {
+		t.Fatalf("expected one reachable TLS host, got %+v", checks)
+	}
+
+	// The server hangs up after the handshake, so the driver cannot log in.
+	if auth := findCheck(t, checks, StageAuth); auth.Status != CheckFailed {
+		t.Errorf("auth status = %v, want failed", auth.Status)
+	}
+	if ks := findCheck(t, checks, StageKeyspace); ks.Status != CheckSkipped {
+		t.Errorf("keyspace status = %v, want skipped", ks.Status)
+	}
+}
+
+func TestTestConnectionUntrustedCertificate(t *testing.T) {
+	port := startTLSServer(t, newTestCA(t))
+
+	checks := TestConnection(context.Background(), &ConnectionConfig{
+		Hosts:      []string{"127.0.0.1"},
+		Port:       port,
+		Timeout:    time.Second,
+		SSLEnabled: true,
+	})
+
+	tlsCheck := findCheck(t, checks, StageTLS)
+	if tlsCheck.Status != CheckFailed || !strings.Contains(tlsCheck.Hint, "ssl.ca_path") {
+		t.Errorf("tls check = %+v, want a failure pointing at ssl.ca_path", tlsCheck)
+	}
+	if auth := findCheck(t, checks, StageAuth); auth.Status != CheckSkipped {
+		t.Errorf("auth status = %v, want skipped", auth.Status)
+	}
+}
+
+func TestTestConnectionInvalidConfig(t *testing.T) {
+	checks := TestConnection(context.Background(), &ConnectionConfig{Port: 9042})
+	if len(checks) != 1 || checks[0].Status != CheckFailed {
+		t.Errorf("expected a single failed check, got %+v", checks)
+	}
+}
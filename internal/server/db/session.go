@@ -2,33 +2,128 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gocql/gocql"
 )
 
+// QueryObserver is told about every query a session runs, with the profile
+// it ran for and the rows it returned.
+type QueryObserver interface {
+	ObserveQuery(profile string, elapsed time.Duration, rows int, err error)
+}
+
 type Session struct {
-	session *gocql.Session
+	session     *gocql.Session
+	entry       *poolEntry
+	speculative gocql.SpeculativeExecutionPolicy
+	profile     string
+	observer    QueryObserver
+	release     func()
+	once        sync.Once
+	released    atomic.Bool
 }
 
 func NewSession(session *gocql.Session) *Session {
 	return &Session{session: session}
 }
 
+// newPooledSession wraps a shared connection. Queries go to the entry's
+// current connection, and closing it calls release once instead of closing
+// the connection.
+func newPooledSession(entry *poolEntry, release func()) *Session {
+	return &Session{entry: entry, release: release}
+}
+
+func (s *Session) conn() *gocql.Session {
+	if s.entry != nil {
+		return s.entry.conn.Load()
+	}
+	return s.session
+}
+
 func (s *Session) QueryContext(ctx context.Context, stmt string, values ...interface{}) *gocql.Query {
-	return s.session.Query(stmt, values...).WithContext(ctx)
+	return s.conn().Query(stmt, values...).WithContext(ctx)
+}
+
+// Health reports the pool's last probe of a shared connection. Dedicated
+// connections are not monitored; they are probed on demand.
+func (s *Session) Health() Health {
+	if s.entry != nil {
+		return *s.entry.health.Load()
+	}
+
+	health := Health{CheckedAt: time.Now()}
+	if s.session == nil || s.session.Closed() {
+		health.State = HealthDown
+		health.LastError = "connection closed"
+		return health
+	}
+
+	err := pingSession(s.session)
+	health.Latency = time.Since(health.CheckedAt)
+	switch {
+	case err != nil:
+		health.State = HealthDown
+		health.LastError = err.Error()
+	case health.Latency > degradedLatency:
+		health.State = HealthDegraded
+	default:
+		health.State = HealthHealthy
+	}
+	return health
+}
+
+// readQuery builds a read. Reads are idempotent, so they may be sent
+// speculatively to another host when the profile enables it.
+func (s *Session) readQuery(ctx context.Context, stmt string, values ...interface{}) *gocql.Query {
+	query := s.QueryContext(ctx, stmt, values...)
+	if s.speculative != nil {
+		query = query.Idempotent(true).SetSpeculativeExecutionPolicy(s.speculative)
+	}
+	return query
+}
+
+// observe reports the session's queries for profile to o, which may be nil.
+func (s *Session) observe(profile string, o QueryObserver) {
+	s.profile = profile
+	s.observer = o
+}
+
+func (s *Session) report(start time.Time, rows int, err error) {
+	if s.observer != nil {
+		s.observer.ObserveQuery(s.profile, time.Since(start), rows, err)
+	}
 }
 
 func (s *Session) ExecuteQuery(ctx context.Context, stmt string, values ...interface{}) error {
-	return s.QueryContext(ctx, stmt, values...).Exec()
+	start := time.Now()
+	err := s.QueryContext(ctx, stmt, values...).Exec()
+	s.report(start, 0, err)
+	return err
 }
 
 func (s *Session) FetchOne(ctx context.Context, dest map[string]interface{}, stmt string, values ...interface{}) error {
-	return s.QueryContext(ctx, stmt, values...).MapScan(dest)
+	start := time.Now()
+	err := s.readQuery(ctx, stmt, values...).MapScan(dest)
+	switch {
+	case err == nil:
+		s.report(start, 1, nil)
+	case errors.Is(err, gocql.ErrNotFound):
+		s.report(start, 0, nil)
+	default:
+		s.report(start, 0, err)
+	}
+	return err
 }
 
 func (s *Session) FetchAll(ctx context.Context, stmt string, values ...interface{}) ([]map[string]interface{}, error) {
-	iter := s.QueryContext(ctx, stmt, values...).Iter()
+	start := time.Now()
+	iter := s.readQuery(ctx, stmt, values...).Iter()
 
 	var results []map[string]interface{}
 	for {
@@ -39,7 +134,9 @@ func (s *Session) FetchAll(ctx context.Context, stmt string, values ...interface
 		results = append(results, row)
 	}
 
-	if err := iter.Close(); err != nil {
+	err := iter.Close()
+	s.report(start, len(results), err)
+	if err != nil {
 		return nil, fmt.Errorf("query iteration failed: %w", err)
 	}
 
@@ -47,7 +144,8 @@ func (s *Session) FetchAll(ctx context.Context, stmt string, values ...interface
 }
 
 func (s *Session) FetchWithPaging(ctx context.Context, stmt string, pageSize int, pageState []byte, values ...interface{}) ([]map[string]interface{}, []byte, error) {
-	query := s.QueryContext(ctx, stmt, values...).PageSize(pageSize)
+	start := time.Now()
+	query := s.readQuery(ctx, stmt, values...).PageSize(pageSize)
 	if pageState != nil {
 		query = query.PageState(pageState)
 	}
@@ -65,7 +163,9 @@ func (s *Session) FetchWithPaging(ctx context.Context, stmt string, pageSize int
 
 	nextPageState := iter.PageState()
 
-	if err := iter.Close(); err != nil {
+	err := iter.Close()
+	s.report(start, len(results), err)
+	if err != nil {
 		return nil, nil, fmt.Errorf("query iteration failed: %w", err)
 	}
 
@@ -73,14 +173,22 @@ func (s *Session) FetchWithPaging(ctx context.Context, stmt string, pageSize int
 }
 
 func (s *Session) Close() {
+	if s.release != nil {
+		s.once.Do(func() {
+			s.released.Store(true)
+			s.release()
+		})
+		return
+	}
 	if s.session != nil {
 		s.session.Close()
 	}
 }
 
 func (s *Session) Closed() bool {
-	if s.session == nil {
+	conn := s.conn()
+	if conn == nil || s.released.Load() {
 		return true
 	}
-	return s.session.Closed()
+	return conn.Closed()
 }
@@ -2,6 +2,7 @@ package db
 
 import (
 	"testing"
+	"time"
 
 	"github.com/gocql/gocql"
 )
@@ -27,3 +28,22 @@ func TestSessionClosed(t *testing.T) {
 		t.Error("Closed() should return underlying session closed state")
 	}
 }
+
+func TestPooledSessionClose(t *testing.T) {
+	mockSession := &gocql.Session{}
+	released := 0
+	session := newPooledSession(newPoolEntry(mockSession, nil, time.Now()), func() { released++ })
+
+	session.Close()
+	session.Close()
+
+	if released != 1 {
+		t.Errorf("expected release to run once, ran %d times", released)
+	}
+	if mockSession.Closed() {
+		t.Error("closing a pooled session must not close the shared connection")
+	}
+	if !session.Closed() {
+		t.Error("expected released session to report closed")
+	}
+}
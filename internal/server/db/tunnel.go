@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KashifKhn/kassie/internal/shared/config"
+	"github.com/gocql/gocql"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/net/proxy"
+)
+
+const tunnelDialTimeout = 10 * time.Second
+
+// newDialer returns the dialer for a profile's tunnel or SOCKS5 proxy, or nil
+// to dial nodes directly.
+func newDialer(cfg *ConnectionConfig) (gocql.Dialer, error) {
+	switch {
+	case cfg.Tunnel != nil:
+		clientCfg, err := sshClientConfig(cfg.Tunnel)
+		if err != nil {
+			return nil, err
+		}
+		return &sshDialer{addr: cfg.Tunnel.Address(), config: clientCfg}, nil
+	case cfg.SOCKS5 != nil:
+		return socks5Dialer(cfg.SOCKS5)
+	default:
+		return nil, nil
+	}
+}
+
+// sshDialer forwards connections through an SSH bastion. The SSH connection
+// is opened on first use and closed once no forwarded connection is left, so
+// it lives exactly as long as the cluster session using it.
+type sshDialer struct {
+	addr   string
+	config *ssh.ClientConfig
+
+	mu     sync.Mutex
+	client *ssh.Client
+	conns  int
+}
+
+func (d *sshDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.client == nil {
+		client, err := d.connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		d.client = client
+		go d.watch(client)
+	}
+
+	conn, err := d.client.DialContext(ctx, network, addr)
+	if err != nil {
+		if d.conns == 0 {
+			_ = d.client.Close()
+			d.client = nil
+		}
+		return nil, fmt.Errorf("ssh tunnel to %s: %w", addr, err)
+	}
+
+	d.conns++
+	return &tunnelConn{Conn: conn, client: d.client, release: d.release}, nil
+}
+
+func (d *sshDialer) connect(ctx context.Context) (*ssh.Client, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", d.addr)
+	if err != nil {
+		return nil, fmt.Errorf("ssh tunnel: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, d.addr, d.config)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("ssh tunnel: %w", err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// watch forgets a client whose connection to the bastion dropped so the next
+// dial reconnects.
+func (d *sshDialer) watch(client *ssh.Client) {
+	_ = client.Wait()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.client == client {
+		d.client = nil
+		d.conns = 0
+	}
+}
+
+func (d *sshDialer) release(client *ssh.Client) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.client != client {
+		return
+	}
+	d.conns--
+	if d.conns == 0 {
+		_ = d.client.Close()
+		d.client = nil
+	}
+}
+
+type tunnelConn struct {
+	net.Conn
+	client  *ssh.Client
+	release func(*ssh.Client)
+	once    sync.Once
+}
+
+func (c *tunnelConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() { c.release(c.client) })
+	return err
+}
+
+func sshClientConfig(t *config.TunnelConfig) (*ssh.ClientConfig, error) {
+	var methods []ssh.AuthMethod
+
+	if t.KeyPath != "" {
+		key, err := os.ReadFile(expandHome(t.KeyPath))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ssh key: %w", err)
+		}
+		var signer ssh.Signer
+		if t.KeyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(t.KeyPassphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ssh key: %w", err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+
+	if t.UseAgent {
+		sock := os.Getenv("SSH_AUTH_SOCK")
+		if sock == "" {
+			return nil, fmt.Errorf("ssh agent requested but SSH_AUTH_SOCK is not set")
+		}
+		methods = append(methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			conn, err := net.Dial("unix", sock)
+			if err != nil {
+				return nil, fmt.Errorf("failed to connect to ssh agent: %w", err)
+			}
+			defer func() { _ = conn.Close() }()
+			return agent.NewClient(conn).Signers()
+		}))
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if !t.InsecureIgnoreHostKey {
+		path := t.KnownHosts
+		if path == "" {
+			path = "~/.ssh/known_hosts"
+		}
+		callback, err := knownhosts.New(expandHome(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load known hosts: %w", err)
+		}
+		hostKeyCallback = callback
+	}
+
+	return &ssh.ClientConfig{
+		User:            t.User,
+		Auth:            methods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         tunnelDialTimeout,
+	}, nil
+}
+
+func socks5Dialer(s *config.SOCKS5Config) (gocql.Dialer, error) {
+	var auth *proxy.Auth
+	if s.Username != "" {
+		auth = &proxy.Auth{User: s.Username, Password: s.Password}
+	}
+
+	dialer, err := proxy.SOCKS5("tcp", s.Address, auth, &net.Dialer{Timeout: tunnelDialTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("socks5 proxy: %w", err)
+	}
+	contextDialer, ok := dialer.(proxy.ContextDialer)
+	if !ok {
+		return nil, fmt.Errorf("socks5 proxy: dialer does not support contexts")
+	}
+	return contextDialer, nil
+}
+
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
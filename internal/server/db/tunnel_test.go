@@ -0,0 +1,335 @@
+package db
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/KashifKhn/kassie/internal/shared/config"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func startEchoServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// startSSHServer runs an SSH server that accepts clientKey and forwards
+// direct-tcpip channels, like a bastion. It returns its address and host key.
+func startSSHServer(t *testing.T, clientKey ssh.PublicKey) (string, ssh.PublicKey) {
+	t.Helper()
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate host key: %v", err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatalf("host signer: %v", err)
+	}
+
+	serverCfg := &ssh.ServerConfig{
+		PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if meta.User() == "bastion" && string(key.Marshal()) == string(clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, io.EOF
+		},
+	}
+	serverCfg.AddHostKey(hostSigner)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSH(conn, serverCfg)
+		}
+	}()
+
+	return ln.Addr().String(), hostSigner.PublicKey()
+}
+
+func serveSSH(conn net.Conn, cfg *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, cfg)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChan := range chans {
+		if newChan.ChannelType() != "direct-tcpip" {
+			_ = newChan.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		var target struct {
+			Host     string
+			Port     uint32
+			OrigHost string
+			OrigPort uint32
+		}
+		if err := ssh.Unmarshal(newChan.ExtraData(), &target); err != nil {
+			_ = newChan.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		upstream, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+		if err != nil {
+			_ = newChan.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, requests, err := newChan.Accept()
+		if err != nil {
+			_ = upstream.Close()
+			continue
+		}
+		go ssh.DiscardRequests(requests)
+		go func() {
+			_, _ = io.Copy(channel, upstream)
+			_ = channel.Close()
+		}()
+		go func() {
+			_, _ = io.Copy(upstream, channel)
+			_ = upstream.Close()
+		}()
+	}
+}
+
+func writeClientKey(t *testing.T, dir string) ssh.PublicKey {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate client key: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatalf("marshal client key: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "id_ed25519"), pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("write client key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("client signer: %v", err)
+	}
+	return signer.PublicKey()
+}
+
+func writeKnownHosts(t *testing.T, dir, addr string, key ssh.PublicKey) string {
+	t.Helper()
+	path := filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, key) + "\n"
+	if err := os.WriteFile(path, []byte(line), 0o600); err != nil {
+		t.Fatalf("write known_hosts: %v", err)
+	}
+	return path
+}
+
+func echo(t *testing.T, conn net.Conn, msg string) {
+	t.Helper()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write([]byte(msg)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	buf := make([]byte, len(msg))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(buf) != msg {
+		t.Fatalf("echo = %q, want %q", buf, msg)
+	}
+}
+
+func TestSSHTunnelDialer(t *testing.T) {
+	dir := t.TempDir()
+	clientKey := writeClientKey(t, dir)
+	sshAddr, hostKey := startSSHServer(t, clientKey)
+	target := startEchoServer(t)
+
+	dialer, err := newDialer(&ConnectionConfig{Tunnel: &config.TunnelConfig{
+		Host:       sshAddr,
+		User:       "bastion",
+		KeyPath:    filepath.Join(dir, "id_ed25519"),
+		KnownHosts: writeKnownHosts(t, dir, sshAddr, hostKey),
+	}})
+	if err != nil {
+		t.Fatalf("newDialer() error = %v", err)
+	}
+	tunnel := dialer.(*sshDialer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	first, err := dialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		t.Fatalf("DialContext() error = %v", err)
+	}
+	second, err := dialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		t.Fatalf("DialContext() error = %v", err)
+	}
+	echo(t, first, "hello")
+	echo(t, second, "world")
+
+	tunnel.mu.Lock()
+	client, conns := tunnel.client, tunnel.conns
+	tunnel.mu.Unlock()
+	if client == nil || conns != 2 {
+		t.Fatalf("expected one ssh connection carrying 2 tunnels, got %d", conns)
+	}
+
+	_ = first.Close()
+	_ = first.Close()
+	_ = second.Close()
+
+	tunnel.mu.Lock()
+	defer tunnel.mu.Unlock()
+	if tunnel.client != nil || tunnel.conns != 0 {
+		t.Error("expected the ssh connection to close with the last tunnel")
+	}
+}
+
+func TestSSHTunnelDialerRejectsUnknownHostKey(t *testing.T) {
+	dir := t.TempDir()
+	clientKey := writeClientKey(t, dir)
+	sshAddr, _ := startSSHServer(t, clientKey)
+	target := startEchoServer(t)
+
+	otherKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	wrongKey, err := ssh.NewPublicKey(otherKey)
+	if err != nil {
+		t.Fatalf("public key: %v", err)
+	}
+
+	dialer, err := newDialer(&ConnectionConfig{Tunnel: &config.TunnelConfig{
+		Host:       sshAddr,
+		User:       "bastion",
+		KeyPath:    filepath.Join(dir, "id_ed25519"),
+		KnownHosts: writeKnownHosts(t, dir, sshAddr, wrongKey),
+	}})
+	if err != nil {
+		t.Fatalf("newDialer() error = %v", err)
+	}
+
+	if _, err := dialer.DialContext(context.Background(), "tcp", target); err == nil {
+		t.Fatal("expected a host key mismatch to be rejected")
+	}
+}
+
+// startSOCKS5Server runs a minimal no-auth SOCKS5 proxy for IPv4 targets.
+func startSOCKS5Server(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSOCKS5(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveSOCKS5(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	greeting := make([]byte, 2)
+	if _, err := io.ReadFull(conn, greeting); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, make([]byte, greeting[1])); err != nil {
+		return
+	}
+	if _, err := conn.Write([]byte{5, 0}); err != nil {
+		return
+	}
+
+	request := make([]byte, 10)
+	if _, err := io.ReadFull(conn, request); err != nil || request[3] != 1 {
+		return
+	}
+	addr := net.JoinHostPort(net.IP(request[4:8]).String(), strconv.Itoa(int(binary.BigEndian.Uint16(request[8:]))))
+	upstream, err := net.Dial("tcp", addr)
+	if err != nil {
+		_, _ = conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer func() { _ = upstream.Close() }()
+	if _, err := conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+
+	go func() { _, _ = io.Copy(upstream, conn) }()
+	_, _ = io.Copy(conn, upstream)
+}
+
+func TestSOCKS5Dialer(t *testing.T) {
+	target := startEchoServer(t)
+
+	dialer, err := newDialer(&ConnectionConfig{SOCKS5: &config.SOCKS5Config{Address: startSOCKS5Server(t)}})
+	if err != nil {
+		t.Fatalf("newDialer() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := dialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		t.Fatalf("DialContext() error = %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	echo(t, conn, "through the proxy")
+}
+
+func TestNewDialerDirect(t *testing.T) {
+	dialer, err := newDialer(&ConnectionConfig{})
+	if err != nil || dialer != nil {
+		t.Errorf("expected no dialer without tunnel or proxy, got %v, %v", dialer, err)
+	}
+}
@@ -21,12 +21,16 @@ type EmbeddedServerConfig struct {
 	GRPCPort       int
 	HTTPPort       int
 	AllowedOrigins []string
+	// ConfigPath is the file profile edits are saved to. Without it, edits
+	// last until the server stops.
+	ConfigPath string
 }
 
 type EmbeddedServer struct {
 	grpcServer  *grpc.Server
 	httpGateway *gateway.Gateway
 	cfg         *EmbeddedServerConfig
+	localKey    string
 	logger      *logger.Logger
 	cancel      context.CancelFunc
 }
@@ -63,13 +67,27 @@ func NewEmbeddedServer(appCfg *config.Config, cfg *EmbeddedServerConfig, log *lo
 		JWTSecret: cfg.JWTSecret,
 	}
 
-	pool := db.NewPool()
+	pool := db.NewPool(db.DefaultIdleTimeout)
+	pool.StartHealthChecks(db.DefaultHealthInterval)
 	store := state.NewStore(config.DefaultSessionTTL)
 
+	var loader *config.Loader
+	if cfg.ConfigPath != "" {
+		loader = config.NewLoaderWithPath(cfg.ConfigPath)
+	}
+	profiles := config.NewProfileStore(appCfg, loader)
+
+	localKey, err := generateRandomSecret(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate local key: %w", err)
+	}
+
 	grpcDeps := &grpc.ServerDeps{
-		Config: appCfg,
-		Pool:   pool,
-		Store:  store,
+		Config:   profiles,
+		Pool:     pool,
+		Store:    store,
+		Profiles: profiles,
+		LocalKey: localKey,
 	}
 
 	grpcServer, err := grpc.NewServer(grpcCfg, grpcDeps, log)
@@ -101,6 +119,7 @@ func NewEmbeddedServer(appCfg *config.Config, cfg *EmbeddedServerConfig, log *lo
 		grpcServer:  grpcServer,
 		httpGateway: httpGateway,
 		cfg:         cfg,
+		localKey:    localKey,
 		logger:      log,
 		cancel:      cancel,
 	}, nil
@@ -150,6 +169,12 @@ func (e *EmbeddedServer) GRPCAddress() string {
 	return e.grpcServer.Address()
 }
 
+// LocalKey lets a client of this process manage profiles without logging
+// in. It must not leave the process.
+func (e *EmbeddedServer) LocalKey() string {
+	return e.localKey
+}
+
 func (e *EmbeddedServer) HTTPAddress() string {
 	return fmt.Sprintf("%s:%d", config.DefaultHost, e.cfg.HTTPPort)
 }
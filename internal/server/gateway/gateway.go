@@ -72,11 +72,37 @@ func (g *Gateway) RegisterServices(ctx context.Context) error {
 		return fmt.Errorf("failed to register data service: %w", err)
 	}
 
+	if err := pb.RegisterDiagnosticsServiceHandlerFromEndpoint(ctx, g.mux, g.cfg.GRPCAddress, opts); err != nil {
+		return fmt.Errorf("failed to register diagnostics service: %w", err)
+	}
+
+	if err := pb.RegisterTokenServiceHandlerFromEndpoint(ctx, g.mux, g.cfg.GRPCAddress, opts); err != nil {
+		return fmt.Errorf("failed to register token service: %w", err)
+	}
+
+	if err := pb.RegisterAdminServiceHandlerFromEndpoint(ctx, g.mux, g.cfg.GRPCAddress, opts); err != nil {
+		return fmt.Errorf("failed to register admin service: %w", err)
+	}
+
+	if err := pb.RegisterProfileServiceHandlerFromEndpoint(ctx, g.mux, g.cfg.GRPCAddress, opts); err != nil {
+		return fmt.Errorf("failed to register profile service: %w", err)
+	}
+
 	g.logger.With().Str("grpc_address", g.cfg.GRPCAddress).Logger().Info("registered gRPC gateway services")
 
 	return nil
 }
 
+// RegisterMetrics serves h at path, outside the API and without auth.
+func (g *Gateway) RegisterMetrics(path string, h http.Handler) error {
+	if err := g.mux.HandlePath(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		h.ServeHTTP(w, r)
+	}); err != nil {
+		return fmt.Errorf("failed to register metrics handler: %w", err)
+	}
+	return nil
+}
+
 func (g *Gateway) Start() error {
 	handler := g.securityHeadersMiddleware(g.corsMiddleware(g.mux))
 
@@ -0,0 +1,120 @@
+package gateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	OIDCLoginPath    = "/api/v1/auth/oidc/login"
+	OIDCCallbackPath = "/api/v1/auth/oidc/callback"
+)
+
+// oidcStateCookie binds a sign-in to the browser that started it, so a
+// callback URL with someone else's state cannot log the victim in as the
+// attacker. It lives as long as a pending login on the server.
+const (
+	oidcStateCookie = "kassie_oidc_state"
+	oidcStateTTL    = 10 * time.Minute
+)
+
+type OIDCHandler interface {
+	AuthCodeURL(ctx context.Context, profile string) (string, error)
+	Exchange(ctx context.Context, state, code string) (*pb.LoginResponse, error)
+	PostLoginURL() string
+}
+
+// RegisterOIDC exposes the browser side of the single sign-on flow. Tokens
+// are handed back to the web UI in the URL fragment of the post login page so
+// they never reach server logs or the Referer header.
+func (g *Gateway) RegisterOIDC(h OIDCHandler) error {
+	if err := g.mux.HandlePath(http.MethodGet, OIDCLoginPath, func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		authURL, err := h.AuthCodeURL(r.Context(), r.URL.Query().Get("profile"))
+		if err != nil {
+			g.oidcFailed(w, r, h, err)
+			return
+		}
+		u, err := url.Parse(authURL)
+		if err != nil {
+			g.oidcFailed(w, r, h, status.Errorf(codes.Internal, "invalid authorization url: %v", err))
+			return
+		}
+		setStateCookie(w, r, u.Query().Get("state"), int(oidcStateTTL.Seconds()))
+		http.Redirect(w, r, authURL, http.StatusFound)
+	}); err != nil {
+		return fmt.Errorf("failed to register oidc login handler: %w", err)
+	}
+
+	if err := g.mux.HandlePath(http.MethodGet, OIDCCallbackPath, func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		query := r.URL.Query()
+		cookie, cookieErr := r.Cookie(oidcStateCookie)
+		setStateCookie(w, r, "", -1)
+
+		if idpErr := query.Get("error"); idpErr != "" {
+			msg := idpErr
+			if desc := query.Get("error_description"); desc != "" {
+				msg += ": " + desc
+			}
+			redirectWithFragment(w, r, h.PostLoginURL(), url.Values{"error": {msg}})
+			return
+		}
+
+		state := query.Get("state")
+		if cookieErr != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+			g.oidcFailed(w, r, h, status.Error(codes.Unauthenticated, "sign-in was not started from this browser"))
+			return
+		}
+
+		resp, err := h.Exchange(r.Context(), state, query.Get("code"))
+		if err != nil {
+			g.oidcFailed(w, r, h, err)
+			return
+		}
+
+		redirectWithFragment(w, r, h.PostLoginURL(), url.Values{
+			"access_token":  {resp.AccessToken},
+			"refresh_token": {resp.RefreshToken},
+			"expires_at":    {strconv.FormatInt(resp.ExpiresAt, 10)},
+			"profile":       {resp.Profile.GetName()},
+		})
+	}); err != nil {
+		return fmt.Errorf("failed to register oidc callback handler: %w", err)
+	}
+
+	g.logger.Info("registered OIDC single sign-on handlers")
+	return nil
+}
+
+func (g *Gateway) oidcFailed(w http.ResponseWriter, r *http.Request, h OIDCHandler, err error) {
+	msg := status.Convert(err).Message()
+	g.logger.With().Err(err).Logger().Warn("single sign-on failed")
+	redirectWithFragment(w, r, h.PostLoginURL(), url.Values{"error": {msg}})
+}
+
+// setStateCookie sets the state cookie, or clears it when maxAge is
+// negative. It is only sent back to the callback.
+func setStateCookie(w http.ResponseWriter, r *http.Request, state string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     OIDCCallbackPath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func redirectWithFragment(w http.ResponseWriter, r *http.Request, target string, values url.Values) {
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, target+"#"+values.Encode(), http.StatusFound)
+}
@@ -0,0 +1,118 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/shared/logger"
+)
+
+type fakeOIDC struct {
+	exchanged []string
+}
+
+func (f *fakeOIDC) AuthCodeURL(context.Context, string) (string, error) {
+	return "https://idp.example.com/authorize?client_id=kassie&state=state-1", nil
+}
+
+func (f *fakeOIDC) Exchange(_ context.Context, state, _ string) (*pb.LoginResponse, error) {
+	f.exchanged = append(f.exchanged, state)
+	return &pb.LoginResponse{AccessToken: "access", Profile: &pb.ProfileInfo{Name: "dev"}}, nil
+}
+
+func (f *fakeOIDC) PostLoginURL() string { return "/login" }
+
+func newOIDCGateway(t *testing.T) (*Gateway, *fakeOIDC) {
+	t.Helper()
+	g, err := NewGateway(&GatewayConfig{}, logger.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &fakeOIDC{}
+	if err := g.RegisterOIDC(h); err != nil {
+		t.Fatal(err)
+	}
+	return g, h
+}
+
+func serve(g *Gateway, target string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	g.mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestOIDCLoginSetsStateCookie(t *testing.T) {
+	g, _ := newOIDCGateway(t)
+
+	rec := serve(g, OIDCLoginPath+"?profile=dev")
+	if rec.Code != http.StatusFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusFound)
+	}
+
+	var cookie *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == oidcStateCookie {
+			cookie = c
+		}
+	}
+	if cookie == nil {
+		t.Fatal("no state cookie was set")
+	}
+	if cookie.Value != "state-1" || !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode || cookie.Path != OIDCCallbackPath {
+		t.Errorf("cookie = %+v", cookie)
+	}
+}
+
+func TestOIDCCallbackState(t *testing.T) {
+	tests := []struct {
+		name    string
+		cookie  *http.Cookie
+		wantErr bool
+	}{
+		{name: "matching cookie", cookie: &http.Cookie{Name: oidcStateCookie, Value: "state-1"}},
+		{name: "no cookie", wantErr: true},
+		{name: "other browser's state", cookie: &http.Cookie{Name: oidcStateCookie, Value: "state-2"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, h := newOIDCGateway(t)
+
+			var cookies []*http.Cookie
+			if tt.cookie != nil {
+				cookies = append(cookies, tt.cookie)
+			}
+			rec := serve(g, OIDCCallbackPath+"?state=state-1&code=abc", cookies...)
+
+			location, err := url.Parse(rec.Header().Get("Location"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			fragment, _ := url.ParseQuery(location.Fragment)
+			if tt.wantErr {
+				if len(h.exchanged) != 0 {
+					t.Errorf("exchanged the code for state %v", h.exchanged)
+				}
+				if !strings.Contains(fragment.Get("error"), "not started from this browser") {
+					t.Errorf("fragment = %v", fragment)
+				}
+				return
+			}
+			if fragment.Get("access_token") != "access" {
+				t.Errorf("fragment = %v", fragment)
+			}
+			if !strings.Contains(rec.Header().Get("Set-Cookie"), "Max-Age=0") {
+				t.Errorf("state cookie was not cleared: %q", rec.Header().Get("Set-Cookie"))
+			}
+		})
+	}
+}
@@ -2,9 +2,15 @@ package grpc
 
 import (
 	"context"
+	"crypto/subtle"
 	"strings"
+	"time"
 
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/server/apitoken"
+	"github.com/KashifKhn/kassie/internal/server/metrics"
 	"github.com/KashifKhn/kassie/internal/server/service"
+	"github.com/KashifKhn/kassie/internal/shared/config"
 	"github.com/KashifKhn/kassie/internal/shared/ctxutil"
 	"github.com/KashifKhn/kassie/internal/shared/logger"
 	"google.golang.org/grpc"
@@ -19,11 +25,94 @@ var publicMethods = map[string]bool{
 	"/kassie.v1.SessionService/GetProfiles": true,
 }
 
-func NewAuthInterceptor(auth TokenValidator, store SessionStore, log *logger.Logger) grpc.UnaryServerInterceptor {
+// testConnectionMethod is public for configured profiles, which the service
+// checks against the server credentials in the request. Inline profiles make
+// the server dial any address, so they need the local key or a token, and
+// on servers with users the admin permission.
+const testConnectionMethod = "/kassie.v1.SessionService/TestConnection"
+
+// localMethods need no token when the caller presents the server's local
+// key, so the TUI and the profile commands can manage and test profiles
+// before logging in, and the process can administer its own sessions. Only embedded servers have a local key, and only the
+// process that started one knows it.
+var localMethods = map[string]bool{
+	testConnectionMethod:                       true,
+	"/kassie.v1.ProfileService/GetProfile":     true,
+	"/kassie.v1.ProfileService/CreateProfile":  true,
+	"/kassie.v1.ProfileService/UpdateProfile":  true,
+	"/kassie.v1.ProfileService/DeleteProfile":  true,
+	"/kassie.v1.AdminService/RevokeTokens":     true,
+	"/kassie.v1.AdminService/ListSessions":     true,
+	"/kassie.v1.AdminService/TerminateSession": true,
+	"/kassie.v1.AdminService/ListConnections":  true,
+}
+
+// methodOperations maps RPCs to the operation category checked by the policy
+// engine. Authenticated methods missing from this map require admin.
+var methodOperations = map[string]config.Operation{
+	"/kassie.v1.SessionService/Logout":                  "",
+	"/kassie.v1.SchemaService/ListKeyspaces":            config.OpRead,
+	"/kassie.v1.SchemaService/ListTables":               config.OpRead,
+	"/kassie.v1.SchemaService/GetTableSchema":           config.OpRead,
+	"/kassie.v1.DataService/QueryRows":                  config.OpRead,
+	"/kassie.v1.DataService/GetNextPage":                config.OpRead,
+	"/kassie.v1.DataService/FilterRows":                 config.OpRead,
+	"/kassie.v1.DiagnosticsService/GetLargeDataReport":  config.OpRead,
+	"/kassie.v1.DiagnosticsService/GetConnectionHealth": "",
+	"/kassie.v1.TokenService/CreateToken":               "",
+	"/kassie.v1.TokenService/ListTokens":                "",
+	"/kassie.v1.TokenService/RevokeToken":               "",
+	"/kassie.v1.AdminService/RevokeTokens":              config.OpAdmin,
+	"/kassie.v1.AdminService/ListSessions":              config.OpAdmin,
+	"/kassie.v1.AdminService/TerminateSession":          config.OpAdmin,
+	"/kassie.v1.AdminService/ListConnections":           config.OpAdmin,
+}
+
+// tokenServicePrefix guards token management: an API token cannot be used to
+// mint or revoke other tokens.
+const tokenServicePrefix = "/kassie.v1.TokenService/"
+
+// profileServicePrefix guards profile management, which rewrites the
+// config file: it needs a server user with admin, so servers without users
+// only accept it from the local key.
+const profileServicePrefix = "/kassie.v1.ProfileService/"
+
+// adminServicePrefix guards session administration, which can end other
+// users' sessions: like profile management it needs a server user with admin
+// or the local key. It is not counted as a query in flight on the caller's
+// session.
+const adminServicePrefix = "/kassie.v1.AdminService/"
+
+type keyspaceRequest interface {
+	GetKeyspace() string
+}
+
+type tableRequest interface {
+	GetTable() string
+}
+
+// NewMetricsInterceptor counts calls per method and status code and times
+// them. It runs ahead of the auth interceptor so that refused calls are
+// counted too.
+func NewMetricsInterceptor(m *metrics.Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.ObserveRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return resp, err
+	}
+}
+
+// NewAuthInterceptor checks the caller's token. localKey is the embedded
+// server's local key; it is empty on standalone servers.
+func NewAuthInterceptor(auth TokenValidator, apiTokens APITokenAuthenticator, store SessionStore, authz Authorizer, localKey string, log *logger.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		if publicMethods[info.FullMethod] {
 			return handler(ctx, req)
 		}
+		if r, ok := req.(*pb.TestConnectionRequest); ok && info.FullMethod == testConnectionMethod && r.GetInlineProfile() == "" {
+			return handler(ctx, req)
+		}
 
 		md, ok := metadata.FromIncomingContext(ctx)
 		if !ok {
@@ -31,6 +120,10 @@ func NewAuthInterceptor(auth TokenValidator, store SessionStore, log *logger.Log
 			return nil, status.Error(codes.Unauthenticated, "missing metadata")
 		}
 
+		if localMethods[info.FullMethod] && isLocalCaller(md, localKey) {
+			return handler(ctx, req)
+		}
+
 		authHeader := md.Get("authorization")
 		if len(authHeader) == 0 {
 			log.Warn("no authorization header")
@@ -43,13 +136,47 @@ func NewAuthInterceptor(auth TokenValidator, store SessionStore, log *logger.Log
 			return nil, status.Error(codes.Unauthenticated, "invalid authorization format")
 		}
 
-		claims, err := auth.ValidateToken(token, service.AccessToken)
-		if err != nil {
-			log.With().Err(err).Logger().Warn("token validation failed")
-			if err == service.ErrExpiredToken {
-				return nil, status.Error(codes.Unauthenticated, "token expired")
+		var claims *service.Claims
+		if strings.HasPrefix(token, apitoken.Prefix) {
+			if apiTokens == nil {
+				return nil, status.Error(codes.Unauthenticated, "api tokens are not enabled")
+			}
+			if strings.HasPrefix(info.FullMethod, tokenServicePrefix) {
+				return nil, status.Error(codes.PermissionDenied, "api tokens cannot manage tokens")
+			}
+
+			var err error
+			claims, err = apiTokens.Authenticate(token)
+			if err != nil {
+				log.With().Err(err).Logger().Warn("api token validation failed")
+				if _, ok := status.FromError(err); ok {
+					return nil, err
+				}
+				if err == apitoken.ErrExpiredToken {
+					return nil, status.Error(codes.Unauthenticated, "token expired")
+				}
+				return nil, status.Error(codes.Unauthenticated, "invalid token")
+			}
+		} else {
+			var err error
+			claims, err = auth.ValidateToken(token, service.AccessToken)
+			if err != nil {
+				log.With().Err(err).Logger().Warn("token validation failed")
+				if err == service.ErrExpiredToken {
+					return nil, status.Error(codes.Unauthenticated, "token expired")
+				}
+				if err == service.ErrRevokedToken {
+					return nil, status.Error(codes.Unauthenticated, "token revoked")
+				}
+				return nil, status.Error(codes.Unauthenticated, "invalid token")
 			}
-			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+
+		if strings.HasPrefix(info.FullMethod, profileServicePrefix) && claims.User == "" {
+			return nil, status.Error(codes.PermissionDenied, "profile management needs a server user with the admin permission")
+		}
+		if strings.HasPrefix(info.FullMethod, adminServicePrefix) && claims.User == "" {
+			return nil, status.Error(codes.PermissionDenied, "session administration needs a server user with the admin permission")
 		}
 
 		session, err := store.Get(claims.SessionID)
@@ -60,7 +187,65 @@ func NewAuthInterceptor(auth TokenValidator, store SessionStore, log *logger.Log
 
 		ctx = ctxutil.WithSessionID(ctx, session.ID)
 		ctx = ctxutil.WithProfile(ctx, claims.Profile)
+		if claims.ID != "" {
+			ctx = ctxutil.WithTokenID(ctx, claims.ID)
+		}
+		if claims.ReadOnly {
+			if op := methodOperation(info.FullMethod); op != "" && op != config.OpRead {
+				return nil, status.Errorf(codes.PermissionDenied, "read-only token may not perform %s", op)
+			}
+		}
+		if claims.User != "" {
+			ctx = ctxutil.WithUser(ctx, claims.User)
+			if err := authorize(authz, info.FullMethod, claims, req); err != nil {
+				log.With().Str("user", claims.User).Str("method", info.FullMethod).Err(err).Logger().Warn("permission denied")
+				return nil, status.Error(codes.PermissionDenied, err.Error())
+			}
+		}
+
+		if methodOperation(info.FullMethod) != "" && !strings.HasPrefix(info.FullMethod, adminServicePrefix) {
+			done := session.Track()
+			defer done()
+		}
 
 		return handler(ctx, req)
 	}
 }
+
+// isLocalCaller reports whether md carries the server's local key.
+func isLocalCaller(md metadata.MD, localKey string) bool {
+	if localKey == "" {
+		return false
+	}
+	values := md.Get(ctxutil.LocalKeyMetadata)
+	return len(values) == 1 && subtle.ConstantTimeCompare([]byte(values[0]), []byte(localKey)) == 1
+}
+
+func authorize(authz Authorizer, method string, claims *service.Claims, req interface{}) error {
+	if authz == nil {
+		return nil
+	}
+
+	op := methodOperation(method)
+	if op == "" {
+		return nil
+	}
+
+	var keyspace, table string
+	if r, ok := req.(keyspaceRequest); ok {
+		keyspace = r.GetKeyspace()
+	}
+	if r, ok := req.(tableRequest); ok {
+		table = r.GetTable()
+	}
+
+	return authz.Authorize(claims.User, claims.Profile, op, keyspace, table)
+}
+
+func methodOperation(method string) config.Operation {
+	op, ok := methodOperations[method]
+	if !ok {
+		return config.OpAdmin
+	}
+	return op
+}
@@ -0,0 +1,196 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/server/service"
+	"github.com/KashifKhn/kassie/internal/server/state"
+	"github.com/KashifKhn/kassie/internal/shared/config"
+	"github.com/KashifKhn/kassie/internal/shared/ctxutil"
+	"github.com/KashifKhn/kassie/internal/shared/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const createProfileMethod = "/kassie.v1.ProfileService/CreateProfile"
+
+type allowAll struct{}
+
+func (allowAll) Authorize(string, string, config.Operation, string, string) error { return nil }
+
+// adminOnly lets only alice perform admin operations.
+type adminOnly struct{}
+
+func (adminOnly) Authorize(user, _ string, op config.Operation, _, _ string) error {
+	if op == config.OpAdmin && user != "alice" {
+		return errors.New("admin permission required")
+	}
+	return nil
+}
+
+// callInterceptor runs method through the interceptor with the given
+// metadata pairs and reports the status code.
+func callInterceptor(interceptor grpc.UnaryServerInterceptor, method string, req interface{}, pairs ...string) codes.Code {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+	handler := func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+	_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+	return status.Code(err)
+}
+
+func TestAuthInterceptorProfileService(t *testing.T) {
+	auth := service.NewAuthService("test-secret")
+	store := state.NewStore(time.Hour)
+	defer store.Close()
+	store.Create("session-1", &config.Profile{Name: "dev"}, nil)
+
+	anonymous, err := auth.GenerateTokenPair("session-1", "dev", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := auth.GenerateTokenPair("session-1", "dev", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	embedded := NewAuthInterceptor(auth, nil, store, nil, "local-key", logger.Default())
+	standalone := NewAuthInterceptor(auth, nil, store, allowAll{}, "", logger.Default())
+
+	tests := []struct {
+		name        string
+		interceptor grpc.UnaryServerInterceptor
+		pairs       []string
+		want        codes.Code
+	}{
+		{name: "no token", interceptor: standalone, want: codes.Unauthenticated},
+		{name: "local key", interceptor: embedded, pairs: []string{ctxutil.LocalKeyMetadata, "local-key"}, want: codes.OK},
+		{name: "wrong local key", interceptor: embedded, pairs: []string{ctxutil.LocalKeyMetadata, "guess"}, want: codes.Unauthenticated},
+		{name: "empty local key on a standalone server", interceptor: standalone, pairs: []string{ctxutil.LocalKeyMetadata, ""}, want: codes.Unauthenticated},
+		{name: "session without a user", interceptor: standalone, pairs: []string{"authorization", "Bearer " + anonymous.AccessToken}, want: codes.PermissionDenied},
+		{name: "server user", interceptor: standalone, pairs: []string{"authorization", "Bearer " + admin.AccessToken}, want: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := callInterceptor(tt.interceptor, createProfileMethod, nil, tt.pairs...); got != tt.want {
+				t.Errorf("code = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthInterceptorAdminService(t *testing.T) {
+	auth := service.NewAuthService("test-secret")
+	store := state.NewStore(time.Hour)
+	defer store.Close()
+	store.Create("session-1", &config.Profile{Name: "dev"}, nil)
+
+	anonymous, err := auth.GenerateTokenPair("session-1", "dev", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := auth.GenerateTokenPair("session-1", "dev", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := auth.GenerateTokenPair("session-1", "dev", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	noUsers := NewAuthInterceptor(auth, nil, store, nil, "", logger.Default())
+	embedded := NewAuthInterceptor(auth, nil, store, nil, "local-key", logger.Default())
+	withUsers := NewAuthInterceptor(auth, nil, store, adminOnly{}, "", logger.Default())
+
+	tests := []struct {
+		name        string
+		interceptor grpc.UnaryServerInterceptor
+		pairs       []string
+		want        codes.Code
+	}{
+		{name: "session on a server without users", interceptor: noUsers, pairs: []string{"authorization", "Bearer " + anonymous.AccessToken}, want: codes.PermissionDenied},
+		{name: "local key", interceptor: embedded, pairs: []string{ctxutil.LocalKeyMetadata, "local-key"}, want: codes.OK},
+		{name: "admin user", interceptor: withUsers, pairs: []string{"authorization", "Bearer " + admin.AccessToken}, want: codes.OK},
+		{name: "user without admin", interceptor: withUsers, pairs: []string{"authorization", "Bearer " + reader.AccessToken}, want: codes.PermissionDenied},
+	}
+
+	for _, method := range []string{"/kassie.v1.AdminService/ListSessions", "/kassie.v1.AdminService/TerminateSession", "/kassie.v1.AdminService/RevokeTokens"} {
+		for _, tt := range tests {
+			t.Run(method+"/"+tt.name, func(t *testing.T) {
+				if got := callInterceptor(tt.interceptor, method, nil, tt.pairs...); got != tt.want {
+					t.Errorf("code = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestAuthInterceptorTestConnection(t *testing.T) {
+	auth := service.NewAuthService("test-secret")
+	store := state.NewStore(time.Hour)
+	defer store.Close()
+	store.Create("session-1", &config.Profile{Name: "dev"}, nil)
+
+	tokens := map[string]string{}
+	for _, user := range []string{"", "alice", "bob"} {
+		pair, err := auth.GenerateTokenPair("session-1", "dev", user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[user] = "Bearer " + pair.AccessToken
+	}
+
+	embedded := NewAuthInterceptor(auth, nil, store, nil, "local-key", logger.Default())
+	standalone := NewAuthInterceptor(auth, nil, store, adminOnly{}, "", logger.Default())
+
+	named := &pb.TestConnectionRequest{Profile: "dev"}
+	inline := &pb.TestConnectionRequest{InlineProfile: `{"hosts":["10.0.0.1"],"port":9042}`}
+
+	tests := []struct {
+		name        string
+		interceptor grpc.UnaryServerInterceptor
+		req         *pb.TestConnectionRequest
+		pairs       []string
+		want        codes.Code
+	}{
+		{name: "named profile without a token", interceptor: standalone, req: named, want: codes.OK},
+		{name: "inline without a token", interceptor: standalone, req: inline, want: codes.Unauthenticated},
+		{name: "inline with the local key", interceptor: embedded, req: inline, pairs: []string{ctxutil.LocalKeyMetadata, "local-key"}, want: codes.OK},
+		{name: "inline with a wrong local key", interceptor: embedded, req: inline, pairs: []string{ctxutil.LocalKeyMetadata, "guess"}, want: codes.Unauthenticated},
+		{name: "inline from a session without users", interceptor: standalone, req: inline, pairs: []string{"authorization", tokens[""]}, want: codes.OK},
+		{name: "inline from an admin", interceptor: standalone, req: inline, pairs: []string{"authorization", tokens["alice"]}, want: codes.OK},
+		{name: "inline from a user without admin", interceptor: standalone, req: inline, pairs: []string{"authorization", tokens["bob"]}, want: codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := callInterceptor(tt.interceptor, testConnectionMethod, tt.req, tt.pairs...); got != tt.want {
+				t.Errorf("code = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthInterceptorLargeDataReport(t *testing.T) {
+	auth := service.NewAuthService("test-secret")
+	store := state.NewStore(time.Hour)
+	defer store.Close()
+	store.Create("session-1", &config.Profile{Name: "dev"}, nil)
+
+	reader, err := auth.GenerateTokenPair("session-1", "dev", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	interceptor := NewAuthInterceptor(auth, nil, store, adminOnly{}, "", logger.Default())
+
+	// Readers get the report; the service leaves out tables they cannot read.
+	got := callInterceptor(interceptor, "/kassie.v1.DiagnosticsService/GetLargeDataReport", &pb.GetLargeDataReportRequest{}, "authorization", "Bearer "+reader.AccessToken)
+	if got != codes.OK {
+		t.Errorf("code = %v, want %v", got, codes.OK)
+	}
+}
@@ -3,12 +3,21 @@ package grpc
 import (
 	"github.com/KashifKhn/kassie/internal/server/service"
 	"github.com/KashifKhn/kassie/internal/server/state"
+	"github.com/KashifKhn/kassie/internal/shared/config"
 )
 
 type TokenValidator interface {
 	ValidateToken(tokenString string, expectedType service.TokenType) (*service.Claims, error)
 }
 
+type APITokenAuthenticator interface {
+	Authenticate(raw string) (*service.Claims, error)
+}
+
 type SessionStore interface {
 	Get(id string) (*state.Session, error)
 }
+
+type Authorizer interface {
+	Authorize(username, profile string, op config.Operation, keyspace, table string) error
+}
@@ -7,6 +7,9 @@ import (
 	"time"
 
 	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/server/apitoken"
+	"github.com/KashifKhn/kassie/internal/server/metrics"
+	"github.com/KashifKhn/kassie/internal/server/policy"
 	"github.com/KashifKhn/kassie/internal/server/service"
 	"github.com/KashifKhn/kassie/internal/shared/logger"
 	"google.golang.org/grpc"
@@ -25,6 +28,11 @@ type Server struct {
 	sessionService *service.SessionService
 	schemaService  *service.SchemaService
 	dataService    *service.DataService
+	diagService    *service.DiagnosticsService
+	oidcService    *service.OIDCService
+	tokenService   *service.TokenService
+	adminService   *service.AdminService
+	profileService *service.ProfileService
 	listener       net.Listener
 	logger         *logger.Logger
 }
@@ -33,6 +41,17 @@ type ServerDeps struct {
 	Config service.ProfileProvider
 	Pool   service.ConnectionPool
 	Store  service.SessionStore
+	Policy *policy.Engine
+	Tokens *apitoken.Store
+	// Profiles enables ProfileService. It should also serve as Config so
+	// that edits are seen at login.
+	Profiles service.ProfileEditor
+	// Metrics, when set, counts and times every call.
+	Metrics *metrics.Metrics
+	// LocalKey lets callers presenting it manage profiles without logging
+	// in. Only embedded servers set it, to a secret known to the process
+	// that started them.
+	LocalKey string
 }
 
 func NewServer(cfg *ServerConfig, deps *ServerDeps, log *logger.Logger) (*Server, error) {
@@ -42,14 +61,34 @@ func NewServer(cfg *ServerConfig, deps *ServerDeps, log *logger.Logger) (*Server
 
 	auth := service.NewAuthService(cfg.JWTSecret)
 
-	sessionSvc := service.NewSessionService(deps.Config, deps.Pool, deps.Store, auth)
-	schemaSvc := service.NewSchemaService(deps.Store)
+	var users service.UserProvider
+	var access service.AccessPolicy
+	var authz Authorizer
+	if deps.Policy != nil {
+		users, access, authz = deps.Policy, deps.Policy, deps.Policy
+	}
+
+	sessionSvc := service.NewSessionService(deps.Config, deps.Pool, deps.Store, auth, users)
+	schemaSvc := service.NewSchemaService(deps.Store, access)
 	dataSvc := service.NewDataService(deps.Store)
+	diagSvc := service.NewDiagnosticsService(deps.Store, access)
 
-	unaryInterceptor := NewAuthInterceptor(auth, deps.Store, log)
+	var tokens service.TokenStore
+	if deps.Tokens != nil {
+		tokens = deps.Tokens
+	}
+	tokenSvc := service.NewTokenService(tokens, sessionSvc, deps.Store, users)
+	adminSvc := service.NewAdminService(auth, deps.Store, tokens, deps.Pool)
+	profileSvc := service.NewProfileService(deps.Profiles, auth, deps.Store, tokens, deps.Pool)
+
+	var interceptors []grpc.UnaryServerInterceptor
+	if deps.Metrics != nil {
+		interceptors = append(interceptors, NewMetricsInterceptor(deps.Metrics))
+	}
+	interceptors = append(interceptors, NewAuthInterceptor(auth, tokenSvc, deps.Store, authz, deps.LocalKey, log))
 
 	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(unaryInterceptor),
+		grpc.ChainUnaryInterceptor(interceptors...),
 		grpc.MaxRecvMsgSize(10*1024*1024),
 		grpc.MaxSendMsgSize(10*1024*1024),
 	)
@@ -57,6 +96,10 @@ func NewServer(cfg *ServerConfig, deps *ServerDeps, log *logger.Logger) (*Server
 	pb.RegisterSessionServiceServer(grpcServer, sessionSvc)
 	pb.RegisterSchemaServiceServer(grpcServer, schemaSvc)
 	pb.RegisterDataServiceServer(grpcServer, dataSvc)
+	pb.RegisterDiagnosticsServiceServer(grpcServer, diagSvc)
+	pb.RegisterTokenServiceServer(grpcServer, tokenSvc)
+	pb.RegisterAdminServiceServer(grpcServer, adminSvc)
+	pb.RegisterProfileServiceServer(grpcServer, profileSvc)
 
 	reflection.Register(grpcServer)
 
@@ -66,12 +109,26 @@ func NewServer(cfg *ServerConfig, deps *ServerDeps, log *logger.Logger) (*Server
 		sessionService: sessionSvc,
 		schemaService:  schemaSvc,
 		dataService:    dataSvc,
+		diagService:    diagSvc,
+		tokenService:   tokenSvc,
+		adminService:   adminSvc,
+		profileService: profileSvc,
 		logger:         log,
 	}
 
+	if deps.Policy != nil {
+		s.oidcService = service.NewOIDCService(sessionSvc, deps.Policy)
+	}
+
 	return s, nil
 }
 
+// OIDC returns the single sign-on flow, or nil when the server runs without a
+// policy engine. The flow reports itself disabled until oidc is configured.
+func (s *Server) OIDC() *service.OIDCService {
+	return s.oidcService
+}
+
 func (s *Server) Start() error {
 	if err := s.Listen(); err != nil {
 		return err
@@ -0,0 +1,162 @@
+// Package metrics exports server metrics to Prometheus.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/KashifKhn/kassie/internal/server/db"
+	"github.com/KashifKhn/kassie/internal/server/state"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Path is where the gateway serves metrics.
+const Path = "/metrics"
+
+// latencyBuckets are the histogram bounds, in seconds, for RPC and query
+// latency.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var healthStates = []db.HealthState{db.HealthHealthy, db.HealthDegraded, db.HealthDown}
+
+type SessionSource interface {
+	List() []state.SessionInfo
+}
+
+type PoolSource interface {
+	Stats() []db.PoolStats
+}
+
+// Metrics counts RPCs and Cassandra queries as they happen. Session, cursor
+// and pool gauges are read from their sources on each scrape.
+type Metrics struct {
+	registry     *prometheus.Registry
+	rpcs         *prometheus.CounterVec
+	rpcLatency   *prometheus.HistogramVec
+	queryLatency *prometheus.HistogramVec
+	queryErrors  *prometheus.CounterVec
+	rows         *prometheus.CounterVec
+}
+
+// New creates a registry. Either source may be nil, which leaves its gauges
+// out.
+func New(sessions SessionSource, pool PoolSource) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		rpcs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kassie_rpc_requests_total",
+			Help: "gRPC calls handled, by method and status code.",
+		}, []string{"method", "code"}),
+		rpcLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "kassie_rpc_duration_seconds",
+			Help:    "gRPC call latency, by method.",
+			Buckets: latencyBuckets,
+		}, []string{"method"}),
+		queryLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "kassie_cassandra_query_duration_seconds",
+			Help:    "Cassandra query latency, by profile.",
+			Buckets: latencyBuckets,
+		}, []string{"profile"}),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kassie_cassandra_query_errors_total",
+			Help: "Cassandra queries that failed, by profile.",
+		}, []string{"profile"}),
+		rows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kassie_cassandra_rows_returned_total",
+			Help: "Rows read from Cassandra, by profile.",
+		}, []string{"profile"}),
+	}
+	m.registry.MustRegister(m.rpcs, m.rpcLatency, m.queryLatency, m.queryErrors, m.rows)
+	if sessions != nil {
+		m.registry.MustRegister(sessionCollector{sessions})
+	}
+	if pool != nil {
+		m.registry.MustRegister(poolCollector{pool})
+	}
+	return m
+}
+
+// ObserveRPC records a finished gRPC call.
+func (m *Metrics) ObserveRPC(method, code string, elapsed time.Duration) {
+	m.rpcs.WithLabelValues(method, code).Inc()
+	m.rpcLatency.WithLabelValues(method).Observe(elapsed.Seconds())
+}
+
+// ObserveQuery records a Cassandra query run for a profile. It implements
+// db.QueryObserver.
+func (m *Metrics) ObserveQuery(profile string, elapsed time.Duration, rows int, err error) {
+	m.queryLatency.WithLabelValues(profile).Observe(elapsed.Seconds())
+	// Every profile with queries gets an error count, even a zero one.
+	failed := m.queryErrors.WithLabelValues(profile)
+	if err != nil {
+		failed.Inc()
+	}
+	m.rows.WithLabelValues(profile).Add(float64(rows))
+}
+
+// Handler serves the metrics to Prometheus.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+var (
+	sessionsDesc = prometheus.NewDesc("kassie_sessions_active", "Logged-in sessions.", nil, nil)
+	cursorsDesc  = prometheus.NewDesc("kassie_cursors_active", "Open paging cursors across all sessions.", nil, nil)
+
+	poolConnectionsDesc = prometheus.NewDesc("kassie_pool_connections", "Shared cluster connections open in the pool.", nil, nil)
+	poolSessionsDesc    = prometheus.NewDesc("kassie_pool_connection_sessions", "Sessions using each shared connection.", []string{"profile"}, nil)
+	poolHealthDesc      = prometheus.NewDesc("kassie_pool_connection_health", "1 for the state of each shared connection's last health check.", []string{"profile", "state"}, nil)
+	poolLatencyDesc     = prometheus.NewDesc("kassie_pool_health_check_latency_seconds", "Latency of each shared connection's last successful health check.", []string{"profile"}, nil)
+	poolReconnectsDesc  = prometheus.NewDesc("kassie_pool_reconnects_total", "Times each shared connection was recreated after failing health checks.", []string{"profile"}, nil)
+)
+
+type sessionCollector struct {
+	sessions SessionSource
+}
+
+func (c sessionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sessionsDesc
+	ch <- cursorsDesc
+}
+
+func (c sessionCollector) Collect(ch chan<- prometheus.Metric) {
+	sessions := c.sessions.List()
+	cursors := 0
+	for _, session := range sessions {
+		cursors += session.Cursors
+	}
+
+	ch <- prometheus.MustNewConstMetric(sessionsDesc, prometheus.GaugeValue, float64(len(sessions)))
+	ch <- prometheus.MustNewConstMetric(cursorsDesc, prometheus.GaugeValue, float64(cursors))
+}
+
+type poolCollector struct {
+	pool PoolSource
+}
+
+func (c poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolConnectionsDesc
+	ch <- poolSessionsDesc
+	ch <- poolHealthDesc
+	ch <- poolLatencyDesc
+	ch <- poolReconnectsDesc
+}
+
+func (c poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.pool.Stats()
+
+	ch <- prometheus.MustNewConstMetric(poolConnectionsDesc, prometheus.GaugeValue, float64(len(stats)))
+	for _, s := range stats {
+		ch <- prometheus.MustNewConstMetric(poolSessionsDesc, prometheus.GaugeValue, float64(s.Refs), s.Profile)
+		for _, st := range healthStates {
+			value := 0.0
+			if s.Health.State == st {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(poolHealthDesc, prometheus.GaugeValue, value, s.Profile, string(st))
+		}
+		ch <- prometheus.MustNewConstMetric(poolLatencyDesc, prometheus.GaugeValue, s.Health.Latency.Seconds(), s.Profile)
+		ch <- prometheus.MustNewConstMetric(poolReconnectsDesc, prometheus.CounterValue, float64(s.Health.Reconnects), s.Profile)
+	}
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KashifKhn/kassie/internal/server/db"
+	"github.com/KashifKhn/kassie/internal/server/state"
+)
+
+type fakeSessions []state.SessionInfo
+
+func (f fakeSessions) List() []state.SessionInfo { return f }
+
+type fakePool []db.PoolStats
+
+func (f fakePool) Stats() []db.PoolStats { return f }
+
+func export(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", Path, nil))
+	if rec.Code != 200 {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	return rec.Body.String()
+}
+
+func TestExportCounters(t *testing.T) {
+	m := New(nil, nil)
+	m.ObserveRPC("/kassie.v1.DataService/QueryRows", "OK", 20*time.Millisecond)
+	m.ObserveRPC("/kassie.v1.DataService/QueryRows", "OK", 2*time.Second)
+	m.ObserveRPC("/kassie.v1.DataService/QueryRows", "PermissionDenied", time.Millisecond)
+	m.ObserveQuery("prod", 30*time.Millisecond, 50, nil)
+	m.ObserveQuery("prod", time.Second, 0, errors.New("timeout"))
+
+	out := export(t, m)
+	for _, want := range []string{
+		"# TYPE kassie_rpc_requests_total counter",
+		`kassie_rpc_requests_total{code="OK",method="/kassie.v1.DataService/QueryRows"} 2`,
+		`kassie_rpc_requests_total{code="PermissionDenied",method="/kassie.v1.DataService/QueryRows"} 1`,
+		"# TYPE kassie_rpc_duration_seconds histogram",
+		`kassie_rpc_duration_seconds_bucket{method="/kassie.v1.DataService/QueryRows",le="0.005"} 1`,
+		`kassie_rpc_duration_seconds_bucket{method="/kassie.v1.DataService/QueryRows",le="0.025"} 2`,
+		`kassie_rpc_duration_seconds_bucket{method="/kassie.v1.DataService/QueryRows",le="+Inf"} 3`,
+		`kassie_rpc_duration_seconds_count{method="/kassie.v1.DataService/QueryRows"} 3`,
+		`kassie_cassandra_query_duration_seconds_count{profile="prod"} 2`,
+		`kassie_cassandra_query_duration_seconds_bucket{profile="prod",le="1"} 2`,
+		`kassie_cassandra_query_errors_total{profile="prod"} 1`,
+		`kassie_cassandra_rows_returned_total{profile="prod"} 50`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "kassie_sessions_active") || strings.Contains(out, "kassie_pool_connections") {
+		t.Errorf("output has gauges without sources:\n%s", out)
+	}
+}
+
+func TestExportGauges(t *testing.T) {
+	first := state.SessionInfo{ID: "first", Cursors: 2}
+	second := state.SessionInfo{ID: "second"}
+
+	pool := fakePool{
+		{Profile: "dev", Refs: 2, Health: db.Health{State: db.HealthHealthy, Latency: 5 * time.Millisecond}},
+		{Profile: "prod", Refs: 1, Health: db.Health{State: db.HealthDown, Reconnects: 3}},
+	}
+
+	out := export(t, New(fakeSessions{first, second}, pool))
+	for _, want := range []string{
+		"kassie_sessions_active 2",
+		"kassie_cursors_active 2",
+		"kassie_pool_connections 2",
+		`kassie_pool_connection_sessions{profile="dev"} 2`,
+		`kassie_pool_connection_health{profile="dev",state="healthy"} 1`,
+		`kassie_pool_connection_health{profile="prod",state="healthy"} 0`,
+		`kassie_pool_connection_health{profile="prod",state="down"} 1`,
+		`kassie_pool_health_check_latency_seconds{profile="dev"} 0.005`,
+		`kassie_pool_reconnects_total{profile="prod"} 3`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestLabelEscaping(t *testing.T) {
+	m := New(nil, nil)
+	m.ObserveQuery("a\"b\\c\nd", time.Millisecond, 1, nil)
+
+	want := `kassie_cassandra_rows_returned_total{profile="a\"b\\c\nd"} 1`
+	if out := export(t, m); !strings.Contains(out, want+"\n") {
+		t.Errorf("output is missing %q:\n%s", want, out)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	m := New(nil, nil)
+	m.ObserveRPC("/kassie.v1.SessionService/Login", "OK", time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", Path, nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), `kassie_rpc_requests_total{code="OK",method="/kassie.v1.SessionService/Login"} 1`) {
+		t.Errorf("body = %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,233 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/KashifKhn/kassie/internal/shared/config"
+)
+
+var ErrAccessDenied = errors.New("access denied")
+
+type snapshot struct {
+	users map[string]*config.User
+	roles map[string]config.Role
+	oidc  *config.OIDCConfig
+}
+
+// Engine evaluates user and role definitions from the server config. The
+// definitions are swapped atomically so Reload is safe while requests are in
+// flight.
+type Engine struct {
+	current atomic.Pointer[snapshot]
+
+	// external holds the IdP groups of users that signed in through OIDC.
+	// Their roles are derived from the current snapshot on every check so a
+	// reload of the group mapping applies to existing sessions.
+	external sync.Map
+}
+
+func NewEngine(cfg *config.ServerConfig) *Engine {
+	e := &Engine{}
+	e.Reload(cfg)
+	return e
+}
+
+func (e *Engine) Reload(cfg *config.ServerConfig) {
+	snap := &snapshot{
+		users: make(map[string]*config.User),
+		roles: cfg.RoleSet(),
+	}
+
+	if cfg != nil {
+		for i := range cfg.Users {
+			u := cfg.Users[i]
+			snap.users[u.Username] = &u
+		}
+		snap.oidc = cfg.OIDC
+	}
+
+	e.current.Store(snap)
+}
+
+func (e *Engine) HasUsers() bool {
+	return e.current.Load().enabled()
+}
+
+func (e *Engine) OIDC() *config.OIDCConfig {
+	return e.current.Load().oidc
+}
+
+func (e *Engine) SSOEnabled() bool {
+	return e.OIDC() != nil
+}
+
+// ResolveExternalUser maps an identity asserted by the OIDC provider to a
+// Kassie user named with config.ExternalUserPrefix, so it never takes over a
+// local user. The roles come from the configured group mapping.
+func (e *Engine) ResolveExternalUser(username string, groups []string) (*config.User, error) {
+	snap := e.current.Load()
+	if snap.oidc == nil {
+		return nil, fmt.Errorf("%w: single sign-on is not configured", ErrAccessDenied)
+	}
+
+	u := snap.externalUser(config.ExternalUserPrefix+username, groups)
+	if len(u.Roles) == 0 {
+		return nil, fmt.Errorf("%w: no role is mapped to the groups of %s", ErrAccessDenied, username)
+	}
+
+	e.external.Store(u.Username, append([]string(nil), groups...))
+	return u, nil
+}
+
+func (e *Engine) GetUser(username string) (*config.User, error) {
+	u, ok := e.current.Load().users[username]
+	if !ok {
+		return nil, config.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func (e *Engine) CanUseProfile(username, profile string) bool {
+	snap := e.current.Load()
+	if !snap.enabled() {
+		return true
+	}
+
+	u, ok := e.lookup(snap, username)
+	if !ok || !u.CanUseProfile(profile) {
+		return false
+	}
+
+	for _, role := range snap.rolesFor(u) {
+		if matchAny(role.Profiles, profile) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Engine) Authorize(username, profile string, op config.Operation, keyspace, table string) error {
+	snap := e.current.Load()
+	if !snap.enabled() {
+		return nil
+	}
+
+	u, ok := e.lookup(snap, username)
+	if !ok {
+		return fmt.Errorf("%w: unknown user %s", ErrAccessDenied, username)
+	}
+	if !u.CanUseProfile(profile) {
+		return fmt.Errorf("%w: user %s may not use profile %s", ErrAccessDenied, username, profile)
+	}
+
+	for _, role := range snap.rolesFor(u) {
+		if role.Allows(op) && roleCovers(role, profile, keyspace, table) {
+			return nil
+		}
+	}
+
+	target := profile
+	if keyspace != "" {
+		target += "/" + keyspace
+		if table != "" {
+			target += "." + table
+		}
+	}
+	return fmt.Errorf("%w: user %s may not perform %s on %s", ErrAccessDenied, username, op, target)
+}
+
+func (e *Engine) CanAccessKeyspace(username, profile, keyspace string) bool {
+	return e.Authorize(username, profile, config.OpRead, keyspace, "") == nil
+}
+
+func (e *Engine) CanAccessTable(username, profile, keyspace, table string) bool {
+	return e.Authorize(username, profile, config.OpRead, keyspace, table) == nil
+}
+
+func (e *Engine) lookup(snap *snapshot, username string) (*config.User, bool) {
+	if !strings.HasPrefix(username, config.ExternalUserPrefix) {
+		u, ok := snap.users[username]
+		return u, ok
+	}
+	if snap.oidc == nil {
+		return nil, false
+	}
+
+	groups, ok := e.external.Load(username)
+	if !ok {
+		return nil, false
+	}
+	return snap.externalUser(username, groups.([]string)), true
+}
+
+func (s *snapshot) enabled() bool {
+	return len(s.users) > 0 || s.oidc != nil
+}
+
+func (s *snapshot) externalUser(username string, groups []string) *config.User {
+	return &config.User{
+		Username: username,
+		Profiles: []string{config.AllProfiles},
+		Roles:    s.oidc.RolesForGroups(groups),
+	}
+}
+
+func (s *snapshot) rolesFor(u *config.User) []config.Role {
+	names := u.RoleNames()
+	roles := make([]config.Role, 0, len(names))
+	for _, name := range names {
+		if r, ok := s.roles[name]; ok {
+			roles = append(roles, r)
+		}
+	}
+	return roles
+}
+
+func roleCovers(role config.Role, profile, keyspace, table string) bool {
+	if !matchAny(role.Profiles, profile) {
+		return false
+	}
+	if keyspace == "" {
+		return true
+	}
+	if !matchAny(role.Keyspaces, keyspace) {
+		return false
+	}
+	if len(role.Tables) == 0 {
+		return true
+	}
+
+	for _, pattern := range role.Tables {
+		ksPattern, tablePattern, _ := strings.Cut(pattern, ".")
+		if !match(ksPattern, keyspace) {
+			continue
+		}
+		if table == "" || match(tablePattern, table) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAny treats an empty pattern list as unrestricted.
+func matchAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if match(p, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func match(pattern, value string) bool {
+	ok, err := path.Match(pattern, value)
+	return err == nil && ok
+}
@@ -0,0 +1,185 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/KashifKhn/kassie/internal/shared/config"
+)
+
+func testServerConfig() *config.ServerConfig {
+	return &config.ServerConfig{
+		Users: []config.User{
+			{Username: "viewer", Profiles: []string{"dev"}},
+			{Username: "editor", Profiles: []string{config.AllProfiles}, Roles: []string{config.RoleEditor}},
+			{Username: "admin", Profiles: []string{config.AllProfiles}, Roles: []string{config.RoleAdmin}},
+			{Username: "analyst", Profiles: []string{config.AllProfiles}, Roles: []string{"analyst"}},
+			{Username: "support", Profiles: []string{config.AllProfiles}, Roles: []string{"support"}},
+		},
+		Roles: []config.Role{
+			{Name: "analyst", Operations: []config.Operation{config.OpRead, config.OpExport}, Profiles: []string{"prod"}, Keyspaces: []string{"analytics_*"}},
+			{Name: "support", Operations: []config.Operation{config.OpRead}, Tables: []string{"app.users", "app.orders_*"}},
+		},
+	}
+}
+
+func TestEngine_Authorize(t *testing.T) {
+	e := NewEngine(testServerConfig())
+
+	tests := []struct {
+		name     string
+		user     string
+		profile  string
+		op       config.Operation
+		keyspace string
+		table    string
+		allowed  bool
+	}{
+		{name: "viewer reads", user: "viewer", profile: "dev", op: config.OpRead, keyspace: "app", table: "users", allowed: true},
+		{name: "viewer cannot write", user: "viewer", profile: "dev", op: config.OpWrite, keyspace: "app", allowed: false},
+		{name: "viewer limited to profile", user: "viewer", profile: "prod", op: config.OpRead, allowed: false},
+		{name: "editor writes", user: "editor", profile: "prod", op: config.OpWrite, keyspace: "app", allowed: true},
+		{name: "editor cannot ddl", user: "editor", profile: "prod", op: config.OpDDL, keyspace: "app", allowed: false},
+		{name: "admin anything", user: "admin", profile: "prod", op: config.OpAdmin, allowed: true},
+		{name: "analyst matching keyspace", user: "analyst", profile: "prod", op: config.OpExport, keyspace: "analytics_daily", allowed: true},
+		{name: "analyst other keyspace", user: "analyst", profile: "prod", op: config.OpRead, keyspace: "app", allowed: false},
+		{name: "analyst other profile", user: "analyst", profile: "dev", op: config.OpRead, keyspace: "analytics_daily", allowed: false},
+		{name: "support allowed table", user: "support", profile: "dev", op: config.OpRead, keyspace: "app", table: "orders_2024", allowed: true},
+		{name: "support other table", user: "support", profile: "dev", op: config.OpRead, keyspace: "app", table: "payments", allowed: false},
+		{name: "support keyspace listing", user: "support", profile: "dev", op: config.OpRead, keyspace: "app", allowed: true},
+		{name: "support other keyspace", user: "support", profile: "dev", op: config.OpRead, keyspace: "billing", allowed: false},
+		{name: "unknown user", user: "mallory", profile: "dev", op: config.OpRead, allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := e.Authorize(tt.user, tt.profile, tt.op, tt.keyspace, tt.table)
+			if tt.allowed && err != nil {
+				t.Errorf("expected allowed, got %v", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrAccessDenied) {
+				t.Errorf("expected ErrAccessDenied, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEngine_CanUseProfile(t *testing.T) {
+	e := NewEngine(testServerConfig())
+
+	if !e.CanUseProfile("viewer", "dev") {
+		t.Error("expected viewer to use dev")
+	}
+	if e.CanUseProfile("viewer", "prod") {
+		t.Error("expected viewer to be denied prod")
+	}
+	if e.CanUseProfile("analyst", "dev") {
+		t.Error("expected analyst role to restrict profiles to prod")
+	}
+	if !e.CanUseProfile("analyst", "prod") {
+		t.Error("expected analyst to use prod")
+	}
+}
+
+func TestEngine_NoUsers(t *testing.T) {
+	e := NewEngine(nil)
+
+	if e.HasUsers() {
+		t.Fatal("expected no users")
+	}
+	if err := e.Authorize("", "dev", config.OpAdmin, "", ""); err != nil {
+		t.Errorf("expected everything allowed without users, got %v", err)
+	}
+	if !e.CanAccessTable("", "dev", "app", "users") {
+		t.Error("expected table access without users")
+	}
+}
+
+func TestEngine_Reload(t *testing.T) {
+	e := NewEngine(testServerConfig())
+
+	if _, err := e.GetUser("editor"); err != nil {
+		t.Fatalf("expected editor to exist, got %v", err)
+	}
+
+	e.Reload(&config.ServerConfig{
+		Users: []config.User{{Username: "editor", Profiles: []string{config.AllProfiles}}},
+	})
+
+	if _, err := e.GetUser("admin"); err != config.ErrUserNotFound {
+		t.Errorf("expected removed user to be gone, got %v", err)
+	}
+	if err := e.Authorize("editor", "dev", config.OpWrite, "app", ""); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("expected editor to fall back to viewer after reload, got %v", err)
+	}
+}
+
+func TestEngine_ResolveExternalUser(t *testing.T) {
+	cfg := testServerConfig()
+	cfg.OIDC = &config.OIDCConfig{
+		Issuer:      "https://idp.example.com",
+		ClientID:    "kassie",
+		RedirectURL: "https://kassie.example.com/api/v1/auth/oidc/callback",
+		GroupRoles:  map[string][]string{"data-team": {"analyst"}, "dba": {config.RoleAdmin}},
+	}
+	e := NewEngine(cfg)
+
+	if _, err := e.ResolveExternalUser("nobody@example.com", []string{"marketing"}); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("expected ErrAccessDenied for unmapped groups, got %v", err)
+	}
+
+	u, err := e.ResolveExternalUser("ana@example.com", []string{"data-team"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(u.Roles) != 1 || u.Roles[0] != "analyst" {
+		t.Errorf("expected analyst role, got %v", u.Roles)
+	}
+
+	if err := e.Authorize("sso:ana@example.com", "prod", config.OpExport, "analytics_daily", ""); err != nil {
+		t.Errorf("expected external analyst to export, got %v", err)
+	}
+	if e.CanUseProfile("sso:ana@example.com", "dev") {
+		t.Error("expected analyst role to restrict profiles to prod")
+	}
+
+	if u.Username != "sso:ana@example.com" {
+		t.Errorf("expected the external user to be namespaced, got %q", u.Username)
+	}
+
+	namesake, err := e.ResolveExternalUser("editor", []string{"data-team"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if namesake.Username != "sso:editor" || namesake.Roles[0] != "analyst" {
+		t.Errorf("expected the IdP identity to stay apart from the local user, got %s %v", namesake.Username, namesake.Roles)
+	}
+	if err := e.Authorize("editor", "dev", config.OpWrite, "app", ""); err != nil {
+		t.Errorf("expected the local editor to keep its own roles, got %v", err)
+	}
+
+	cfg.OIDC.GroupRoles["data-team"] = []string{config.RoleViewer}
+	e.Reload(cfg)
+	if err := e.Authorize("sso:ana@example.com", "prod", config.OpExport, "analytics_daily", ""); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("expected reloaded mapping to apply to external user, got %v", err)
+	}
+
+	cfg.OIDC = nil
+	e.Reload(cfg)
+	if e.CanUseProfile("sso:ana@example.com", "prod") {
+		t.Error("expected external users to lose access once oidc is removed")
+	}
+}
+
+func TestEngine_OIDCOnlyEnablesAuth(t *testing.T) {
+	e := NewEngine(&config.ServerConfig{
+		OIDC: &config.OIDCConfig{Issuer: "https://idp", ClientID: "kassie", RedirectURL: "https://kassie/cb"},
+	})
+
+	if !e.HasUsers() {
+		t.Error("expected oidc to enable authentication")
+	}
+	if err := e.Authorize("", "dev", config.OpRead, "", ""); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("expected anonymous access to be denied, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/server/state"
+	"github.com/KashifKhn/kassie/internal/shared/ctxutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type AdminService struct {
+	pb.UnimplementedAdminServiceServer
+	auth   *AuthService
+	store  SessionStore
+	tokens TokenStore
+	pool   ConnectionPool
+}
+
+func NewAdminService(auth *AuthService, store SessionStore, tokens TokenStore, pool ConnectionPool) *AdminService {
+	return &AdminService{
+		auth:   auth,
+		store:  store,
+		tokens: tokens,
+		pool:   pool,
+	}
+}
+
+// RevokeTokens invalidates every access and refresh token issued so far to a
+// user or for a profile, closes their sessions and deletes their API tokens.
+// When both are given, tokens matching either are revoked.
+func (s *AdminService) RevokeTokens(ctx context.Context, req *pb.RevokeTokensRequest) (*pb.RevokeTokensResponse, error) {
+	if req.User == "" && req.Profile == "" {
+		return nil, status.Error(codes.InvalidArgument, "user or profile is required")
+	}
+
+	if req.User != "" {
+		s.auth.RevokeUser(req.User)
+	}
+	if req.Profile != "" {
+		s.auth.RevokeProfile(req.Profile)
+	}
+
+	sessions := s.store.DeleteMatching(func(session *state.Session) bool {
+		if req.User != "" && session.User == req.User {
+			return true
+		}
+		return req.Profile != "" && session.Profile != nil && session.Profile.Name == req.Profile
+	})
+
+	resp := &pb.RevokeTokensResponse{SessionsTerminated: int32(sessions)}
+	if s.tokens != nil {
+		n, err := s.tokens.RevokeMatching(req.User, req.Profile)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to revoke api tokens: %v", err)
+		}
+		resp.ApiTokensRevoked = int32(n)
+	}
+
+	return resp, nil
+}
+
+func (s *AdminService) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	current, _ := ctxutil.GetSessionID(ctx)
+
+	list := s.store.List()
+	sessions := make([]*pb.SessionInfo, 0, len(list))
+	for _, session := range list {
+		info := &pb.SessionInfo{
+			Id:              session.ID,
+			User:            session.User,
+			CreatedAt:       session.CreatedAt.Unix(),
+			LastAccess:      session.LastAccess.Unix(),
+			InFlightQueries: int32(session.InFlight),
+			OpenCursors:     int32(session.Cursors),
+			Current:         session.ID == current,
+		}
+		if session.Profile != nil {
+			info.Profile = session.Profile.Name
+		}
+		sessions = append(sessions, info)
+	}
+
+	return &pb.ListSessionsResponse{Sessions: sessions}, nil
+}
+
+// TerminateSession closes a session and revokes its refresh token. Sessions of
+// API tokens are reopened on the token's next use; revoke the token to stop it.
+func (s *AdminService) TerminateSession(ctx context.Context, req *pb.TerminateSessionRequest) (*pb.TerminateSessionResponse, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "session id is required")
+	}
+
+	session, err := s.store.Info(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "session not found: %s", req.Id)
+	}
+
+	s.auth.Revoke(session.RefreshID, RefreshToken)
+	s.store.Delete(session.ID)
+
+	return &pb.TerminateSessionResponse{}, nil
+}
+
+// ListConnections reports the shared cluster connections and how many
+// sessions reference each. Dedicated connections for prompted credentials
+// are not pooled and not listed.
+func (s *AdminService) ListConnections(ctx context.Context, req *pb.ListConnectionsRequest) (*pb.ListConnectionsResponse, error) {
+	if s.pool == nil {
+		return &pb.ListConnectionsResponse{}, nil
+	}
+
+	stats := s.pool.Stats()
+	connections := make([]*pb.ConnectionInfo, 0, len(stats))
+	for _, st := range stats {
+		connections = append(connections, &pb.ConnectionInfo{
+			Profile:  st.Profile,
+			Refs:     int32(st.Refs),
+			OpenedAt: st.OpenedAt.Unix(),
+			LastUsed: st.LastUsed.Unix(),
+			Health:   connectionHealthToProto(st.Health),
+		})
+	}
+
+	return &pb.ListConnectionsResponse{Connections: connections}, nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/server/apitoken"
+	"github.com/KashifKhn/kassie/internal/shared/config"
+	"github.com/KashifKhn/kassie/internal/shared/ctxutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAdminService_RevokeTokens(t *testing.T) {
+	sessions, store, auth := newUserSessionService(t)
+
+	tokens, err := apitoken.NewStore("")
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	raw, _, err := tokens.Create("ci", "alice", "dev", false, 0)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	login, err := sessions.Login(context.Background(), &pb.LoginRequest{Profile: "dev", Username: "alice", Password: "s3cret"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	other := store.Create("other", &config.Profile{Name: "prod"}, nil)
+	other.User = "bob"
+
+	svc := NewAdminService(auth, store, tokens, nil)
+	resp, err := svc.RevokeTokens(context.Background(), &pb.RevokeTokensRequest{User: "alice"})
+	if err != nil {
+		t.Fatalf("RevokeTokens() error = %v", err)
+	}
+	if resp.SessionsTerminated != 1 || resp.ApiTokensRevoked != 1 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	if _, err := sessions.Refresh(context.Background(), &pb.RefreshRequest{RefreshToken: login.RefreshToken}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected refresh to fail after revocation, got %v", err)
+	}
+	if _, err := tokens.Verify(raw); err == nil {
+		t.Error("expected api token to be revoked")
+	}
+	if _, err := store.Get("other"); err != nil {
+		t.Errorf("expected other users' sessions to survive, got %v", err)
+	}
+
+	resp, err = svc.RevokeTokens(context.Background(), &pb.RevokeTokensRequest{Profile: "prod"})
+	if err != nil {
+		t.Fatalf("RevokeTokens() error = %v", err)
+	}
+	if resp.SessionsTerminated != 1 {
+		t.Errorf("expected the prod session to be closed, got %+v", resp)
+	}
+}
+
+func TestAdminService_RevokeTokens_RequiresTarget(t *testing.T) {
+	svc := NewAdminService(NewAuthService("test-secret"), newMockSessionStore(), nil, nil)
+
+	_, err := svc.RevokeTokens(context.Background(), &pb.RevokeTokensRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestAdminService_ListAndTerminateSessions(t *testing.T) {
+	sessions, store, auth := newUserSessionService(t)
+	svc := NewAdminService(auth, store, nil, nil)
+
+	login, err := sessions.Login(context.Background(), &pb.LoginRequest{Profile: "dev", Username: "alice", Password: "s3cret"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	claims, err := auth.ValidateToken(login.AccessToken, AccessToken)
+	if err != nil {
+		t.Fatalf("expected valid access token, got %v", err)
+	}
+
+	own := store.Create("admin-session", &config.Profile{Name: "prod"}, nil)
+	own.User = "root"
+	own.Cursors.Create(nil, "ks", "users", "", 100)
+	done := own.Track()
+	defer done()
+
+	ctx := ctxutil.WithSessionID(context.Background(), own.ID)
+	list, err := svc.ListSessions(ctx, &pb.ListSessionsRequest{})
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(list.Sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(list.Sessions))
+	}
+
+	byID := make(map[string]*pb.SessionInfo)
+	for _, info := range list.Sessions {
+		byID[info.Id] = info
+	}
+	alice := byID[claims.SessionID]
+	if alice == nil || alice.User != "alice" || alice.Profile != "dev" || alice.Current {
+		t.Errorf("unexpected session info %+v", alice)
+	}
+	admin := byID[own.ID]
+	if admin == nil || !admin.Current || admin.OpenCursors != 1 || admin.InFlightQueries != 1 {
+		t.Errorf("unexpected session info %+v", admin)
+	}
+
+	if _, err := svc.TerminateSession(ctx, &pb.TerminateSessionRequest{Id: claims.SessionID}); err != nil {
+		t.Fatalf("TerminateSession() error = %v", err)
+	}
+	if _, err := store.Get(claims.SessionID); err == nil {
+		t.Error("expected session to be closed")
+	}
+	if _, err := auth.ValidateToken(login.RefreshToken, RefreshToken); err != ErrRevokedToken {
+		t.Errorf("expected refresh token to be revoked, got %v", err)
+	}
+
+	tests := []struct {
+		name string
+		id   string
+		code codes.Code
+	}{
+		{name: "missing id", id: "", code: codes.InvalidArgument},
+		{name: "unknown id", id: claims.SessionID, code: codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.TerminateSession(ctx, &pb.TerminateSessionRequest{Id: tt.id})
+			if status.Code(err) != tt.code {
+				t.Errorf("expected %v, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestAdminService_ListConnections(t *testing.T) {
+	svc := NewAdminService(NewAuthService("test-secret"), newMockSessionStore(), nil, &mockPool{})
+
+	resp, err := svc.ListConnections(context.Background(), &pb.ListConnectionsRequest{})
+	if err != nil {
+		t.Fatalf("ListConnections() error = %v", err)
+	}
+	if len(resp.Connections) != 1 || resp.Connections[0].Profile != "dev" || resp.Connections[0].Refs != 1 {
+		t.Errorf("unexpected connections %+v", resp.Connections)
+	}
+}
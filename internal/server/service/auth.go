@@ -4,6 +4,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/KashifKhn/kassie/internal/server/state"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
@@ -17,6 +18,7 @@ var (
 	ErrInvalidToken  = errors.New("invalid token")
 	ErrExpiredToken  = errors.New("token expired")
 	ErrInvalidClaims = errors.New("invalid token claims")
+	ErrRevokedToken  = errors.New("token revoked")
 )
 
 type TokenType string
@@ -29,59 +31,72 @@ const (
 type Claims struct {
 	SessionID string    `json:"session_id"`
 	Profile   string    `json:"profile"`
+	User      string    `json:"user,omitempty"`
 	Type      TokenType `json:"type"`
+	ReadOnly  bool      `json:"read_only,omitempty"`
 	jwt.RegisteredClaims
 }
 
 type AuthService struct {
 	secretKey []byte
+	revoked   *state.RevocationStore
 }
 
 func NewAuthService(secretKey string) *AuthService {
 	return &AuthService{
 		secretKey: []byte(secretKey),
+		revoked:   state.NewRevocationStore(RefreshTokenDuration),
 	}
 }
 
-func (a *AuthService) GenerateTokenPair(sessionID, profile string) (accessToken, refreshToken string, expiresAt int64, err error) {
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    int64
+	AccessID     string
+	RefreshID    string
+}
+
+func (a *AuthService) GenerateTokenPair(sessionID, profile, user string) (*TokenPair, error) {
 	now := time.Now()
 
-	accessClaims := &Claims{
-		SessionID: sessionID,
-		Profile:   profile,
-		Type:      AccessToken,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenDuration)),
-			IssuedAt:  jwt.NewNumericDate(now),
-			ID:        uuid.New().String(),
-		},
+	accessToken, accessClaims, err := a.sign(sessionID, profile, user, AccessToken, now, AccessTokenDuration)
+	if err != nil {
+		return nil, err
 	}
 
-	accessTkn := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessToken, err = accessTkn.SignedString(a.secretKey)
+	refreshToken, refreshClaims, err := a.sign(sessionID, profile, user, RefreshToken, now, RefreshTokenDuration)
 	if err != nil {
-		return "", "", 0, err
+		return nil, err
 	}
 
-	refreshClaims := &Claims{
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    accessClaims.ExpiresAt.Unix(),
+		AccessID:     accessClaims.ID,
+		RefreshID:    refreshClaims.ID,
+	}, nil
+}
+
+func (a *AuthService) sign(sessionID, profile, user string, tokenType TokenType, now time.Time, ttl time.Duration) (string, *Claims, error) {
+	claims := &Claims{
 		SessionID: sessionID,
 		Profile:   profile,
-		Type:      RefreshToken,
+		User:      user,
+		Type:      tokenType,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(RefreshTokenDuration)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			ID:        uuid.New().String(),
 		},
 	}
 
-	refreshTkn := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshToken, err = refreshTkn.SignedString(a.secretKey)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.secretKey)
 	if err != nil {
-		return "", "", 0, err
+		return "", nil, err
 	}
-
-	expiresAt = accessClaims.ExpiresAt.Unix()
-	return accessToken, refreshToken, expiresAt, nil
+	return token, claims, nil
 }
 
 func (a *AuthService) ValidateToken(tokenString string, expectedType TokenType) (*Claims, error) {
@@ -108,33 +123,36 @@ func (a *AuthService) ValidateToken(tokenString string, expectedType TokenType)
 		return nil, ErrInvalidClaims
 	}
 
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	if a.revoked.IsRevoked(claims.ID, claims.User, claims.Profile, issuedAt) {
+		return nil, ErrRevokedToken
+	}
+
 	return claims, nil
 }
 
-func (a *AuthService) RefreshAccessToken(refreshTokenString string) (accessToken string, expiresAt int64, err error) {
-	claims, err := a.ValidateToken(refreshTokenString, RefreshToken)
-	if err != nil {
-		return "", 0, err
-	}
-
-	now := time.Now()
-	accessClaims := &Claims{
-		SessionID: claims.SessionID,
-		Profile:   claims.Profile,
-		Type:      AccessToken,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenDuration)),
-			IssuedAt:  jwt.NewNumericDate(now),
-			ID:        uuid.New().String(),
-		},
+// Revoke rejects the token with the given ID from now on. The entry is kept
+// for the longest token lifetime, after which the token has expired anyway.
+func (a *AuthService) Revoke(tokenID string, tokenType TokenType) {
+	ttl := AccessTokenDuration
+	if tokenType == RefreshToken {
+		ttl = RefreshTokenDuration
 	}
+	a.revoked.Revoke(tokenID, time.Now().Add(ttl))
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessToken, err = token.SignedString(a.secretKey)
-	if err != nil {
-		return "", 0, err
-	}
+// RevokeUser rejects every token issued to user so far. Token issue times
+// have second precision, so tokens from the current second are not covered;
+// callers close the matching sessions as well.
+func (a *AuthService) RevokeUser(user string) {
+	a.revoked.RevokeUser(user, time.Now().Truncate(time.Second))
+}
 
-	expiresAt = accessClaims.ExpiresAt.Unix()
-	return accessToken, expiresAt, nil
+// RevokeProfile rejects every token issued for profile so far, with the same
+// precision caveat as RevokeUser.
+func (a *AuthService) RevokeProfile(profile string) {
+	a.revoked.RevokeProfile(profile, time.Now().Truncate(time.Second))
 }
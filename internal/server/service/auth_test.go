@@ -8,19 +8,24 @@ import (
 func TestAuthService_GenerateTokenPair(t *testing.T) {
 	auth := NewAuthService("test-secret-key")
 
-	accessToken, refreshToken, expiresAt, err := auth.GenerateTokenPair("session-1", "test-profile")
+	pair, err := auth.GenerateTokenPair("session-1", "test-profile", "")
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 
-	if accessToken == "" {
+	if pair.AccessToken == "" {
 		t.Error("expected non-empty access token")
 	}
 
-	if refreshToken == "" {
+	if pair.RefreshToken == "" {
 		t.Error("expected non-empty refresh token")
 	}
 
+	if pair.AccessID == "" || pair.RefreshID == "" || pair.AccessID == pair.RefreshID {
+		t.Errorf("expected distinct token IDs, got %q and %q", pair.AccessID, pair.RefreshID)
+	}
+
+	expiresAt := pair.ExpiresAt
 	if expiresAt == 0 {
 		t.Error("expected non-zero expires at")
 	}
@@ -35,10 +40,11 @@ func TestAuthService_GenerateTokenPair(t *testing.T) {
 func TestAuthService_ValidateAccessToken(t *testing.T) {
 	auth := NewAuthService("test-secret-key")
 
-	accessToken, _, _, err := auth.GenerateTokenPair("session-1", "test-profile")
+	pair, err := auth.GenerateTokenPair("session-1", "test-profile", "")
 	if err != nil {
 		t.Fatalf("failed to generate token: %v", err)
 	}
+	accessToken := pair.AccessToken
 
 	claims, err := auth.ValidateToken(accessToken, AccessToken)
 	if err != nil {
@@ -61,10 +67,11 @@ func TestAuthService_ValidateAccessToken(t *testing.T) {
 func TestAuthService_ValidateRefreshToken(t *testing.T) {
 	auth := NewAuthService("test-secret-key")
 
-	_, refreshToken, _, err := auth.GenerateTokenPair("session-1", "test-profile")
+	pair, err := auth.GenerateTokenPair("session-1", "test-profile", "")
 	if err != nil {
 		t.Fatalf("failed to generate token: %v", err)
 	}
+	refreshToken := pair.RefreshToken
 
 	claims, err := auth.ValidateToken(refreshToken, RefreshToken)
 	if err != nil {
@@ -83,10 +90,11 @@ func TestAuthService_ValidateRefreshToken(t *testing.T) {
 func TestAuthService_ValidateToken_WrongType(t *testing.T) {
 	auth := NewAuthService("test-secret-key")
 
-	accessToken, _, _, err := auth.GenerateTokenPair("session-1", "test-profile")
+	pair, err := auth.GenerateTokenPair("session-1", "test-profile", "")
 	if err != nil {
 		t.Fatalf("failed to generate token: %v", err)
 	}
+	accessToken := pair.AccessToken
 
 	_, err = auth.ValidateToken(accessToken, RefreshToken)
 	if err != ErrInvalidClaims {
@@ -107,67 +115,77 @@ func TestAuthService_ValidateToken_WrongSecret(t *testing.T) {
 	auth1 := NewAuthService("secret-1")
 	auth2 := NewAuthService("secret-2")
 
-	token, _, _, err := auth1.GenerateTokenPair("session-1", "test-profile")
+	pair, err := auth1.GenerateTokenPair("session-1", "test-profile", "")
 	if err != nil {
 		t.Fatalf("failed to generate token: %v", err)
 	}
 
-	_, err = auth2.ValidateToken(token, AccessToken)
+	_, err = auth2.ValidateToken(pair.AccessToken, AccessToken)
 	if err != ErrInvalidToken {
 		t.Errorf("expected ErrInvalidToken for wrong secret, got %v", err)
 	}
 }
 
-func TestAuthService_RefreshAccessToken(t *testing.T) {
+func TestAuthService_Revoke(t *testing.T) {
 	auth := NewAuthService("test-secret-key")
 
-	_, refreshToken, _, err := auth.GenerateTokenPair("session-1", "test-profile")
+	pair, err := auth.GenerateTokenPair("session-1", "test-profile", "alice")
 	if err != nil {
 		t.Fatalf("failed to generate token: %v", err)
 	}
 
-	newAccessToken, expiresAt, err := auth.RefreshAccessToken(refreshToken)
-	if err != nil {
-		t.Fatalf("expected no error, got %v", err)
-	}
-
-	if newAccessToken == "" {
-		t.Error("expected non-empty new access token")
-	}
-
-	if expiresAt == 0 {
-		t.Error("expected non-zero expires at")
-	}
-
-	claims, err := auth.ValidateToken(newAccessToken, AccessToken)
-	if err != nil {
-		t.Fatalf("failed to validate new access token: %v", err)
-	}
+	auth.Revoke(pair.AccessID, AccessToken)
 
-	if claims.SessionID != "session-1" {
-		t.Errorf("expected session ID session-1, got %s", claims.SessionID)
+	if _, err := auth.ValidateToken(pair.AccessToken, AccessToken); err != ErrRevokedToken {
+		t.Errorf("expected ErrRevokedToken, got %v", err)
 	}
-}
-
-func TestAuthService_RefreshAccessToken_InvalidRefreshToken(t *testing.T) {
-	auth := NewAuthService("test-secret-key")
-
-	_, _, err := auth.RefreshAccessToken("invalid-token")
-	if err != ErrInvalidToken {
-		t.Errorf("expected ErrInvalidToken, got %v", err)
+	if _, err := auth.ValidateToken(pair.RefreshToken, RefreshToken); err != nil {
+		t.Errorf("expected refresh token to stay valid, got %v", err)
 	}
 }
 
-func TestAuthService_RefreshAccessToken_WithAccessToken(t *testing.T) {
+func TestAuthService_RevokeUserAndProfile(t *testing.T) {
 	auth := NewAuthService("test-secret-key")
 
-	accessToken, _, _, err := auth.GenerateTokenPair("session-1", "test-profile")
+	tests := []struct {
+		name    string
+		user    string
+		profile string
+		want    error
+	}{
+		{name: "revoked user", user: "alice", profile: "dev", want: ErrRevokedToken},
+		{name: "other user", user: "bob", profile: "dev", want: nil},
+		{name: "revoked profile", user: "bob", profile: "prod", want: ErrRevokedToken},
+		{name: "anonymous on revoked profile", profile: "prod", want: ErrRevokedToken},
+	}
+
+	tokens := make([]string, len(tests))
+	for i, tt := range tests {
+		pair, err := auth.GenerateTokenPair("session-1", tt.profile, tt.user)
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+		tokens[i] = pair.AccessToken
+	}
+
+	// Cutoffs have second precision; move past the second the tokens were issued in.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	auth.RevokeUser("alice")
+	auth.RevokeProfile("prod")
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := auth.ValidateToken(tokens[i], AccessToken); err != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	pair, err := auth.GenerateTokenPair("session-2", "prod", "alice")
 	if err != nil {
 		t.Fatalf("failed to generate token: %v", err)
 	}
-
-	_, _, err = auth.RefreshAccessToken(accessToken)
-	if err != ErrInvalidClaims {
-		t.Errorf("expected ErrInvalidClaims when using access token, got %v", err)
+	if _, err := auth.ValidateToken(pair.AccessToken, AccessToken); err != nil {
+		t.Errorf("expected tokens issued after the cutoff to be valid, got %v", err)
 	}
 }
@@ -0,0 +1,441 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/server/db"
+	"github.com/KashifKhn/kassie/internal/server/state"
+	"github.com/KashifKhn/kassie/internal/shared/config"
+	"github.com/KashifKhn/kassie/internal/shared/ctxutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultLargeDataLimit = 50
+	maxLargeDataLimit     = 1000
+	largeDataPageSize     = 500
+)
+
+type largeDataSource struct {
+	kind      pb.LargeDataKind
+	table     string
+	sizeCol   string
+	countCol  string
+	fetchDesc string
+}
+
+var largeDataSources = []largeDataSource{
+	{kind: pb.LargeDataKind_LARGE_DATA_KIND_PARTITION, table: "system.large_partitions", sizeCol: "partition_size", countCol: "rows", fetchDesc: "large partitions"},
+	{kind: pb.LargeDataKind_LARGE_DATA_KIND_ROW, table: "system.large_rows", sizeCol: "row_size", fetchDesc: "large rows"},
+	{kind: pb.LargeDataKind_LARGE_DATA_KIND_CELL, table: "system.large_cells", sizeCol: "cell_size", countCol: "collection_elements", fetchDesc: "large cells"},
+}
+
+type DiagnosticsService struct {
+	pb.UnimplementedDiagnosticsServiceServer
+	store  SessionStore
+	policy AccessPolicy
+}
+
+func NewDiagnosticsService(store SessionStore, policy AccessPolicy) *DiagnosticsService {
+	return &DiagnosticsService{
+		store:  store,
+		policy: policy,
+	}
+}
+
+func (d *DiagnosticsService) GetLargeDataReport(ctx context.Context, req *pb.GetLargeDataReportRequest) (*pb.GetLargeDataReportResponse, error) {
+	if req.Table != "" && req.Keyspace == "" {
+		return nil, status.Error(codes.InvalidArgument, "keyspace is required when table is set")
+	}
+	if req.Keyspace != "" {
+		if err := validateIdentifier(req.Keyspace); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid keyspace: %v", err)
+		}
+	}
+	if req.Table != "" {
+		if err := validateIdentifier(req.Table); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid table: %v", err)
+		}
+	}
+
+	session, err := GetSessionFromContext(ctx, d.store)
+	if err != nil {
+		return nil, err
+	}
+
+	isScylla, version, err := detectCluster(ctx, session.Connection)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to read system.local: %v", err)
+	}
+	if !isScylla {
+		return nil, status.Errorf(codes.FailedPrecondition, "large data report requires ScyllaDB (detected Cassandra %s)", version)
+	}
+
+	targets, err := d.largeDataTargets(ctx, session, req.Keyspace, req.Table)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list tables: %v", err)
+	}
+
+	var entries []*pb.LargeDataEntry
+	add := func(entry *pb.LargeDataEntry) {
+		if entry.Keyspace == "" || entry.Table == "" {
+			return
+		}
+		if !d.canAccessTable(ctx, session.Profile, entry.Keyspace, entry.Table) {
+			return
+		}
+		entries = append(entries, entry)
+	}
+	for _, src := range largeDataSources {
+		if err := fetchLargeData(ctx, session.Connection, src, targets, add); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to fetch %s: %v", src.fetchDesc, err)
+		}
+	}
+
+	tables := groupLargeData(entries, normalizeLargeDataLimit(int(req.Limit)))
+
+	for _, t := range tables {
+		columns, err := partitionKeyColumns(ctx, session.Connection, t.Keyspace, t.Table)
+		if err != nil {
+			continue
+		}
+		for _, e := range t.Entries {
+			e.WhereClause = partitionWhereClause(columns, e.PartitionKey)
+		}
+	}
+
+	return &pb.GetLargeDataReportResponse{
+		IsScylla: true,
+		Version:  version,
+		Tables:   tables,
+	}, nil
+}
+
+type tableRef struct {
+	keyspace string
+	table    string
+}
+
+// largeDataTargets lists the tables to read offenders for. keyspace_name and
+// table_name are the partition key of the large data tables, so a filtered
+// report reads only the partitions it needs. A nil list means every table.
+func (d *DiagnosticsService) largeDataTargets(ctx context.Context, session *state.Session, keyspace, table string) ([]tableRef, error) {
+	if keyspace == "" {
+		return nil, nil
+	}
+	if table != "" {
+		return []tableRef{{keyspace: keyspace, table: table}}, nil
+	}
+
+	rows, err := session.Connection.FetchAll(ctx, `SELECT table_name FROM system_schema.tables WHERE keyspace_name = ?`, keyspace)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]tableRef, 0, len(rows))
+	for _, row := range rows {
+		name, _ := row["table_name"].(string)
+		if name != "" && d.canAccessTable(ctx, session.Profile, keyspace, name) {
+			targets = append(targets, tableRef{keyspace: keyspace, table: name})
+		}
+	}
+	return targets, nil
+}
+
+// fetchLargeData reads the offenders of targets, or of every table when
+// targets is nil, page by page and hands each one to add.
+func fetchLargeData(ctx context.Context, conn *db.Session, src largeDataSource, targets []tableRef, add func(*pb.LargeDataEntry)) error {
+	if targets == nil {
+		return fetchLargeDataPages(ctx, conn, src, add, "SELECT * FROM "+src.table)
+	}
+	for _, t := range targets {
+		stmt := "SELECT * FROM " + src.table + " WHERE keyspace_name = ? AND table_name = ?"
+		if err := fetchLargeDataPages(ctx, conn, src, add, stmt, t.keyspace, t.table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fetchLargeDataPages(ctx context.Context, conn *db.Session, src largeDataSource, add func(*pb.LargeDataEntry), stmt string, values ...interface{}) error {
+	var pageState []byte
+	for {
+		rows, next, err := conn.FetchWithPaging(ctx, stmt, largeDataPageSize, pageState, values...)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			add(largeDataEntryFromRow(src, row))
+		}
+		if len(next) == 0 {
+			return nil
+		}
+		pageState = next
+	}
+}
+
+// canAccessTable reports whether the caller may read the table, so the
+// report only names tables the caller could open.
+func (d *DiagnosticsService) canAccessTable(ctx context.Context, profile *config.Profile, keyspace, table string) bool {
+	user, ok := ctxutil.GetUser(ctx)
+	if d.policy == nil || !ok || profile == nil {
+		return true
+	}
+	return d.policy.CanAccessTable(user, profile.Name, keyspace, table)
+}
+
+// GetConnectionHealth reports the health of the caller's cluster connection
+// as last probed by the pool.
+func (d *DiagnosticsService) GetConnectionHealth(ctx context.Context, req *pb.GetConnectionHealthRequest) (*pb.GetConnectionHealthResponse, error) {
+	session, err := GetSessionFromContext(ctx, d.store)
+	if err != nil {
+		return nil, err
+	}
+	if session.Connection == nil {
+		return nil, status.Error(codes.FailedPrecondition, "session has no cluster connection")
+	}
+
+	resp := &pb.GetConnectionHealthResponse{
+		Health: connectionHealthToProto(session.Connection.Health()),
+	}
+	if session.Profile != nil {
+		resp.Profile = session.Profile.Name
+	}
+	return resp, nil
+}
+
+func connectionHealthToProto(h db.Health) *pb.ConnectionHealth {
+	state := pb.ConnectionState_CONNECTION_STATE_UNSPECIFIED
+	switch h.State {
+	case db.HealthHealthy:
+		state = pb.ConnectionState_CONNECTION_STATE_HEALTHY
+	case db.HealthDegraded:
+		state = pb.ConnectionState_CONNECTION_STATE_DEGRADED
+	case db.HealthDown:
+		state = pb.ConnectionState_CONNECTION_STATE_DOWN
+	}
+
+	health := &pb.ConnectionHealth{
+		State:      state,
+		LastError:  h.LastError,
+		LatencyMs:  h.Latency.Milliseconds(),
+		Reconnects: int32(h.Reconnects),
+	}
+	if !h.CheckedAt.IsZero() {
+		health.CheckedAt = h.CheckedAt.Unix()
+	}
+	return health
+}
+
+func detectCluster(ctx context.Context, conn *db.Session) (bool, string, error) {
+	rows, err := conn.FetchAll(ctx, `SELECT * FROM system.local WHERE key = 'local'`)
+	if err != nil {
+		return false, "", err
+	}
+	if len(rows) == 0 {
+		return false, "", fmt.Errorf("system.local returned no rows")
+	}
+
+	isScylla, version := detectScylla(rows[0])
+	if isScylla {
+		return true, version, nil
+	}
+
+	// system.versions only exists on ScyllaDB and carries the real Scylla
+	// release, whereas release_version reports the emulated Cassandra version.
+	versions, err := conn.FetchAll(ctx, `SELECT version FROM system.versions WHERE key = 'local'`)
+	if err == nil && len(versions) > 0 {
+		if v, ok := versions[0]["version"].(string); ok && v != "" {
+			return true, v, nil
+		}
+	}
+
+	return false, version, nil
+}
+
+func detectScylla(local map[string]interface{}) (bool, string) {
+	version, _ := local["release_version"].(string)
+
+	for key, value := range local {
+		if strings.HasPrefix(key, "scylla") {
+			return true, version
+		}
+		if strings.HasSuffix(key, "version") {
+			if s, ok := value.(string); ok && strings.Contains(strings.ToLower(s), "scylla") {
+				return true, version
+			}
+		}
+	}
+
+	return false, version
+}
+
+func largeDataEntryFromRow(src largeDataSource, row map[string]interface{}) *pb.LargeDataEntry {
+	entry := &pb.LargeDataEntry{Kind: src.kind}
+
+	entry.Keyspace, _ = row["keyspace_name"].(string)
+	entry.Table, _ = row["table_name"].(string)
+	entry.SstableName, _ = row["sstable_name"].(string)
+	entry.PartitionKey, _ = row["partition_key"].(string)
+	entry.ClusteringKey, _ = row["clustering_key"].(string)
+	entry.ColumnName, _ = row["column_name"].(string)
+	entry.SizeBytes = toInt64(row[src.sizeCol])
+
+	if src.countCol != "" {
+		entry.Rows = toInt64(row[src.countCol])
+	}
+
+	if t, ok := row["compaction_time"].(time.Time); ok && !t.IsZero() {
+		entry.CompactionTime = t.UnixMilli()
+	}
+
+	return entry
+}
+
+func toInt64(value interface{}) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	default:
+		return 0
+	}
+}
+
+func normalizeLargeDataLimit(limit int) int {
+	if limit <= 0 {
+		return defaultLargeDataLimit
+	}
+	if limit > maxLargeDataLimit {
+		return maxLargeDataLimit
+	}
+	return limit
+}
+
+func groupLargeData(entries []*pb.LargeDataEntry, limit int) []*pb.LargeDataTable {
+	byTable := make(map[string]*pb.LargeDataTable)
+	order := make([]*pb.LargeDataTable, 0)
+
+	for _, e := range entries {
+		key := e.Keyspace + "." + e.Table
+		t, ok := byTable[key]
+		if !ok {
+			t = &pb.LargeDataTable{Keyspace: e.Keyspace, Table: e.Table}
+			byTable[key] = t
+			order = append(order, t)
+		}
+		t.Entries = append(t.Entries, e)
+		if e.SizeBytes > t.MaxSizeBytes {
+			t.MaxSizeBytes = e.SizeBytes
+		}
+	}
+
+	for _, t := range order {
+		sort.SliceStable(t.Entries, func(i, j int) bool {
+			return t.Entries[i].SizeBytes > t.Entries[j].SizeBytes
+		})
+		if len(t.Entries) > limit {
+			t.Entries = t.Entries[:limit]
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].MaxSizeBytes != order[j].MaxSizeBytes {
+			return order[i].MaxSizeBytes > order[j].MaxSizeBytes
+		}
+		return order[i].Keyspace+"."+order[i].Table < order[j].Keyspace+"."+order[j].Table
+	})
+
+	return order
+}
+
+type keyColumn struct {
+	name     string
+	cqlType  string
+	position int
+}
+
+func partitionKeyColumns(ctx context.Context, conn *db.Session, keyspace, table string) ([]keyColumn, error) {
+	query := `SELECT column_name, type, kind, position FROM system_schema.columns WHERE keyspace_name = ? AND table_name = ?`
+	rows, err := conn.FetchAll(ctx, query, keyspace, table)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]keyColumn, 0, 1)
+	for _, row := range rows {
+		if kind, _ := row["kind"].(string); kind != "partition_key" {
+			continue
+		}
+		name, _ := row["column_name"].(string)
+		cqlType, _ := row["type"].(string)
+		position, _ := row["position"].(int)
+		columns = append(columns, keyColumn{name: name, cqlType: cqlType, position: position})
+	}
+
+	sort.Slice(columns, func(i, j int) bool {
+		return columns[i].position < columns[j].position
+	})
+
+	return columns, nil
+}
+
+var (
+	numericLiteral = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+	uuidLiteral    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hexLiteral     = regexp.MustCompile(`^(0x)?[0-9a-fA-F]+$`)
+)
+
+// partitionWhereClause turns the textual partition key Scylla records in its
+// large data tables into a WHERE clause usable with FilterRows. Composite keys
+// are recorded with their components joined by ':', which a component may
+// contain too, so they are left as the raw key. An empty string is returned
+// when the key cannot be mapped safely.
+func partitionWhereClause(columns []keyColumn, partitionKey string) string {
+	if len(columns) != 1 || partitionKey == "" {
+		return ""
+	}
+
+	quoted, err := db.QuoteIdentifier(columns[0].name)
+	if err != nil {
+		return ""
+	}
+	literal, ok := cqlLiteral(columns[0].cqlType, partitionKey)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s = %s", quoted, literal)
+}
+
+func cqlLiteral(cqlType, value string) (string, bool) {
+	switch cqlType {
+	case "text", "varchar", "ascii", "inet", "timestamp", "date", "time":
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'", true
+	case "int", "bigint", "smallint", "tinyint", "varint", "decimal", "float", "double", "counter":
+		return value, numericLiteral.MatchString(value)
+	case "uuid", "timeuuid":
+		return value, uuidLiteral.MatchString(value)
+	case "boolean":
+		lower := strings.ToLower(value)
+		return lower, lower == "true" || lower == "false"
+	case "blob":
+		if !hexLiteral.MatchString(value) {
+			return "", false
+		}
+		if !strings.HasPrefix(value, "0x") {
+			value = "0x" + value
+		}
+		return value, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/server/db"
+	"github.com/KashifKhn/kassie/internal/shared/config"
+	"github.com/KashifKhn/kassie/internal/shared/ctxutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDetectScylla(t *testing.T) {
+	tests := []struct {
+		name        string
+		local       map[string]interface{}
+		wantScylla  bool
+		wantVersion string
+	}{
+		{
+			name:        "cassandra",
+			local:       map[string]interface{}{"release_version": "4.1.3", "cql_version": "3.4.6"},
+			wantScylla:  false,
+			wantVersion: "4.1.3",
+		},
+		{
+			name:        "scylla specific column",
+			local:       map[string]interface{}{"release_version": "3.0.8", "scylla_nr_shards": 4},
+			wantScylla:  true,
+			wantVersion: "3.0.8",
+		},
+		{
+			name:        "scylla in version string",
+			local:       map[string]interface{}{"release_version": "3.0.8", "native_protocol_version": "4", "server_version": "5.4.0-scylla"},
+			wantScylla:  true,
+			wantVersion: "3.0.8",
+		},
+		{
+			name:       "empty row",
+			local:      map[string]interface{}{},
+			wantScylla: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isScylla, version := detectScylla(tt.local)
+			if isScylla != tt.wantScylla {
+				t.Errorf("expected scylla=%v, got %v", tt.wantScylla, isScylla)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("expected version %q, got %q", tt.wantVersion, version)
+			}
+		})
+	}
+}
+
+func TestPartitionWhereClause(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []keyColumn
+		key     string
+		want    string
+	}{
+		{
+			name:    "single text key",
+			columns: []keyColumn{{name: "user_id", cqlType: "text"}},
+			key:     "alice",
+			want:    `"user_id" = 'alice'`,
+		},
+		{
+			name:    "text key with quote and colon",
+			columns: []keyColumn{{name: "name", cqlType: "text"}},
+			key:     "o'brien:1",
+			want:    `"name" = 'o''brien:1'`,
+		},
+		{
+			name:    "composite key",
+			columns: []keyColumn{{name: "tenant", cqlType: "text"}, {name: "bucket", cqlType: "int"}},
+			key:     "acme:42",
+			want:    "",
+		},
+		{
+			name:    "composite key with a colon in a component",
+			columns: []keyColumn{{name: "host", cqlType: "text"}, {name: "port", cqlType: "int"}},
+			key:     "10.0.0.1:9042:7",
+			want:    "",
+		},
+		{
+			name:    "uuid key",
+			columns: []keyColumn{{name: "id", cqlType: "uuid"}},
+			key:     "550e8400-e29b-41d4-a716-446655440000",
+			want:    `"id" = 550e8400-e29b-41d4-a716-446655440000`,
+		},
+		{
+			name:    "blob key",
+			columns: []keyColumn{{name: "hash", cqlType: "blob"}},
+			key:     "deadbeef",
+			want:    `"hash" = 0xdeadbeef`,
+		},
+		{
+			name:    "invalid numeric",
+			columns: []keyColumn{{name: "id", cqlType: "int"}},
+			key:     "1 OR 1=1",
+			want:    "",
+		},
+		{
+			name:    "unsupported type",
+			columns: []keyColumn{{name: "k", cqlType: "frozen<tuple<int, int>>"}},
+			key:     "(1, 2)",
+			want:    "",
+		},
+		{
+			name:    "no columns",
+			columns: nil,
+			key:     "x",
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := partitionWhereClause(tt.columns, tt.key)
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+			if got != "" {
+				if err := validateWhereClause(got); err != nil {
+					t.Errorf("generated clause rejected by validator: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestGroupLargeData(t *testing.T) {
+	entries := []*pb.LargeDataEntry{
+		{Keyspace: "ks", Table: "small", SizeBytes: 10},
+		{Keyspace: "ks", Table: "big", SizeBytes: 500},
+		{Keyspace: "ks", Table: "big", SizeBytes: 900},
+		{Keyspace: "ks", Table: "big", SizeBytes: 100},
+		{Keyspace: "ks", Table: "small", SizeBytes: 20},
+	}
+
+	tables := groupLargeData(entries, 2)
+
+	if len(tables) != 2 {
+		t.Fatalf("expected 2 tables, got %d", len(tables))
+	}
+	if tables[0].Table != "big" {
+		t.Errorf("expected largest table first, got %s", tables[0].Table)
+	}
+	if tables[0].MaxSizeBytes != 900 {
+		t.Errorf("expected max size 900, got %d", tables[0].MaxSizeBytes)
+	}
+	if len(tables[0].Entries) != 2 {
+		t.Fatalf("expected entries limited to 2, got %d", len(tables[0].Entries))
+	}
+	if tables[0].Entries[0].SizeBytes != 900 || tables[0].Entries[1].SizeBytes != 500 {
+		t.Errorf("expected entries sorted by size, got %d, %d", tables[0].Entries[0].SizeBytes, tables[0].Entries[1].SizeBytes)
+	}
+}
+
+func TestLargeDataEntryFromRow(t *testing.T) {
+	compacted := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	row := map[string]interface{}{
+		"keyspace_name":       "ks",
+		"table_name":          "events",
+		"sstable_name":        "me-1-big-Data.db",
+		"cell_size":           int64(2048),
+		"partition_key":       "alice",
+		"clustering_key":      "2024-01-01",
+		"column_name":         "payload",
+		"collection_elements": int64(12),
+		"compaction_time":     compacted,
+	}
+
+	entry := largeDataEntryFromRow(largeDataSources[2], row)
+
+	if entry.Kind != pb.LargeDataKind_LARGE_DATA_KIND_CELL {
+		t.Errorf("expected cell kind, got %v", entry.Kind)
+	}
+	if entry.SizeBytes != 2048 {
+		t.Errorf("expected size 2048, got %d", entry.SizeBytes)
+	}
+	if entry.Rows != 12 {
+		t.Errorf("expected 12 collection elements, got %d", entry.Rows)
+	}
+	if entry.ColumnName != "payload" || entry.ClusteringKey != "2024-01-01" {
+		t.Errorf("unexpected keys: %+v", entry)
+	}
+	if entry.CompactionTime != compacted.UnixMilli() {
+		t.Errorf("expected compaction time %d, got %d", compacted.UnixMilli(), entry.CompactionTime)
+	}
+}
+
+func TestNormalizeLargeDataLimit(t *testing.T) {
+	tests := []struct {
+		input    int
+		expected int
+	}{
+		{0, defaultLargeDataLimit},
+		{-5, defaultLargeDataLimit},
+		{10, 10},
+		{maxLargeDataLimit + 1, maxLargeDataLimit},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeLargeDataLimit(tt.input); got != tt.expected {
+			t.Errorf("normalizeLargeDataLimit(%d) = %d, want %d", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestDiagnosticsService_GetLargeDataReport_InvalidArguments(t *testing.T) {
+	service := NewDiagnosticsService(newMockSessionStore(), nil)
+
+	tests := []struct {
+		name string
+		req  *pb.GetLargeDataReportRequest
+	}{
+		{name: "table without keyspace", req: &pb.GetLargeDataReportRequest{Table: "events"}},
+		{name: "invalid keyspace", req: &pb.GetLargeDataReportRequest{Keyspace: "bad-ks"}},
+		{name: "invalid table", req: &pb.GetLargeDataReportRequest{Keyspace: "ks", Table: "bad;table"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.GetLargeDataReport(context.Background(), tt.req)
+			if status.Code(err) != codes.InvalidArgument {
+				t.Errorf("expected InvalidArgument, got %v", err)
+			}
+		})
+	}
+}
+
+// tablePolicy lets every user read only the listed keyspace.table names.
+type tablePolicy map[string]bool
+
+func (p tablePolicy) CanAccessKeyspace(_, _, keyspace string) bool { return true }
+
+func (p tablePolicy) CanAccessTable(_, _, keyspace, table string) bool {
+	return p[keyspace+"."+table]
+}
+
+func TestDiagnosticsService_CanAccessTable(t *testing.T) {
+	service := NewDiagnosticsService(newMockSessionStore(), tablePolicy{"app.users": true})
+	profile := &config.Profile{Name: "dev"}
+	ctx := ctxutil.WithUser(context.Background(), "bob")
+
+	if !service.canAccessTable(ctx, profile, "app", "users") {
+		t.Error("expected app.users to be reported")
+	}
+	if service.canAccessTable(ctx, profile, "app", "payments") {
+		t.Error("expected app.payments to be left out")
+	}
+	if !service.canAccessTable(context.Background(), profile, "app", "payments") {
+		t.Error("expected callers without a user to see every table")
+	}
+}
+
+func TestConnectionHealthToProto(t *testing.T) {
+	checked := time.Unix(1707500000, 0)
+
+	tests := []struct {
+		name   string
+		health db.Health
+		want   pb.ConnectionState
+	}{
+		{name: "healthy", health: db.Health{State: db.HealthHealthy, CheckedAt: checked}, want: pb.ConnectionState_CONNECTION_STATE_HEALTHY},
+		{name: "degraded", health: db.Health{State: db.HealthDegraded}, want: pb.ConnectionState_CONNECTION_STATE_DEGRADED},
+		{name: "down", health: db.Health{State: db.HealthDown, LastError: "timeout", Reconnects: 2}, want: pb.ConnectionState_CONNECTION_STATE_DOWN},
+		{name: "unknown", health: db.Health{}, want: pb.ConnectionState_CONNECTION_STATE_UNSPECIFIED},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := connectionHealthToProto(tt.health)
+			if got.State != tt.want {
+				t.Errorf("State = %v, want %v", got.State, tt.want)
+			}
+			if got.LastError != tt.health.LastError || got.Reconnects != int32(tt.health.Reconnects) {
+				t.Errorf("unexpected health %+v", got)
+			}
+			if tt.health.CheckedAt.IsZero() != (got.CheckedAt == 0) {
+				t.Errorf("CheckedAt = %d for %v", got.CheckedAt, tt.health.CheckedAt)
+			}
+		})
+	}
+}
+
+func TestDiagnosticsService_GetConnectionHealth_NoConnection(t *testing.T) {
+	store := newMockSessionStore()
+	store.Create("s1", &config.Profile{Name: "dev"}, nil)
+	service := NewDiagnosticsService(store, nil)
+
+	ctx := ctxutil.WithSessionID(context.Background(), "s1")
+	_, err := service.GetConnectionHealth(ctx, &pb.GetConnectionHealthRequest{})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition, got %v", err)
+	}
+}
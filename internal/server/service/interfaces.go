@@ -4,22 +4,55 @@ import (
 	"github.com/KashifKhn/kassie/internal/server/db"
 	"github.com/KashifKhn/kassie/internal/server/state"
 	"github.com/KashifKhn/kassie/internal/shared/config"
-	"github.com/gocql/gocql"
 )
 
 type SessionStore interface {
 	Create(id string, profile *config.Profile, conn *db.Session) *state.Session
 	Get(id string) (*state.Session, error)
+	Update(id string, update func(*state.Session)) error
+	Info(id string) (state.SessionInfo, error)
+	List() []state.SessionInfo
 	Delete(id string)
+	DeleteMatching(match func(*state.Session) bool) int
 	CloseAll()
 	Close()
 }
 
 type ConnectionPool interface {
-	GetOrCreate(profileName string, cfg *db.ConnectionConfig) (*gocql.Session, error)
+	Acquire(profileName string, cfg *db.ConnectionConfig) (*db.Session, error)
+	Open(profileName string, cfg *db.ConnectionConfig) (*db.Session, error)
+	Stats() []db.PoolStats
+	Close(profileName string) error
 }
 
 type ProfileProvider interface {
 	GetProfile(name string) (*config.Profile, error)
 	GetProfiles() []config.Profile
 }
+
+// ProfileEditor changes profiles as written in the configuration file, where
+// secrets are kept as ${VAR} references.
+type ProfileEditor interface {
+	ProfileProvider
+	RawProfile(name string) (*config.Profile, error)
+	Create(profile config.Profile) error
+	Update(name string, edit func(*config.Profile) error) error
+	Delete(name string) error
+}
+
+type UserProvider interface {
+	HasUsers() bool
+	GetUser(username string) (*config.User, error)
+	CanUseProfile(username, profile string) bool
+	SSOEnabled() bool
+}
+
+type ExternalUserResolver interface {
+	OIDC() *config.OIDCConfig
+	ResolveExternalUser(username string, groups []string) (*config.User, error)
+}
+
+type AccessPolicy interface {
+	CanAccessKeyspace(username, profile, keyspace string) bool
+	CanAccessTable(username, profile, keyspace, table string) bool
+}
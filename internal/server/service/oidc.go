@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/shared/config"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	oidcLoginTTL     = 10 * time.Minute
+	maxPendingLogins = 1024
+)
+
+type pendingLogin struct {
+	profile   string
+	verifier  string
+	nonce     string
+	expiresAt time.Time
+}
+
+// OIDCService runs the authorization code flow with PKCE against the
+// configured identity provider and turns a verified ID token into a regular
+// Kassie session.
+type OIDCService struct {
+	sessions *SessionService
+	users    ExternalUserResolver
+
+	mu       sync.Mutex
+	pending  map[string]pendingLogin
+	provider *oidc.Provider
+	issuer   string
+}
+
+func NewOIDCService(sessions *SessionService, users ExternalUserResolver) *OIDCService {
+	return &OIDCService{
+		sessions: sessions,
+		users:    users,
+		pending:  make(map[string]pendingLogin),
+	}
+}
+
+// PostLoginURL is where the browser is sent once the flow has finished.
+func (s *OIDCService) PostLoginURL() string {
+	if cfg := s.users.OIDC(); cfg != nil {
+		return cfg.GetPostLoginURL()
+	}
+	return config.DefaultOIDCPostLoginURL
+}
+
+// AuthCodeURL starts a login for profile and returns the provider URL the
+// browser should be redirected to.
+func (s *OIDCService) AuthCodeURL(ctx context.Context, profile string) (string, error) {
+	if profile == "" {
+		return "", status.Error(codes.InvalidArgument, "profile name is required")
+	}
+	if p, err := s.sessions.cfg.GetProfile(profile); err == nil && p.PromptsForCredentials() {
+		return "", status.Errorf(codes.FailedPrecondition, "profile %s needs database credentials, which single sign-on cannot supply", profile)
+	}
+
+	cfg, provider, err := s.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "failed to generate state: %v", err)
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "failed to generate nonce: %v", err)
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	s.mu.Lock()
+	s.prunePendingLocked(time.Now())
+	if len(s.pending) >= maxPendingLogins {
+		s.mu.Unlock()
+		return "", status.Error(codes.ResourceExhausted, "too many sign-ins in progress")
+	}
+	s.pending[state] = pendingLogin{
+		profile:   profile,
+		verifier:  verifier,
+		nonce:     nonce,
+		expiresAt: time.Now().Add(oidcLoginTTL),
+	}
+	s.mu.Unlock()
+
+	return oauthConfig(cfg, provider).AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// Exchange completes a login started by AuthCodeURL. The state is single use.
+func (s *OIDCService) Exchange(ctx context.Context, state, code string) (*pb.LoginResponse, error) {
+	if state == "" || code == "" {
+		return nil, status.Error(codes.InvalidArgument, "state and code are required")
+	}
+
+	s.mu.Lock()
+	login, ok := s.pending[state]
+	delete(s.pending, state)
+	s.mu.Unlock()
+
+	if !ok || time.Now().After(login.expiresAt) {
+		return nil, status.Error(codes.Unauthenticated, "sign-in expired or was not started by this server")
+	}
+
+	cfg, provider, err := s.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauthConfig(cfg, provider).Exchange(ctx, code, oauth2.VerifierOption(login.verifier))
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "failed to exchange authorization code: %v", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, status.Error(codes.Unauthenticated, "identity provider did not return an id_token")
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid id_token: %v", err)
+	}
+	if idToken.Nonce != login.nonce {
+		return nil, status.Error(codes.Unauthenticated, "id_token nonce mismatch")
+	}
+
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "failed to decode id_token claims: %v", err)
+	}
+
+	username, groups, err := identityFromClaims(cfg, claims)
+	if err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	user, err := s.users.ResolveExternalUser(username, groups)
+	if err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	return s.sessions.openSession(login.profile, user.Username, nil)
+}
+
+// discover returns the current OIDC settings and the discovered provider.
+// Discovery is retried on the next login if it fails, and redone when the
+// issuer changes on reload.
+func (s *OIDCService) discover(ctx context.Context) (*config.OIDCConfig, *oidc.Provider, error) {
+	cfg := s.users.OIDC()
+	if cfg == nil {
+		return nil, nil, status.Error(codes.FailedPrecondition, "single sign-on is not configured")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.provider != nil && s.issuer == cfg.Issuer {
+		return cfg, s.provider, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Unavailable, "failed to discover identity provider: %v", err)
+	}
+	s.provider = provider
+	s.issuer = cfg.Issuer
+
+	return cfg, provider, nil
+}
+
+func (s *OIDCService) prunePendingLocked(now time.Time) {
+	for state, login := range s.pending {
+		if now.After(login.expiresAt) {
+			delete(s.pending, state)
+		}
+	}
+}
+
+func oauthConfig(cfg *config.OIDCConfig, provider *oidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       cfg.GetScopes(),
+	}
+}
+
+func identityFromClaims(cfg *config.OIDCConfig, claims map[string]any) (string, []string, error) {
+	claim := cfg.GetUsernameClaim()
+	username, _ := claims[claim].(string)
+	if username == "" {
+		return "", nil, fmt.Errorf("id_token has no %s claim", claim)
+	}
+
+	if claim == "email" {
+		if verified, ok := claims["email_verified"].(bool); ok && !verified {
+			return "", nil, errors.New("email address is not verified by the identity provider")
+		}
+	}
+
+	var groups []string
+	switch v := claims[cfg.GetGroupsClaim()].(type) {
+	case string:
+		groups = []string{v}
+	case []any:
+		for _, g := range v {
+			if name, ok := g.(string); ok {
+				groups = append(groups, name)
+			}
+		}
+	}
+
+	return username, groups, nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
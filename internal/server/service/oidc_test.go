@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/KashifKhn/kassie/internal/server/policy"
+	"github.com/KashifKhn/kassie/internal/shared/config"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/coreos/go-oidc/v3/oidc/oidctest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// mockIdP wraps the oidctest discovery server with a token endpoint that
+// checks the PKCE verifier and returns a signed ID token.
+type mockIdP struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	challenge string
+	nonce     string
+	claims    map[string]any
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	idp := &mockIdP{t: t, key: key}
+	discovery := &oidctest.Server{
+		PublicKeys: []oidctest.PublicKey{{PublicKey: key.Public(), KeyID: "test-key", Algorithm: oidc.RS256}},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", idp.serveToken)
+	mux.Handle("/", discovery)
+
+	idp.server = httptest.NewServer(mux)
+	discovery.SetIssuer(idp.server.URL)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+func (m *mockIdP) serveToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != m.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	claims := map[string]any{
+		"iss":   m.server.URL,
+		"aud":   "kassie",
+		"sub":   "user-1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": m.nonce,
+	}
+	for k, v := range m.claims {
+		claims[k] = v
+	}
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		m.t.Fatalf("failed to encode claims: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "idp-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     oidctest.SignIDToken(m.key, "test-key", oidc.RS256, string(raw)),
+	})
+}
+
+func newTestOIDCService(t *testing.T, idp *mockIdP) (*OIDCService, *AuthService) {
+	t.Helper()
+
+	engine := policy.NewEngine(&config.ServerConfig{
+		OIDC: &config.OIDCConfig{
+			Issuer:      idp.server.URL,
+			ClientID:    "kassie",
+			RedirectURL: "http://localhost:8080/api/v1/auth/oidc/callback",
+			GroupRoles:  map[string][]string{"engineering": {config.RoleEditor}},
+		},
+	})
+
+	cfg := &mockProfileProvider{
+		profiles: map[string]*config.Profile{
+			"dev": {Name: "dev", Hosts: []string{"localhost"}, Port: 9042},
+		},
+	}
+	auth := NewAuthService("test-secret")
+	sessions := NewSessionService(cfg, &mockPool{}, newMockSessionStore(), auth, engine)
+
+	return NewOIDCService(sessions, engine), auth
+}
+
+// startLogin begins a sign-in and records the PKCE challenge and nonce the
+// browser would carry to the provider.
+func startLogin(t *testing.T, svc *OIDCService, idp *mockIdP) string {
+	t.Helper()
+
+	authURL, err := svc.AuthCodeURL(context.Background(), "dev")
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("invalid auth url: %v", err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" {
+		t.Fatalf("expected S256 PKCE challenge, got %q", q.Get("code_challenge_method"))
+	}
+
+	idp.challenge = q.Get("code_challenge")
+	idp.nonce = q.Get("nonce")
+	return q.Get("state")
+}
+
+func TestOIDCService_Flow(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.claims = map[string]any{"email": "ana@example.com", "email_verified": true, "groups": []string{"engineering"}}
+	svc, auth := newTestOIDCService(t, idp)
+
+	state := startLogin(t, svc, idp)
+
+	resp, err := svc.Exchange(context.Background(), state, "auth-code")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if resp.Profile.GetName() != "dev" {
+		t.Errorf("expected profile dev, got %q", resp.Profile.GetName())
+	}
+
+	claims, err := auth.ValidateToken(resp.AccessToken, AccessToken)
+	if err != nil {
+		t.Fatalf("expected valid access token, got %v", err)
+	}
+	if claims.User != "sso:ana@example.com" {
+		t.Errorf("expected user claim sso:ana@example.com, got %q", claims.User)
+	}
+
+	if _, err := svc.Exchange(context.Background(), state, "auth-code"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected replayed state to be rejected, got %v", err)
+	}
+}
+
+func TestOIDCService_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims map[string]any
+		nonce  string
+		code   codes.Code
+	}{
+		{name: "unmapped group", claims: map[string]any{"email": "bob@example.com", "groups": []string{"sales"}}, code: codes.PermissionDenied},
+		{name: "unverified email", claims: map[string]any{"email": "eve@example.com", "email_verified": false, "groups": []string{"engineering"}}, code: codes.PermissionDenied},
+		{name: "missing email", claims: map[string]any{"groups": []string{"engineering"}}, code: codes.PermissionDenied},
+		{name: "nonce mismatch", claims: map[string]any{"email": "ana@example.com", "groups": []string{"engineering"}}, nonce: "forged", code: codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newMockIdP(t)
+			idp.claims = tt.claims
+			svc, _ := newTestOIDCService(t, idp)
+
+			state := startLogin(t, svc, idp)
+			if tt.nonce != "" {
+				idp.nonce = tt.nonce
+			}
+
+			_, err := svc.Exchange(context.Background(), state, "auth-code")
+			if status.Code(err) != tt.code {
+				t.Errorf("expected %v, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestOIDCService_PKCEVerifierChecked(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.claims = map[string]any{"email": "ana@example.com", "groups": []string{"engineering"}}
+	svc, _ := newTestOIDCService(t, idp)
+
+	state := startLogin(t, svc, idp)
+	idp.challenge = "intercepted"
+
+	if _, err := svc.Exchange(context.Background(), state, "auth-code"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated when the verifier does not match, got %v", err)
+	}
+}
+
+func TestOIDCService_UnknownState(t *testing.T) {
+	idp := newMockIdP(t)
+	svc, _ := newTestOIDCService(t, idp)
+
+	if _, err := svc.Exchange(context.Background(), "never-issued", "auth-code"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated, got %v", err)
+	}
+	if _, err := svc.AuthCodeURL(context.Background(), ""); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for missing profile, got %v", err)
+	}
+}
+
+func TestIdentityFromClaims(t *testing.T) {
+	cfg := &config.OIDCConfig{UsernameClaim: "preferred_username", GroupsClaim: "roles"}
+
+	tests := []struct {
+		name       string
+		claims     map[string]any
+		wantUser   string
+		wantGroups []string
+		wantErr    bool
+	}{
+		{name: "list groups", claims: map[string]any{"preferred_username": "ana", "roles": []any{"dba", 7, "ops"}}, wantUser: "ana", wantGroups: []string{"dba", "ops"}},
+		{name: "single group", claims: map[string]any{"preferred_username": "ana", "roles": "dba"}, wantUser: "ana", wantGroups: []string{"dba"}},
+		{name: "no groups", claims: map[string]any{"preferred_username": "ana"}, wantUser: "ana"},
+		{name: "missing username", claims: map[string]any{"email": "ana@example.com"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, groups, err := identityFromClaims(cfg, tt.claims)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("identityFromClaims() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if user != tt.wantUser {
+				t.Errorf("user = %q, want %q", user, tt.wantUser)
+			}
+			if !reflect.DeepEqual(groups, tt.wantGroups) {
+				t.Errorf("groups = %v, want %v", groups, tt.wantGroups)
+			}
+		})
+	}
+}
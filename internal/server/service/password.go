@@ -0,0 +1,85 @@
+package service
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrUnsupportedHash = errors.New("unsupported password hash")
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func VerifyPassword(hash, password string) (bool, error) {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return verifyArgon2id(hash, password)
+	default:
+		return false, ErrUnsupportedHash
+	}
+}
+
+// verifyArgon2id checks a PHC formatted hash as produced by the argon2 CLI:
+// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>
+func verifyArgon2id(hash, password string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false, fmt.Errorf("%w: malformed argon2id hash", ErrUnsupportedHash)
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, fmt.Errorf("%w: unsupported argon2 version", ErrUnsupportedHash)
+	}
+
+	var memory, iterations uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads); err != nil {
+		return false, fmt.Errorf("%w: invalid argon2 parameters", ErrUnsupportedHash)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, fmt.Errorf("%w: invalid argon2 salt", ErrUnsupportedHash)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return false, fmt.Errorf("%w: invalid argon2 key", ErrUnsupportedHash)
+	}
+
+	computed := argon2.IDKey([]byte(password), salt, iterations, memory, threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, computed) == 1, nil
+}
+
+// burnPasswordCheck spends roughly the same time as a real bcrypt comparison so
+// unknown usernames cannot be told apart from wrong passwords by timing.
+func burnPasswordCheck(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = HashPassword("kassie-dummy-password")
+	})
+	_, _ = VerifyPassword(dummyHash, password)
+}
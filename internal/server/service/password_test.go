@@ -0,0 +1,63 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+func TestHashAndVerifyPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	if !strings.HasPrefix(hash, "$2a$") {
+		t.Fatalf("expected bcrypt hash, got %q", hash)
+	}
+
+	ok, err := VerifyPassword(hash, "correct horse")
+	if err != nil || !ok {
+		t.Errorf("expected password to verify, got ok=%v err=%v", ok, err)
+	}
+
+	ok, err = VerifyPassword(hash, "battery staple")
+	if err != nil || ok {
+		t.Errorf("expected mismatch without error, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestVerifyPassword_Argon2id(t *testing.T) {
+	salt := []byte("somesalt")
+	key := argon2.IDKey([]byte("password"), salt, 2, 64*1024, 4, 32)
+	hash := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, 64*1024, 2, 4,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+
+	ok, err := VerifyPassword(hash, "password")
+	if err != nil || !ok {
+		t.Errorf("expected password to verify, got ok=%v err=%v", ok, err)
+	}
+
+	ok, err = VerifyPassword(hash, "wrong")
+	if err != nil || ok {
+		t.Errorf("expected mismatch without error, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestVerifyPassword_Unsupported(t *testing.T) {
+	tests := []string{
+		"plaintext",
+		"$argon2i$v=19$m=65536,t=2,p=4$c29tZXNhbHQ$aGFzaA",
+		"$argon2id$v=19$m=65536,t=2,p=4$c29tZXNhbHQ",
+		"$argon2id$v=16$m=65536,t=2,p=4$c29tZXNhbHQ$aGFzaA",
+	}
+
+	for _, hash := range tests {
+		if _, err := VerifyPassword(hash, "password"); !errors.Is(err, ErrUnsupportedHash) {
+			t.Errorf("VerifyPassword(%q) error = %v, want ErrUnsupportedHash", hash, err)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/server/state"
+	"github.com/KashifKhn/kassie/internal/shared/config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProfileService edits the server's profiles. Passwords are only taken as
+// the name of an environment variable and written as ${NAME}, so the
+// configuration file never gains a plaintext secret through it.
+type ProfileService struct {
+	pb.UnimplementedProfileServiceServer
+	profiles ProfileEditor
+	auth     *AuthService
+	store    SessionStore
+	tokens   TokenStore
+	pool     ConnectionPool
+}
+
+func NewProfileService(profiles ProfileEditor, auth *AuthService, store SessionStore, tokens TokenStore, pool ConnectionPool) *ProfileService {
+	return &ProfileService{
+		profiles: profiles,
+		auth:     auth,
+		store:    store,
+		tokens:   tokens,
+		pool:     pool,
+	}
+}
+
+func (s *ProfileService) GetProfile(ctx context.Context, req *pb.GetProfileRequest) (*pb.GetProfileResponse, error) {
+	if s.profiles == nil {
+		return nil, status.Error(codes.Unimplemented, "profile editing is not enabled")
+	}
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	p, err := s.profiles.RawProfile(req.Name)
+	if err != nil {
+		return nil, profileError(err)
+	}
+
+	spec, literal := profileToSpec(p)
+	return &pb.GetProfileResponse{Profile: spec, PasswordLiteral: literal}, nil
+}
+
+func (s *ProfileService) CreateProfile(ctx context.Context, req *pb.CreateProfileRequest) (*pb.CreateProfileResponse, error) {
+	if s.profiles == nil {
+		return nil, status.Error(codes.Unimplemented, "profile editing is not enabled")
+	}
+	if req.Profile == nil || req.Profile.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "profile name is required")
+	}
+
+	p, err := specToProfile(req.Profile)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.profiles.Create(*p); err != nil {
+		return nil, profileError(err)
+	}
+	return &pb.CreateProfileResponse{}, nil
+}
+
+func (s *ProfileService) UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest) (*pb.UpdateProfileResponse, error) {
+	if s.profiles == nil {
+		return nil, status.Error(codes.Unimplemented, "profile editing is not enabled")
+	}
+	if req.Name == "" || req.Profile == nil {
+		return nil, status.Error(codes.InvalidArgument, "name and profile are required")
+	}
+	if req.Profile.Name != "" && req.Profile.Name != req.Name {
+		return nil, status.Error(codes.InvalidArgument, "profiles cannot be renamed")
+	}
+	if req.ClearPassword && req.Profile.PasswordEnv != "" {
+		return nil, status.Error(codes.InvalidArgument, "password_env cannot be set with clear_password")
+	}
+
+	spec := proto.Clone(req.Profile).(*pb.ProfileSpec)
+	spec.Name = req.Name
+	updated, err := specToProfile(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.profiles.Update(req.Name, func(p *config.Profile) error {
+		if spec.PasswordEnv == "" && !spec.Prompt && !req.ClearPassword && p.Auth != nil && p.Auth.Password != "" {
+			if updated.Auth == nil {
+				updated.Auth = &config.AuthConfig{}
+			}
+			updated.Auth.Password = p.Auth.Password
+		}
+		updated.Driver, updated.Tunnel, updated.SOCKS5 = p.Driver, p.Tunnel, p.SOCKS5
+		updated.Extends, updated.Abstract = p.Extends, p.Abstract
+		updated.Environment, updated.Color, updated.PageSize = p.Environment, p.Color, p.PageSize
+		updated.DefaultKeyspace, updated.DefaultTable = p.DefaultKeyspace, p.DefaultTable
+		*p = *updated
+		return nil
+	})
+	if err != nil {
+		return nil, profileError(err)
+	}
+
+	s.closeSessions(req.Name)
+	return &pb.UpdateProfileResponse{}, nil
+}
+
+func (s *ProfileService) DeleteProfile(ctx context.Context, req *pb.DeleteProfileRequest) (*pb.DeleteProfileResponse, error) {
+	if s.profiles == nil {
+		return nil, status.Error(codes.Unimplemented, "profile editing is not enabled")
+	}
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	if err := s.profiles.Delete(req.Name); err != nil {
+		return nil, profileError(err)
+	}
+
+	s.auth.RevokeProfile(req.Name)
+	s.closeSessions(req.Name)
+	if s.tokens != nil {
+		if _, err := s.tokens.RevokeMatching("", req.Name); err != nil {
+			return nil, status.Errorf(codes.Internal, "profile deleted but its api tokens were not revoked: %v", err)
+		}
+	}
+	return &pb.DeleteProfileResponse{}, nil
+}
+
+// closeSessions ends the sessions on a changed profile and drops its pooled
+// connection, which was dialled with the old settings.
+func (s *ProfileService) closeSessions(profile string) {
+	s.store.DeleteMatching(func(session *state.Session) bool {
+		return session.Profile != nil && session.Profile.Name == profile
+	})
+	_ = s.pool.Close(profile)
+}
+
+func specToProfile(spec *pb.ProfileSpec) (*config.Profile, error) {
+	if err := rejectSecretReferences(spec); err != nil {
+		return nil, err
+	}
+
+	p := &config.Profile{
+		Name:                spec.Name,
+		Hosts:               spec.Hosts,
+		Port:                int(spec.Port),
+		Keyspace:            spec.Keyspace,
+		SecureConnectBundle: spec.SecureConnectBundle,
+	}
+
+	if spec.Prompt && spec.PasswordEnv != "" {
+		return nil, status.Error(codes.InvalidArgument, "password_env cannot be set with prompt")
+	}
+	if spec.Username != "" || spec.PasswordEnv != "" || spec.Prompt {
+		p.Auth = &config.AuthConfig{Username: spec.Username, Prompt: spec.Prompt}
+	}
+	if spec.PasswordEnv != "" {
+		ref, err := config.EnvReference(spec.PasswordEnv)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		p.Auth.Password = ref
+	}
+
+	if spec.SslEnabled || spec.SslCertPath != "" || spec.SslKeyPath != "" || spec.SslCaPath != "" || spec.SslInsecureSkipVerify {
+		p.SSL = &config.SSLConfig{
+			Enabled:            spec.SslEnabled,
+			CertPath:           spec.SslCertPath,
+			KeyPath:            spec.SslKeyPath,
+			CAPath:             spec.SslCaPath,
+			InsecureSkipVerify: spec.SslInsecureSkipVerify,
+		}
+	}
+
+	return p, nil
+}
+
+// rejectSecretReferences refuses file:, cmd: and keyring: values in every
+// field of spec. A saved profile is resolved like any other when it is next
+// connected to, so a reference would read files or run commands on the
+// machine that loads the config.
+func rejectSecretReferences(spec *pb.ProfileSpec) error {
+	values := append([]string{spec.Name, spec.Keyspace, spec.Username, spec.SecureConnectBundle, spec.SslCertPath, spec.SslKeyPath, spec.SslCaPath}, spec.Hosts...)
+	for _, value := range values {
+		if config.IsSecretReference(value) {
+			return status.Errorf(codes.InvalidArgument, "%q: %v", value, config.ErrUntrustedSecretRef)
+		}
+	}
+	return nil
+}
+
+// profileToSpec converts a profile as written in the file. A password that
+// is not a single ${VAR} reference is reported rather than returned.
+func profileToSpec(p *config.Profile) (*pb.ProfileSpec, bool) {
+	spec := &pb.ProfileSpec{
+		Name:                p.Name,
+		Hosts:               p.Hosts,
+		Port:                int32(p.Port),
+		Keyspace:            p.Keyspace,
+		SecureConnectBundle: p.SecureConnectBundle,
+	}
+
+	var literal bool
+	if p.Auth != nil {
+		spec.Username = p.Auth.Username
+		spec.Prompt = p.Auth.Prompt
+		if name, ok := config.EnvReferenceName(p.Auth.Password); ok {
+			spec.PasswordEnv = name
+		} else {
+			literal = p.Auth.Password != "" && !config.IsSecretReference(p.Auth.Password) && !config.IsEncrypted(p.Auth.Password)
+		}
+	}
+
+	if p.SSL != nil {
+		spec.SslEnabled = p.SSL.Enabled
+		spec.SslCertPath = p.SSL.CertPath
+		spec.SslKeyPath = p.SSL.KeyPath
+		spec.SslCaPath = p.SSL.CAPath
+		spec.SslInsecureSkipVerify = p.SSL.InsecureSkipVerify
+	}
+
+	return spec, literal
+}
+
+func profileError(err error) error {
+	switch {
+	case errors.Is(err, config.ErrProfileInUse):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, config.ErrNoProfiles):
+		return status.Error(codes.FailedPrecondition, "the last profile cannot be deleted")
+	case errors.Is(err, config.ErrDuplicateProfile):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, config.ErrVarNotFound):
+		return status.Errorf(codes.FailedPrecondition, "%v (set it in the server's environment first)", err)
+	case errors.Is(err, config.ErrFileReadError), errors.Is(err, config.ErrFileWriteError), errors.Is(err, config.ErrInvalidJSON), errors.Is(err, config.ErrInvalidSyntax):
+		return status.Errorf(codes.Internal, "failed to update config file: %v", err)
+	case errors.Is(err, config.ErrProfileNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
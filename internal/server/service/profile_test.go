@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/server/apitoken"
+	"github.com/KashifKhn/kassie/internal/shared/config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestProfileService(t *testing.T) (*ProfileService, *config.ProfileStore, *mockSessionStore, *mockPool, *apitoken.Store) {
+	t.Helper()
+	t.Setenv("PROFILE_TEST_PASSWORD", "s3cret")
+
+	cfg := &config.Config{
+		Profiles: []config.Profile{
+			{
+				Name:   "dev",
+				Hosts:  []string{"127.0.0.1"},
+				Port:   9042,
+				Auth:   &config.AuthConfig{Username: "app", Password: "literal"},
+				Driver: &config.DriverConfig{Consistency: "QUORUM"},
+
+				Environment:     config.EnvironmentDev,
+				DefaultKeyspace: "app",
+				DefaultTable:    "users",
+			},
+			{Name: "prod", Hosts: []string{"10.0.0.1"}, Port: 9042},
+		},
+	}
+	cfg.SetDefaults()
+
+	tokens, err := apitoken.NewStore("")
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	profiles := config.NewProfileStore(cfg, nil)
+	store := newMockSessionStore()
+	pool := &mockPool{}
+	return NewProfileService(profiles, NewAuthService("test-secret"), store, tokens, pool), profiles, store, pool, tokens
+}
+
+func TestProfileService_CreateProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     *pb.ProfileSpec
+		wantCode codes.Code
+	}{
+		{
+			name: "env password",
+			spec: &pb.ProfileSpec{Name: "staging", Hosts: []string{"10.0.1.1"}, Port: 9042, Username: "app", PasswordEnv: "PROFILE_TEST_PASSWORD"},
+		},
+		{
+			name:     "invalid env name",
+			spec:     &pb.ProfileSpec{Name: "staging", Hosts: []string{"10.0.1.1"}, Port: 9042, PasswordEnv: "not-a-var"},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "unset env var",
+			spec:     &pb.ProfileSpec{Name: "staging", Hosts: []string{"10.0.1.1"}, Port: 9042, PasswordEnv: "PROFILE_TEST_UNSET"},
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			name:     "password with prompt",
+			spec:     &pb.ProfileSpec{Name: "staging", Hosts: []string{"10.0.1.1"}, Port: 9042, Prompt: true, PasswordEnv: "PROFILE_TEST_PASSWORD"},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "duplicate",
+			spec:     &pb.ProfileSpec{Name: "dev", Hosts: []string{"10.0.1.1"}, Port: 9042},
+			wantCode: codes.AlreadyExists,
+		},
+		{
+			name:     "command username",
+			spec:     &pb.ProfileSpec{Name: "staging", Hosts: []string{"10.0.1.1"}, Port: 9042, Username: "cmd:touch /tmp/pwned"},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "file reference in ssl path",
+			spec:     &pb.ProfileSpec{Name: "staging", Hosts: []string{"10.0.1.1"}, Port: 9042, SslEnabled: true, SslCaPath: "file:/etc/shadow"},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "keyring host",
+			spec:     &pb.ProfileSpec{Name: "staging", Hosts: []string{"keyring:kassie/prod"}, Port: 9042},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "no hosts",
+			spec:     &pb.ProfileSpec{Name: "staging", Port: 9042},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, profiles, _, _, _ := newTestProfileService(t)
+
+			_, err := svc.CreateProfile(context.Background(), &pb.CreateProfileRequest{Profile: tt.spec})
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("CreateProfile() code = %v, want %v (%v)", status.Code(err), tt.wantCode, err)
+			}
+			if tt.wantCode != codes.OK {
+				return
+			}
+
+			p, err := profiles.GetProfile(tt.spec.Name)
+			if err != nil {
+				t.Fatalf("GetProfile() error = %v", err)
+			}
+			if p.Auth.Password != "s3cret" {
+				t.Errorf("interpolated password = %q", p.Auth.Password)
+			}
+
+			resp, err := svc.GetProfile(context.Background(), &pb.GetProfileRequest{Name: tt.spec.Name})
+			if err != nil {
+				t.Fatalf("GetProfile() error = %v", err)
+			}
+			if resp.Profile.PasswordEnv != "PROFILE_TEST_PASSWORD" || resp.PasswordLiteral {
+				t.Errorf("GetProfile() = %+v", resp)
+			}
+		})
+	}
+}
+
+func TestProfileService_UpdateProfile(t *testing.T) {
+	svc, profiles, store, pool, _ := newTestProfileService(t)
+	dev, _ := profiles.GetProfile("dev")
+	store.Create("session-1", dev, nil)
+
+	resp, err := svc.GetProfile(context.Background(), &pb.GetProfileRequest{Name: "dev"})
+	if err != nil {
+		t.Fatalf("GetProfile() error = %v", err)
+	}
+	if !resp.PasswordLiteral || resp.Profile.PasswordEnv != "" {
+		t.Errorf("GetProfile() = %+v, want a literal password reported but not returned", resp)
+	}
+
+	spec := resp.Profile
+	spec.Keyspace = "orders"
+	if _, err := svc.UpdateProfile(context.Background(), &pb.UpdateProfileRequest{Name: "dev", Profile: spec}); err != nil {
+		t.Fatalf("UpdateProfile() error = %v", err)
+	}
+
+	p, _ := profiles.GetProfile("dev")
+	if p.Keyspace != "orders" || p.Auth.Password != "literal" {
+		t.Errorf("profile = keyspace %q password %q, want the password kept", p.Keyspace, p.Auth.Password)
+	}
+	if p.Driver == nil || p.Driver.Consistency != "QUORUM" {
+		t.Error("driver settings were dropped")
+	}
+	if p.Environment != config.EnvironmentDev || p.DefaultTable != "users" {
+		t.Errorf("display settings = %q %q, want them kept", p.Environment, p.DefaultTable)
+	}
+	if _, err := store.Get("session-1"); err == nil {
+		t.Error("expected the session on the edited profile to be closed")
+	}
+	if len(pool.closed) != 1 || pool.closed[0] != "dev" {
+		t.Errorf("pool closed = %v, want [dev]", pool.closed)
+	}
+
+	spec.PasswordEnv = "PROFILE_TEST_PASSWORD"
+	if _, err := svc.UpdateProfile(context.Background(), &pb.UpdateProfileRequest{Name: "dev", Profile: spec}); err != nil {
+		t.Fatalf("UpdateProfile() error = %v", err)
+	}
+	resp, _ = svc.GetProfile(context.Background(), &pb.GetProfileRequest{Name: "dev"})
+	if resp.PasswordLiteral || resp.Profile.PasswordEnv != "PROFILE_TEST_PASSWORD" {
+		t.Errorf("GetProfile() = %+v, want the env reference", resp)
+	}
+
+	spec.PasswordEnv = ""
+	if _, err := svc.UpdateProfile(context.Background(), &pb.UpdateProfileRequest{Name: "dev", Profile: spec, ClearPassword: true}); err != nil {
+		t.Fatalf("UpdateProfile() error = %v", err)
+	}
+	if p, _ := profiles.GetProfile("dev"); p.Auth.Password != "" {
+		t.Errorf("password = %q, want cleared", p.Auth.Password)
+	}
+
+	renamed := &pb.ProfileSpec{Name: "other", Hosts: []string{"127.0.0.1"}, Port: 9042}
+	if _, err := svc.UpdateProfile(context.Background(), &pb.UpdateProfileRequest{Name: "dev", Profile: renamed}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("rename error = %v, want InvalidArgument", err)
+	}
+	missing := &pb.ProfileSpec{Hosts: []string{"127.0.0.1"}, Port: 9042}
+	if _, err := svc.UpdateProfile(context.Background(), &pb.UpdateProfileRequest{Name: "missing", Profile: missing}); status.Code(err) != codes.NotFound {
+		t.Errorf("missing profile error = %v, want NotFound", err)
+	}
+}
+
+func TestProfileService_DeleteProfile(t *testing.T) {
+	svc, profiles, store, _, tokens := newTestProfileService(t)
+	prod, _ := profiles.GetProfile("prod")
+	store.Create("session-1", prod, nil)
+	raw, _, err := tokens.Create("ci", "", "prod", false, 0)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if _, err := svc.DeleteProfile(context.Background(), &pb.DeleteProfileRequest{Name: "prod"}); err != nil {
+		t.Fatalf("DeleteProfile() error = %v", err)
+	}
+	if _, err := profiles.GetProfile("prod"); err == nil {
+		t.Error("expected the profile to be removed")
+	}
+	if _, err := store.Get("session-1"); err == nil {
+		t.Error("expected the session on the deleted profile to be closed")
+	}
+	if _, err := tokens.Verify(raw); err == nil {
+		t.Error("expected the profile's api token to be revoked")
+	}
+
+	if _, err := svc.DeleteProfile(context.Background(), &pb.DeleteProfileRequest{Name: "prod"}); status.Code(err) != codes.NotFound {
+		t.Errorf("second delete error = %v, want NotFound", err)
+	}
+	if _, err := svc.DeleteProfile(context.Background(), &pb.DeleteProfileRequest{Name: "dev"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("deleting the last profile error = %v, want FailedPrecondition", err)
+	}
+}
@@ -5,18 +5,21 @@ import (
 	"sort"
 
 	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/shared/ctxutil"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 type SchemaService struct {
 	pb.UnimplementedSchemaServiceServer
-	store SessionStore
+	store  SessionStore
+	policy AccessPolicy
 }
 
-func NewSchemaService(store SessionStore) *SchemaService {
+func NewSchemaService(store SessionStore, policy AccessPolicy) *SchemaService {
 	return &SchemaService{
-		store: store,
+		store:  store,
+		policy: policy,
 	}
 }
 
@@ -37,7 +40,7 @@ func (s *SchemaService) ListKeyspaces(ctx context.Context, req *pb.ListKeyspaces
 		name, _ := row["keyspace_name"].(string)
 		replication, _ := row["replication"].(map[string]string)
 
-		if name == "" {
+		if name == "" || !s.canAccessKeyspace(ctx, session.Profile.Name, name) {
 			continue
 		}
 
@@ -83,7 +86,7 @@ func (s *SchemaService) ListTables(ctx context.Context, req *pb.ListTablesReques
 	tables := make([]*pb.Table, 0, len(rows))
 	for _, row := range rows {
 		name, ok := row["table_name"].(string)
-		if !ok || name == "" {
+		if !ok || name == "" || !s.canAccessTable(ctx, session.Profile.Name, req.Keyspace, name) {
 			continue
 		}
 
@@ -102,6 +105,22 @@ func (s *SchemaService) ListTables(ctx context.Context, req *pb.ListTablesReques
 	}, nil
 }
 
+func (s *SchemaService) canAccessKeyspace(ctx context.Context, profile, keyspace string) bool {
+	user, ok := ctxutil.GetUser(ctx)
+	if s.policy == nil || !ok {
+		return true
+	}
+	return s.policy.CanAccessKeyspace(user, profile, keyspace)
+}
+
+func (s *SchemaService) canAccessTable(ctx context.Context, profile, keyspace, table string) bool {
+	user, ok := ctxutil.GetUser(ctx)
+	if s.policy == nil || !ok {
+		return true
+	}
+	return s.policy.CanAccessTable(user, profile, keyspace, table)
+}
+
 func (s *SchemaService) GetTableSchema(ctx context.Context, req *pb.GetTableSchemaRequest) (*pb.GetTableSchemaResponse, error) {
 	if req.Keyspace == "" || req.Table == "" {
 		return nil, status.Error(codes.InvalidArgument, "keyspace and table are required")
@@ -28,9 +28,25 @@ func (m *mockSchemaStore) Get(id string) (*state.Session, error) {
 	return m.session, nil
 }
 
+func (m *mockSchemaStore) Update(id string, update func(*state.Session)) error {
+	return nil
+}
+
+func (m *mockSchemaStore) Info(id string) (state.SessionInfo, error) {
+	return state.SessionInfo{}, nil
+}
+
+func (m *mockSchemaStore) List() []state.SessionInfo {
+	return nil
+}
+
 func (m *mockSchemaStore) Delete(id string) {
 }
 
+func (m *mockSchemaStore) DeleteMatching(match func(*state.Session) bool) int {
+	return 0
+}
+
 func (m *mockSchemaStore) CloseAll() {
 }
 
@@ -39,7 +55,7 @@ func (m *mockSchemaStore) Close() {
 
 func TestSchemaService_ListTables_MissingKeyspace(t *testing.T) {
 	store := &mockSchemaStore{}
-	service := NewSchemaService(store)
+	service := NewSchemaService(store, nil)
 
 	_, err := service.ListTables(context.Background(), &pb.ListTablesRequest{Keyspace: ""})
 
@@ -59,7 +75,7 @@ func TestSchemaService_ListTables_MissingKeyspace(t *testing.T) {
 
 func TestSchemaService_GetTableSchema_MissingKeyspace(t *testing.T) {
 	store := &mockSchemaStore{}
-	service := NewSchemaService(store)
+	service := NewSchemaService(store, nil)
 
 	_, err := service.GetTableSchema(context.Background(), &pb.GetTableSchemaRequest{
 		Keyspace: "",
@@ -82,7 +98,7 @@ func TestSchemaService_GetTableSchema_MissingKeyspace(t *testing.T) {
 
 func TestSchemaService_GetTableSchema_MissingTable(t *testing.T) {
 	store := &mockSchemaStore{}
-	service := NewSchemaService(store)
+	service := NewSchemaService(store, nil)
 
 	_, err := service.GetTableSchema(context.Background(), &pb.GetTableSchemaRequest{
 		Keyspace: "users_ks",
@@ -2,11 +2,14 @@ package service
 
 import (
 	"context"
-	"time"
+	"encoding/json"
+	"strings"
+	"sync"
 
 	pb "github.com/KashifKhn/kassie/api/gen/go"
 	"github.com/KashifKhn/kassie/internal/server/db"
 	"github.com/KashifKhn/kassie/internal/server/state"
+	"github.com/KashifKhn/kassie/internal/shared/config"
 	"github.com/KashifKhn/kassie/internal/shared/ctxutil"
 	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
@@ -19,14 +22,22 @@ type SessionService struct {
 	pool  ConnectionPool
 	store SessionStore
 	auth  *AuthService
+	users UserProvider
+
+	refreshMu sync.Mutex
+
+	testConnection func(context.Context, *db.ConnectionConfig) []db.Check
 }
 
-func NewSessionService(cfg ProfileProvider, pool ConnectionPool, store SessionStore, auth *AuthService) *SessionService {
+func NewSessionService(cfg ProfileProvider, pool ConnectionPool, store SessionStore, auth *AuthService, users UserProvider) *SessionService {
 	return &SessionService{
 		cfg:   cfg,
 		pool:  pool,
 		store: store,
 		auth:  auth,
+		users: users,
+
+		testConnection: db.TestConnection,
 	}
 }
 
@@ -35,85 +46,339 @@ func (s *SessionService) Login(ctx context.Context, req *pb.LoginRequest) (*pb.L
 		return nil, status.Error(codes.InvalidArgument, "profile name is required")
 	}
 
-	profile, err := s.cfg.GetProfile(req.Profile)
+	user, err := s.authenticate(req.Username, req.Password)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "profile not found: %s", req.Profile)
+		return nil, err
 	}
 
-	connCfg := db.ProfileToConnectionConfig(profile)
-	gocqlSession, err := s.pool.GetOrCreate(profile.Name, connCfg)
-	if err != nil {
-		return nil, status.Errorf(codes.Unavailable, "failed to connect to database: %v", err)
+	username := ""
+	if user != nil {
+		username = user.Username
 	}
 
-	sessionID := uuid.New().String()
-	dbSession := db.NewSession(gocqlSession)
-	s.store.Create(sessionID, profile, dbSession)
+	return s.openSession(req.Profile, username, &dbCredentials{username: req.DbUsername, password: req.DbPassword})
+}
+
+// dbCredentials are database credentials supplied at login for profiles with
+// auth.prompt. They only live as long as the session's connection.
+type dbCredentials struct {
+	username string
+	password string
+}
+
+// openSession connects to the profile and issues a token pair. It is shared by
+// password login and single sign-on, which have already established username.
+func (s *SessionService) openSession(profileName, username string, creds *dbCredentials) (*pb.LoginResponse, error) {
+	session, err := s.connect(profileName, username, creds)
+	if err != nil {
+		return nil, err
+	}
+	profile := session.Profile
 
-	accessToken, refreshToken, expiresAt, err := s.auth.GenerateTokenPair(sessionID, profile.Name)
+	pair, err := s.auth.GenerateTokenPair(session.ID, profile.Name, username)
 	if err != nil {
-		s.store.Delete(sessionID)
+		s.store.Delete(session.ID)
 		return nil, status.Errorf(codes.Internal, "failed to generate tokens: %v", err)
 	}
+	s.setRefreshID(session.ID, pair.RefreshID)
 
 	return &pb.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresAt:    expiresAt,
-		Profile: &pb.ProfileInfo{
-			Name:       profile.Name,
-			Hosts:      profile.Hosts,
-			Port:       int32(profile.Port),
-			Keyspace:   profile.Keyspace,
-			SslEnabled: profile.SSL != nil && profile.SSL.Enabled,
-		},
+		AccessToken:  pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		ExpiresAt:    pair.ExpiresAt,
+		Profile:      profileInfo(profile),
 	}, nil
 }
 
+// connect checks that username may use the profile and registers a new
+// session holding a reference on the profile's pooled connection, released
+// when the session closes. Profiles that prompt for database credentials get
+// a dedicated connection using creds instead, which is closed with the session.
+func (s *SessionService) connect(profileName, username string, creds *dbCredentials) (*state.Session, error) {
+	profile, err := s.connectableProfile(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if username != "" && !s.users.CanUseProfile(username, profile.Name) {
+		return nil, status.Errorf(codes.PermissionDenied, "user %s is not allowed to use profile %s", username, profile.Name)
+	}
+
+	connCfg, err := db.ProfileToConnectionConfig(profile)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "failed to resolve credentials: %v", err)
+	}
+
+	var conn *db.Session
+	if profile.PromptsForCredentials() {
+		if creds == nil || creds.username == "" || creds.password == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "database username and password are required for profile %s", profile.Name)
+		}
+		connCfg.Username = creds.username
+		connCfg.Password = creds.password
+		conn, err = s.pool.Open(profile.Name, connCfg)
+	} else {
+		conn, err = s.pool.Acquire(profile.Name, connCfg)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to connect to database: %v", err)
+	}
+
+	session := s.store.Create(uuid.New().String(), profile, conn)
+	_ = s.store.Update(session.ID, func(session *state.Session) { session.User = username })
+	return session, nil
+}
+
+// Refresh rotates the refresh token: every call returns a new pair and the
+// presented refresh token stops working. Presenting a token that was already
+// rotated means it leaked, so the whole session is terminated.
 func (s *SessionService) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.RefreshResponse, error) {
 	if req.RefreshToken == "" {
 		return nil, status.Error(codes.InvalidArgument, "refresh token is required")
 	}
 
-	accessToken, expiresAt, err := s.auth.RefreshAccessToken(req.RefreshToken)
+	claims, err := s.auth.ValidateToken(req.RefreshToken, RefreshToken)
 	if err != nil {
 		return nil, status.Errorf(codes.Unauthenticated, "failed to refresh token: %v", err)
 	}
 
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	session, err := s.store.Get(claims.SessionID)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "session not found or expired")
+	}
+
+	if s.refreshID(session.ID) != claims.ID {
+		s.auth.Revoke(claims.ID, RefreshToken)
+		s.endSession(session)
+		return nil, status.Error(codes.Unauthenticated, "refresh token reuse detected, session terminated")
+	}
+
+	pair, err := s.auth.GenerateTokenPair(session.ID, claims.Profile, claims.User)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate tokens: %v", err)
+	}
+	// The old token is not added to the revocation list: it must keep
+	// validating so that a replay reaches the reuse check above.
+	s.setRefreshID(session.ID, pair.RefreshID)
+
 	return &pb.RefreshResponse{
-		AccessToken: accessToken,
-		ExpiresAt:   expiresAt,
+		AccessToken:  pair.AccessToken,
+		ExpiresAt:    pair.ExpiresAt,
+		RefreshToken: pair.RefreshToken,
 	}, nil
 }
 
 func (s *SessionService) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	if tokenID, ok := ctxutil.GetTokenID(ctx); ok {
+		s.auth.Revoke(tokenID, AccessToken)
+	}
+
 	if sessionID, ok := ctxutil.GetSessionID(ctx); ok {
-		s.store.Delete(sessionID)
+		s.refreshMu.Lock()
+		if session, err := s.store.Get(sessionID); err == nil {
+			s.endSession(session)
+		}
+		s.refreshMu.Unlock()
 	}
 
 	return &pb.LogoutResponse{}, nil
 }
 
+// endSession closes session and revokes its current refresh token. Access
+// tokens of the session fail once it is gone.
+func (s *SessionService) endSession(session *state.Session) {
+	s.auth.Revoke(s.refreshID(session.ID), RefreshToken)
+	s.store.Delete(session.ID)
+}
+
+func (s *SessionService) refreshID(sessionID string) string {
+	info, _ := s.store.Info(sessionID)
+	return info.RefreshID
+}
+
+func (s *SessionService) setRefreshID(sessionID, refreshID string) {
+	_ = s.store.Update(sessionID, func(session *state.Session) { session.RefreshID = refreshID })
+}
+
+// connectableProfile looks up a profile sessions may connect to. Abstract
+// profiles only exist to be extended.
+func (s *SessionService) connectableProfile(name string) (*config.Profile, error) {
+	profile, err := s.cfg.GetProfile(name)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "profile not found: %s", name)
+	}
+	if profile.Abstract {
+		return nil, status.Errorf(codes.FailedPrecondition, "profile %s is abstract and can only be extended", name)
+	}
+	return profile, nil
+}
+
 func (s *SessionService) GetProfiles(ctx context.Context, req *pb.GetProfilesRequest) (*pb.GetProfilesResponse, error) {
 	profileList := s.cfg.GetProfiles()
 	profiles := make([]*pb.ProfileInfo, 0, len(profileList))
 
 	for i := range profileList {
-		p := &profileList[i]
-		profiles = append(profiles, &pb.ProfileInfo{
-			Name:       p.Name,
-			Hosts:      p.Hosts,
-			Port:       int32(p.Port),
-			Keyspace:   p.Keyspace,
-			SslEnabled: p.SSL != nil && p.SSL.Enabled,
-		})
+		if profileList[i].Abstract {
+			continue
+		}
+		profiles = append(profiles, profileInfo(&profileList[i]))
 	}
 
 	return &pb.GetProfilesResponse{
-		Profiles: profiles,
+		Profiles:     profiles,
+		AuthRequired: s.usersEnabled(),
+		SsoEnabled:   s.users != nil && s.users.SSOEnabled(),
 	}, nil
 }
 
+// TestConnection runs the staged connection checks for a configured or an
+// inline profile. Configured profiles are checked against the server
+// credentials in the request. Inline profiles make the server dial any
+// address, so the auth interceptor only lets them through with the local key
+// or a token, and on servers with users the admin permission. Their secret
+// references are never resolved: that would read files and run commands on
+// the server for the caller.
+func (s *SessionService) TestConnection(ctx context.Context, req *pb.TestConnectionRequest) (*pb.TestConnectionResponse, error) {
+	var profile *config.Profile
+	var err error
+	switch {
+	case req.Profile != "" && req.InlineProfile != "":
+		return nil, status.Error(codes.InvalidArgument, "set either profile or inline_profile, not both")
+	case req.Profile != "":
+		user, err := s.authenticate(req.Username, req.Password)
+		if err != nil {
+			return nil, err
+		}
+		profile, err = s.connectableProfile(req.Profile)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil && !s.users.CanUseProfile(user.Username, profile.Name) {
+			return nil, status.Errorf(codes.PermissionDenied, "user %s is not allowed to use profile %s", user.Username, profile.Name)
+		}
+	case req.InlineProfile != "":
+		profile, err = parseInlineProfile(req.InlineProfile)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid inline profile: %v", err)
+		}
+	default:
+		return nil, status.Error(codes.InvalidArgument, "profile or inline_profile is required")
+	}
+
+	connCfg, err := db.ProfileToConnectionConfig(profile)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "failed to resolve credentials: %v", err)
+	}
+	if profile.PromptsForCredentials() {
+		if req.DbUsername == "" || req.DbPassword == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "database username and password are required for profile %s", profile.Name)
+		}
+		connCfg.Username = req.DbUsername
+		connCfg.Password = req.DbPassword
+	}
+
+	resp := &pb.TestConnectionResponse{Ok: true}
+	for _, check := range s.testConnection(ctx, connCfg) {
+		if check.Status == db.CheckFailed {
+			resp.Ok = false
+		}
+		resp.Checks = append(resp.Checks, checkToProto(check))
+	}
+	return resp, nil
+}
+
+func parseInlineProfile(raw string) (*config.Profile, error) {
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.DisallowUnknownFields()
+
+	var profile config.Profile
+	if err := dec.Decode(&profile); err != nil {
+		return nil, err
+	}
+	if profile.Name == "" {
+		profile.Name = "inline"
+	}
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	if err := profile.RejectSecretReferences(); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func checkToProto(c db.Check) *pb.ConnectionCheck {
+	check := &pb.ConnectionCheck{
+		Stage:     c.Stage,
+		Host:      c.Host,
+		LatencyMs: c.Latency.Milliseconds(),
+		Detail:    c.Detail,
+		Hint:      c.Hint,
+	}
+	switch c.Status {
+	case db.CheckPassed:
+		check.Status = pb.CheckStatus_CHECK_STATUS_PASSED
+	case db.CheckFailed:
+		check.Status = pb.CheckStatus_CHECK_STATUS_FAILED
+	case db.CheckSkipped:
+		check.Status = pb.CheckStatus_CHECK_STATUS_SKIPPED
+	}
+	return check
+}
+
+func profileInfo(p *config.Profile) *pb.ProfileInfo {
+	info := &pb.ProfileInfo{
+		Name:                p.Name,
+		Hosts:               p.Hosts,
+		Port:                int32(p.Port),
+		Keyspace:            p.Keyspace,
+		SslEnabled:          p.SSL != nil && p.SSL.Enabled,
+		CredentialsRequired: p.PromptsForCredentials(),
+		Environment:         p.Environment,
+		Color:               p.DisplayColor(),
+		PageSize:            int32(p.PageSize),
+		DefaultKeyspace:     p.OpenKeyspace(),
+		DefaultTable:        p.DefaultTable,
+	}
+	if info.CredentialsRequired {
+		info.DefaultDbUsername = p.Auth.Username
+	}
+	return info
+}
+
+func (s *SessionService) usersEnabled() bool {
+	return s.users != nil && s.users.HasUsers()
+}
+
+func (s *SessionService) authenticate(username, password string) (*config.User, error) {
+	if !s.usersEnabled() {
+		return nil, nil
+	}
+
+	if username == "" || password == "" {
+		return nil, status.Error(codes.Unauthenticated, "username and password are required")
+	}
+
+	user, err := s.users.GetUser(username)
+	if err != nil {
+		burnPasswordCheck(password)
+		return nil, status.Error(codes.Unauthenticated, "invalid username or password")
+	}
+
+	ok, err := VerifyPassword(user.PasswordHash, password)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to verify password: %v", err)
+	}
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid username or password")
+	}
+
+	return user, nil
+}
+
 func GetSessionFromContext(ctx context.Context, store SessionStore) (*state.Session, error) {
 	sessionID, ok := ctxutil.GetSessionID(ctx)
 	if !ok {
@@ -125,6 +390,5 @@ func GetSessionFromContext(ctx context.Context, store SessionStore) (*state.Sess
 		return nil, status.Errorf(codes.Unauthenticated, "session not found or expired: %v", err)
 	}
 
-	session.LastAccess = time.Now()
 	return session, nil
 }
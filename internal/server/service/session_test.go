@@ -3,6 +3,10 @@ package service
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,7 +14,9 @@ import (
 	"github.com/KashifKhn/kassie/internal/server/db"
 	"github.com/KashifKhn/kassie/internal/server/state"
 	"github.com/KashifKhn/kassie/internal/shared/config"
+	"github.com/KashifKhn/kassie/internal/shared/ctxutil"
 	"github.com/gocql/gocql"
+	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -18,13 +24,32 @@ import (
 type mockPool struct {
 	session *gocql.Session
 	err     error
+	opened  []*db.ConnectionConfig
+	closed  []string
 }
 
-func (m *mockPool) GetOrCreate(profileName string, cfg *db.ConnectionConfig) (*gocql.Session, error) {
+func (m *mockPool) Acquire(profileName string, cfg *db.ConnectionConfig) (*db.Session, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
-	return m.session, nil
+	return db.NewSession(m.session), nil
+}
+
+func (m *mockPool) Open(profileName string, cfg *db.ConnectionConfig) (*db.Session, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.opened = append(m.opened, cfg)
+	return db.NewSession(m.session), nil
+}
+
+func (m *mockPool) Stats() []db.PoolStats {
+	return []db.PoolStats{{Profile: "dev", Refs: 1}}
+}
+
+func (m *mockPool) Close(profileName string) error {
+	m.closed = append(m.closed, profileName)
+	return nil
 }
 
 type mockProfileProvider struct {
@@ -76,10 +101,65 @@ func (m *mockSessionStore) Get(id string) (*state.Session, error) {
 	return nil, state.ErrSessionNotFound
 }
 
+func (m *mockSessionStore) Update(id string, update func(*state.Session)) error {
+	sess, ok := m.sessions[id]
+	if !ok {
+		return state.ErrSessionNotFound
+	}
+	update(sess)
+	return nil
+}
+
+func (m *mockSessionStore) Info(id string) (state.SessionInfo, error) {
+	sess, ok := m.sessions[id]
+	if !ok {
+		return state.SessionInfo{}, state.ErrSessionNotFound
+	}
+	return mockSessionInfo(sess), nil
+}
+
+func (m *mockSessionStore) List() []state.SessionInfo {
+	sessions := make([]state.SessionInfo, 0, len(m.sessions))
+	for _, sess := range m.sessions {
+		sessions = append(sessions, mockSessionInfo(sess))
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions
+}
+
+func mockSessionInfo(sess *state.Session) state.SessionInfo {
+	info := state.SessionInfo{
+		ID:         sess.ID,
+		Profile:    sess.Profile,
+		User:       sess.User,
+		RefreshID:  sess.RefreshID,
+		CreatedAt:  sess.CreatedAt,
+		LastAccess: sess.LastAccess,
+		InFlight:   sess.InFlight(),
+	}
+	if sess.Cursors != nil {
+		info.Cursors = sess.Cursors.Count()
+	}
+	return info
+}
+
 func (m *mockSessionStore) Delete(id string) {
 	delete(m.sessions, id)
 }
 
+func (m *mockSessionStore) DeleteMatching(match func(*state.Session) bool) int {
+	removed := 0
+	for id, sess := range m.sessions {
+		if match(sess) {
+			delete(m.sessions, id)
+			removed++
+		}
+	}
+	return removed
+}
+
 func (m *mockSessionStore) CloseAll() {
 	m.sessions = make(map[string]*state.Session)
 }
@@ -92,7 +172,7 @@ func TestSessionService_Login_MissingProfile(t *testing.T) {
 	pool := &mockPool{}
 	store := newMockSessionStore()
 	auth := NewAuthService("test-secret")
-	service := NewSessionService(cfg, pool, store, auth)
+	service := NewSessionService(cfg, pool, store, auth, nil)
 
 	_, err := service.Login(context.Background(), &pb.LoginRequest{Profile: ""})
 
@@ -115,7 +195,7 @@ func TestSessionService_Login_ProfileNotFound(t *testing.T) {
 	pool := &mockPool{}
 	store := newMockSessionStore()
 	auth := NewAuthService("test-secret")
-	service := NewSessionService(cfg, pool, store, auth)
+	service := NewSessionService(cfg, pool, store, auth, nil)
 
 	_, err := service.Login(context.Background(), &pb.LoginRequest{Profile: "nonexistent"})
 
@@ -133,6 +213,21 @@ func TestSessionService_Login_ProfileNotFound(t *testing.T) {
 	}
 }
 
+func TestSessionService_Login_AbstractProfile(t *testing.T) {
+	cfg := &mockProfileProvider{
+		profiles: map[string]*config.Profile{
+			"base": {Name: "base", Abstract: true, Port: 9042},
+		},
+	}
+	service := NewSessionService(cfg, &mockPool{}, newMockSessionStore(), NewAuthService("test-secret"), nil)
+
+	_, err := service.Login(context.Background(), &pb.LoginRequest{Profile: "base"})
+
+	if st, _ := status.FromError(err); st.Code() != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition, got %v", err)
+	}
+}
+
 func TestSessionService_Login_ConnectionFailed(t *testing.T) {
 	cfg := &mockProfileProvider{
 		profiles: map[string]*config.Profile{
@@ -142,7 +237,7 @@ func TestSessionService_Login_ConnectionFailed(t *testing.T) {
 	pool := &mockPool{err: errors.New("connection failed")}
 	store := newMockSessionStore()
 	auth := NewAuthService("test-secret")
-	service := NewSessionService(cfg, pool, store, auth)
+	service := NewSessionService(cfg, pool, store, auth, nil)
 
 	_, err := service.Login(context.Background(), &pb.LoginRequest{Profile: "test"})
 
@@ -165,7 +260,7 @@ func TestSessionService_Refresh_MissingToken(t *testing.T) {
 	pool := &mockPool{}
 	store := newMockSessionStore()
 	auth := NewAuthService("test-secret")
-	service := NewSessionService(cfg, pool, store, auth)
+	service := NewSessionService(cfg, pool, store, auth, nil)
 
 	_, err := service.Refresh(context.Background(), &pb.RefreshRequest{RefreshToken: ""})
 
@@ -188,7 +283,7 @@ func TestSessionService_Refresh_InvalidToken(t *testing.T) {
 	pool := &mockPool{}
 	store := newMockSessionStore()
 	auth := NewAuthService("test-secret")
-	service := NewSessionService(cfg, pool, store, auth)
+	service := NewSessionService(cfg, pool, store, auth, nil)
 
 	_, err := service.Refresh(context.Background(), &pb.RefreshRequest{RefreshToken: "invalid-token"})
 
@@ -211,12 +306,13 @@ func TestSessionService_GetProfiles(t *testing.T) {
 		profiles: map[string]*config.Profile{
 			"local": {Name: "local", Hosts: []string{"localhost"}, Port: 9042},
 			"prod":  {Name: "prod", Hosts: []string{"prod.example.com"}, Port: 9042},
+			"base":  {Name: "base", Abstract: true},
 		},
 	}
 	pool := &mockPool{}
 	store := newMockSessionStore()
 	auth := NewAuthService("test-secret")
-	service := NewSessionService(cfg, pool, store, auth)
+	service := NewSessionService(cfg, pool, store, auth, nil)
 
 	resp, err := service.GetProfiles(context.Background(), &pb.GetProfilesRequest{})
 
@@ -225,7 +321,7 @@ func TestSessionService_GetProfiles(t *testing.T) {
 	}
 
 	if len(resp.Profiles) != 2 {
-		t.Errorf("expected 2 profiles, got %d", len(resp.Profiles))
+		t.Errorf("expected 2 profiles without the abstract one, got %d", len(resp.Profiles))
 	}
 }
 
@@ -247,3 +343,396 @@ func TestGetSessionFromContext_NoSessionID(t *testing.T) {
 		t.Errorf("expected Internal, got %v", st.Code())
 	}
 }
+
+type mockUserProvider struct {
+	users map[string]*config.User
+}
+
+func (m *mockUserProvider) HasUsers() bool {
+	return len(m.users) > 0
+}
+
+func (m *mockUserProvider) GetUser(username string) (*config.User, error) {
+	if u, ok := m.users[username]; ok {
+		return u, nil
+	}
+	return nil, config.ErrUserNotFound
+}
+
+func (m *mockUserProvider) CanUseProfile(username, profile string) bool {
+	u, ok := m.users[username]
+	return ok && u.CanUseProfile(profile)
+}
+
+func (m *mockUserProvider) SSOEnabled() bool {
+	return false
+}
+
+func newUserSessionService(t *testing.T) (*SessionService, *mockSessionStore, *AuthService) {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+
+	cfg := &mockProfileProvider{
+		profiles: map[string]*config.Profile{
+			"dev":  {Name: "dev", Hosts: []string{"localhost"}, Port: 9042},
+			"prod": {Name: "prod", Hosts: []string{"prod.example.com"}, Port: 9042},
+		},
+	}
+	users := &mockUserProvider{
+		users: map[string]*config.User{
+			"alice": {Username: "alice", PasswordHash: string(hash), Profiles: []string{"dev"}},
+		},
+	}
+	store := newMockSessionStore()
+	auth := NewAuthService("test-secret")
+
+	return NewSessionService(cfg, &mockPool{}, store, auth, users), store, auth
+}
+
+func TestSessionService_Login_WithUser(t *testing.T) {
+	service, store, auth := newUserSessionService(t)
+
+	resp, err := service.Login(context.Background(), &pb.LoginRequest{Profile: "dev", Username: "alice", Password: "s3cret"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	claims, err := auth.ValidateToken(resp.AccessToken, AccessToken)
+	if err != nil {
+		t.Fatalf("expected valid access token, got %v", err)
+	}
+	if claims.User != "alice" {
+		t.Errorf("expected user claim alice, got %q", claims.User)
+	}
+
+	session, err := store.Get(claims.SessionID)
+	if err != nil {
+		t.Fatalf("expected session to be stored, got %v", err)
+	}
+	if session.User != "alice" {
+		t.Errorf("expected session user alice, got %q", session.User)
+	}
+}
+
+func TestSessionService_Login_UserErrors(t *testing.T) {
+	service, _, _ := newUserSessionService(t)
+
+	tests := []struct {
+		name string
+		req  *pb.LoginRequest
+		code codes.Code
+	}{
+		{name: "missing credentials", req: &pb.LoginRequest{Profile: "dev"}, code: codes.Unauthenticated},
+		{name: "wrong password", req: &pb.LoginRequest{Profile: "dev", Username: "alice", Password: "nope"}, code: codes.Unauthenticated},
+		{name: "unknown user", req: &pb.LoginRequest{Profile: "dev", Username: "mallory", Password: "s3cret"}, code: codes.Unauthenticated},
+		{name: "profile not allowed", req: &pb.LoginRequest{Profile: "prod", Username: "alice", Password: "s3cret"}, code: codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Login(context.Background(), tt.req)
+			if status.Code(err) != tt.code {
+				t.Errorf("expected %v, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestSessionService_GetProfiles_AuthRequired(t *testing.T) {
+	service, _, _ := newUserSessionService(t)
+
+	resp, err := service.GetProfiles(context.Background(), &pb.GetProfilesRequest{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !resp.AuthRequired {
+		t.Error("expected auth_required when users are configured")
+	}
+}
+
+func TestSessionService_Refresh_Rotation(t *testing.T) {
+	service, store, auth := newUserSessionService(t)
+
+	login, err := service.Login(context.Background(), &pb.LoginRequest{Profile: "dev", Username: "alice", Password: "s3cret"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	first, err := service.Refresh(context.Background(), &pb.RefreshRequest{RefreshToken: login.RefreshToken})
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if first.RefreshToken == "" || first.RefreshToken == login.RefreshToken {
+		t.Fatal("expected a new refresh token on every refresh")
+	}
+
+	claims, err := auth.ValidateToken(first.AccessToken, AccessToken)
+	if err != nil {
+		t.Fatalf("expected valid access token, got %v", err)
+	}
+	if claims.User != "alice" || claims.Profile != "dev" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	second, err := service.Refresh(context.Background(), &pb.RefreshRequest{RefreshToken: first.RefreshToken})
+	if err != nil {
+		t.Fatalf("Refresh() with rotated token error = %v", err)
+	}
+
+	// Replaying the original token is treated as theft and ends the session.
+	_, err = service.Refresh(context.Background(), &pb.RefreshRequest{RefreshToken: login.RefreshToken})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated on reuse, got %v", err)
+	}
+	if _, err := store.Get(claims.SessionID); err == nil {
+		t.Error("expected reuse to terminate the session")
+	}
+	if _, err := service.Refresh(context.Background(), &pb.RefreshRequest{RefreshToken: second.RefreshToken}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected the latest refresh token to die with the session, got %v", err)
+	}
+}
+
+func TestSessionService_Refresh_WithAccessToken(t *testing.T) {
+	service, _, _ := newUserSessionService(t)
+
+	login, err := service.Login(context.Background(), &pb.LoginRequest{Profile: "dev", Username: "alice", Password: "s3cret"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	_, err = service.Refresh(context.Background(), &pb.RefreshRequest{RefreshToken: login.AccessToken})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated when using an access token, got %v", err)
+	}
+}
+
+func TestSessionService_Logout_RevokesTokens(t *testing.T) {
+	service, store, auth := newUserSessionService(t)
+
+	login, err := service.Login(context.Background(), &pb.LoginRequest{Profile: "dev", Username: "alice", Password: "s3cret"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	claims, err := auth.ValidateToken(login.AccessToken, AccessToken)
+	if err != nil {
+		t.Fatalf("expected valid access token, got %v", err)
+	}
+
+	ctx := ctxutil.WithSessionID(context.Background(), claims.SessionID)
+	ctx = ctxutil.WithTokenID(ctx, claims.ID)
+	if _, err := service.Logout(ctx, &pb.LogoutRequest{}); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+
+	if _, err := store.Get(claims.SessionID); err == nil {
+		t.Error("expected logout to delete the session")
+	}
+	if _, err := auth.ValidateToken(login.AccessToken, AccessToken); err != ErrRevokedToken {
+		t.Errorf("expected access token to be revoked, got %v", err)
+	}
+	if _, err := auth.ValidateToken(login.RefreshToken, RefreshToken); err != ErrRevokedToken {
+		t.Errorf("expected refresh token to be revoked, got %v", err)
+	}
+}
+
+func TestSessionService_Login_PromptedCredentials(t *testing.T) {
+	cfg := &mockProfileProvider{
+		profiles: map[string]*config.Profile{
+			"shared": {Name: "shared", Hosts: []string{"localhost"}, Port: 9042, Auth: &config.AuthConfig{Username: "analyst", Prompt: true}},
+		},
+	}
+	pool := &mockPool{}
+	service := NewSessionService(cfg, pool, newMockSessionStore(), NewAuthService("test-secret"), nil)
+
+	profiles, err := service.GetProfiles(context.Background(), &pb.GetProfilesRequest{})
+	if err != nil {
+		t.Fatalf("GetProfiles() error = %v", err)
+	}
+	if info := profiles.Profiles[0]; !info.CredentialsRequired || info.DefaultDbUsername != "analyst" {
+		t.Errorf("expected prompted profile info, got %+v", info)
+	}
+
+	tests := []struct {
+		name string
+		req  *pb.LoginRequest
+		code codes.Code
+	}{
+		{name: "missing credentials", req: &pb.LoginRequest{Profile: "shared"}, code: codes.InvalidArgument},
+		{name: "missing password", req: &pb.LoginRequest{Profile: "shared", DbUsername: "jane"}, code: codes.InvalidArgument},
+		{name: "credentials", req: &pb.LoginRequest{Profile: "shared", DbUsername: "jane", DbPassword: "pw"}, code: codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Login(context.Background(), tt.req)
+			if status.Code(err) != tt.code {
+				t.Errorf("expected %v, got %v", tt.code, err)
+			}
+		})
+	}
+
+	if len(pool.opened) != 1 {
+		t.Fatalf("expected one dedicated connection, got %d", len(pool.opened))
+	}
+	if pool.opened[0].Username != "jane" || pool.opened[0].Password != "pw" {
+		t.Errorf("expected login credentials on the connection, got %q", pool.opened[0].Username)
+	}
+	if cfg.profiles["shared"].Auth.Password != "" {
+		t.Error("login credentials must not be written back to the profile")
+	}
+}
+
+func TestSessionService_TestConnection(t *testing.T) {
+	cfg := &mockProfileProvider{
+		profiles: map[string]*config.Profile{
+			"dev":    {Name: "dev", Hosts: []string{"localhost"}, Port: 9042, Keyspace: "app"},
+			"shared": {Name: "shared", Hosts: []string{"localhost"}, Port: 9042, Auth: &config.AuthConfig{Username: "analyst", Prompt: true}},
+		},
+	}
+	service := NewSessionService(cfg, &mockPool{}, newMockSessionStore(), NewAuthService("test-secret"), nil)
+
+	var tested []*db.ConnectionConfig
+	service.testConnection = func(ctx context.Context, c *db.ConnectionConfig) []db.Check {
+		tested = append(tested, c)
+		checks := []db.Check{{Stage: db.StageDNS, Host: "localhost:9042", Status: db.CheckPassed, Latency: 3 * time.Millisecond}}
+		if c.Keyspace == "missing" {
+			return append(checks, db.Check{Stage: db.StageKeyspace, Status: db.CheckFailed, Hint: "check the keyspace"})
+		}
+		return checks
+	}
+
+	tests := []struct {
+		name   string
+		req    *pb.TestConnectionRequest
+		code   codes.Code
+		wantOK bool
+	}{
+		{name: "profile", req: &pb.TestConnectionRequest{Profile: "dev"}, code: codes.OK, wantOK: true},
+		{name: "inline", req: &pb.TestConnectionRequest{InlineProfile: `{"hosts":["10.0.0.1"],"port":9042}`}, code: codes.OK, wantOK: true},
+		{name: "failed check", req: &pb.TestConnectionRequest{InlineProfile: `{"hosts":["10.0.0.1"],"port":9042,"keyspace":"missing"}`}, code: codes.OK},
+		{name: "prompted", req: &pb.TestConnectionRequest{Profile: "shared", DbUsername: "jane", DbPassword: "pw"}, code: codes.OK, wantOK: true},
+		{name: "prompted without credentials", req: &pb.TestConnectionRequest{Profile: "shared"}, code: codes.InvalidArgument},
+		{name: "neither", req: &pb.TestConnectionRequest{}, code: codes.InvalidArgument},
+		{name: "both", req: &pb.TestConnectionRequest{Profile: "dev", InlineProfile: "{}"}, code: codes.InvalidArgument},
+		{name: "unknown profile", req: &pb.TestConnectionRequest{Profile: "nope"}, code: codes.NotFound},
+		{name: "invalid inline", req: &pb.TestConnectionRequest{InlineProfile: `{"hosts":[],"port":9042}`}, code: codes.InvalidArgument},
+		{name: "unknown inline field", req: &pb.TestConnectionRequest{InlineProfile: `{"hosts":["a"],"port":9042,"hostz":[]}`}, code: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := service.TestConnection(context.Background(), tt.req)
+			if status.Code(err) != tt.code {
+				t.Fatalf("expected %v, got %v", tt.code, err)
+			}
+			if err != nil {
+				return
+			}
+			if resp.Ok != tt.wantOK {
+				t.Errorf("ok = %v, want %v", resp.Ok, tt.wantOK)
+			}
+			first := resp.Checks[0]
+			if first.Stage != db.StageDNS || first.Status != pb.CheckStatus_CHECK_STATUS_PASSED || first.LatencyMs != 3 {
+				t.Errorf("unexpected check %+v", first)
+			}
+		})
+	}
+
+	last := tested[len(tested)-1]
+	if last.Username != "jane" || last.Password != "pw" {
+		t.Errorf("expected the supplied database credentials, got %q", last.Username)
+	}
+}
+
+func TestSessionService_TestConnection_InlineSecretReferences(t *testing.T) {
+	service := NewSessionService(&mockProfileProvider{}, &mockPool{}, newMockSessionStore(), NewAuthService("test-secret"), nil)
+	service.testConnection = func(context.Context, *db.ConnectionConfig) []db.Check {
+		t.Error("dialed an inline profile holding a secret reference")
+		return nil
+	}
+
+	marker := filepath.Join(t.TempDir(), "ran")
+	secret := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secret, []byte("s3cret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, auth := range []string{
+		`{"username":"u","password":"cmd:touch ` + marker + `; echo x"}`,
+		`{"username":"u","password":"file:` + secret + `"}`,
+		`{"username":"cmd:touch ` + marker + `","password":"p"}`,
+		`{"username":"u","password":"keyring:kassie/u"}`,
+	} {
+		inline := `{"hosts":["127.0.0.1"],"port":9042,"auth":` + auth + `}`
+		_, err := service.TestConnection(context.Background(), &pb.TestConnectionRequest{InlineProfile: inline})
+		if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "not accepted") {
+			t.Errorf("TestConnection(%s) error = %v, want the reference refused", auth, err)
+		}
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Errorf("the cmd: reference ran (stat error = %v)", err)
+	}
+}
+
+func TestSessionService_TestConnection_WithUsers(t *testing.T) {
+	service, _, _ := newUserSessionService(t)
+	service.testConnection = func(context.Context, *db.ConnectionConfig) []db.Check { return nil }
+
+	tests := []struct {
+		name string
+		req  *pb.TestConnectionRequest
+		code codes.Code
+	}{
+		{name: "allowed", req: &pb.TestConnectionRequest{Profile: "dev", Username: "alice", Password: "s3cret"}, code: codes.OK},
+		{name: "no credentials", req: &pb.TestConnectionRequest{Profile: "dev"}, code: codes.Unauthenticated},
+		{name: "profile not allowed", req: &pb.TestConnectionRequest{Profile: "prod", Username: "alice", Password: "s3cret"}, code: codes.PermissionDenied},
+		// The auth interceptor has already required admin for inline profiles.
+		{name: "inline", req: &pb.TestConnectionRequest{InlineProfile: `{"hosts":["10.0.0.1"],"port":9042}`}, code: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.TestConnection(context.Background(), tt.req)
+			if status.Code(err) != tt.code {
+				t.Errorf("expected %v, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestProfileInfoDisplay(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile config.Profile
+		want    *pb.ProfileInfo
+	}{
+		{
+			name:    "plain",
+			profile: config.Profile{Name: "local", Keyspace: "app", PageSize: 100},
+			want:    &pb.ProfileInfo{Name: "local", Keyspace: "app", PageSize: 100, DefaultKeyspace: "app"},
+		},
+		{
+			name:    "environment color",
+			profile: config.Profile{Name: "prod", Environment: config.EnvironmentProd, PageSize: 50, DefaultKeyspace: "orders", DefaultTable: "events"},
+			want:    &pb.ProfileInfo{Name: "prod", Environment: "prod", Color: "#c62828", PageSize: 50, DefaultKeyspace: "orders", DefaultTable: "events"},
+		},
+		{
+			name:    "own color",
+			profile: config.Profile{Name: "qa", Environment: config.EnvironmentStaging, Color: "#123456"},
+			want:    &pb.ProfileInfo{Name: "qa", Environment: "staging", Color: "#123456"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := profileInfo(&tt.profile)
+			if got.Environment != tt.want.Environment || got.Color != tt.want.Color || got.PageSize != tt.want.PageSize ||
+				got.DefaultKeyspace != tt.want.DefaultKeyspace || got.DefaultTable != tt.want.DefaultTable {
+				t.Errorf("profileInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
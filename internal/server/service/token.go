@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/server/apitoken"
+	"github.com/KashifKhn/kassie/internal/shared/ctxutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type TokenStore interface {
+	Create(name, user, profile string, readOnly bool, ttl time.Duration) (string, *apitoken.Token, error)
+	Verify(raw string) (*apitoken.Token, error)
+	List(user string) []apitoken.Token
+	Revoke(id, user string) error
+	RevokeMatching(user, profile string) (int, error)
+}
+
+// TokenService manages personal API tokens and authenticates requests that
+// present one. Each token gets its own server session, created on first use
+// and recreated when it expires.
+type TokenService struct {
+	pb.UnimplementedTokenServiceServer
+	tokens   TokenStore
+	sessions *SessionService
+	store    SessionStore
+	users    UserProvider
+
+	mu     sync.Mutex
+	active map[string]string
+}
+
+func NewTokenService(tokens TokenStore, sessions *SessionService, store SessionStore, users UserProvider) *TokenService {
+	return &TokenService{
+		tokens:   tokens,
+		sessions: sessions,
+		store:    store,
+		users:    users,
+		active:   make(map[string]string),
+	}
+}
+
+func (s *TokenService) CreateToken(ctx context.Context, req *pb.CreateTokenRequest) (*pb.CreateTokenResponse, error) {
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "token name is required")
+	}
+	if req.Profile == "" {
+		return nil, status.Error(codes.InvalidArgument, "profile name is required")
+	}
+	if req.TtlSeconds < 0 {
+		return nil, status.Error(codes.InvalidArgument, "ttl must not be negative")
+	}
+
+	profile, err := s.sessions.connectableProfile(req.Profile)
+	if err != nil {
+		return nil, err
+	}
+	if profile.PromptsForCredentials() {
+		return nil, status.Errorf(codes.FailedPrecondition, "profile %s needs database credentials at login and cannot be used with api tokens", req.Profile)
+	}
+
+	user, _ := ctxutil.GetUser(ctx)
+	if s.usersEnabled() && !s.users.CanUseProfile(user, req.Profile) {
+		return nil, status.Errorf(codes.PermissionDenied, "user %s is not allowed to use profile %s", user, req.Profile)
+	}
+
+	raw, token, err := s.tokens.Create(req.Name, user, req.Profile, req.ReadOnly, time.Duration(req.TtlSeconds)*time.Second)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create token: %v", err)
+	}
+
+	return &pb.CreateTokenResponse{
+		Token: raw,
+		Info:  tokenToProto(token),
+	}, nil
+}
+
+func (s *TokenService) ListTokens(ctx context.Context, req *pb.ListTokensRequest) (*pb.ListTokensResponse, error) {
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+
+	user, _ := ctxutil.GetUser(ctx)
+	list := s.tokens.List(user)
+
+	tokens := make([]*pb.ApiToken, 0, len(list))
+	for i := range list {
+		tokens = append(tokens, tokenToProto(&list[i]))
+	}
+
+	return &pb.ListTokensResponse{Tokens: tokens}, nil
+}
+
+func (s *TokenService) RevokeToken(ctx context.Context, req *pb.RevokeTokenRequest) (*pb.RevokeTokenResponse, error) {
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "token id is required")
+	}
+
+	user, _ := ctxutil.GetUser(ctx)
+	if err := s.tokens.Revoke(req.Id, user); err != nil {
+		if errors.Is(err, apitoken.ErrTokenNotFound) {
+			return nil, status.Errorf(codes.NotFound, "token not found: %s", req.Id)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to revoke token: %v", err)
+	}
+
+	s.mu.Lock()
+	if sessionID, ok := s.active[req.Id]; ok {
+		delete(s.active, req.Id)
+		s.store.Delete(sessionID)
+	}
+	s.mu.Unlock()
+
+	return &pb.RevokeTokenResponse{}, nil
+}
+
+// Authenticate resolves an API token to claims equivalent to those of an
+// access token, opening a session for it when needed.
+func (s *TokenService) Authenticate(raw string) (*Claims, error) {
+	if s.tokens == nil {
+		return nil, apitoken.ErrInvalidToken
+	}
+
+	token, err := s.tokens.Verify(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	// A token issued while the server had no users must not bypass
+	// authentication once users are configured.
+	if s.usersEnabled() && (token.User == "" || !s.users.CanUseProfile(token.User, token.Profile)) {
+		return nil, apitoken.ErrInvalidToken
+	}
+
+	sessionID, ok := s.session(token.ID)
+	if !ok {
+		// Connecting can take as long as the driver's timeout, so it runs
+		// without the lock and the result is checked again afterwards.
+		session, err := s.sessions.connect(token.Profile, token.User, nil)
+		if err != nil {
+			return nil, err
+		}
+		if sessionID, err = s.adopt(raw, token.ID, session.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Claims{
+		SessionID: sessionID,
+		Profile:   token.Profile,
+		User:      token.User,
+		Type:      AccessToken,
+		ReadOnly:  token.ReadOnly,
+	}, nil
+}
+
+// session returns the live session opened for the token with id.
+func (s *TokenService) session(id string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessionID, ok := s.active[id]
+	if !ok {
+		return "", false
+	}
+	if _, err := s.store.Get(sessionID); err != nil {
+		return "", false
+	}
+	return sessionID, true
+}
+
+// adopt records sessionID as the session of the token with id and returns
+// the session to use. A session another request opened in the meantime wins,
+// and a token revoked while connecting gets none.
+func (s *TokenService) adopt(raw, id, sessionID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.tokens.Verify(raw); err != nil {
+		s.store.Delete(sessionID)
+		return "", err
+	}
+	if existing, ok := s.active[id]; ok {
+		if _, err := s.store.Get(existing); err == nil {
+			s.store.Delete(sessionID)
+			return existing, nil
+		}
+	}
+	s.active[id] = sessionID
+	return sessionID, nil
+}
+
+func (s *TokenService) available() error {
+	if s.tokens == nil {
+		return status.Error(codes.FailedPrecondition, "api tokens are only available with kassie server")
+	}
+	return nil
+}
+
+func (s *TokenService) usersEnabled() bool {
+	return s.users != nil && s.users.HasUsers()
+}
+
+func tokenToProto(t *apitoken.Token) *pb.ApiToken {
+	info := &pb.ApiToken{
+		Id:        t.ID,
+		Name:      t.Name,
+		User:      t.User,
+		Profile:   t.Profile,
+		ReadOnly:  t.ReadOnly,
+		CreatedAt: t.CreatedAt.Unix(),
+	}
+	if !t.ExpiresAt.IsZero() {
+		info.ExpiresAt = t.ExpiresAt.Unix()
+	}
+	if !t.LastUsedAt.IsZero() {
+		info.LastUsedAt = t.LastUsedAt.Unix()
+	}
+	return info
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/server/apitoken"
+	"github.com/KashifKhn/kassie/internal/shared/config"
+	"github.com/KashifKhn/kassie/internal/shared/ctxutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestTokenService(t *testing.T, users UserProvider) (*TokenService, *mockSessionStore) {
+	t.Helper()
+
+	tokens, err := apitoken.NewStore("")
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	cfg := &mockProfileProvider{
+		profiles: map[string]*config.Profile{
+			"dev":    {Name: "dev", Hosts: []string{"localhost"}, Port: 9042},
+			"prod":   {Name: "prod", Hosts: []string{"prod.example.com"}, Port: 9042},
+			"shared": {Name: "shared", Hosts: []string{"localhost"}, Port: 9042, Auth: &config.AuthConfig{Prompt: true}},
+		},
+	}
+	store := newMockSessionStore()
+	sessions := NewSessionService(cfg, &mockPool{}, store, NewAuthService("test-secret"), users)
+
+	return NewTokenService(tokens, sessions, store, users), store
+}
+
+func TestTokenService_CreateAndAuthenticate(t *testing.T) {
+	svc, store := newTestTokenService(t, nil)
+
+	resp, err := svc.CreateToken(context.Background(), &pb.CreateTokenRequest{Name: "cron", Profile: "dev", ReadOnly: true})
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	if resp.Info.ExpiresAt != 0 {
+		t.Errorf("expected token without ttl to never expire, got %d", resp.Info.ExpiresAt)
+	}
+
+	claims, err := svc.Authenticate(resp.Token)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if claims.Profile != "dev" || !claims.ReadOnly {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if _, err := store.Get(claims.SessionID); err != nil {
+		t.Fatalf("expected a session for the token, got %v", err)
+	}
+
+	again, err := svc.Authenticate(resp.Token)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if again.SessionID != claims.SessionID {
+		t.Error("expected the token session to be reused")
+	}
+
+	store.Delete(claims.SessionID)
+	renewed, err := svc.Authenticate(resp.Token)
+	if err != nil {
+		t.Fatalf("Authenticate() after session expiry error = %v", err)
+	}
+	if renewed.SessionID == claims.SessionID {
+		t.Error("expected a new session once the old one is gone")
+	}
+
+	if _, err := svc.RevokeToken(context.Background(), &pb.RevokeTokenRequest{Id: resp.Info.Id}); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	if _, err := store.Get(renewed.SessionID); err == nil {
+		t.Error("expected revoking to close the token session")
+	}
+	if _, err := svc.Authenticate(resp.Token); err == nil {
+		t.Error("expected revoked token to be rejected")
+	}
+}
+
+func TestTokenService_AdoptAfterConnect(t *testing.T) {
+	svc, store := newTestTokenService(t, nil)
+	profile := &config.Profile{Name: "dev"}
+
+	resp, err := svc.CreateToken(context.Background(), &pb.CreateTokenRequest{Name: "cron", Profile: "dev"})
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+
+	store.Create("first", profile, nil)
+	if got, err := svc.adopt(resp.Token, resp.Info.Id, "first"); err != nil || got != "first" {
+		t.Fatalf("adopt() = %q, %v; want first", got, err)
+	}
+
+	// A second request that connected at the same time uses the first
+	// session and closes its own.
+	store.Create("second", profile, nil)
+	if got, err := svc.adopt(resp.Token, resp.Info.Id, "second"); err != nil || got != "first" {
+		t.Errorf("adopt() = %q, %v; want first", got, err)
+	}
+	if _, err := store.Get("second"); err == nil {
+		t.Error("expected the losing session to be closed")
+	}
+
+	// A token revoked while connecting gets no session.
+	if _, err := svc.RevokeToken(context.Background(), &pb.RevokeTokenRequest{Id: resp.Info.Id}); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	store.Create("third", profile, nil)
+	if _, err := svc.adopt(resp.Token, resp.Info.Id, "third"); err == nil {
+		t.Error("expected adopt() to reject a revoked token")
+	}
+	if _, err := store.Get("third"); err == nil {
+		t.Error("expected the session of a revoked token to be closed")
+	}
+}
+
+func TestTokenService_Errors(t *testing.T) {
+	svc, _ := newTestTokenService(t, nil)
+
+	tests := []struct {
+		name string
+		req  *pb.CreateTokenRequest
+		code codes.Code
+	}{
+		{name: "missing name", req: &pb.CreateTokenRequest{Profile: "dev"}, code: codes.InvalidArgument},
+		{name: "missing profile", req: &pb.CreateTokenRequest{Name: "ci"}, code: codes.InvalidArgument},
+		{name: "unknown profile", req: &pb.CreateTokenRequest{Name: "ci", Profile: "staging"}, code: codes.NotFound},
+		{name: "prompted credentials", req: &pb.CreateTokenRequest{Name: "ci", Profile: "shared"}, code: codes.FailedPrecondition},
+		{name: "negative ttl", req: &pb.CreateTokenRequest{Name: "ci", Profile: "dev", TtlSeconds: -1}, code: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateToken(context.Background(), tt.req)
+			if status.Code(err) != tt.code {
+				t.Errorf("expected %v, got %v", tt.code, err)
+			}
+		})
+	}
+
+	if _, err := svc.RevokeToken(context.Background(), &pb.RevokeTokenRequest{Id: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+}
+
+func TestTokenService_WithUsers(t *testing.T) {
+	users := &mockUserProvider{
+		users: map[string]*config.User{
+			"alice": {Username: "alice", Profiles: []string{"dev"}},
+		},
+	}
+	svc, _ := newTestTokenService(t, users)
+	ctx := ctxutil.WithUser(context.Background(), "alice")
+
+	if _, err := svc.CreateToken(ctx, &pb.CreateTokenRequest{Name: "ci", Profile: "prod"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied for a profile the user cannot use, got %v", err)
+	}
+
+	resp, err := svc.CreateToken(ctx, &pb.CreateTokenRequest{Name: "ci", Profile: "dev"})
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	if resp.Info.User != "alice" {
+		t.Errorf("expected token owned by alice, got %q", resp.Info.User)
+	}
+
+	list, err := svc.ListTokens(context.Background(), &pb.ListTokensRequest{})
+	if err != nil {
+		t.Fatalf("ListTokens() error = %v", err)
+	}
+	if len(list.Tokens) != 0 {
+		t.Errorf("expected other users not to see alice's tokens, got %d", len(list.Tokens))
+	}
+
+	delete(users.users, "alice")
+	if _, err := svc.Authenticate(resp.Token); err == nil {
+		t.Error("expected token of a removed user to be rejected")
+	}
+}
+
+func TestTokenService_AnonymousTokenRejectedOnceUsersExist(t *testing.T) {
+	users := &mockUserProvider{users: map[string]*config.User{}}
+	svc, _ := newTestTokenService(t, users)
+
+	resp, err := svc.CreateToken(context.Background(), &pb.CreateTokenRequest{Name: "legacy", Profile: "dev"})
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+
+	users.users["alice"] = &config.User{Username: "alice", Profiles: []string{config.AllProfiles}}
+	if _, err := svc.Authenticate(resp.Token); err == nil {
+		t.Error("expected token without an owner to be rejected when users are configured")
+	}
+}
+
+func TestTokenService_Unavailable(t *testing.T) {
+	svc := NewTokenService(nil, nil, newMockSessionStore(), nil)
+
+	if _, err := svc.ListTokens(context.Background(), &pb.ListTokensRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition, got %v", err)
+	}
+	if _, err := svc.Authenticate(apitoken.Prefix + "x"); err == nil {
+		t.Error("expected authentication to fail without a token store")
+	}
+}
@@ -0,0 +1,108 @@
+package state
+
+import (
+	"sync"
+	"time"
+)
+
+// RevocationStore remembers revoked tokens by their ID until they would have
+// expired anyway, plus cutoffs that revoke every token a user or profile was
+// issued before a point in time. Entries older than maxAge, the longest token
+// lifetime, can no longer match a valid token and are pruned.
+type RevocationStore struct {
+	mu        sync.RWMutex
+	tokens    map[string]time.Time
+	users     map[string]time.Time
+	profiles  map[string]time.Time
+	maxAge    time.Duration
+	lastPrune time.Time
+}
+
+func NewRevocationStore(maxAge time.Duration) *RevocationStore {
+	return &RevocationStore{
+		tokens:    make(map[string]time.Time),
+		users:     make(map[string]time.Time),
+		profiles:  make(map[string]time.Time),
+		maxAge:    maxAge,
+		lastPrune: time.Now(),
+	}
+}
+
+// Revoke rejects the token with id until expiresAt.
+func (r *RevocationStore) Revoke(id string, expiresAt time.Time) {
+	if id == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[id] = expiresAt
+	r.pruneLocked()
+}
+
+// RevokeUser rejects every token issued to user before cutoff.
+func (r *RevocationStore) RevokeUser(user string, cutoff time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.users[user] = cutoff
+	r.pruneLocked()
+}
+
+// RevokeProfile rejects every token issued for profile before cutoff.
+func (r *RevocationStore) RevokeProfile(profile string, cutoff time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.profiles[profile] = cutoff
+	r.pruneLocked()
+}
+
+func (r *RevocationStore) IsRevoked(id, user, profile string, issuedAt time.Time) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.tokens[id]; ok && id != "" {
+		return true
+	}
+	if cutoff, ok := r.users[user]; ok && user != "" && issuedAt.Before(cutoff) {
+		return true
+	}
+	if cutoff, ok := r.profiles[profile]; ok && issuedAt.Before(cutoff) {
+		return true
+	}
+	return false
+}
+
+func (r *RevocationStore) Count() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.tokens) + len(r.users) + len(r.profiles)
+}
+
+// pruneLocked drops entries that cannot match a live token any more. It runs
+// at most once a minute so frequent refreshes stay cheap.
+func (r *RevocationStore) pruneLocked() {
+	now := time.Now()
+	if now.Sub(r.lastPrune) < time.Minute {
+		return
+	}
+	r.lastPrune = now
+
+	for id, expiresAt := range r.tokens {
+		if now.After(expiresAt) {
+			delete(r.tokens, id)
+		}
+	}
+	for user, cutoff := range r.users {
+		if now.Sub(cutoff) > r.maxAge {
+			delete(r.users, user)
+		}
+	}
+	for profile, cutoff := range r.profiles {
+		if now.Sub(cutoff) > r.maxAge {
+			delete(r.profiles, profile)
+		}
+	}
+}
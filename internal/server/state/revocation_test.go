@@ -0,0 +1,70 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRevocationStore_IsRevoked(t *testing.T) {
+	store := NewRevocationStore(time.Hour)
+	now := time.Now()
+
+	store.Revoke("jti-1", now.Add(time.Minute))
+	store.RevokeUser("alice", now)
+	store.RevokeProfile("prod", now)
+
+	tests := []struct {
+		name     string
+		id       string
+		user     string
+		profile  string
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "revoked id", id: "jti-1", profile: "dev", issuedAt: now.Add(time.Second), want: true},
+		{name: "other id", id: "jti-2", profile: "dev", issuedAt: now.Add(-time.Second), want: false},
+		{name: "user before cutoff", id: "jti-3", user: "alice", profile: "dev", issuedAt: now.Add(-time.Second), want: true},
+		{name: "user after cutoff", id: "jti-4", user: "alice", profile: "dev", issuedAt: now.Add(time.Second), want: false},
+		{name: "other user", id: "jti-5", user: "bob", profile: "dev", issuedAt: now.Add(-time.Second), want: false},
+		{name: "profile before cutoff", id: "jti-6", user: "bob", profile: "prod", issuedAt: now.Add(-time.Second), want: true},
+		{name: "profile after cutoff", id: "jti-7", profile: "prod", issuedAt: now.Add(time.Second), want: false},
+		{name: "empty id", id: "", profile: "dev", issuedAt: now, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := store.IsRevoked(tt.id, tt.user, tt.profile, tt.issuedAt); got != tt.want {
+				t.Errorf("IsRevoked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevocationStore_Prune(t *testing.T) {
+	store := NewRevocationStore(time.Hour)
+	now := time.Now()
+
+	store.Revoke("expired", now.Add(-time.Second))
+	store.Revoke("live", now.Add(time.Hour))
+	store.RevokeUser("old", now.Add(-2*time.Hour))
+	store.RevokeProfile("recent", now)
+
+	if store.Count() != 4 {
+		t.Fatalf("expected pruning to be deferred, got %d entries", store.Count())
+	}
+
+	store.mu.Lock()
+	store.lastPrune = now.Add(-2 * time.Minute)
+	store.pruneLocked()
+	store.mu.Unlock()
+
+	if store.Count() != 2 {
+		t.Errorf("expected 2 entries after pruning, got %d", store.Count())
+	}
+	if !store.IsRevoked("live", "", "", now) {
+		t.Error("expected unexpired revocation to be kept")
+	}
+	if !store.IsRevoked("x", "", "recent", now.Add(-time.Second)) {
+		t.Error("expected recent profile cutoff to be kept")
+	}
+}
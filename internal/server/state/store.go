@@ -2,7 +2,9 @@ package state
 
 import (
 	"errors"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/KashifKhn/kassie/internal/server/db"
@@ -14,13 +16,59 @@ var (
 	ErrSessionExpired  = errors.New("session expired")
 )
 
+// Session is a logged-in client's state. User, RefreshID and LastAccess are
+// guarded by the store: change them with Store.Update and read them from a
+// SessionInfo.
 type Session struct {
 	ID         string
 	Profile    *config.Profile
+	User       string
+	RefreshID  string
 	Connection *db.Session
 	CreatedAt  time.Time
 	LastAccess time.Time
 	Cursors    *CursorStore
+
+	inFlight atomic.Int32
+}
+
+// Track marks a query as running on the session until the returned func is
+// called.
+func (s *Session) Track() func() {
+	s.inFlight.Add(1)
+	return func() { s.inFlight.Add(-1) }
+}
+
+func (s *Session) InFlight() int {
+	return int(s.inFlight.Load())
+}
+
+// SessionInfo is a copy of a session's fields taken under the store's lock.
+type SessionInfo struct {
+	ID         string
+	Profile    *config.Profile
+	User       string
+	RefreshID  string
+	CreatedAt  time.Time
+	LastAccess time.Time
+	InFlight   int
+	Cursors    int
+}
+
+func (s *Session) info() SessionInfo {
+	info := SessionInfo{
+		ID:         s.ID,
+		Profile:    s.Profile,
+		User:       s.User,
+		RefreshID:  s.RefreshID,
+		CreatedAt:  s.CreatedAt,
+		LastAccess: s.LastAccess,
+		InFlight:   s.InFlight(),
+	}
+	if s.Cursors != nil {
+		info.Cursors = s.Cursors.Count()
+	}
+	return info
 }
 
 type Store struct {
@@ -93,6 +141,70 @@ func (s *Store) Delete(id string) {
 	}
 }
 
+// Update calls update with the session under the store's lock.
+func (s *Store) Update(id string, update func(*Session)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, exists := s.sessions[id]
+	if !exists {
+		return ErrSessionNotFound
+	}
+	update(session)
+	return nil
+}
+
+// Info returns a copy of the session without touching its last access time.
+func (s *Store) Info(id string) (SessionInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, exists := s.sessions[id]
+	if !exists {
+		return SessionInfo{}, ErrSessionNotFound
+	}
+	return session.info(), nil
+}
+
+// List returns copies of the live sessions, oldest first, without touching
+// their last access time.
+func (s *Store) List() []SessionInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sessions := make([]SessionInfo, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		if time.Since(session.LastAccess) <= s.ttl {
+			sessions = append(sessions, session.info())
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions
+}
+
+// DeleteMatching closes every session for which match returns true and
+// reports how many were removed.
+func (s *Store) DeleteMatching(match func(*Session) bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for id, session := range s.sessions {
+		if !match(session) {
+			continue
+		}
+		session.Cursors.Stop()
+		if session.Connection != nil {
+			session.Connection.Close()
+		}
+		delete(s.sessions, id)
+		removed++
+	}
+	return removed
+}
+
 func (s *Store) cleanup() {
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
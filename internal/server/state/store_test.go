@@ -118,3 +118,86 @@ func TestStore_CloseAll(t *testing.T) {
 		t.Errorf("expected count 0 after CloseAll, got %d", store.Count())
 	}
 }
+
+func TestStore_DeleteMatching(t *testing.T) {
+	store := NewStore(30 * time.Minute)
+	defer store.Close()
+
+	dev := &config.Profile{Name: "dev"}
+	prod := &config.Profile{Name: "prod"}
+	store.Create("session-1", dev, nil).User = "alice"
+	store.Create("session-2", prod, nil).User = "alice"
+	store.Create("session-3", dev, nil).User = "bob"
+
+	removed := store.DeleteMatching(func(s *Session) bool { return s.User == "alice" })
+	if removed != 2 {
+		t.Errorf("expected 2 sessions removed, got %d", removed)
+	}
+	if store.Count() != 1 {
+		t.Errorf("expected 1 session left, got %d", store.Count())
+	}
+	if _, err := store.Get("session-3"); err != nil {
+		t.Errorf("expected bob's session to remain, got %v", err)
+	}
+}
+
+func TestStore_List(t *testing.T) {
+	store := NewStore(30 * time.Minute)
+	defer store.Close()
+
+	profile := &config.Profile{Name: "dev"}
+	first := store.Create("session-1", profile, nil)
+	store.Create("session-2", profile, nil)
+	stale := store.Create("session-3", profile, nil)
+	stale.LastAccess = time.Now().Add(-time.Hour)
+	first.CreatedAt = time.Now().Add(-time.Minute)
+
+	done := first.Track()
+	sessions := store.List()
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 live sessions, got %d", len(sessions))
+	}
+	if sessions[0].ID != "session-1" {
+		t.Errorf("expected oldest session first, got %s", sessions[0].ID)
+	}
+	if sessions[0].InFlight != 1 {
+		t.Errorf("expected 1 in-flight query, got %d", sessions[0].InFlight)
+	}
+
+	done()
+	if first.InFlight() != 0 {
+		t.Errorf("expected no in-flight queries, got %d", first.InFlight())
+	}
+}
+
+func TestStore_UpdateAndInfo(t *testing.T) {
+	store := NewStore(30 * time.Minute)
+	defer store.Close()
+
+	store.Create("session-1", &config.Profile{Name: "dev"}, nil)
+	before := store.List()
+
+	if err := store.Update("session-1", func(s *Session) {
+		s.User = "alice"
+		s.RefreshID = "refresh-1"
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	info, err := store.Info("session-1")
+	if err != nil {
+		t.Fatalf("Info() error = %v", err)
+	}
+	if info.User != "alice" || info.RefreshID != "refresh-1" {
+		t.Errorf("Info() = %+v", info)
+	}
+	if before[0].User != "" {
+		t.Error("expected List() to return copies")
+	}
+
+	if err := store.Update("missing", func(*Session) {}); err != ErrSessionNotFound {
+		t.Errorf("Update() error = %v, want ErrSessionNotFound", err)
+	}
+	if _, err := store.Info("missing"); err != ErrSessionNotFound {
+		t.Errorf("Info() error = %v, want ErrSessionNotFound", err)
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import "errors"
+
+var ErrInvalidBundle = errors.New("invalid secure connect bundle configuration")
+
+// UsesBundle reports whether the profile connects to DataStax Astra through a
+// secure connect bundle, which supplies the hosts, port and TLS settings.
+func (p *Profile) UsesBundle() bool {
+	return p.SecureConnectBundle != ""
+}
+
+// validateBundle checks a bundle profile. Its auth holds the Astra client ID
+// and secret as username and password.
+func (p *Profile) validateBundle() error {
+	if len(p.Hosts) > 0 {
+		return fieldError("hosts", ErrInvalidBundle, "must not be set with secure_connect_bundle")
+	}
+	if p.Port != 0 {
+		return fieldError("port", ErrInvalidBundle, "must not be set with secure_connect_bundle")
+	}
+	if p.SSL != nil {
+		return fieldError("ssl", ErrInvalidBundle, "must not be set with secure_connect_bundle")
+	}
+	if p.Tunnel != nil || p.SOCKS5 != nil {
+		return fieldError("secure_connect_bundle", ErrInvalidBundle, "cannot be combined with tunnel or socks5")
+	}
+	if p.Auth == nil || p.Auth.Username == "" {
+		return fieldError("auth.username", ErrInvalidBundle, "the Astra client ID is required")
+	}
+	if !p.Auth.Prompt && p.Auth.Password == "" {
+		return fieldError("auth.password", ErrInvalidBundle, "the Astra client secret is required")
+	}
+	return nil
+}
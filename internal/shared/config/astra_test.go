@@ -0,0 +1,56 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProfileValidateBundle(t *testing.T) {
+	bundle := func(mod func(*Profile)) Profile {
+		p := Profile{
+			Name:                "astra",
+			SecureConnectBundle: "~/secure-connect-prod.zip",
+			Auth:                &AuthConfig{Username: "client-id", Password: "secret"},
+		}
+		if mod != nil {
+			mod(&p)
+		}
+		return p
+	}
+
+	tests := []struct {
+		name    string
+		profile Profile
+		field   string
+	}{
+		{name: "bundle", profile: bundle(nil)},
+		{name: "prompted secret", profile: bundle(func(p *Profile) { p.Auth.Password = ""; p.Auth.Prompt = true })},
+		{name: "with keyspace", profile: bundle(func(p *Profile) { p.Keyspace = "app" })},
+		{name: "hosts", profile: bundle(func(p *Profile) { p.Hosts = []string{"127.0.0.1"} }), field: "hosts"},
+		{name: "port", profile: bundle(func(p *Profile) { p.Port = 9042 }), field: "port"},
+		{name: "ssl", profile: bundle(func(p *Profile) { p.SSL = &SSLConfig{Enabled: true} }), field: "ssl"},
+		{name: "tunnel", profile: bundle(func(p *Profile) { p.SOCKS5 = &SOCKS5Config{Address: "proxy:1080"} }), field: "secure_connect_bundle"},
+		{name: "no auth", profile: bundle(func(p *Profile) { p.Auth = nil }), field: "auth.username"},
+		{name: "no secret", profile: bundle(func(p *Profile) { p.Auth.Password = "" }), field: "auth.password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.profile.Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) || fieldErr.Field != tt.field || fieldErr.Profile != "astra" {
+				t.Fatalf("expected error at %s, got %v", tt.field, err)
+			}
+			if !errors.Is(err, ErrInvalidBundle) {
+				t.Errorf("expected ErrInvalidBundle, got %v", err)
+			}
+		})
+	}
+}
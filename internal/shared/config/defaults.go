@@ -4,6 +4,7 @@ import "time"
 
 const (
 	DefaultHost         = "127.0.0.1"
+	DefaultCQLPort      = 9042
 	DefaultServerHost   = "0.0.0.0"
 	DefaultGRPCPort     = 50051
 	DefaultHTTPPort     = 8080
@@ -17,4 +18,9 @@ const (
 	DefaultWriteTimeout = 15 * time.Second
 	DefaultIdleTimeout  = 60 * time.Second
 	DefaultShutdownTime = 10 * time.Second
+
+	DefaultConfigPollInterval = 2 * time.Second
+
+	DefaultSecretCacheTTL       = 5 * time.Minute
+	DefaultSecretCommandTimeout = 30 * time.Second
 )
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var ErrInvalidDisplay = errors.New("invalid display setting")
+
+// Environments a profile can be tagged with. Clients tint prod profiles red
+// and ask for the profile name to be typed before changing one.
+const (
+	EnvironmentDev     = "dev"
+	EnvironmentStaging = "staging"
+	EnvironmentProd    = "prod"
+)
+
+var environments = []string{EnvironmentDev, EnvironmentStaging, EnvironmentProd}
+
+var environmentColors = map[string]string{
+	EnvironmentDev:     "#2e7d32",
+	EnvironmentStaging: "#f9a825",
+	EnvironmentProd:    "#c62828",
+}
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// DisplayColor is the color clients tint the profile with: its own color,
+// else its environment's, else none.
+func (p *Profile) DisplayColor() string {
+	if p.Color != "" {
+		return p.Color
+	}
+	return environmentColors[p.Environment]
+}
+
+// OpenKeyspace is the keyspace clients open on connecting: default_keyspace,
+// else the session keyspace.
+func (p *Profile) OpenKeyspace() string {
+	if p.DefaultKeyspace != "" {
+		return p.DefaultKeyspace
+	}
+	return p.Keyspace
+}
+
+func (p *Profile) validateDisplay() error {
+	if p.Environment != "" && !slices.Contains(environments, p.Environment) {
+		return fieldError("environment", ErrInvalidDisplay, "%q is not one of %s", p.Environment, strings.Join(environments, ", "))
+	}
+	if p.Color != "" && !colorPattern.MatchString(p.Color) {
+		return fieldError("color", ErrInvalidDisplay, "%q is not a #rrggbb color", p.Color)
+	}
+	if p.PageSize < 0 || p.PageSize > DefaultMaxPageSize {
+		return fieldError("page_size", ErrInvalidPageSize, "%d is outside 1-%d", p.PageSize, DefaultMaxPageSize)
+	}
+	if p.DefaultTable != "" && p.OpenKeyspace() == "" && !p.Abstract {
+		return fieldError("default_table", ErrInvalidDisplay, "needs default_keyspace or keyspace")
+	}
+	return nil
+}
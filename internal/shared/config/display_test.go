@@ -0,0 +1,94 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProfileValidateDisplay(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		wantErr error
+	}{
+		{name: "none", profile: Profile{}},
+		{name: "all set", profile: Profile{Environment: EnvironmentProd, Color: "#A0b1C2", PageSize: 500, DefaultKeyspace: "app", DefaultTable: "users"}},
+		{name: "table in session keyspace", profile: Profile{Keyspace: "app", DefaultTable: "users"}},
+		{name: "unknown environment", profile: Profile{Environment: "production"}, wantErr: ErrInvalidDisplay},
+		{name: "named color", profile: Profile{Color: "red"}, wantErr: ErrInvalidDisplay},
+		{name: "short color", profile: Profile{Color: "#f00"}, wantErr: ErrInvalidDisplay},
+		{name: "page size too large", profile: Profile{PageSize: DefaultMaxPageSize + 1}, wantErr: ErrInvalidPageSize},
+		{name: "negative page size", profile: Profile{PageSize: -1}, wantErr: ErrInvalidPageSize},
+		{name: "table without keyspace", profile: Profile{DefaultTable: "users"}, wantErr: ErrInvalidDisplay},
+		{name: "abstract table without keyspace", profile: Profile{DefaultTable: "users", Abstract: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.profile.Name = "test"
+			tt.profile.Hosts = []string{"localhost"}
+			tt.profile.Port = 9042
+			err := tt.profile.Validate()
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProfileDisplayColor(t *testing.T) {
+	tests := []struct {
+		profile Profile
+		want    string
+	}{
+		{profile: Profile{}, want: ""},
+		{profile: Profile{Environment: EnvironmentDev}, want: "#2e7d32"},
+		{profile: Profile{Environment: EnvironmentProd}, want: "#c62828"},
+		{profile: Profile{Environment: EnvironmentProd, Color: "#000080"}, want: "#000080"},
+		{profile: Profile{Color: "#000080"}, want: "#000080"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.profile.DisplayColor(); got != tt.want {
+			t.Errorf("DisplayColor() of %+v = %q, want %q", tt.profile, got, tt.want)
+		}
+	}
+}
+
+func TestProfilePageSizeDefaults(t *testing.T) {
+	cfg := &Config{
+		Defaults: DefaultConfig{PageSize: 250},
+		Profiles: []Profile{
+			{Name: "base", Abstract: true, Environment: EnvironmentProd, PageSize: 20},
+			{Name: "prod", Extends: "base", Hosts: []string{"db"}, Port: 9042},
+			{Name: "dev", Hosts: []string{"localhost"}, Port: 9042},
+		},
+	}
+	if err := cfg.ResolveInheritance(); err != nil {
+		t.Fatalf("ResolveInheritance() error = %v", err)
+	}
+	cfg.SetDefaults()
+
+	_, prod := cfg.FindProfile("prod")
+	if prod.Environment != EnvironmentProd || prod.PageSize != 20 {
+		t.Errorf("prod = environment %q page size %d, want them inherited", prod.Environment, prod.PageSize)
+	}
+	if _, dev := cfg.FindProfile("dev"); dev.PageSize != 250 {
+		t.Errorf("dev page size = %d, want defaults.page_size", dev.PageSize)
+	}
+
+	if err := ApplyOverrides(cfg, &Override{PageSize: 30}); err != nil {
+		t.Fatalf("ApplyOverrides() error = %v", err)
+	}
+	for _, p := range cfg.Profiles {
+		if p.PageSize != 30 {
+			t.Errorf("%s page size = %d, want the override", p.Name, p.PageSize)
+		}
+	}
+}
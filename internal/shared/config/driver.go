@@ -0,0 +1,236 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidDriver = errors.New("invalid driver setting")
+
+// Retry policies accepted in driver.retry.policy.
+const (
+	RetryPolicySimple      = "simple"
+	RetryPolicyExponential = "exponential"
+)
+
+// Compression algorithms accepted in driver.compression.
+const (
+	CompressionNone   = "none"
+	CompressionSnappy = "snappy"
+	CompressionLZ4    = "lz4"
+)
+
+const (
+	maxRetries               = 10
+	maxSpeculativeAttempts   = 10
+	minDriverTimeoutMs       = 100
+	maxDriverTimeoutMs       = 300000
+	minProtocolVersion       = 3
+	maxProtocolVersion       = 5
+	defaultRetryMinBackoffMs = 100
+	defaultRetryMaxBackoffMs = 10000
+)
+
+var consistencyLevels = []string{
+	"ANY", "ONE", "TWO", "THREE", "QUORUM", "ALL",
+	"LOCAL_QUORUM", "EACH_QUORUM", "LOCAL_ONE",
+}
+
+// DriverConfig tunes the cluster driver for a profile. Zero values keep the
+// driver defaults.
+type DriverConfig struct {
+	LocalDC                  string             `json:"local_dc,omitempty"`
+	DisableTokenAware        bool               `json:"disable_token_aware,omitempty"`
+	Consistency              string             `json:"consistency,omitempty"`
+	ConnectTimeoutMs         int                `json:"connect_timeout_ms,omitempty"`
+	TimeoutMs                int                `json:"timeout_ms,omitempty"`
+	ProtocolVersion          int                `json:"protocol_version,omitempty"`
+	Compression              string             `json:"compression,omitempty"`
+	DisableInitialHostLookup bool               `json:"disable_initial_host_lookup,omitempty"`
+	Retry                    *RetryConfig       `json:"retry,omitempty"`
+	SpeculativeExecution     *SpeculativeConfig `json:"speculative_execution,omitempty"`
+}
+
+type RetryConfig struct {
+	Policy       string `json:"policy"`
+	MaxRetries   int    `json:"max_retries"`
+	MinBackoffMs int    `json:"min_backoff_ms,omitempty"`
+	MaxBackoffMs int    `json:"max_backoff_ms,omitempty"`
+}
+
+// SpeculativeConfig sends up to Attempts extra copies of a read, DelayMs
+// apart, when the first host is slow to answer.
+type SpeculativeConfig struct {
+	Attempts int `json:"attempts"`
+	DelayMs  int `json:"delay_ms"`
+}
+
+// FieldError reports an invalid value at a config key, such as
+// driver.retry.max_retries in a profile or defaults.page_size.
+type FieldError struct {
+	Profile string
+	// Extends lists the profiles Profile inherits from, nearest first, since
+	// the bad value may come from any of them.
+	Extends []string
+	Field   string
+	Err     error
+}
+
+func (e *FieldError) Error() string {
+	if e.Profile == "" {
+		return fmt.Sprintf("%s: %v", e.Field, e.Err)
+	}
+	if len(e.Extends) > 0 {
+		return fmt.Sprintf("profile %s (extends %s): %s: %v", e.Profile, strings.Join(e.Extends, " -> "), e.Field, e.Err)
+	}
+	return fmt.Sprintf("profile %s: %s: %v", e.Profile, e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+func fieldError(field string, sentinel error, format string, args ...interface{}) error {
+	return &FieldError{
+		Field: field,
+		Err:   fmt.Errorf("%w: %s", sentinel, fmt.Sprintf(format, args...)),
+	}
+}
+
+func driverError(field, format string, args ...interface{}) error {
+	return fieldError("driver."+field, ErrInvalidDriver, format, args...)
+}
+
+func (d *DriverConfig) Validate() error {
+	if d.LocalDC != strings.TrimSpace(d.LocalDC) {
+		return driverError("local_dc", "must not have surrounding spaces")
+	}
+	if d.Consistency != "" && !validConsistency(d.Consistency) {
+		return driverError("consistency", "unknown level %q (want one of %s)", d.Consistency, strings.Join(consistencyLevels, ", "))
+	}
+	if err := validateDriverTimeout("connect_timeout_ms", d.ConnectTimeoutMs); err != nil {
+		return err
+	}
+	if err := validateDriverTimeout("timeout_ms", d.TimeoutMs); err != nil {
+		return err
+	}
+	if d.ProtocolVersion != 0 && (d.ProtocolVersion < minProtocolVersion || d.ProtocolVersion > maxProtocolVersion) {
+		return driverError("protocol_version", "must be between %d and %d, got %d", minProtocolVersion, maxProtocolVersion, d.ProtocolVersion)
+	}
+	switch d.Compression {
+	case "", CompressionNone, CompressionSnappy, CompressionLZ4:
+	default:
+		return driverError("compression", "unknown algorithm %q (want none, snappy or lz4)", d.Compression)
+	}
+
+	if r := d.Retry; r != nil {
+		switch r.Policy {
+		case RetryPolicySimple, RetryPolicyExponential:
+		default:
+			return driverError("retry.policy", "unknown policy %q (want simple or exponential)", r.Policy)
+		}
+		if r.MaxRetries < 0 || r.MaxRetries > maxRetries {
+			return driverError("retry.max_retries", "must be between 0 and %d, got %d", maxRetries, r.MaxRetries)
+		}
+		if r.MinBackoffMs < 0 {
+			return driverError("retry.min_backoff_ms", "must not be negative")
+		}
+		if r.MaxBackoffMs < 0 {
+			return driverError("retry.max_backoff_ms", "must not be negative")
+		}
+		if r.GetMaxBackoffMs() < r.GetMinBackoffMs() {
+			return driverError("retry.max_backoff_ms", "must not be less than min_backoff_ms (%d)", r.GetMinBackoffMs())
+		}
+	}
+
+	if s := d.SpeculativeExecution; s != nil {
+		if s.Attempts < 1 || s.Attempts > maxSpeculativeAttempts {
+			return driverError("speculative_execution.attempts", "must be between 1 and %d, got %d", maxSpeculativeAttempts, s.Attempts)
+		}
+		if s.DelayMs < 1 {
+			return driverError("speculative_execution.delay_ms", "must be positive")
+		}
+	}
+
+	return nil
+}
+
+func validateDriverTimeout(field string, ms int) error {
+	if ms != 0 && (ms < minDriverTimeoutMs || ms > maxDriverTimeoutMs) {
+		return driverError(field, "must be between %d and %d, got %d", minDriverTimeoutMs, maxDriverTimeoutMs, ms)
+	}
+	return nil
+}
+
+func validConsistency(level string) bool {
+	for _, l := range consistencyLevels {
+		if strings.EqualFold(level, l) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RetryConfig) GetMinBackoffMs() int {
+	if r.MinBackoffMs == 0 {
+		return defaultRetryMinBackoffMs
+	}
+	return r.MinBackoffMs
+}
+
+func (r *RetryConfig) GetMaxBackoffMs() int {
+	if r.MaxBackoffMs == 0 {
+		return defaultRetryMaxBackoffMs
+	}
+	return r.MaxBackoffMs
+}
+
+func (d *DriverConfig) Clone() *DriverConfig {
+	clone := *d
+	if d.Retry != nil {
+		retry := *d.Retry
+		clone.Retry = &retry
+	}
+	if d.SpeculativeExecution != nil {
+		spec := *d.SpeculativeExecution
+		clone.SpeculativeExecution = &spec
+	}
+	return &clone
+}
+
+// MergeWith overlays the non-zero settings of override.
+func (d *DriverConfig) MergeWith(override *DriverConfig) {
+	if override.LocalDC != "" {
+		d.LocalDC = override.LocalDC
+	}
+	if override.DisableTokenAware {
+		d.DisableTokenAware = true
+	}
+	if override.Consistency != "" {
+		d.Consistency = override.Consistency
+	}
+	if override.ConnectTimeoutMs != 0 {
+		d.ConnectTimeoutMs = override.ConnectTimeoutMs
+	}
+	if override.TimeoutMs != 0 {
+		d.TimeoutMs = override.TimeoutMs
+	}
+	if override.ProtocolVersion != 0 {
+		d.ProtocolVersion = override.ProtocolVersion
+	}
+	if override.Compression != "" {
+		d.Compression = override.Compression
+	}
+	if override.DisableInitialHostLookup {
+		d.DisableInitialHostLookup = true
+	}
+	if override.Retry != nil {
+		retry := *override.Retry
+		d.Retry = &retry
+	}
+	if override.SpeculativeExecution != nil {
+		spec := *override.SpeculativeExecution
+		d.SpeculativeExecution = &spec
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDriverConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver DriverConfig
+		field  string
+	}{
+		{name: "empty", driver: DriverConfig{}},
+		{
+			name: "full",
+			driver: DriverConfig{
+				LocalDC:              "dc1",
+				Consistency:          "local_quorum",
+				ConnectTimeoutMs:     2000,
+				TimeoutMs:            5000,
+				ProtocolVersion:      4,
+				Compression:          CompressionLZ4,
+				Retry:                &RetryConfig{Policy: RetryPolicyExponential, MaxRetries: 3, MinBackoffMs: 50, MaxBackoffMs: 2000},
+				SpeculativeExecution: &SpeculativeConfig{Attempts: 2, DelayMs: 100},
+			},
+		},
+		{name: "padded local dc", driver: DriverConfig{LocalDC: " dc1"}, field: "driver.local_dc"},
+		{name: "unknown consistency", driver: DriverConfig{Consistency: "MOST"}, field: "driver.consistency"},
+		{name: "connect timeout too small", driver: DriverConfig{ConnectTimeoutMs: 10}, field: "driver.connect_timeout_ms"},
+		{name: "negative timeout", driver: DriverConfig{TimeoutMs: -1}, field: "driver.timeout_ms"},
+		{name: "protocol too old", driver: DriverConfig{ProtocolVersion: 2}, field: "driver.protocol_version"},
+		{name: "unknown compression", driver: DriverConfig{Compression: "zstd"}, field: "driver.compression"},
+		{name: "unknown retry policy", driver: DriverConfig{Retry: &RetryConfig{Policy: "forever"}}, field: "driver.retry.policy"},
+		{name: "too many retries", driver: DriverConfig{Retry: &RetryConfig{Policy: RetryPolicySimple, MaxRetries: 50}}, field: "driver.retry.max_retries"},
+		{name: "negative backoff", driver: DriverConfig{Retry: &RetryConfig{Policy: RetryPolicyExponential, MinBackoffMs: -5}}, field: "driver.retry.min_backoff_ms"},
+		{name: "inverted backoff", driver: DriverConfig{Retry: &RetryConfig{Policy: RetryPolicyExponential, MinBackoffMs: 500, MaxBackoffMs: 100}}, field: "driver.retry.max_backoff_ms"},
+		{name: "no speculative attempts", driver: DriverConfig{SpeculativeExecution: &SpeculativeConfig{DelayMs: 100}}, field: "driver.speculative_execution.attempts"},
+		{name: "no speculative delay", driver: DriverConfig{SpeculativeExecution: &SpeculativeConfig{Attempts: 1}}, field: "driver.speculative_execution.delay_ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.driver.Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("expected a FieldError, got %v", err)
+			}
+			if fieldErr.Field != tt.field {
+				t.Errorf("Field = %q, want %q", fieldErr.Field, tt.field)
+			}
+			if !errors.Is(err, ErrInvalidDriver) {
+				t.Errorf("expected ErrInvalidDriver, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProfileValidateDriver(t *testing.T) {
+	p := Profile{
+		Name:   "prod",
+		Hosts:  []string{"localhost"},
+		Port:   9042,
+		Driver: &DriverConfig{Compression: "zstd"},
+	}
+
+	err := p.Validate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.HasPrefix(err.Error(), "profile prod: driver.compression: ") {
+		t.Errorf("expected error to point at the key, got %q", err)
+	}
+}
+
+func TestDriverConfigMergeWith(t *testing.T) {
+	base := &DriverConfig{
+		LocalDC:     "dc1",
+		Consistency: "QUORUM",
+		Retry:       &RetryConfig{Policy: RetryPolicySimple, MaxRetries: 1},
+	}
+	clone := base.Clone()
+
+	base.MergeWith(&DriverConfig{
+		Consistency: "LOCAL_ONE",
+		Compression: CompressionSnappy,
+		Retry:       &RetryConfig{Policy: RetryPolicyExponential, MaxRetries: 3},
+	})
+
+	if base.LocalDC != "dc1" || base.Consistency != "LOCAL_ONE" || base.Compression != CompressionSnappy {
+		t.Errorf("unexpected merge result %+v", base)
+	}
+	if base.Retry.Policy != RetryPolicyExponential || base.Retry.MaxRetries != 3 {
+		t.Errorf("expected retry to be replaced, got %+v", base.Retry)
+	}
+	if clone.Consistency != "QUORUM" || clone.Retry.MaxRetries != 1 {
+		t.Error("clone must not share state with the original")
+	}
+}
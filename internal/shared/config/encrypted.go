@@ -0,0 +1,199 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"filippo.io/age"
+)
+
+var ErrDecryptSecret = errors.New("cannot decrypt secret")
+
+const encryptedPrefix = "enc:"
+
+// SecretDecrypter decrypts enc: values: base64 age files encrypted to a
+// passphrase or to an age identity. The passphrase comes from
+// KASSIE_CONFIG_PASSPHRASE or Prompt, and is asked for at most once per
+// process. Identities are read from KASSIE_AGE_IDENTITY, or
+// DefaultIdentityPath.
+type SecretDecrypter struct {
+	mu sync.Mutex
+	// Prompt asks for the passphrase. Without it, passphrase-encrypted
+	// values need KASSIE_CONFIG_PASSPHRASE.
+	Prompt     func() (string, error)
+	passphrase string
+	identities []age.Identity
+	plain      map[string]string
+}
+
+func NewSecretDecrypter() *SecretDecrypter {
+	return &SecretDecrypter{plain: make(map[string]string)}
+}
+
+// DefaultSecretDecrypter decrypts the enc: values in configs as they load.
+var DefaultSecretDecrypter = NewSecretDecrypter()
+
+// IsEncrypted reports whether value is an enc: value.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// DefaultIdentityPath is the age identity file next to the user config.
+func DefaultIdentityPath() string {
+	return filepath.Join(filepath.Dir(NewLoader().primaryPath), "age-identity.txt")
+}
+
+// IdentityPath is KASSIE_AGE_IDENTITY, or DefaultIdentityPath.
+func IdentityPath() string {
+	if path := os.Getenv("KASSIE_AGE_IDENTITY"); path != "" {
+		return path
+	}
+	return DefaultIdentityPath()
+}
+
+// EncryptSecret returns the enc: value holding secret for recipients.
+func EncryptSecret(secret string, recipients ...age.Recipient) (string, error) {
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipients...)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.WriteString(w, secret); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// Decrypt returns value unchanged unless it is an enc: value. Decrypted
+// values are kept for the life of the process, so reloads do not ask again.
+func (d *SecretDecrypter) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if plain, ok := d.plain[value]; ok {
+		return plain, nil
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("%w: not base64", ErrDecryptSecret)
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(ciphertext), passphraseIdentity{d}, fileIdentity{d})
+	if errors.Is(err, ErrDecryptSecret) {
+		return "", err
+	}
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) && noMatch.StanzaTypes[0] == "scrypt" {
+			// Let the next load ask again.
+			d.passphrase = ""
+		}
+		return "", fmt.Errorf("%w: %v", ErrDecryptSecret, err)
+	}
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDecryptSecret, err)
+	}
+	d.plain[value] = string(plain)
+	return string(plain), nil
+}
+
+// passphraseIdentity and fileIdentity get the passphrase or read the
+// identity file only once a value turns out to need them.
+type passphraseIdentity struct{ d *SecretDecrypter }
+
+func (i passphraseIdentity) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	if stanzas[0].Type != "scrypt" {
+		return nil, age.ErrIncorrectIdentity
+	}
+	passphrase, err := i.d.getPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	id, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return id.Unwrap(stanzas)
+}
+
+type fileIdentity struct{ d *SecretDecrypter }
+
+func (i fileIdentity) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	if stanzas[0].Type == "scrypt" {
+		return nil, age.ErrIncorrectIdentity
+	}
+	ids, err := i.d.getIdentities()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		fileKey, err := id.Unwrap(stanzas)
+		if errors.Is(err, age.ErrIncorrectIdentity) {
+			continue
+		}
+		return fileKey, err
+	}
+	return nil, age.ErrIncorrectIdentity
+}
+
+func (d *SecretDecrypter) getPassphrase() (string, error) {
+	if d.passphrase != "" {
+		return d.passphrase, nil
+	}
+	passphrase := os.Getenv("KASSIE_CONFIG_PASSPHRASE")
+	if passphrase == "" && d.Prompt != nil {
+		var err error
+		if passphrase, err = d.Prompt(); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrDecryptSecret, err)
+		}
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("%w: encrypted with a passphrase; set KASSIE_CONFIG_PASSPHRASE", ErrDecryptSecret)
+	}
+	d.passphrase = passphrase
+	return passphrase, nil
+}
+
+func (d *SecretDecrypter) getIdentities() ([]age.Identity, error) {
+	if d.identities == nil {
+		path := IdentityPath()
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: encrypted to an age identity: %v", ErrDecryptSecret, err)
+		}
+		defer f.Close()
+		ids, err := age.ParseIdentities(f)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrDecryptSecret, path, err)
+		}
+		d.identities = ids
+	}
+	return d.identities, nil
+}
+
+// decryptField decrypts value in place with DefaultSecretDecrypter.
+func decryptField(value *string, name string) error {
+	if !IsEncrypted(*value) {
+		return nil
+	}
+	plain, err := DefaultSecretDecrypter.Decrypt(*value)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", name, err)
+	}
+	*value = plain
+	return nil
+}
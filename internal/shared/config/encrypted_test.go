@@ -0,0 +1,145 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func encryptForTest(t *testing.T, secret string, recipient age.Recipient) string {
+	t.Helper()
+	value, err := EncryptSecret(secret, recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(value) {
+		t.Fatalf("EncryptSecret() = %q, want an enc: value", value)
+	}
+	return value
+}
+
+func TestSecretDecrypterPassphrase(t *testing.T) {
+	recipient, err := age.NewScryptRecipient("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient.SetWorkFactor(10)
+	value := encryptForTest(t, "s3cret", recipient)
+
+	tests := []struct {
+		name    string
+		env     string
+		prompt  []string
+		want    string
+		wantErr bool
+	}{
+		{name: "environment", env: "correct horse", want: "s3cret"},
+		{name: "prompt", prompt: []string{"correct horse"}, want: "s3cret"},
+		{name: "wrong passphrase", env: "battery staple", wantErr: true},
+		{name: "no passphrase", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("KASSIE_CONFIG_PASSPHRASE", tt.env)
+			d := NewSecretDecrypter()
+			asked := 0
+			if tt.prompt != nil {
+				d.Prompt = func() (string, error) {
+					asked++
+					return tt.prompt[0], nil
+				}
+			}
+
+			got, err := d.Decrypt(value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrDecryptSecret) {
+					t.Fatalf("Decrypt() error = %v, want %v", err, ErrDecryptSecret)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decrypt() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Decrypt() = %q, want %q", got, tt.want)
+			}
+
+			other := encryptForTest(t, "other", recipient)
+			if got, err := d.Decrypt(other); err != nil || got != "other" {
+				t.Errorf("Decrypt() = %q, %v, want %q", got, err, "other")
+			}
+			if asked > 1 {
+				t.Errorf("prompted %d times, want at most once", asked)
+			}
+		})
+	}
+}
+
+func TestSecretDecrypterIdentity(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "age-identity.txt")
+	if err := os.WriteFile(path, []byte(id.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KASSIE_AGE_IDENTITY", path)
+	t.Setenv("KASSIE_CONFIG_PASSPHRASE", "")
+
+	d := NewSecretDecrypter()
+	d.Prompt = func() (string, error) {
+		t.Error("prompted for a passphrase")
+		return "", nil
+	}
+	got, err := d.Decrypt(encryptForTest(t, "s3cret", id.Recipient()))
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if got != "s3cret" {
+		t.Errorf("Decrypt() = %q, want %q", got, "s3cret")
+	}
+
+	stranger, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Decrypt(encryptForTest(t, "s3cret", stranger.Recipient())); !errors.Is(err, ErrDecryptSecret) {
+		t.Errorf("Decrypt() error = %v, want %v", err, ErrDecryptSecret)
+	}
+	if got, err := d.Decrypt("plain"); err != nil || got != "plain" {
+		t.Errorf("Decrypt() = %q, %v, want the value unchanged", got, err)
+	}
+	if _, err := d.Decrypt("enc:not base64!"); !errors.Is(err, ErrDecryptSecret) {
+		t.Errorf("Decrypt() error = %v, want %v", err, ErrDecryptSecret)
+	}
+}
+
+func TestInterpolateProfileDecryptsSecrets(t *testing.T) {
+	recipient, err := age.NewScryptRecipient("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient.SetWorkFactor(10)
+	value := encryptForTest(t, "s3cret", recipient)
+	t.Setenv("KASSIE_CONFIG_PASSPHRASE", "correct horse")
+
+	cfg := &Config{Profiles: []Profile{{
+		Name:  "prod",
+		Hosts: []string{"db"},
+		Auth:  &AuthConfig{Username: "app", Password: value},
+	}}}
+	if masked := MaskSecrets(cfg); masked.Profiles[0].Auth.Password != value {
+		t.Errorf("MaskSecrets() password = %q, want the enc: value kept", masked.Profiles[0].Auth.Password)
+	}
+	if err := InterpolateProfile(&cfg.Profiles[0]); err != nil {
+		t.Fatalf("InterpolateProfile() error = %v", err)
+	}
+	if got := cfg.Profiles[0].Auth.Password; got != "s3cret" {
+		t.Errorf("password = %q, want %q", got, "s3cret")
+	}
+}
@@ -105,6 +105,46 @@ func InterpolateProfile(profile *Profile) error {
 		}
 	}
 
+	if err := interpolateField(&profile.SecureConnectBundle, "secure connect bundle"); err != nil {
+		return err
+	}
+
+	if t := profile.Tunnel; t != nil {
+		for name, field := range map[string]*string{
+			"tunnel key path":       &t.KeyPath,
+			"tunnel key passphrase": &t.KeyPassphrase,
+			"tunnel known hosts":    &t.KnownHosts,
+		} {
+			if err := interpolateField(field, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if profile.SOCKS5 != nil {
+		if err := interpolateField(&profile.SOCKS5.Password, "socks5 password"); err != nil {
+			return err
+		}
+	}
+
+	for _, secret := range profile.secretFields() {
+		if err := decryptField(secret.value, secret.field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func interpolateField(value *string, name string) error {
+	if !strings.Contains(*value, "${") {
+		return nil
+	}
+	interpolated, err := InterpolateEnvVars(*value)
+	if err != nil {
+		return fmt.Errorf("failed to interpolate %s: %w", name, err)
+	}
+	*value = interpolated
 	return nil
 }
 
@@ -114,5 +154,19 @@ func InterpolateConfig(config *Config) error {
 			return fmt.Errorf("failed to interpolate profile %s: %w", config.Profiles[i].Name, err)
 		}
 	}
+
+	if config.Server != nil && config.Server.OIDC != nil && strings.Contains(config.Server.OIDC.ClientSecret, "${") {
+		interpolated, err := InterpolateEnvVars(config.Server.OIDC.ClientSecret)
+		if err != nil {
+			return fmt.Errorf("failed to interpolate oidc client secret: %w", err)
+		}
+		config.Server.OIDC.ClientSecret = interpolated
+	}
+	if config.Server != nil && config.Server.OIDC != nil {
+		if err := decryptField(&config.Server.OIDC.ClientSecret, "server.oidc.client_secret"); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrCircularExtends = errors.New("circular profile inheritance")
+	ErrAbstractProfile = errors.New("profile is abstract")
+)
+
+const maxExtendsDepth = 10
+
+// ResolveInheritance fills in each profile that extends another with the
+// settings it inherits, merging the profile over its resolved parent with
+// MergeWith. Parents may themselves extend other profiles.
+func (c *Config) ResolveInheritance() error {
+	resolved := make(map[string]*Profile, len(c.Profiles))
+	for i := range c.Profiles {
+		p, err := c.resolveProfile(c.Profiles[i].Name, resolved, make(map[string]bool), 0)
+		if err != nil {
+			return err
+		}
+		c.Profiles[i] = *p
+	}
+	return nil
+}
+
+func (c *Config) resolveProfile(name string, resolved map[string]*Profile, visited map[string]bool, depth int) (*Profile, error) {
+	if p, ok := resolved[name]; ok {
+		return p.Clone(), nil
+	}
+
+	_, p := c.FindProfile(name)
+	if p == nil {
+		return nil, ErrProfileNotFound
+	}
+	if p.Extends == "" {
+		resolved[name] = p.Clone()
+		return p.Clone(), nil
+	}
+
+	if visited[name] || depth > maxExtendsDepth {
+		return nil, &FieldError{Profile: name, Field: "extends", Err: fmt.Errorf("%w: %s", ErrCircularExtends, strings.Join(append([]string{name}, c.ExtendsChain(name)...), " -> "))}
+	}
+	visited[name] = true
+
+	if idx, _ := c.FindProfile(p.Extends); idx == -1 {
+		return nil, &FieldError{Profile: name, Field: "extends", Err: fmt.Errorf("%w: %s", ErrProfileNotFound, p.Extends)}
+	}
+	parent, err := c.resolveProfile(p.Extends, resolved, visited, depth+1)
+	if err != nil {
+		return nil, err
+	}
+
+	parent.Name = name
+	if err := parent.MergeWith(p); err != nil {
+		return nil, err
+	}
+	parent.Extends = p.Extends
+	parent.Abstract = p.Abstract
+
+	resolved[name] = parent.Clone()
+	return parent, nil
+}
+
+// ExtendsChain lists the profiles name inherits from, nearest first. It
+// stops before a profile repeats, so a cycle is listed once.
+func (c *Config) ExtendsChain(name string) []string {
+	chain := []string{name}
+	seen := map[string]bool{name: true}
+	for {
+		_, p := c.FindProfile(chain[len(chain)-1])
+		if p == nil || p.Extends == "" {
+			break
+		}
+		chain = append(chain, p.Extends)
+		if seen[p.Extends] {
+			break
+		}
+		seen[p.Extends] = true
+	}
+	return chain[1:]
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveInheritance(t *testing.T) {
+	cfg := &Config{
+		Profiles: []Profile{
+			{
+				Name:     "orders-prod",
+				Extends:  "base-prod",
+				Hosts:    []string{"10.0.1.1"},
+				Keyspace: "orders",
+			},
+			{
+				Name:     "base-prod",
+				Extends:  "base",
+				Abstract: true,
+				SSL:      &SSLConfig{Enabled: true, CAPath: "/etc/ssl/prod.pem"},
+				Auth:     &AuthConfig{Username: "app", Password: "${PROD_PASSWORD}"},
+			},
+			{
+				Name:     "base",
+				Abstract: true,
+				Port:     9142,
+				Driver:   &DriverConfig{Consistency: "LOCAL_QUORUM"},
+			},
+		},
+	}
+
+	if err := cfg.ResolveInheritance(); err != nil {
+		t.Fatalf("ResolveInheritance() error = %v", err)
+	}
+
+	orders := cfg.Profiles[0]
+	if orders.Name != "orders-prod" || orders.Abstract || orders.Extends != "base-prod" {
+		t.Errorf("orders-prod identity = %q abstract=%v extends=%q", orders.Name, orders.Abstract, orders.Extends)
+	}
+	if !reflect.DeepEqual(orders.Hosts, []string{"10.0.1.1"}) || orders.Port != 9142 || orders.Keyspace != "orders" {
+		t.Errorf("orders-prod = %+v", orders)
+	}
+	if orders.SSL == nil || orders.SSL.CAPath != "/etc/ssl/prod.pem" || orders.Auth == nil || orders.Auth.Password != "${PROD_PASSWORD}" {
+		t.Errorf("orders-prod ssl = %+v auth = %+v", orders.SSL, orders.Auth)
+	}
+	if orders.Driver == nil || orders.Driver.Consistency != "LOCAL_QUORUM" {
+		t.Errorf("orders-prod driver = %+v", orders.Driver)
+	}
+	if base := cfg.Profiles[1]; !base.Abstract || base.Port != 9142 {
+		t.Errorf("base-prod = %+v", base)
+	}
+
+	cfg.Profiles[1].SSL.CAPath = "changed"
+	if orders.SSL.CAPath == "changed" {
+		t.Error("resolved profiles share blocks with their parent")
+	}
+}
+
+func TestResolveInheritanceErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles []Profile
+		wantErr  error
+		wantText string
+	}{
+		{
+			name:     "missing parent",
+			profiles: []Profile{{Name: "a", Extends: "b", Hosts: []string{"h"}, Port: 9042}},
+			wantErr:  ErrProfileNotFound,
+			wantText: "profile a: extends",
+		},
+		{
+			name:     "self",
+			profiles: []Profile{{Name: "a", Extends: "a"}},
+			wantErr:  ErrCircularExtends,
+			wantText: "a -> a",
+		},
+		{
+			name: "cycle",
+			profiles: []Profile{
+				{Name: "a", Extends: "b"},
+				{Name: "b", Extends: "c"},
+				{Name: "c", Extends: "a"},
+			},
+			wantErr:  ErrCircularExtends,
+			wantText: "a -> b -> c -> a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Profiles: tt.profiles}
+			err := cfg.ResolveInheritance()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResolveInheritance() error = %v, want %v", err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantText) {
+				t.Errorf("ResolveInheritance() error = %q, want it to mention %q", err, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestLoadInheritedProfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantErr  error
+		wantText string
+	}{
+		{
+			name: "valid",
+			data: `{"profiles": [
+				{"name": "base", "abstract": true, "port": 9042, "auth": {"username": "app", "password": "p"}},
+				{"name": "dev", "extends": "base", "hosts": ["127.0.0.1"]}
+			]}`,
+		},
+		{
+			name: "error names the chain",
+			data: `{"profiles": [
+				{"name": "base", "abstract": true, "port": 9042},
+				{"name": "base-prod", "abstract": true, "extends": "base"},
+				{"name": "orders", "extends": "base-prod"}
+			]}`,
+			wantErr:  ErrNoHosts,
+			wantText: "profile orders (extends base-prod -> base): hosts",
+		},
+		{
+			name: "abstract default profile",
+			data: `{"profiles": [
+				{"name": "base", "abstract": true},
+				{"name": "dev", "extends": "base", "hosts": ["h"], "port": 9042}
+			], "defaults": {"default_profile": "base"}}`,
+			wantErr:  ErrAbstractProfile,
+			wantText: "defaults.default_profile",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := NewLoader().LoadFromPath(path)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("LoadFromPath() error = %v", err)
+				}
+				if _, dev := cfg.FindProfile("dev"); dev.Auth == nil || dev.Auth.Username != "app" || dev.Port != 9042 {
+					t.Errorf("dev = %+v", dev)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadFromPath() error = %v, want %v", err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantText) {
+				t.Errorf("LoadFromPath() error = %q, want it to mention %q", err, tt.wantText)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidSyntax = errors.New("invalid config syntax")
+
+// Format is the file format of a config, picked by extension.
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+)
+
+// configExtensions lists the extensions tried when looking for a config
+// file, in order of preference.
+var configExtensions = []string{".json", ".yaml", ".yml", ".toml"}
+
+// FormatForPath returns the format of the file at path. Files without a
+// known extension are read as JSON.
+func FormatForPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	}
+	return FormatJSON
+}
+
+// toJSON converts YAML or TOML to JSON so every format is decoded, and
+// reports type errors, the same way.
+func toJSON(format Format, data []byte) ([]byte, error) {
+	var value interface{}
+	var err error
+	switch format {
+	case FormatYAML:
+		err = yaml.Unmarshal(data, &value)
+	case FormatTOML:
+		var table map[string]interface{}
+		err = toml.Unmarshal(data, &table)
+		value = table
+	default:
+		return data, nil
+	}
+	if err == nil {
+		value, err = jsonValue(value)
+	}
+	if err != nil {
+		msg := strings.TrimPrefix(err.Error(), string(format)+": ")
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidSyntax, format, msg)
+	}
+	return json.Marshal(value)
+}
+
+// jsonValue converts a decoded YAML or TOML value to one encoding/json
+// can marshal. Config settings are never dates, so those are refused
+// rather than turned into strings.
+func jsonValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, elem := range v {
+			converted, err := jsonValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = converted
+		}
+		return v, nil
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			converted, err := jsonValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			m[fmt.Sprint(key)] = converted
+		}
+		return m, nil
+	case []interface{}:
+		for i, elem := range v {
+			converted, err := jsonValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	case []map[string]interface{}:
+		list := make([]interface{}, len(v))
+		for i, elem := range v {
+			converted, err := jsonValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = converted
+		}
+		return list, nil
+	case time.Time:
+		return nil, fmt.Errorf("unsupported value %v: dates and times are not config values", v)
+	}
+	return value, nil
+}
+
+// encodeConfig writes config in the given format. YAML keeps the field
+// order of the structs; TOML sorts keys, as its encoder does for maps.
+func encodeConfig(format Format, config *Config) ([]byte, error) {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil || format == FormatJSON {
+		return data, err
+	}
+
+	value, err := decodeOrdered(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case FormatTOML:
+		enc := toml.NewEncoder(&buf)
+		enc.Indent = ""
+		err = enc.Encode(plainValue(value))
+	default:
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err = enc.Encode(yamlNode(value)); err == nil {
+			err = enc.Close()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// plainValue turns a decodeOrdered value into maps and Go numbers for the
+// TOML encoder. Nulls are left out, as TOML has none.
+func plainValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case orderedMap:
+		m := make(map[string]interface{}, len(v))
+		for _, item := range v {
+			if item.Value != nil {
+				m[item.Key] = plainValue(item.Value)
+			}
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, elem := range v {
+			list[i] = plainValue(elem)
+		}
+		if isObjectList(v) {
+			tables := make([]map[string]interface{}, len(list))
+			for i, elem := range list {
+				tables[i] = elem.(map[string]interface{})
+			}
+			return tables
+		}
+		return list
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return value
+}
+
+// yamlNode builds the YAML document for a decodeOrdered value, keeping its
+// key order.
+func yamlNode(value interface{}) *yaml.Node {
+	switch v := value.(type) {
+	case orderedMap:
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		for _, item := range v {
+			n.Content = append(n.Content, yamlNode(item.Key), yamlNode(item.Value))
+		}
+		return n
+	case []interface{}:
+		n := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, elem := range v {
+			n.Content = append(n.Content, yamlNode(elem))
+		}
+		return n
+	}
+
+	n := &yaml.Node{}
+	_ = n.Encode(plainValue(value))
+	return n
+}
+
+// isObjectList reports whether list holds only objects, such as profiles.
+func isObjectList(list []interface{}) bool {
+	for _, elem := range list {
+		if _, ok := elem.(orderedMap); !ok {
+			return false
+		}
+	}
+	return len(list) > 0
+}
+
+// decodeJSONError turns a decoding error into one that names the line and
+// column, or the field path, of the problem.
+func decodeJSONError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, col := position(data, syntaxErr.Offset)
+		return fmt.Errorf("%w: line %d, column %d: %v", ErrInvalidJSON, line, col, err)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return fmt.Errorf("%w: %w", ErrInvalidJSON, &FieldError{
+			Field: typeErr.Field,
+			Err:   fmt.Errorf("%w: expected %s, got %s", ErrInvalidConfig, typeErr.Type, typeErr.Value),
+		})
+	}
+
+	return fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+}
+
+func position(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := utf8.RuneCount(before[bytes.LastIndexByte(before, '\n')+1:])
+	if col == 0 {
+		col = 1
+	}
+	return line, col
+}
+
+// orderedMap is a JSON object that keeps its key order, so encoded files
+// list fields in the same order as the structs they came from.
+type orderedMap []orderedItem
+
+type orderedItem struct {
+	Key   string
+	Value interface{}
+}
+
+func decodeOrdered(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return decodeOrderedValue(dec)
+}
+
+func decodeOrderedValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		var m orderedMap
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, orderedItem{Key: key.(string), Value: value})
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		if m == nil {
+			m = orderedMap{}
+		}
+		return m, nil
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			value, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+	return tok, nil
+}
@@ -0,0 +1,223 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const yamlConfig = `# team config
+version: "1.0"
+profiles:
+  - name: dev
+    hosts: [127.0.0.1, "10.0.0.2"]
+    port: 9042
+    auth:
+      username: cassandra
+      password: 'it''s # not a comment'
+  - name: prod
+    hosts:
+    - 10.0.0.1
+    port: 9042
+    driver: {consistency: LOCAL_QUORUM, retry: {policy: simple, max_retries: 2}}
+defaults:
+  page_size: 50
+server:
+  oidc:
+    issuer: https://id.example.com
+    client_id: kassie
+    redirect_url: http://localhost:8080/auth/callback
+    group_roles:
+      "db admins": [admin]
+`
+
+const tomlConfig = `# team config
+version = "1.0"
+
+[[profiles]]
+name = "dev"
+hosts = ["127.0.0.1", '10.0.0.2']
+port = 9_042
+
+[profiles.auth]
+username = "cassandra"
+password = "it's # not a comment"
+
+[[profiles]]
+name = "prod"
+hosts = [
+  "10.0.0.1", # primary
+]
+port = 9042
+driver = { consistency = "LOCAL_QUORUM", retry = { policy = "simple", max_retries = 2 } }
+
+[defaults]
+page_size = 50
+
+[server.oidc]
+issuer = "https://id.example.com"
+client_id = "kassie"
+redirect_url = "http://localhost:8080/auth/callback"
+group_roles."db admins" = ["admin"]
+`
+
+func TestParseConfigFormats(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		data   string
+	}{
+		{name: "yaml", format: FormatYAML, data: yamlConfig},
+		{name: "toml", format: FormatTOML, data: tomlConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseConfig(tt.format, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("parseConfig() error = %v", err)
+			}
+
+			if len(cfg.Profiles) != 2 {
+				t.Fatalf("profiles = %d, want 2", len(cfg.Profiles))
+			}
+			dev, prod := cfg.Profiles[0], cfg.Profiles[1]
+			if !reflect.DeepEqual(dev.Hosts, []string{"127.0.0.1", "10.0.0.2"}) || dev.Port != 9042 {
+				t.Errorf("dev = %+v", dev)
+			}
+			if dev.Auth == nil || dev.Auth.Password != "it's # not a comment" {
+				t.Errorf("dev auth = %+v", dev.Auth)
+			}
+			if prod.Driver == nil || prod.Driver.Consistency != "LOCAL_QUORUM" || prod.Driver.Retry.MaxRetries != 2 {
+				t.Errorf("prod driver = %+v", prod.Driver)
+			}
+			if cfg.Defaults.PageSize != 50 {
+				t.Errorf("page_size = %d, want 50", cfg.Defaults.PageSize)
+			}
+			if got := cfg.Server.OIDC.GroupRoles["db admins"]; !reflect.DeepEqual(got, []string{"admin"}) {
+				t.Errorf("group_roles = %v", cfg.Server.OIDC.GroupRoles)
+			}
+		})
+	}
+}
+
+func TestParseConfigNumbers(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		data   string
+		want   int
+	}{
+		{name: "yaml hex", format: FormatYAML, data: "profiles:\n  - name: a\n    port: 0x2352\n", want: 9042},
+		{name: "yaml octal", format: FormatYAML, data: "profiles:\n  - name: a\n    port: 0o21522\n", want: 9042},
+		{name: "toml hex", format: FormatTOML, data: "[[profiles]]\nname = \"a\"\nport = 0x2352\n", want: 9042},
+		{name: "toml underscores", format: FormatTOML, data: "[[profiles]]\nname = \"a\"\nport = 9_042\n", want: 9042},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseConfig(tt.format, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("parseConfig() error = %v", err)
+			}
+			if got := cfg.Profiles[0].Port; got != tt.want {
+				t.Errorf("port = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		data     string
+		wantErr  error
+		wantText string
+	}{
+		{name: "json syntax", format: FormatJSON, data: "{\n  \"profiles\": [,]\n}", wantErr: ErrInvalidJSON, wantText: "line 2"},
+		{name: "json type", format: FormatJSON, data: `{"profiles": [{"name": "a", "port": "x"}]}`, wantErr: ErrInvalidConfig, wantText: "profiles.0.port"},
+		{name: "yaml indentation", format: FormatYAML, data: "profiles:\n  - name: a\n     port: 1\n", wantErr: ErrInvalidSyntax, wantText: "line 3"},
+		{name: "yaml duplicate key", format: FormatYAML, data: "version: a\nversion: b\n", wantErr: ErrInvalidSyntax, wantText: "already defined"},
+		{name: "yaml type", format: FormatYAML, data: "profiles:\n  - port: nine\n", wantErr: ErrInvalidConfig, wantText: "profiles.0.port"},
+		{name: "toml duplicate table", format: FormatTOML, data: "[defaults]\n[defaults]\n", wantErr: ErrInvalidSyntax, wantText: "line 2"},
+		{name: "toml missing value", format: FormatTOML, data: "version =\n", wantErr: ErrInvalidSyntax, wantText: "line 1"},
+		{name: "toml leading zero", format: FormatTOML, data: "[defaults]\npage_size = 010\n", wantErr: ErrInvalidSyntax, wantText: "line 2"},
+		{name: "toml date", format: FormatTOML, data: "version = 1979-05-27\n", wantErr: ErrInvalidSyntax, wantText: "unsupported value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseConfig(tt.format, []byte(tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseConfig() error = %v, want %v", err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantText) {
+				t.Errorf("parseConfig() error = %q, want it to mention %q", err, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestEncodeConfigRoundTrip(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0",
+		Profiles: []Profile{
+			{
+				Name:  "dev",
+				Hosts: []string{"127.0.0.1"},
+				Port:  9042,
+				Auth:  &AuthConfig{Username: "app", Password: "${DEV_PASSWORD}"},
+				SSL:   &SSLConfig{Enabled: true, CAPath: "/etc/ssl/ca: root.pem"},
+			},
+			{Name: "prod", Hosts: []string{"true", "10.0.0.1"}, Port: 9042, Keyspace: "# orders"},
+		},
+		Server: &ServerConfig{Roles: []Role{{Name: "ops", Operations: []Operation{AllOperations}}}},
+	}
+	cfg.SetDefaults()
+
+	for _, format := range []Format{FormatJSON, FormatYAML, FormatTOML} {
+		t.Run(string(format), func(t *testing.T) {
+			data, err := encodeConfig(format, cfg)
+			if err != nil {
+				t.Fatalf("encodeConfig() error = %v", err)
+			}
+			got, err := parseConfig(format, data)
+			if err != nil {
+				t.Fatalf("parseConfig() error = %v\n%s", err, data)
+			}
+			if !reflect.DeepEqual(got, cfg) {
+				t.Errorf("round trip = %+v, want %+v\n%s", got, cfg, data)
+			}
+		})
+	}
+}
+
+func TestLoaderFindsOtherFormats(t *testing.T) {
+	dir := t.TempDir()
+	loader := NewLoader()
+	loader.primaryPath = filepath.Join(dir, "config.json")
+	loader.trustPath = filepath.Join(dir, "trusted_projects")
+	loader.systemPath = ""
+	loader.workDir = dir
+
+	yamlPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(yamlPath, []byte(yamlConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := loader.GetConfigPath()
+	if err != nil || path != yamlPath {
+		t.Fatalf("GetConfigPath() = %q, %v; want %q", path, err, yamlPath)
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Defaults.PageSize != 50 {
+		t.Errorf("page_size = %d, want 50", cfg.Defaults.PageSize)
+	}
+}
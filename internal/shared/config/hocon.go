@@ -0,0 +1,302 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The HOCON reader covers what driver application.conf files use: objects
+// with or without braces, dotted keys, = and : separators, arrays, quoted,
+// triple-quoted and unquoted strings, and # and // comments. Objects given
+// twice are merged. Every value is read as a string. Includes, += and
+// substitutions inside a longer value are rejected; a value that is only a
+// ${...} substitution is kept as text, and callers must refuse it unless
+// they can use it as is.
+
+type hoconParser struct {
+	src string
+	pos int
+}
+
+func decodeHOCON(data []byte) (map[string]interface{}, error) {
+	p := &hoconParser{src: string(data)}
+	root := map[string]interface{}{}
+
+	p.skipBlank()
+	var end byte
+	if p.peek() == '{' {
+		p.pos++
+		end = '}'
+	}
+	if err := p.fields(root, end); err != nil {
+		return nil, fmt.Errorf("line %d: %v", p.line(), err)
+	}
+	p.skipBlank()
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("line %d: unexpected %q after the root object", p.line(), p.src[p.pos])
+	}
+	return root, nil
+}
+
+func (p *hoconParser) line() int {
+	return strings.Count(p.src[:p.pos], "\n") + 1
+}
+
+func (p *hoconParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *hoconParser) atComment() bool {
+	return p.peek() == '#' || strings.HasPrefix(p.src[p.pos:], "//")
+}
+
+func (p *hoconParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+// skipBlank skips whitespace, newlines, comments and the commas that may
+// separate fields and elements.
+func (p *hoconParser) skipBlank() {
+	for p.pos < len(p.src) {
+		switch {
+		case strings.IndexByte(" \t\r\n,", p.src[p.pos]) >= 0:
+			p.pos++
+		case p.atComment():
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// fields reads fields into m until end, or until the input ends when end
+// is 0.
+func (p *hoconParser) fields(m map[string]interface{}, end byte) error {
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.src) {
+			if end != 0 {
+				return errors.New("unclosed {")
+			}
+			return nil
+		}
+		if end != 0 && p.src[p.pos] == end {
+			p.pos++
+			return nil
+		}
+		if strings.HasPrefix(p.src[p.pos:], "include ") || strings.HasPrefix(p.src[p.pos:], "include\"") {
+			return errors.New("include is not supported")
+		}
+
+		path, err := p.keyPath()
+		if err != nil {
+			return err
+		}
+		p.skipSpace()
+		switch {
+		case p.peek() == '{':
+		case p.peek() == '=' || p.peek() == ':':
+			p.pos++
+		case strings.HasPrefix(p.src[p.pos:], "+="):
+			return fmt.Errorf("%s: += is not supported", strings.Join(path, "."))
+		default:
+			return fmt.Errorf("%s: expected = or :", strings.Join(path, "."))
+		}
+
+		value, err := p.value()
+		if err != nil {
+			return fmt.Errorf("%s: %v", strings.Join(path, "."), err)
+		}
+		setHOCON(m, path, value)
+	}
+}
+
+func (p *hoconParser) keyPath() ([]string, error) {
+	var path []string
+	for {
+		var key string
+		if p.peek() == '"' {
+			s, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		} else {
+			start := p.pos
+			for p.pos < len(p.src) && isHOCONKeyChar(p.src[p.pos]) && !p.atComment() {
+				p.pos++
+			}
+			key = p.src[start:p.pos]
+			if key == "" {
+				return nil, fmt.Errorf("unexpected %q", p.peek())
+			}
+		}
+		path = append(path, key)
+		if p.peek() != '.' {
+			return path, nil
+		}
+		p.pos++
+	}
+}
+
+func isHOCONKeyChar(c byte) bool {
+	return strings.IndexByte("$\"{}[]:=,+#`^?!@*&\\. \t\r\n", c) < 0
+}
+
+func (p *hoconParser) value() (interface{}, error) {
+	p.skipSpace()
+	switch p.peek() {
+	case '{':
+		p.pos++
+		m := map[string]interface{}{}
+		if err := p.fields(m, '}'); err != nil {
+			return nil, err
+		}
+		return m, nil
+	case '[':
+		p.pos++
+		list := []interface{}{}
+		for {
+			p.skipBlank()
+			switch p.peek() {
+			case 0:
+				return nil, errors.New("unclosed [")
+			case ']':
+				p.pos++
+				return list, nil
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+	}
+	return p.simpleValue()
+}
+
+// simpleValue reads the rest of a value on its line, joining quoted and
+// unquoted parts the way HOCON concatenates them.
+func (p *hoconParser) simpleValue() (interface{}, error) {
+	var b strings.Builder
+	quotedEnd := 0
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if strings.IndexByte("\n,}]", c) >= 0 || p.atComment() {
+			break
+		}
+		if c == '"' {
+			s, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			b.WriteString(s)
+			quotedEnd = b.Len()
+			continue
+		}
+		if strings.HasPrefix(p.src[p.pos:], "${") {
+			end := strings.IndexByte(p.src[p.pos:], '}')
+			if end < 0 {
+				return nil, errors.New("unclosed substitution")
+			}
+			sub := p.src[p.pos : p.pos+end+1]
+			p.pos += end + 1
+			if b.Len() > 0 || strings.TrimRight(p.restOfValue(), " \t\r") != "" {
+				return nil, fmt.Errorf("%s: substitutions inside a value are not supported", sub)
+			}
+			return sub, nil
+		}
+		if strings.IndexByte("{[", c) >= 0 {
+			return nil, fmt.Errorf("unexpected %q", c)
+		}
+		b.WriteByte(c)
+		p.pos++
+	}
+
+	s := b.String()
+	s = s[:quotedEnd] + strings.TrimRight(s[quotedEnd:], " \t\r")
+	if s == "" && quotedEnd == 0 {
+		return nil, errors.New("missing value")
+	}
+	return s, nil
+}
+
+// restOfValue returns what is left of the value being read, without moving.
+func (p *hoconParser) restOfValue() string {
+	start := p.pos
+	for p.pos < len(p.src) && strings.IndexByte("\n,}]", p.src[p.pos]) < 0 && !p.atComment() {
+		p.pos++
+	}
+	rest := p.src[start:p.pos]
+	p.pos = start
+	return rest
+}
+
+func (p *hoconParser) quoted() (string, error) {
+	if strings.HasPrefix(p.src[p.pos:], `"""`) {
+		end := strings.Index(p.src[p.pos+3:], `"""`)
+		if end < 0 {
+			return "", errors.New("unterminated string")
+		}
+		// Quotes just before the closing """ belong to the string.
+		end += p.pos + 3
+		for end+3 < len(p.src) && p.src[end+3] == '"' {
+			end++
+		}
+		s := p.src[p.pos+3 : end]
+		p.pos = end + 3
+		return s, nil
+	}
+
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) && p.src[p.pos] != '"' && p.src[p.pos] != '\n' {
+		if p.src[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.peek() != '"' {
+		return "", errors.New("unterminated string")
+	}
+	p.pos++
+
+	var s string
+	if err := json.Unmarshal([]byte(p.src[start:p.pos]), &s); err != nil {
+		return "", fmt.Errorf("invalid string %s", p.src[start:p.pos])
+	}
+	return s, nil
+}
+
+// setHOCON stores value at path, merging objects into objects that are
+// already there.
+func setHOCON(m map[string]interface{}, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[key] = next
+		}
+		m = next
+	}
+
+	key := path[len(path)-1]
+	existing, ok := m[key].(map[string]interface{})
+	obj, isObj := value.(map[string]interface{})
+	if !ok || !isObj {
+		m[key] = value
+		return
+	}
+	for k, v := range obj {
+		setHOCON(existing, []string{k}, v)
+	}
+}
@@ -0,0 +1,428 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrImport = errors.New("cannot import profile")
+
+var hoconEnvRegex = regexp.MustCompile(`^\$\{\??([A-Z_][A-Z0-9_]*)\}$`)
+
+// ImportedProfile is a profile read from another tool's config, with a note
+// for each setting that could not be carried over.
+type ImportedProfile struct {
+	Profile  Profile
+	Warnings []string
+}
+
+type ImportOptions struct {
+	// Name names the profile. Without it, a name is derived from the first
+	// host.
+	Name string
+	// PasswordEnv names the environment variable that holds the password.
+	// Passwords are never copied out of the source file; the profile stores
+	// a ${PasswordEnv} reference instead.
+	PasswordEnv string
+}
+
+// ImportCqlshrc reads the [connection], [authentication], [ssl] and
+// [certfiles] sections of a cqlshrc file.
+func ImportCqlshrc(data []byte, opts ImportOptions) (*ImportedProfile, error) {
+	sections, err := parseINI(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cqlshrc: %v", ErrImport, err)
+	}
+	imp := &ImportedProfile{}
+	p := &imp.Profile
+
+	conn := sections["connection"]
+	host := conn["hostname"]
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	p.Hosts = []string{host}
+	p.Port = DefaultCQLPort
+	if port := conn["port"]; port != "" {
+		if p.Port, err = strconv.Atoi(port); err != nil {
+			return nil, fmt.Errorf("%w: cqlshrc: [connection] port %q is not a number", ErrImport, port)
+		}
+	}
+	driver := &DriverConfig{}
+	for key, target := range map[string]*int{
+		"connect_timeout": &driver.ConnectTimeoutMs,
+		"request_timeout": &driver.TimeoutMs,
+	} {
+		value := conn[key]
+		if value == "" {
+			continue
+		}
+		secs, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: cqlshrc: [connection] %s %q is not a number of seconds", ErrImport, key, value)
+		}
+		*target = int(math.Round(secs * 1000))
+	}
+	if *driver != (DriverConfig{}) {
+		p.Driver = driver
+	}
+	useSSL := false
+	if value := conn["ssl"]; value != "" {
+		if useSSL, err = parseImportBool(value); err != nil {
+			return nil, fmt.Errorf("%w: cqlshrc: [connection] ssl: %v", ErrImport, err)
+		}
+	}
+
+	auth := sections["authentication"]
+	p.Keyspace = auth["keyspace"]
+	imp.importCredentials(auth["username"], auth["password"], opts.PasswordEnv)
+
+	ssl := sections["ssl"]
+	if useSSL || len(ssl) > 0 {
+		p.SSL = &SSLConfig{
+			Enabled:  useSSL,
+			CAPath:   expandImportPath(ssl["certfile"]),
+			KeyPath:  expandImportPath(ssl["userkey"]),
+			CertPath: expandImportPath(ssl["usercert"]),
+		}
+		if certfile := sections["certfiles"][host]; certfile != "" {
+			p.SSL.CAPath = expandImportPath(certfile)
+		}
+		if value := ssl["validate"]; value != "" {
+			validate, err := parseImportBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: cqlshrc: [ssl] validate: %v", ErrImport, err)
+			}
+			p.SSL.InsecureSkipVerify = !validate
+		}
+		if !useSSL {
+			imp.warn("[ssl] is set but [connection] ssl is not; ssl.enabled is false")
+		}
+	}
+
+	known := map[string][]string{
+		"connection":     {"hostname", "port", "ssl", "connect_timeout", "request_timeout"},
+		"authentication": {"username", "password", "keyspace"},
+		"ssl":            {"certfile", "userkey", "usercert", "validate"},
+	}
+	for section, keys := range known {
+		for key := range sections[section] {
+			if !slices.Contains(keys, key) {
+				imp.warn("[%s] %s is not imported", section, key)
+			}
+		}
+	}
+
+	return imp.finish(opts.Name, "cqlshrc")
+}
+
+// ImportDataStaxConf reads the datastax-java-driver block of a DataStax
+// Java driver 4.x application.conf.
+func ImportDataStaxConf(data []byte, opts ImportOptions) (*ImportedProfile, error) {
+	root, err := decodeHOCON(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: application.conf: %v", ErrImport, err)
+	}
+	block, ok := root["datastax-java-driver"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: application.conf has no datastax-java-driver block", ErrImport)
+	}
+	settings := map[string]interface{}{}
+	flattenHOCON(settings, "", block)
+
+	imp := &ImportedProfile{}
+	p := &imp.Profile
+	driver := &DriverConfig{}
+	str := func(key string) string {
+		s, _ := settings[key].(string)
+		delete(settings, key)
+		return s
+	}
+
+	// A password read from the environment becomes a ${NAME} reference.
+	password, passwordEnv := str("advanced.auth-provider.password"), opts.PasswordEnv
+	if m := hoconEnvRegex.FindStringSubmatch(password); m != nil {
+		if passwordEnv == "" {
+			passwordEnv = m[1]
+		}
+		password = ""
+	} else if strings.HasPrefix(password, "${") {
+		return nil, fmt.Errorf("%w: application.conf: advanced.auth-provider.password: only ${NAME} environment substitutions are supported", ErrImport)
+	}
+
+	// Other substitutions would need the rest of the config resolved, so
+	// they are refused rather than imported as text.
+	for key, value := range settings {
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+		for _, v := range values {
+			if s, ok := v.(string); ok && strings.HasPrefix(s, "${") {
+				return nil, fmt.Errorf("%w: application.conf: %s: %s substitutions are not supported", ErrImport, key, s)
+			}
+		}
+	}
+
+	if points, ok := settings["basic.contact-points"].([]interface{}); ok {
+		delete(settings, "basic.contact-points")
+		for _, point := range points {
+			s, _ := point.(string)
+			host, port := s, DefaultCQLPort
+			if h, ps, err := net.SplitHostPort(s); err == nil {
+				host = h
+				if port, err = strconv.Atoi(ps); err != nil {
+					return nil, fmt.Errorf("%w: application.conf: contact point %q has no valid port", ErrImport, s)
+				}
+			}
+			if p.Port != 0 && port != p.Port {
+				imp.warn("contact point %s uses port %d; all hosts use port %d", s, port, p.Port)
+			}
+			if p.Port == 0 {
+				p.Port = port
+			}
+			p.Hosts = append(p.Hosts, host)
+		}
+	}
+	if bundle := str("basic.cloud.secure-connect-bundle"); bundle != "" {
+		bundle = strings.TrimPrefix(strings.TrimPrefix(bundle, "file://"), "file:")
+		p.SecureConnectBundle = expandImportPath(bundle)
+	}
+	if len(p.Hosts) == 0 && p.SecureConnectBundle == "" {
+		p.Hosts = []string{"127.0.0.1"}
+		p.Port = DefaultCQLPort
+	}
+	p.Keyspace = str("basic.session-keyspace")
+	sessionName := str("basic.session-name")
+
+	driver.LocalDC = str("basic.load-balancing-policy.local-datacenter")
+	driver.Consistency = strings.ToUpper(str("basic.request.consistency"))
+	for key, target := range map[string]*int{
+		"basic.request.timeout":               &driver.TimeoutMs,
+		"advanced.connection.connect-timeout": &driver.ConnectTimeoutMs,
+	} {
+		if value := str(key); value != "" {
+			d, err := parseHOCONDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: application.conf: %s: %v", ErrImport, key, err)
+			}
+			*target = int(d / time.Millisecond)
+		}
+	}
+	if version := str("advanced.protocol.version"); version != "" {
+		n, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(version), "V"))
+		if err != nil {
+			imp.warn("advanced.protocol.version %s is not imported", version)
+		} else {
+			driver.ProtocolVersion = n
+		}
+	}
+	driver.Compression = strings.ToLower(str("advanced.protocol.compression"))
+	if *driver != (DriverConfig{}) {
+		p.Driver = driver
+	}
+
+	if class := str("advanced.auth-provider.class"); class != "" && class != "PlainTextAuthProvider" {
+		imp.warn("advanced.auth-provider.class %s is not supported; only username and password are imported", class)
+	}
+	imp.importCredentials(str("advanced.auth-provider.username"), password, passwordEnv)
+
+	if class := str("advanced.ssl-engine-factory.class"); class != "" {
+		p.SSL = &SSLConfig{Enabled: true}
+		if str("advanced.ssl-engine-factory.truststore-path") != "" {
+			imp.warn("JKS truststores are not supported; export the CA certificate to PEM and set ssl.ca_path")
+		}
+		if str("advanced.ssl-engine-factory.keystore-path") != "" {
+			imp.warn("JKS keystores are not supported; export the client certificate and key to PEM and set ssl.cert_path and ssl.key_path")
+		}
+		if strings.EqualFold(str("advanced.ssl-engine-factory.hostname-validation"), "false") {
+			imp.warn("hostname validation was disabled; kassie verifies server certificates unless ssl.insecure_skip_verify is set")
+		}
+		str("advanced.ssl-engine-factory.truststore-password")
+		str("advanced.ssl-engine-factory.keystore-password")
+	}
+
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		imp.warn("%s is not imported", key)
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = sessionName
+	}
+	return imp.finish(name, "application.conf")
+}
+
+// importCredentials keeps the username and replaces the password with a
+// reference to passwordEnv.
+func (imp *ImportedProfile) importCredentials(username, password, passwordEnv string) {
+	if username == "" && password == "" && passwordEnv == "" {
+		return
+	}
+	imp.Profile.Auth = &AuthConfig{Username: username}
+	if passwordEnv != "" {
+		imp.Profile.Auth.Password = "${" + passwordEnv + "}"
+	} else if password != "" {
+		imp.warn("the password is not imported; keep it in an environment variable and reference it as ${NAME}")
+	}
+}
+
+func (imp *ImportedProfile) warn(format string, args ...interface{}) {
+	imp.Warnings = append(imp.Warnings, fmt.Sprintf(format, args...))
+}
+
+func (imp *ImportedProfile) finish(name, source string) (*ImportedProfile, error) {
+	if name == "" {
+		name = importName(&imp.Profile)
+	}
+	imp.Profile.Name = name
+	sort.Strings(imp.Warnings)
+	if err := imp.Profile.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrImport, source, err)
+	}
+	return imp, nil
+}
+
+// importName derives a profile name from the first host's leading label,
+// or the bundle's file name.
+func importName(p *Profile) string {
+	if p.SecureConnectBundle != "" {
+		return strings.TrimSuffix(filepath.Base(p.SecureConnectBundle), filepath.Ext(p.SecureConnectBundle))
+	}
+	if len(p.Hosts) == 0 {
+		return "imported"
+	}
+	host := p.Hosts[0]
+	if net.ParseIP(host) != nil {
+		return strings.NewReplacer(".", "-", ":", "-").Replace(host)
+	}
+	name, _, _ := strings.Cut(host, ".")
+	return name
+}
+
+// parseINI reads the sections of an INI file the way Python's configparser
+// does: keys are lower-cased, both = and : separate values, lines starting
+// with # or ; are comments and indented lines continue a value.
+func parseINI(data []byte) (map[string]map[string]string, error) {
+	sections := map[string]map[string]string{}
+	var section map[string]string
+	var lastKey string
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == '#' || trimmed[0] == ';' {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if section == nil || lastKey == "" {
+				return nil, fmt.Errorf("line %d: unexpected indentation", i+1)
+			}
+			section[lastKey] += "\n" + trimmed
+			continue
+		}
+		if trimmed[0] == '[' {
+			if !strings.HasSuffix(trimmed, "]") {
+				return nil, fmt.Errorf("line %d: unclosed section header", i+1)
+			}
+			name := strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			if sections[name] == nil {
+				sections[name] = map[string]string{}
+			}
+			section, lastKey = sections[name], ""
+			continue
+		}
+
+		sep := strings.IndexAny(trimmed, "=:")
+		if sep < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", i+1)
+		}
+		if section == nil {
+			return nil, fmt.Errorf("line %d: key outside a section", i+1)
+		}
+		lastKey = strings.ToLower(strings.TrimSpace(trimmed[:sep]))
+		section[lastKey] = strings.TrimSpace(trimmed[sep+1:])
+	}
+	return sections, nil
+}
+
+func parseImportBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "yes", "true", "on":
+		return true, nil
+	case "0", "no", "false", "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("%q is not a boolean", s)
+}
+
+// parseHOCONDuration reads durations such as "5 seconds" or "500ms". A bare
+// number is milliseconds.
+func parseHOCONDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a duration", s)
+	}
+
+	var unit time.Duration
+	switch strings.TrimSpace(s[i:]) {
+	case "", "ms", "milli", "millis", "millisecond", "milliseconds":
+		unit = time.Millisecond
+	case "ns", "nano", "nanos", "nanosecond", "nanoseconds":
+		unit = time.Nanosecond
+	case "us", "micro", "micros", "microsecond", "microseconds":
+		unit = time.Microsecond
+	case "s", "second", "seconds":
+		unit = time.Second
+	case "m", "minute", "minutes":
+		unit = time.Minute
+	case "h", "hour", "hours":
+		unit = time.Hour
+	case "d", "day", "days":
+		unit = 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("%q has an unknown unit", s)
+	}
+	return time.Duration(n * float64(unit)), nil
+}
+
+// flattenHOCON collects the leaves of m under dotted keys. Arrays are
+// leaves.
+func flattenHOCON(out map[string]interface{}, prefix string, m map[string]interface{}) {
+	for key, value := range m {
+		if child, ok := value.(map[string]interface{}); ok {
+			flattenHOCON(out, prefix+key+".", child)
+			continue
+		}
+		out[prefix+key] = value
+	}
+}
+
+func expandImportPath(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
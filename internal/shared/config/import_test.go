@@ -0,0 +1,272 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImportCqlshrc(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		opts         ImportOptions
+		want         Profile
+		wantWarnings []string
+		wantErr      bool
+	}{
+		{
+			name: "full",
+			data: `; cqlsh settings
+[authentication]
+username = app
+password = s3cret
+keyspace = orders
+
+[connection]
+hostname = cass1.example.com
+port = 9142
+ssl = true
+connect_timeout = 5
+request_timeout = 20
+timeout = 10
+
+[ssl]
+certfile = /etc/ssl/ca.pem
+userkey = /etc/ssl/client.key
+usercert: /etc/ssl/client.pem
+validate = false
+version = TLSv1_2
+
+[certfiles]
+cass1.example.com = /etc/ssl/cass1-ca.pem
+
+[ui]
+color = on
+`,
+			opts: ImportOptions{PasswordEnv: "ORDERS_PASSWORD"},
+			want: Profile{
+				Name:     "cass1",
+				Hosts:    []string{"cass1.example.com"},
+				Port:     9142,
+				Keyspace: "orders",
+				Auth:     &AuthConfig{Username: "app", Password: "${ORDERS_PASSWORD}"},
+				SSL: &SSLConfig{
+					Enabled:            true,
+					CAPath:             "/etc/ssl/cass1-ca.pem",
+					KeyPath:            "/etc/ssl/client.key",
+					CertPath:           "/etc/ssl/client.pem",
+					InsecureSkipVerify: true,
+				},
+				Driver: &DriverConfig{ConnectTimeoutMs: 5000, TimeoutMs: 20000},
+			},
+			wantWarnings: []string{"[connection] timeout is not imported", "[ssl] version is not imported"},
+		},
+		{
+			name: "defaults and skipped password",
+			data: "[authentication]\nusername = app\npassword = s3cret\n",
+			opts: ImportOptions{Name: "local"},
+			want: Profile{
+				Name:  "local",
+				Hosts: []string{"127.0.0.1"},
+				Port:  9042,
+				Auth:  &AuthConfig{Username: "app"},
+			},
+			wantWarnings: []string{"the password is not imported; keep it in an environment variable and reference it as ${NAME}"},
+		},
+		{
+			name:    "bad port",
+			data:    "[connection]\nport = ninety\n",
+			wantErr: true,
+		},
+		{
+			name:    "key outside a section",
+			data:    "hostname = h\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid profile",
+			data:    "[connection]\nport = 0\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ImportCqlshrc([]byte(tt.data), tt.opts)
+			if tt.wantErr {
+				if !errors.Is(err, ErrImport) {
+					t.Fatalf("ImportCqlshrc() error = %v, want %v", err, ErrImport)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ImportCqlshrc() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Profile, tt.want) {
+				t.Errorf("profile = %+v, want %+v", got.Profile, tt.want)
+			}
+			if !reflect.DeepEqual(got.Warnings, tt.wantWarnings) {
+				t.Errorf("warnings = %q, want %q", got.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestImportDataStaxConf(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		opts         ImportOptions
+		want         Profile
+		wantWarnings []string
+		wantErr      string
+	}{
+		{
+			name: "driver block",
+			data: `# application.conf
+datastax-java-driver {
+  basic {
+    contact-points = [ "10.0.1.1:9042", "10.0.1.2:9042" ]
+    session-name = orders-prod
+    session-keyspace = orders
+    load-balancing-policy.local-datacenter = dc1
+    request {
+      timeout = 5 seconds
+      consistency = local_quorum
+    }
+  }
+  advanced.auth-provider {
+    class = PlainTextAuthProvider
+    username = "app"
+    password = ${?ORDERS_PASSWORD}
+  }
+  advanced.ssl-engine-factory {
+    class = DefaultSslEngineFactory
+    truststore-path = /etc/ssl/truststore.jks
+    truststore-password = changeit
+  }
+  advanced.protocol { version = V4, compression = LZ4 }
+  advanced.connection.connect-timeout = 2000
+  advanced.metrics.session.enabled = [ connected-nodes ]
+}
+`,
+			want: Profile{
+				Name:     "orders-prod",
+				Hosts:    []string{"10.0.1.1", "10.0.1.2"},
+				Port:     9042,
+				Keyspace: "orders",
+				Auth:     &AuthConfig{Username: "app", Password: "${ORDERS_PASSWORD}"},
+				SSL:      &SSLConfig{Enabled: true},
+				Driver: &DriverConfig{
+					LocalDC:          "dc1",
+					Consistency:      "LOCAL_QUORUM",
+					TimeoutMs:        5000,
+					ConnectTimeoutMs: 2000,
+					ProtocolVersion:  4,
+					Compression:      "lz4",
+				},
+			},
+			wantWarnings: []string{
+				"JKS truststores are not supported; export the CA certificate to PEM and set ssl.ca_path",
+				"advanced.metrics.session.enabled is not imported",
+			},
+		},
+		{
+			name: "dotted keys and bundle",
+			data: `datastax-java-driver.basic.cloud.secure-connect-bundle = "file:/home/me/secure-connect-orders.zip"
+datastax-java-driver.advanced.auth-provider.username = token
+datastax-java-driver.advanced.auth-provider.password = "AstraCS:abc"
+`,
+			opts: ImportOptions{PasswordEnv: "ASTRA_TOKEN"},
+			want: Profile{
+				Name:                "secure-connect-orders",
+				SecureConnectBundle: "/home/me/secure-connect-orders.zip",
+				Auth:                &AuthConfig{Username: "token", Password: "${ASTRA_TOKEN}"},
+			},
+		},
+		{
+			name:    "no driver block",
+			data:    "akka { loglevel = INFO }",
+			wantErr: "no datastax-java-driver block",
+		},
+		{
+			name:    "unclosed object",
+			data:    "datastax-java-driver {\n basic.session-keyspace = a\n",
+			wantErr: "unclosed {",
+		},
+		{
+			name:    "include",
+			data:    `include "common.conf"`,
+			wantErr: "include is not supported",
+		},
+		{
+			name:    "substitution",
+			data:    "datastax-java-driver.basic.contact-points = ${hosts}",
+			wantErr: "basic.contact-points: ${hosts} substitutions are not supported",
+		},
+		{
+			name:    "substitution in a list",
+			data:    "datastax-java-driver.basic.contact-points = [ \"10.0.1.1:9042\", ${?extra} ]",
+			wantErr: "${?extra} substitutions are not supported",
+		},
+		{
+			name:    "concatenated substitution",
+			data:    "datastax-java-driver.basic.session-keyspace = ${prefix}_orders",
+			wantErr: "substitutions inside a value are not supported",
+		},
+		{
+			name:    "password substitution",
+			data:    "datastax-java-driver.advanced.auth-provider.password = ${secrets.db}",
+			wantErr: "only ${NAME} environment substitutions",
+		},
+		{
+			name:    "bad duration",
+			data:    "datastax-java-driver.basic.request.timeout = 5 fortnights",
+			wantErr: "unknown unit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ImportDataStaxConf([]byte(tt.data), tt.opts)
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrImport) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ImportDataStaxConf() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ImportDataStaxConf() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Profile, tt.want) {
+				t.Errorf("profile = %+v, want %+v", got.Profile, tt.want)
+			}
+			if !reflect.DeepEqual(got.Warnings, tt.wantWarnings) {
+				t.Errorf("warnings = %q, want %q", got.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestDecodeHOCON(t *testing.T) {
+	got, err := decodeHOCON([]byte(`{
+  a.b = 1 // comment
+  a { c: "x y", d = """raw "text"""" }
+  list = [ one
+    "two", three ]
+  joined = foo "bar" baz
+}`))
+	if err != nil {
+		t.Fatalf("decodeHOCON() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"a":      map[string]interface{}{"b": "1", "c": "x y", "d": `raw "text"`},
+		"list":   []interface{}{"one", "two", "three"},
+		"joined": "foo bar baz",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeHOCON() = %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const maskedSecret = "********"
+
+// ReadFile parses the config file at path as written, without defaults,
+// environment interpolation or validation.
+func ReadFile(path string) (*Config, error) {
+	return (&Loader{}).readFile(path)
+}
+
+// Encode writes config in the given format.
+func Encode(format Format, config *Config) ([]byte, error) {
+	return encodeConfig(format, config)
+}
+
+// MaskSecrets returns a copy of config with passwords, passphrases and
+// client secrets replaced. ${VAR}, file:, cmd: and keyring: references and
+// enc: values are kept since they do not reveal the secret.
+func MaskSecrets(config *Config) *Config {
+	masked := config.clone()
+	for i := range masked.Profiles {
+		p := &masked.Profiles[i]
+		if p.Auth != nil {
+			maskSecret(&p.Auth.Password)
+		}
+		if p.Tunnel != nil {
+			maskSecret(&p.Tunnel.KeyPassphrase)
+		}
+		if p.SOCKS5 != nil {
+			maskSecret(&p.SOCKS5.Password)
+		}
+	}
+	if s := masked.Server; s != nil {
+		for i := range s.Users {
+			maskSecret(&s.Users[i].PasswordHash)
+		}
+		if s.OIDC != nil {
+			maskSecret(&s.OIDC.ClientSecret)
+		}
+	}
+	return masked
+}
+
+func maskSecret(value *string) {
+	if _, ok := EnvReferenceName(*value); ok || *value == "" || IsSecretReference(*value) || IsEncrypted(*value) {
+		return
+	}
+	*value = maskedSecret
+}
+
+// UnknownFields lists the keys in the config file at path that no config
+// setting reads, such as a misspelt "hostss". The loader ignores them.
+func UnknownFields(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFileReadError, err)
+	}
+	data, err = toJSON(FormatForPath(path), data)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, decodeJSONError(data, err)
+	}
+
+	var unknown []string
+	collectUnknownFields(value, reflect.TypeOf(Config{}), "", &unknown)
+	return unknown, nil
+}
+
+func collectUnknownFields(value interface{}, t reflect.Type, path string, unknown *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Map:
+			for key, elem := range v {
+				collectUnknownFields(elem, t.Elem(), joinFieldPath(path, key), unknown)
+			}
+		case reflect.Struct:
+			fields := jsonFields(t)
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				field, ok := fields[key]
+				switch {
+				case ok:
+					collectUnknownFields(v[key], field, joinFieldPath(path, key), unknown)
+				case path == "" && key == "$schema":
+				default:
+					*unknown = append(*unknown, joinFieldPath(path, key))
+				}
+			}
+		}
+	case []interface{}:
+		if t.Kind() == reflect.Slice {
+			for i, elem := range v {
+				collectUnknownFields(elem, t.Elem(), fmt.Sprintf("%s[%d]", path, i), unknown)
+			}
+		}
+	}
+}
+
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = t.Field(i).Type
+		}
+	}
+	return fields
+}
+
+func joinFieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMaskSecrets(t *testing.T) {
+	cfg := &Config{
+		Profiles: []Profile{
+			{Name: "dev", Auth: &AuthConfig{Username: "app", Password: "literal"}},
+			{Name: "prod", Auth: &AuthConfig{Password: "${PROD_PASSWORD}"}, SOCKS5: &SOCKS5Config{Address: "proxy:1080", Password: "p"}},
+		},
+		Server: &ServerConfig{
+			Users: []User{{Username: "ana", PasswordHash: "$2a$10$abc"}},
+			OIDC:  &OIDCConfig{ClientSecret: "secret"},
+		},
+	}
+
+	masked := MaskSecrets(cfg)
+
+	if got := masked.Profiles[0].Auth.Password; got != maskedSecret {
+		t.Errorf("literal password = %q, want masked", got)
+	}
+	if got := masked.Profiles[1].Auth.Password; got != "${PROD_PASSWORD}" {
+		t.Errorf("env reference = %q, want kept", got)
+	}
+	if masked.Profiles[1].SOCKS5.Password != maskedSecret || masked.Server.Users[0].PasswordHash != maskedSecret || masked.Server.OIDC.ClientSecret != maskedSecret {
+		t.Errorf("secrets not masked: %+v %+v", masked.Profiles[1].SOCKS5, masked.Server)
+	}
+	if masked.Profiles[0].Auth.Username != "app" {
+		t.Errorf("username = %q, want kept", masked.Profiles[0].Auth.Username)
+	}
+	if cfg.Profiles[0].Auth.Password != "literal" {
+		t.Error("MaskSecrets() changed the original config")
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	tests := []struct {
+		file string
+		data string
+		want []string
+	}{
+		{
+			file: "config.json",
+			data: `{"$schema": "x", "profiles": [{"name": "a", "hostss": ["h"], "driver": {"retries": 1}}], "default": {}}`,
+			want: []string{"default", "profiles[0].driver.retries", "profiles[0].hostss"},
+		},
+		{
+			file: "config.yaml",
+			data: "profiles:\n  - name: a\n    hosts: [h]\nserver:\n  oidc:\n    group_roles: {ops: [admin]}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := UnknownFields(path)
+			if err != nil {
+				t.Fatalf("UnknownFields() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnknownFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// The docs site serves the schema from docs/public. Regenerate it with
+// kassie config schema > docs/public/config.schema.json.
+func TestPublishedSchemaIsCurrent(t *testing.T) {
+	published, err := os.ReadFile(filepath.Join("..", "..", "..", "docs", "public", "config.schema.json"))
+	if err != nil {
+		t.Fatalf("read published schema: %v", err)
+	}
+	schema, err := Schema()
+	if err != nil {
+		t.Fatalf("Schema() error = %v", err)
+	}
+	if !bytes.Equal(published, schema) {
+		t.Error("docs/public/config.schema.json is out of date, regenerate it with kassie config schema")
+	}
+}
@@ -0,0 +1,390 @@
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// projectConfigName is the file a project checks in to share profiles. It
+// may use any of the config extensions.
+const projectConfigName = ".kassie.json"
+
+// legacyProjectConfigName is the project file earlier releases read from the
+// working directory. It is still loaded there, below .kassie.json and under
+// the same trust rule, but not from parent directories.
+const legacyProjectConfigName = "kassie.config.json"
+
+// trustFileName lists, one per line next to the user file, the directories
+// whose project files are loaded. A project file can point a profile at
+// other hosts, add server users or run cmd: secrets, so one that merely sits
+// in a parent directory, such as a cloned repository, is ignored until its
+// directory is trusted.
+const trustFileName = "trusted_projects"
+
+// OriginDefault marks settings that no config file set.
+const OriginDefault = "default"
+
+// Origins maps a setting, such as profiles[dev].port, to the file that set
+// it last.
+type Origins map[string]string
+
+func systemConfigPath() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("ProgramData"), "kassie", "config.json")
+	}
+	return filepath.Join("/etc", "kassie", "config.json")
+}
+
+// Layers lists the config files Load merges, lowest precedence first: the
+// system file, the user file, trusted .kassie files from the outermost
+// directory down to the working directory, a trusted kassie.config.json in
+// the working directory, then the file given with --config.
+func (l *Loader) Layers() []string {
+	var layers []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		abs, err := filepath.Abs(path)
+		if err != nil || seen[abs] {
+			return
+		}
+		seen[abs] = true
+		layers = append(layers, path)
+	}
+
+	if path, ok := findConfigFile(l.systemPath); ok {
+		add(path)
+	}
+	if path, ok := findConfigFile(l.primaryPath); ok {
+		add(path)
+	}
+	trusted := l.trustedProjects()
+	for _, path := range l.projectFiles() {
+		if trusted[filepath.Dir(path)] {
+			add(path)
+		}
+	}
+	if l.explicitPath != "" && fileExists(l.explicitPath) {
+		add(l.explicitPath)
+	}
+	return layers
+}
+
+// UntrustedProjectFiles lists the project files Layers leaves out because
+// their directory is not trusted.
+func (l *Loader) UntrustedProjectFiles() []string {
+	trusted := l.trustedProjects()
+	var files []string
+	for _, path := range l.projectFiles() {
+		if !trusted[filepath.Dir(path)] {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// TrustProject adds dir to the trusted directories, so its project file is
+// loaded.
+func (l *Loader) TrustProject(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+	if l.trustedProjects()[abs] {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.trustPath), 0755); err != nil {
+		return fmt.Errorf("%w: %v", ErrFileWriteError, err)
+	}
+	f, err := os.OpenFile(l.trustPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrFileWriteError, err)
+	}
+	if _, err := fmt.Fprintln(f, abs); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("%w: %v", ErrFileWriteError, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("%w: %v", ErrFileWriteError, err)
+	}
+	return nil
+}
+
+// trustedProjects reads the trust file. Blank lines and lines starting with
+// # are skipped; a missing file trusts nothing.
+func (l *Loader) trustedProjects() map[string]bool {
+	trusted := make(map[string]bool)
+	f, err := os.Open(l.trustPath)
+	if err != nil {
+		return trusted
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		trusted[filepath.Clean(line)] = true
+	}
+	return trusted
+}
+
+func (l *Loader) projectFiles() []string {
+	if l.workDir == "" {
+		return nil
+	}
+	workDir, err := filepath.Abs(l.workDir)
+	if err != nil {
+		return nil
+	}
+	dir := workDir
+
+	var files []string
+	for {
+		if path, ok := findConfigFile(filepath.Join(dir, projectConfigName)); ok {
+			files = append(files, path)
+		}
+		if dir == workDir {
+			if path, ok := findConfigFile(filepath.Join(dir, legacyProjectConfigName)); ok {
+				files = append(files, path)
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	for i, j := 0, len(files)-1; i < j; i, j = i+1, j-1 {
+		files[i], files[j] = files[j], files[i]
+	}
+	return files
+}
+
+// LoadStack merges every layer, then applies defaults, interpolates
+// environment variables and validates the result. It also reports which
+// file set each setting.
+func (l *Loader) LoadStack() (*Config, Origins, error) {
+	merged, origins, err := l.ReadStack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := merged.ResolveInheritance(); err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve profile inheritance: %w", err)
+	}
+
+	merged.SetDefaults()
+
+	if err := InterpolateConfig(merged); err != nil {
+		return nil, nil, fmt.Errorf("failed to interpolate environment variables: %w", err)
+	}
+
+	if err := merged.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	return merged, origins, nil
+}
+
+// loadStackWith merges the stack the way LoadStack does, with layer in place
+// of the file at path. The file need not exist yet: a new user file goes
+// below the project files and a new --config file on top.
+func (l *Loader) loadStackWith(path string, layer *Config) (*Config, error) {
+	layers := l.Layers()
+	if !slices.Contains(layers, path) {
+		at := len(layers)
+		if path == l.primaryPath {
+			at = 0
+			if _, ok := findConfigFile(l.systemPath); ok {
+				at = 1
+			}
+		}
+		layers = slices.Insert(layers, at, path)
+	}
+
+	merged := &Config{}
+	for _, p := range layers {
+		next := layer
+		var err error
+		if p != path {
+			if next, err = l.readFile(p); err != nil {
+				return nil, fmt.Errorf("%s: %w", p, err)
+			}
+		}
+		if merged, err = mergeConfigs(merged, next); err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+	}
+
+	if err := merged.ResolveInheritance(); err != nil {
+		return nil, err
+	}
+	merged.SetDefaults()
+	if err := InterpolateConfig(merged); err != nil {
+		return nil, err
+	}
+	if err := merged.Validate(); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// ReadStack merges every layer as written, without defaults, environment
+// variables or validation.
+func (l *Loader) ReadStack() (*Config, Origins, error) {
+	if l.explicitPath != "" && !fileExists(l.explicitPath) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrFileNotFound, l.explicitPath)
+	}
+	layers := l.Layers()
+	if len(layers) == 0 {
+		return nil, nil, ErrFileNotFound
+	}
+
+	merged := &Config{}
+	origins := make(Origins)
+	for _, path := range layers {
+		layer, err := l.readFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if merged, err = mergeConfigs(merged, layer); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if err := origins.record(layer, path); err != nil {
+			return nil, nil, err
+		}
+	}
+	return merged, origins, nil
+}
+
+// record notes path as the origin of every setting layer sets, following
+// the rules of mergeConfigs: empty values do not override, and blocks that
+// replace each other as a whole drop the origins of what they replace.
+func (o Origins) record(layer *Config, path string) error {
+	settings, err := Settings(layer)
+	if err != nil {
+		return err
+	}
+
+	var replaced []string
+	if layer.Server.hasSettings() {
+		replaced = append(replaced, "server.")
+	}
+	var explicit []string
+	for _, p := range layer.Profiles {
+		prefix := "profiles[" + p.Name + "]."
+		if p.Tunnel != nil || p.SOCKS5 != nil {
+			replaced = append(replaced, prefix+"tunnel.", prefix+"socks5.")
+		}
+		// An ssl block sets enabled even when it is false.
+		if p.SSL != nil {
+			explicit = append(explicit, prefix+"ssl.enabled")
+		}
+	}
+	for setting := range o {
+		for _, prefix := range replaced {
+			if strings.HasPrefix(setting, prefix) {
+				delete(o, setting)
+			}
+		}
+	}
+
+	for _, s := range settings {
+		if emptySetting(s.Value) {
+			continue
+		}
+		// Naming a profile only picks it out; the file that added it
+		// stays its origin.
+		if _, ok := o[s.Path]; ok && strings.HasSuffix(s.Path, "].name") {
+			continue
+		}
+		o[s.Path] = path
+	}
+	for _, setting := range explicit {
+		o[setting] = path
+	}
+	return nil
+}
+
+func emptySetting(value string) bool {
+	switch value {
+	case `""`, "0", "false", "null", "[]", "{}":
+		return true
+	}
+	return false
+}
+
+// Origin returns the file that set a setting, or OriginDefault.
+func (o Origins) Origin(setting string) string {
+	if path, ok := o[setting]; ok {
+		return path
+	}
+	return OriginDefault
+}
+
+// Setting is one value of a config, such as profiles[dev].port = 9042.
+// Lists of plain values are a single setting.
+type Setting struct {
+	Path  string
+	Value string
+}
+
+// Settings flattens config into its settings, in file order. Profiles are
+// named by profile name so a setting keeps its path across layers.
+func Settings(config *Config) ([]Setting, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	value, err := decodeOrdered(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var settings []Setting
+	flattenSettings(value, "", &settings)
+	return settings, nil
+}
+
+func flattenSettings(value interface{}, path string, settings *[]Setting) {
+	switch v := value.(type) {
+	case orderedMap:
+		for _, item := range v {
+			flattenSettings(item.Value, joinFieldPath(path, item.Key), settings)
+		}
+		return
+	case []interface{}:
+		if isObjectList(v) {
+			for i, elem := range v {
+				key := fmt.Sprint(i)
+				if path == "profiles" {
+					key = profileKey(elem.(orderedMap), i)
+				}
+				flattenSettings(elem, fmt.Sprintf("%s[%s]", path, key), settings)
+			}
+			return
+		}
+	}
+
+	data, _ := json.Marshal(value)
+	*settings = append(*settings, Setting{Path: path, Value: string(data)})
+}
+
+func profileKey(profile orderedMap, index int) string {
+	for _, item := range profile {
+		if name, ok := item.Value.(string); ok && item.Key == "name" && name != "" {
+			return name
+		}
+	}
+	return fmt.Sprint(index)
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newStackLoader returns a loader whose system, user and working directories
+// all live under a temp dir, so the real config files never leak in.
+func newStackLoader(t *testing.T) (*Loader, string) {
+	t.Helper()
+	root := t.TempDir()
+	workDir := filepath.Join(root, "work", "repo", "service")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	loader := NewLoader()
+	loader.systemPath = filepath.Join(root, "etc", "config.json")
+	loader.primaryPath = filepath.Join(root, "home", "config.json")
+	loader.trustPath = filepath.Join(root, "home", "trusted_projects")
+	loader.workDir = workDir
+	return loader, root
+}
+
+func writeLayer(t *testing.T, path, data string) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadStack(t *testing.T) {
+	loader, root := newStackLoader(t)
+
+	system := writeLayer(t, filepath.Join(root, "etc", "config.json"),
+		`{"defaults": {"timeout_ms": 3000}, "clients": {"tui": {"theme": "dark"}}}`)
+	user := writeLayer(t, filepath.Join(root, "home", "config.yaml"),
+		"profiles:\n  - name: local\n    hosts: [127.0.0.1]\n    port: 9042\ndefaults:\n  page_size: 50\n")
+	outer := writeLayer(t, filepath.Join(root, "work", ".kassie.toml"),
+		"[[profiles]]\nname = \"local\"\nkeyspace = \"app\"\n\n[defaults]\npage_size = 200\n")
+	inner := writeLayer(t, filepath.Join(root, "work", "repo", ".kassie.json"),
+		`{"profiles": [{"name": "staging", "hosts": ["10.0.0.5"], "port": 9042}], "defaults": {"default_profile": "staging"}}`)
+	explicit := writeLayer(t, filepath.Join(root, "override.json"),
+		`{"defaults": {"page_size": 25}}`)
+	loader.explicitPath = explicit
+
+	if got, want := loader.Layers(), []string{system, user, explicit}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Layers() before trusting = %v, want %v", got, want)
+	}
+	if got, want := loader.UntrustedProjectFiles(), []string{outer, inner}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("UntrustedProjectFiles() = %v, want %v", got, want)
+	}
+	for _, dir := range []string{filepath.Dir(outer), filepath.Dir(inner), filepath.Dir(inner)} {
+		if err := loader.TrustProject(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := loader.UntrustedProjectFiles(); len(got) != 0 {
+		t.Errorf("UntrustedProjectFiles() after trusting = %v", got)
+	}
+
+	if got, want := loader.Layers(), []string{system, user, outer, inner, explicit}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Layers() = %v, want %v", got, want)
+	}
+
+	cfg, origins, err := loader.LoadStack()
+	if err != nil {
+		t.Fatalf("LoadStack() error = %v", err)
+	}
+
+	if cfg.Defaults.PageSize != 25 || cfg.Defaults.TimeoutMs != 3000 || cfg.Defaults.DefaultProfile != "staging" {
+		t.Errorf("defaults = %+v", cfg.Defaults)
+	}
+	if _, local := cfg.FindProfile("local"); local == nil || local.Keyspace != "app" || local.Port != 9042 {
+		t.Errorf("local = %+v", local)
+	}
+	if _, staging := cfg.FindProfile("staging"); staging == nil {
+		t.Error("staging profile from the project file is missing")
+	}
+
+	wantOrigins := map[string]string{
+		"defaults.page_size":            explicit,
+		"defaults.timeout_ms":           system,
+		"defaults.default_profile":      inner,
+		"clients.tui.theme":             system,
+		"profiles[local].name":          user,
+		"profiles[local].hosts":         user,
+		"profiles[local].keyspace":      outer,
+		"profiles[staging].hosts":       inner,
+		"clients.web.default_port":      OriginDefault,
+		"profiles[local].auth.username": OriginDefault,
+	}
+	for setting, want := range wantOrigins {
+		if got := origins.Origin(setting); got != want {
+			t.Errorf("Origin(%q) = %q, want %q", setting, got, want)
+		}
+	}
+}
+
+func TestLegacyProjectFile(t *testing.T) {
+	loader, root := newStackLoader(t)
+	workDir := loader.workDir
+
+	writeLayer(t, filepath.Join(root, "work", legacyProjectConfigName), `{"defaults": {"page_size": 10}}`)
+	legacy := writeLayer(t, filepath.Join(workDir, "kassie.config.yaml"), "defaults:\n  page_size: 20\n")
+	project := writeLayer(t, filepath.Join(workDir, projectConfigName), `{"defaults": {"page_size": 30}}`)
+
+	if got, want := loader.UntrustedProjectFiles(), []string{legacy, project}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("UntrustedProjectFiles() = %v, want %v", got, want)
+	}
+	if err := loader.TrustProject(workDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.TrustProject(filepath.Join(root, "work")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := loader.Layers(), []string{legacy, project}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Layers() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadStackErrors(t *testing.T) {
+	t.Run("no files", func(t *testing.T) {
+		loader, _ := newStackLoader(t)
+		if _, _, err := loader.LoadStack(); !errors.Is(err, ErrFileNotFound) {
+			t.Errorf("LoadStack() error = %v, want %v", err, ErrFileNotFound)
+		}
+	})
+
+	t.Run("missing explicit file", func(t *testing.T) {
+		loader, root := newStackLoader(t)
+		writeLayer(t, filepath.Join(root, "home", "config.json"), `{"profiles": [{"name": "a", "hosts": ["h"], "port": 9042}]}`)
+		loader.explicitPath = filepath.Join(root, "missing.json")
+		if _, _, err := loader.LoadStack(); !errors.Is(err, ErrFileNotFound) {
+			t.Errorf("LoadStack() error = %v, want %v", err, ErrFileNotFound)
+		}
+	})
+
+	t.Run("bad layer is named", func(t *testing.T) {
+		loader, root := newStackLoader(t)
+		writeLayer(t, filepath.Join(root, "home", "config.json"), `{"profiles": [{"name": "a", "hosts": ["h"], "port": 9042}]}`)
+		bad := writeLayer(t, filepath.Join(root, "work", ".kassie.json"), `{"profiles": [`)
+		if err := loader.TrustProject(filepath.Dir(bad)); err != nil {
+			t.Fatal(err)
+		}
+		_, _, err := loader.LoadStack()
+		if !errors.Is(err, ErrInvalidJSON) {
+			t.Fatalf("LoadStack() error = %v, want %v", err, ErrInvalidJSON)
+		}
+		if got := err.Error(); !strings.HasPrefix(got, bad) {
+			t.Errorf("LoadStack() error = %q, want it to start with %q", got, bad)
+		}
+	})
+}
+
+func TestOriginsReplacedBlocks(t *testing.T) {
+	origins := make(Origins)
+	base := &Config{Profiles: []Profile{{Name: "prod", Tunnel: &TunnelConfig{Host: "bastion", User: "ops"}}}}
+	override := &Config{Profiles: []Profile{{Name: "prod", Tunnel: &TunnelConfig{Host: "jump"}}}}
+
+	if err := origins.record(base, "base.json"); err != nil {
+		t.Fatal(err)
+	}
+	if err := origins.record(override, "override.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := origins.Origin("profiles[prod].tunnel.host"); got != "override.json" {
+		t.Errorf("tunnel.host origin = %q, want override.json", got)
+	}
+	if got := origins.Origin("profiles[prod].tunnel.user"); got != OriginDefault {
+		t.Errorf("tunnel.user origin = %q, want %q since the block was replaced", got, OriginDefault)
+	}
+}
@@ -1,11 +1,13 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 var (
@@ -17,19 +19,23 @@ var (
 )
 
 type Loader struct {
+	systemPath   string
 	primaryPath  string
-	fallbackPath string
 	explicitPath string
+	trustPath    string
+	workDir      string
 }
 
 func NewLoader() *Loader {
 	homeDir, _ := os.UserHomeDir()
-	primaryPath := filepath.Join(homeDir, ".config", "kassie", "config.json")
-	fallbackPath := filepath.Join(".", "kassie.config.json")
+	configDir := filepath.Join(homeDir, ".config", "kassie")
+	workDir, _ := os.Getwd()
 
 	return &Loader{
-		primaryPath:  primaryPath,
-		fallbackPath: fallbackPath,
+		systemPath:  systemConfigPath(),
+		primaryPath: filepath.Join(configDir, "config.json"),
+		trustPath:   filepath.Join(configDir, trustFileName),
+		workDir:     workDir,
 	}
 }
 
@@ -39,6 +45,8 @@ func NewLoaderWithPath(path string) *Loader {
 	return loader
 }
 
+// GetConfigPath returns the file profile edits are written to: the --config
+// file, or else the user file. Both are layers that Load reads.
 func (l *Loader) GetConfigPath() (string, error) {
 	if l.explicitPath != "" {
 		if fileExists(l.explicitPath) {
@@ -47,24 +55,46 @@ func (l *Loader) GetConfigPath() (string, error) {
 		return "", fmt.Errorf("%w: %s", ErrFileNotFound, l.explicitPath)
 	}
 
-	if fileExists(l.primaryPath) {
-		return l.primaryPath, nil
-	}
-
-	if fileExists(l.fallbackPath) {
-		return l.fallbackPath, nil
+	if path, ok := findConfigFile(l.primaryPath); ok {
+		return path, nil
 	}
 
 	return "", ErrFileNotFound
 }
 
-func (l *Loader) Load() (*Config, error) {
-	configPath, err := l.GetConfigPath()
-	if err != nil {
-		return nil, err
+// findConfigFile looks for path, then for the same name with each of the
+// other config extensions, so config.yaml is found in place of config.json.
+func findConfigFile(path string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	if fileExists(path) {
+		return path, true
+	}
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, ext := range configExtensions {
+		if candidate := base + ext; fileExists(candidate) {
+			return candidate, true
+		}
 	}
+	return "", false
+}
 
-	return l.LoadFromPath(configPath)
+// DefaultConfigPath returns the config file in ~/.config/kassie, in
+// whichever supported format exists, or config.json when there is none.
+func DefaultConfigPath() string {
+	path := NewLoader().primaryPath
+	if found, ok := findConfigFile(path); ok {
+		return found
+	}
+	return path
+}
+
+// Load merges the config stack listed by Layers. LoadFromPath loads a single
+// file on its own.
+func (l *Loader) Load() (*Config, error) {
+	config, _, err := l.LoadStack()
+	return config, err
 }
 
 func (l *Loader) LoadFromPath(path string) (*Config, error) {
@@ -83,18 +113,18 @@ func (l *Loader) LoadFromPath(path string) (*Config, error) {
 		return nil, fmt.Errorf("%w: %v", ErrFileReadError, err)
 	}
 
-	if len(data) == 0 {
-		return nil, fmt.Errorf("%w: file is empty", ErrInvalidJSON)
+	config, err := parseConfig(FormatForPath(path), data)
+	if err != nil {
+		return nil, err
 	}
 
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+	if err := config.ResolveInheritance(); err != nil {
+		return nil, fmt.Errorf("failed to resolve profile inheritance: %w", err)
 	}
 
 	config.SetDefaults()
 
-	if err := InterpolateConfig(&config); err != nil {
+	if err := InterpolateConfig(config); err != nil {
 		return nil, fmt.Errorf("failed to interpolate environment variables: %w", err)
 	}
 
@@ -102,6 +132,23 @@ func (l *Loader) LoadFromPath(path string) (*Config, error) {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
 
+	return config, nil
+}
+
+func parseConfig(format Format, data []byte) (*Config, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidJSON)
+	}
+
+	data, err := toJSON(format, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, decodeJSONError(data, err)
+	}
 	return &config, nil
 }
 
@@ -114,17 +161,24 @@ func (l *Loader) Save(config *Config) error {
 		return fmt.Errorf("config validation failed: %w", err)
 	}
 
-	path := l.explicitPath
-	if path == "" {
-		path = l.primaryPath
+	return l.write(l.savePath(), config)
+}
+
+// savePath is the explicit path, or the primary path when none was given.
+func (l *Loader) savePath() string {
+	if l.explicitPath != "" {
+		return l.explicitPath
 	}
+	return l.primaryPath
+}
 
+func (l *Loader) write(path string, config *Config) error {
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("%w: %v", ErrFileWriteError, err)
 	}
 
-	data, err := json.MarshalIndent(config, "", "  ")
+	data, err := encodeConfig(FormatForPath(path), config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -14,8 +14,8 @@ func TestNewLoader(t *testing.T) {
 	if loader.primaryPath == "" {
 		t.Error("primaryPath should not be empty")
 	}
-	if loader.fallbackPath == "" {
-		t.Error("fallbackPath should not be empty")
+	if loader.trustPath == "" {
+		t.Error("trustPath should not be empty")
 	}
 	if loader.explicitPath != "" {
 		t.Error("explicitPath should be empty")
@@ -73,26 +73,11 @@ func TestLoaderGetConfigPath(t *testing.T) {
 				return filepath.Base(path) == "primary.json"
 			},
 		},
-		{
-			name: "fallback path exists",
-			setupFiles: func() *Loader {
-				loader := NewLoader()
-				loader.primaryPath = filepath.Join(tmpDir, "nonexistent_primary.json")
-				loader.fallbackPath = filepath.Join(tmpDir, "fallback.json")
-				_ = os.WriteFile(loader.fallbackPath, []byte("{}"), 0644)
-				return loader
-			},
-			wantErr: nil,
-			checkPath: func(path string) bool {
-				return filepath.Base(path) == "fallback.json"
-			},
-		},
 		{
 			name: "no config file exists",
 			setupFiles: func() *Loader {
 				loader := NewLoader()
 				loader.primaryPath = filepath.Join(tmpDir, "nonexistent1.json")
-				loader.fallbackPath = filepath.Join(tmpDir, "nonexistent2.json")
 				return loader
 			},
 			wantErr: ErrFileNotFound,
@@ -480,23 +465,11 @@ func TestLoaderExists(t *testing.T) {
 			},
 			want: true,
 		},
-		{
-			name: "fallback path exists",
-			setup: func() *Loader {
-				loader := NewLoader()
-				loader.primaryPath = filepath.Join(tmpDir, "nonexistent_primary.json")
-				loader.fallbackPath = filepath.Join(tmpDir, "fallback.json")
-				_ = os.WriteFile(loader.fallbackPath, []byte("{}"), 0644)
-				return loader
-			},
-			want: true,
-		},
 		{
 			name: "no path exists",
 			setup: func() *Loader {
 				loader := NewLoader()
 				loader.primaryPath = filepath.Join(tmpDir, "none1.json")
-				loader.fallbackPath = filepath.Join(tmpDir, "none2.json")
 				return loader
 			},
 			want: false,
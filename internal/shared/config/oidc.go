@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const (
+	DefaultOIDCUsernameClaim = "email"
+	DefaultOIDCGroupsClaim   = "groups"
+	DefaultOIDCPostLoginURL  = "/login"
+)
+
+var DefaultOIDCScopes = []string{"openid", "email", "profile"}
+
+// ExternalUserPrefix namespaces users that sign in through OIDC, so an
+// identity asserted by the provider never matches a local user.
+const ExternalUserPrefix = "sso:"
+
+func (o *OIDCConfig) Validate() error {
+	if o.Issuer == "" {
+		return fmt.Errorf("%w: issuer is required", ErrInvalidOIDC)
+	}
+	if o.ClientID == "" {
+		return fmt.Errorf("%w: client_id is required", ErrInvalidOIDC)
+	}
+	if o.RedirectURL == "" {
+		return fmt.Errorf("%w: redirect_url is required", ErrInvalidOIDC)
+	}
+
+	for name, raw := range map[string]string{"issuer": o.Issuer, "redirect_url": o.RedirectURL} {
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("%w: %s must be an absolute URL", ErrInvalidOIDC, name)
+		}
+	}
+
+	return nil
+}
+
+func (o *OIDCConfig) GetScopes() []string {
+	if len(o.Scopes) == 0 {
+		return DefaultOIDCScopes
+	}
+	return o.Scopes
+}
+
+func (o *OIDCConfig) GetUsernameClaim() string {
+	if o.UsernameClaim == "" {
+		return DefaultOIDCUsernameClaim
+	}
+	return o.UsernameClaim
+}
+
+func (o *OIDCConfig) GetGroupsClaim() string {
+	if o.GroupsClaim == "" {
+		return DefaultOIDCGroupsClaim
+	}
+	return o.GroupsClaim
+}
+
+func (o *OIDCConfig) GetPostLoginURL() string {
+	if o.PostLoginURL == "" {
+		return DefaultOIDCPostLoginURL
+	}
+	return o.PostLoginURL
+}
+
+// RolesForGroups maps IdP groups to Kassie roles. DefaultRoles are added for
+// every external user; an empty result means the user gets no access.
+func (o *OIDCConfig) RolesForGroups(groups []string) []string {
+	seen := make(map[string]bool)
+	var roles []string
+
+	add := func(names []string) {
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				roles = append(roles, name)
+			}
+		}
+	}
+
+	add(o.DefaultRoles)
+	for _, g := range groups {
+		add(o.GroupRoles[g])
+	}
+
+	return roles
+}
+
+func (o *OIDCConfig) clone() *OIDCConfig {
+	clone := *o
+	clone.Scopes = append([]string(nil), o.Scopes...)
+	clone.DefaultRoles = append([]string(nil), o.DefaultRoles...)
+	if o.GroupRoles != nil {
+		clone.GroupRoles = make(map[string][]string, len(o.GroupRoles))
+		for g, roles := range o.GroupRoles {
+			clone.GroupRoles[g] = append([]string(nil), roles...)
+		}
+	}
+	return &clone
+}
+
+func (s *ServerConfig) validateOIDC(roles map[string]Role) error {
+	if s.OIDC == nil {
+		return nil
+	}
+
+	if err := s.OIDC.Validate(); err != nil {
+		return err
+	}
+
+	for _, name := range s.OIDC.DefaultRoles {
+		if _, ok := roles[name]; !ok {
+			return fmt.Errorf("%w: oidc default role %s", ErrRoleNotFound, name)
+		}
+	}
+	for group, names := range s.OIDC.GroupRoles {
+		for _, name := range names {
+			if _, ok := roles[name]; !ok {
+				return fmt.Errorf("%w: oidc group %s maps to unknown role %s", ErrRoleNotFound, group, name)
+			}
+		}
+	}
+
+	return nil
+}
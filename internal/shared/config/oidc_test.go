@@ -0,0 +1,124 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func validOIDCConfig() *OIDCConfig {
+	return &OIDCConfig{
+		Issuer:      "https://idp.example.com",
+		ClientID:    "kassie",
+		RedirectURL: "https://kassie.example.com/api/v1/auth/oidc/callback",
+	}
+}
+
+func TestOIDCConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*OIDCConfig)
+		wantErr error
+	}{
+		{name: "valid", modify: func(*OIDCConfig) {}, wantErr: nil},
+		{name: "missing issuer", modify: func(o *OIDCConfig) { o.Issuer = "" }, wantErr: ErrInvalidOIDC},
+		{name: "missing client id", modify: func(o *OIDCConfig) { o.ClientID = "" }, wantErr: ErrInvalidOIDC},
+		{name: "missing redirect url", modify: func(o *OIDCConfig) { o.RedirectURL = "" }, wantErr: ErrInvalidOIDC},
+		{name: "relative redirect url", modify: func(o *OIDCConfig) { o.RedirectURL = "/callback" }, wantErr: ErrInvalidOIDC},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := validOIDCConfig()
+			tt.modify(o)
+			err := o.Validate()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("OIDCConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOIDCConfigDefaults(t *testing.T) {
+	o := validOIDCConfig()
+
+	if !reflect.DeepEqual(o.GetScopes(), DefaultOIDCScopes) {
+		t.Errorf("GetScopes() = %v, want %v", o.GetScopes(), DefaultOIDCScopes)
+	}
+	if o.GetUsernameClaim() != "email" {
+		t.Errorf("GetUsernameClaim() = %q, want email", o.GetUsernameClaim())
+	}
+	if o.GetGroupsClaim() != "groups" {
+		t.Errorf("GetGroupsClaim() = %q, want groups", o.GetGroupsClaim())
+	}
+	if o.GetPostLoginURL() != DefaultOIDCPostLoginURL {
+		t.Errorf("GetPostLoginURL() = %q, want %q", o.GetPostLoginURL(), DefaultOIDCPostLoginURL)
+	}
+}
+
+func TestOIDCConfigRolesForGroups(t *testing.T) {
+	o := validOIDCConfig()
+	o.DefaultRoles = []string{RoleViewer}
+	o.GroupRoles = map[string][]string{
+		"dba":     {RoleAdmin},
+		"backend": {RoleEditor, RoleViewer},
+	}
+
+	tests := []struct {
+		name   string
+		groups []string
+		want   []string
+	}{
+		{name: "no groups", groups: nil, want: []string{RoleViewer}},
+		{name: "mapped group", groups: []string{"dba"}, want: []string{RoleViewer, RoleAdmin}},
+		{name: "deduplicated", groups: []string{"backend", "unknown"}, want: []string{RoleViewer, RoleEditor}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := o.RolesForGroups(tt.groups); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RolesForGroups(%v) = %v, want %v", tt.groups, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigValidateOIDC(t *testing.T) {
+	tests := []struct {
+		name    string
+		oidc    *OIDCConfig
+		wantErr error
+	}{
+		{
+			name:    "valid mapping",
+			oidc:    &OIDCConfig{Issuer: "https://idp", ClientID: "kassie", RedirectURL: "https://kassie/cb", GroupRoles: map[string][]string{"dba": {RoleAdmin}}},
+			wantErr: nil,
+		},
+		{
+			name:    "unknown group role",
+			oidc:    &OIDCConfig{Issuer: "https://idp", ClientID: "kassie", RedirectURL: "https://kassie/cb", GroupRoles: map[string][]string{"dba": {"root"}}},
+			wantErr: ErrRoleNotFound,
+		},
+		{
+			name:    "unknown default role",
+			oidc:    &OIDCConfig{Issuer: "https://idp", ClientID: "kassie", RedirectURL: "https://kassie/cb", DefaultRoles: []string{"guest"}},
+			wantErr: ErrRoleNotFound,
+		},
+		{
+			name:    "missing issuer",
+			oidc:    &OIDCConfig{ClientID: "kassie", RedirectURL: "https://kassie/cb"},
+			wantErr: ErrInvalidOIDC,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validUserConfig()
+			cfg.Server.OIDC = tt.oidc
+			err := cfg.Validate()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -39,6 +39,9 @@ func ApplyOverrides(config *Config, override *Override) error {
 
 	if override.PageSize > 0 {
 		config.Defaults.PageSize = override.PageSize
+		for i := range config.Profiles {
+			config.Profiles[i].PageSize = override.PageSize
+		}
 	}
 
 	if override.TimeoutMs > 0 {
@@ -100,11 +103,27 @@ func MergeConfigs(base *Config, override *Config) (*Config, error) {
 	if base == nil {
 		return nil, fmt.Errorf("base config is nil")
 	}
-	if override == nil {
-		return base.clone(), nil
+
+	merged, err := mergeConfigs(base, override)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := merged.Validate(); err != nil {
+		return nil, fmt.Errorf("merged config validation failed: %w", err)
 	}
 
+	return merged, nil
+}
+
+// mergeConfigs merges without validating, since a layer of a config stack
+// need not be complete on its own. Booleans only override when set, so a
+// layer that leaves them out does not turn them off.
+func mergeConfigs(base *Config, override *Config) (*Config, error) {
 	merged := base.clone()
+	if override == nil {
+		return merged, nil
+	}
 
 	for _, overrideProfile := range override.Profiles {
 		idx, existingProfile := merged.FindProfile(overrideProfile.Name)
@@ -113,12 +132,14 @@ func MergeConfigs(base *Config, override *Config) (*Config, error) {
 				return nil, fmt.Errorf("failed to merge profile %s: %w", overrideProfile.Name, err)
 			}
 		} else {
-			if err := merged.AddProfile(overrideProfile); err != nil {
-				return nil, fmt.Errorf("failed to add profile %s: %w", overrideProfile.Name, err)
-			}
+			merged.Profiles = append(merged.Profiles, *overrideProfile.Clone())
 		}
 	}
 
+	if override.Version != "" {
+		merged.Version = override.Version
+	}
+
 	if override.Defaults.PageSize > 0 {
 		merged.Defaults.PageSize = override.Defaults.PageSize
 	}
@@ -135,21 +156,29 @@ func MergeConfigs(base *Config, override *Config) (*Config, error) {
 		merged.Clients.Web.DefaultPort = override.Clients.Web.DefaultPort
 	}
 
-	merged.Clients.Web.AutoOpenBrowser = override.Clients.Web.AutoOpenBrowser
+	if override.Clients.Web.AutoOpenBrowser {
+		merged.Clients.Web.AutoOpenBrowser = true
+	}
 
 	if override.Clients.TUI.Theme != "" {
 		merged.Clients.TUI.Theme = override.Clients.TUI.Theme
 	}
 
-	merged.Clients.TUI.VimMode = override.Clients.TUI.VimMode
+	if override.Clients.TUI.VimMode {
+		merged.Clients.TUI.VimMode = true
+	}
 
-	if err := merged.Validate(); err != nil {
-		return nil, fmt.Errorf("merged config validation failed: %w", err)
+	if override.Server.hasSettings() {
+		merged.Server = override.Server.clone()
 	}
 
 	return merged, nil
 }
 
+func (s *ServerConfig) hasSettings() bool {
+	return s != nil && (len(s.Users) > 0 || len(s.Roles) > 0 || s.OIDC != nil || s.TokenFile != "")
+}
+
 func (c *Config) clone() *Config {
 	clone := &Config{
 		Version:  c.Version,
@@ -162,6 +191,10 @@ func (c *Config) clone() *Config {
 		clone.Profiles[i] = *c.Profiles[i].Clone()
 	}
 
+	if c.Server != nil {
+		clone.Server = c.Server.clone()
+	}
+
 	return clone
 }
 
@@ -1,21 +1,32 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+)
 
 func (p *Profile) Clone() *Profile {
 	clone := &Profile{
-		Name:     p.Name,
-		Hosts:    make([]string, len(p.Hosts)),
-		Port:     p.Port,
-		Keyspace: p.Keyspace,
+		Name:                p.Name,
+		Hosts:               slices.Clone(p.Hosts),
+		Port:                p.Port,
+		Keyspace:            p.Keyspace,
+		SecureConnectBundle: p.SecureConnectBundle,
+		Environment:         p.Environment,
+		Color:               p.Color,
+		PageSize:            p.PageSize,
+		DefaultKeyspace:     p.DefaultKeyspace,
+		DefaultTable:        p.DefaultTable,
+		Extends:             p.Extends,
+		Abstract:            p.Abstract,
+		Ephemeral:           p.Ephemeral,
 	}
 
-	copy(clone.Hosts, p.Hosts)
-
 	if p.Auth != nil {
 		clone.Auth = &AuthConfig{
 			Username: p.Auth.Username,
 			Password: p.Auth.Password,
+			Prompt:   p.Auth.Prompt,
 		}
 	}
 
@@ -29,6 +40,18 @@ func (p *Profile) Clone() *Profile {
 		}
 	}
 
+	if p.Driver != nil {
+		clone.Driver = p.Driver.Clone()
+	}
+
+	if p.Tunnel != nil {
+		clone.Tunnel = p.Tunnel.Clone()
+	}
+
+	if p.SOCKS5 != nil {
+		clone.SOCKS5 = p.SOCKS5.Clone()
+	}
+
 	return clone
 }
 
@@ -50,6 +73,38 @@ func (p *Profile) MergeWith(override *Profile) error {
 		p.Keyspace = override.Keyspace
 	}
 
+	if override.SecureConnectBundle != "" {
+		p.SecureConnectBundle = override.SecureConnectBundle
+	}
+
+	if override.Environment != "" {
+		p.Environment = override.Environment
+	}
+
+	if override.Color != "" {
+		p.Color = override.Color
+	}
+
+	if override.PageSize != 0 {
+		p.PageSize = override.PageSize
+	}
+
+	if override.DefaultKeyspace != "" {
+		p.DefaultKeyspace = override.DefaultKeyspace
+	}
+
+	if override.DefaultTable != "" {
+		p.DefaultTable = override.DefaultTable
+	}
+
+	if override.Extends != "" {
+		p.Extends = override.Extends
+	}
+
+	if override.Abstract {
+		p.Abstract = true
+	}
+
 	if override.Auth != nil {
 		if p.Auth == nil {
 			p.Auth = &AuthConfig{}
@@ -60,6 +115,9 @@ func (p *Profile) MergeWith(override *Profile) error {
 		if override.Auth.Password != "" {
 			p.Auth.Password = override.Auth.Password
 		}
+		if override.Auth.Prompt {
+			p.Auth.Prompt = true
+		}
 	}
 
 	if override.SSL != nil {
@@ -81,6 +139,23 @@ func (p *Profile) MergeWith(override *Profile) error {
 		}
 	}
 
+	if override.Driver != nil {
+		if p.Driver == nil {
+			p.Driver = &DriverConfig{}
+		}
+		p.Driver.MergeWith(override.Driver)
+	}
+
+	if override.Tunnel != nil {
+		p.Tunnel = override.Tunnel.Clone()
+		p.SOCKS5 = nil
+	}
+
+	if override.SOCKS5 != nil {
+		p.SOCKS5 = override.SOCKS5.Clone()
+		p.Tunnel = nil
+	}
+
 	return nil
 }
 
@@ -135,6 +210,11 @@ func (c *Config) SetDefaults() {
 	if c.Defaults.PageSize == 0 {
 		c.Defaults.PageSize = 100
 	}
+	for i := range c.Profiles {
+		if c.Profiles[i].PageSize == 0 {
+			c.Profiles[i].PageSize = c.Defaults.PageSize
+		}
+	}
 	if c.Defaults.TimeoutMs == 0 {
 		c.Defaults.TimeoutMs = 5000
 	}
@@ -30,6 +30,18 @@ func TestProfileClone(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "profile with prompted auth",
+			profile: Profile{
+				Name:  "test",
+				Hosts: []string{"localhost"},
+				Port:  9042,
+				Auth: &AuthConfig{
+					Username: "analyst",
+					Prompt:   true,
+				},
+			},
+		},
 		{
 			name: "profile with ssl",
 			profile: Profile{
@@ -105,6 +117,9 @@ func TestProfileClone(t *testing.T) {
 				if clone.Auth.Password != tt.profile.Auth.Password {
 					t.Errorf("Auth.Password = %v, want %v", clone.Auth.Password, tt.profile.Auth.Password)
 				}
+				if clone.Auth.Prompt != tt.profile.Auth.Prompt {
+					t.Errorf("Auth.Prompt = %v, want %v", clone.Auth.Prompt, tt.profile.Auth.Prompt)
+				}
 				if clone.Auth == tt.profile.Auth {
 					t.Error("Auth pointer is same, should be different")
 				}
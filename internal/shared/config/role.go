@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	OpRead   Operation = "read"
+	OpWrite  Operation = "write"
+	OpDDL    Operation = "ddl"
+	OpExport Operation = "export"
+	OpAdmin  Operation = "admin"
+
+	// AllOperations grants every operation category.
+	AllOperations Operation = "*"
+)
+
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+
+	// DefaultRole is applied to users that do not list any roles.
+	DefaultRole = RoleViewer
+)
+
+var validOperations = map[Operation]bool{
+	OpRead:        true,
+	OpWrite:       true,
+	OpDDL:         true,
+	OpExport:      true,
+	OpAdmin:       true,
+	AllOperations: true,
+}
+
+func BuiltinRoles() []Role {
+	return []Role{
+		{Name: RoleViewer, Operations: []Operation{OpRead}},
+		{Name: RoleEditor, Operations: []Operation{OpRead, OpWrite, OpExport}},
+		{Name: RoleAdmin, Operations: []Operation{AllOperations}},
+	}
+}
+
+func (r *Role) Validate() error {
+	if r.Name == "" {
+		return ErrInvalidRole
+	}
+	if len(r.Operations) == 0 {
+		return fmt.Errorf("%w: role %s grants no operations", ErrInvalidRole, r.Name)
+	}
+	for _, op := range r.Operations {
+		if !validOperations[op] {
+			return fmt.Errorf("%w: role %s has unknown operation %q", ErrInvalidRole, r.Name, op)
+		}
+	}
+
+	patterns := make([]string, 0, len(r.Profiles)+len(r.Keyspaces)+len(r.Tables))
+	patterns = append(patterns, r.Profiles...)
+	patterns = append(patterns, r.Keyspaces...)
+	for _, t := range r.Tables {
+		if !strings.Contains(t, ".") {
+			return fmt.Errorf("%w: role %s table pattern %q must be keyspace.table", ErrInvalidRole, r.Name, t)
+		}
+		patterns = append(patterns, t)
+	}
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("%w: role %s has malformed pattern %q", ErrInvalidRole, r.Name, p)
+		}
+	}
+
+	return nil
+}
+
+func (r *Role) Allows(op Operation) bool {
+	for _, o := range r.Operations {
+		if o == AllOperations || o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleSet returns the built-in roles overlaid with the roles defined in the
+// server config, keyed by name.
+func (s *ServerConfig) RoleSet() map[string]Role {
+	roles := make(map[string]Role)
+	for _, r := range BuiltinRoles() {
+		roles[r.Name] = r
+	}
+	if s == nil {
+		return roles
+	}
+	for _, r := range s.Roles {
+		roles[r.Name] = r
+	}
+	return roles
+}
+
+func (s *ServerConfig) validateRoles() error {
+	names := make(map[string]bool)
+	for i := range s.Roles {
+		r := &s.Roles[i]
+		if err := r.Validate(); err != nil {
+			return err
+		}
+		if names[r.Name] {
+			return ErrDuplicateRole
+		}
+		names[r.Name] = true
+	}
+
+	roles := s.RoleSet()
+	for _, u := range s.Users {
+		for _, name := range u.Roles {
+			if _, ok := roles[name]; !ok {
+				return fmt.Errorf("%w: user %s references unknown role %s", ErrRoleNotFound, u.Username, name)
+			}
+		}
+	}
+
+	return s.validateOIDC(roles)
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRoleValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    Role
+		wantErr error
+	}{
+		{
+			name:    "valid role",
+			role:    Role{Name: "analyst", Operations: []Operation{OpRead, OpExport}, Keyspaces: []string{"analytics_*"}},
+			wantErr: nil,
+		},
+		{
+			name:    "table patterns",
+			role:    Role{Name: "support", Operations: []Operation{OpRead}, Tables: []string{"app.users", "app.orders_*"}},
+			wantErr: nil,
+		},
+		{
+			name:    "empty name",
+			role:    Role{Operations: []Operation{OpRead}},
+			wantErr: ErrInvalidRole,
+		},
+		{
+			name:    "no operations",
+			role:    Role{Name: "empty"},
+			wantErr: ErrInvalidRole,
+		},
+		{
+			name:    "unknown operation",
+			role:    Role{Name: "bad", Operations: []Operation{"delete"}},
+			wantErr: ErrInvalidRole,
+		},
+		{
+			name:    "table without keyspace",
+			role:    Role{Name: "bad", Operations: []Operation{OpRead}, Tables: []string{"users"}},
+			wantErr: ErrInvalidRole,
+		},
+		{
+			name:    "malformed pattern",
+			role:    Role{Name: "bad", Operations: []Operation{OpRead}, Keyspaces: []string{"app_["}},
+			wantErr: ErrInvalidRole,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.role.Validate()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Role.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRoleAllows(t *testing.T) {
+	viewer := Role{Name: RoleViewer, Operations: []Operation{OpRead}}
+	admin := Role{Name: RoleAdmin, Operations: []Operation{AllOperations}}
+
+	if !viewer.Allows(OpRead) {
+		t.Error("expected viewer to allow read")
+	}
+	if viewer.Allows(OpWrite) {
+		t.Error("expected viewer to deny write")
+	}
+	if !admin.Allows(OpDDL) {
+		t.Error("expected admin to allow ddl")
+	}
+}
+
+func TestServerConfigRoleSet(t *testing.T) {
+	var nilServer *ServerConfig
+	if len(nilServer.RoleSet()) != 3 {
+		t.Fatalf("expected built-in roles for nil server config")
+	}
+
+	s := &ServerConfig{Roles: []Role{
+		{Name: RoleViewer, Operations: []Operation{OpRead, OpExport}},
+		{Name: "analyst", Operations: []Operation{OpRead}},
+	}}
+	roles := s.RoleSet()
+
+	if len(roles) != 4 {
+		t.Errorf("expected 4 roles, got %d", len(roles))
+	}
+	viewer := roles[RoleViewer]
+	if !viewer.Allows(OpExport) {
+		t.Error("expected configured viewer role to override built-in")
+	}
+}
+
+func TestConfigValidateRoles(t *testing.T) {
+	tests := []struct {
+		name    string
+		server  ServerConfig
+		wantErr error
+	}{
+		{
+			name: "built-in role",
+			server: ServerConfig{
+				Users: []User{{Username: "alice", PasswordHash: testBcryptHash, Profiles: []string{"dev"}, Roles: []string{RoleEditor}}},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "custom role",
+			server: ServerConfig{
+				Users: []User{{Username: "alice", PasswordHash: testBcryptHash, Roles: []string{"analyst"}}},
+				Roles: []Role{{Name: "analyst", Operations: []Operation{OpRead}}},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "unknown role",
+			server: ServerConfig{
+				Users: []User{{Username: "alice", PasswordHash: testBcryptHash, Roles: []string{"superuser"}}},
+			},
+			wantErr: ErrRoleNotFound,
+		},
+		{
+			name: "duplicate role",
+			server: ServerConfig{
+				Roles: []Role{{Name: "analyst", Operations: []Operation{OpRead}}, {Name: "analyst", Operations: []Operation{OpRead}}},
+			},
+			wantErr: ErrDuplicateRole,
+		},
+		{
+			name: "invalid role",
+			server: ServerConfig{
+				Roles: []Role{{Name: "analyst"}},
+			},
+			wantErr: ErrInvalidRole,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validUserConfig()
+			cfg.Server = &tt.server
+			err := cfg.Validate()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
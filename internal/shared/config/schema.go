@@ -0,0 +1,136 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaURL is where the docs site publishes the schema returned by Schema.
+const SchemaURL = "https://kassie.kashifkhan.dev/config.schema.json"
+
+// schemaRules adds the limits Validate enforces to the generated schema,
+// keyed by struct name and JSON field.
+var schemaRules = map[string]map[string]interface{}{
+	"Config.profiles":                 {"minItems": 1},
+	"Profile.port":                    {"minimum": 1, "maximum": 65535},
+	"Profile.environment":             {"enum": environments},
+	"Profile.color":                   {"pattern": colorPattern.String()},
+	"Profile.page_size":               {"minimum": 1, "maximum": DefaultMaxPageSize},
+	"DefaultConfig.page_size":         {"minimum": 1, "maximum": DefaultMaxPageSize},
+	"DefaultConfig.timeout_ms":        {"minimum": 100, "maximum": 300000},
+	"WebConfig.default_port":          {"minimum": 1, "maximum": 65535},
+	"DriverConfig.consistency":        {"examples": consistencyLevels},
+	"DriverConfig.connect_timeout_ms": {"minimum": minDriverTimeoutMs, "maximum": maxDriverTimeoutMs},
+	"DriverConfig.timeout_ms":         {"minimum": minDriverTimeoutMs, "maximum": maxDriverTimeoutMs},
+	"DriverConfig.protocol_version":   {"minimum": minProtocolVersion, "maximum": maxProtocolVersion},
+	"DriverConfig.compression":        {"enum": []string{CompressionNone, CompressionSnappy, CompressionLZ4}},
+	"RetryConfig.policy":              {"enum": []string{RetryPolicySimple, RetryPolicyExponential}},
+	"RetryConfig.max_retries":         {"minimum": 0, "maximum": maxRetries},
+	"RetryConfig.min_backoff_ms":      {"minimum": 0},
+	"RetryConfig.max_backoff_ms":      {"minimum": 0},
+	"SpeculativeConfig.attempts":      {"minimum": 1, "maximum": maxSpeculativeAttempts},
+	"SpeculativeConfig.delay_ms":      {"minimum": 1},
+	"SOCKS5Config.address":            {"pattern": `^.+:\d+$`},
+}
+
+var schemaRequired = map[string][]string{
+	"Config":            {"profiles"},
+	"Profile":           {"name"},
+	"User":              {"username", "password_hash"},
+	"Role":              {"name", "operations"},
+	"OIDCConfig":        {"issuer", "client_id", "redirect_url"},
+	"TunnelConfig":      {"host", "user"},
+	"SOCKS5Config":      {"address"},
+	"RetryConfig":       {"policy"},
+	"SpeculativeConfig": {"attempts", "delay_ms"},
+}
+
+// Schema returns a JSON Schema for the config file, for editors to validate
+// and complete config.json, config.yaml and config.toml.
+func Schema() ([]byte, error) {
+	g := &schemaGenerator{defs: map[string]interface{}{}}
+	root := g.object(reflect.TypeOf(Config{}))
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$id"] = SchemaURL
+	root["title"] = "Kassie configuration"
+	// Editors read "$schema" from the file itself to find this schema.
+	root["properties"].(map[string]interface{})["$schema"] = map[string]interface{}{"type": "string"}
+	root["$defs"] = g.defs
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+type schemaGenerator struct {
+	defs map[string]interface{}
+}
+
+func (g *schemaGenerator) object(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+
+		prop := g.schema(field.Type)
+		for key, value := range schemaRules[t.Name()+"."+name] {
+			prop[key] = value
+		}
+		properties[name] = prop
+	}
+
+	obj := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if required := schemaRequired[t.Name()]; len(required) > 0 {
+		sorted := append([]string(nil), required...)
+		sort.Strings(sorted)
+		obj["required"] = sorted
+	}
+	return obj
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	if t == reflect.TypeOf(Operation("")) {
+		ops := make([]string, 0, len(validOperations))
+		for op := range validOperations {
+			ops = append(ops, string(op))
+		}
+		sort.Strings(ops)
+		return map[string]interface{}{"type": "string", "enum": ops}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = nil
+			g.defs[t.Name()] = g.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+	}
+	return map[string]interface{}{}
+}
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zalando/go-keyring"
+)
+
+var (
+	ErrInvalidSecretRef   = errors.New("invalid secret reference")
+	ErrSecretLookup       = errors.New("secret lookup failed")
+	ErrUntrustedSecretRef = errors.New("secret references are only read from local config files")
+)
+
+// SecretProvider looks up a secret from the part of a reference after its
+// scheme, such as the path in file:/run/secrets/db. Errors must not include
+// the secret.
+type SecretProvider func(ref string) (string, error)
+
+// SecretResolver turns file:, cmd: and keyring: references into the secrets
+// they name, and caches them for ttl so a command is not run on every login.
+// Values without a known scheme are returned unchanged.
+type SecretResolver struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	providers map[string]SecretProvider
+	cache     map[string]cachedSecret
+	now       func() time.Time
+}
+
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+func NewSecretResolver(ttl time.Duration) *SecretResolver {
+	return &SecretResolver{
+		ttl: ttl,
+		providers: map[string]SecretProvider{
+			"file":    readSecretFile,
+			"cmd":     runSecretCommand,
+			"keyring": readKeyring,
+		},
+		cache: make(map[string]cachedSecret),
+		now:   time.Now,
+	}
+}
+
+// DefaultSecretResolver resolves the secret references in profiles when
+// they are connected to.
+var DefaultSecretResolver = NewSecretResolver(DefaultSecretCacheTTL)
+
+// ResolveSecret resolves value with DefaultSecretResolver.
+func ResolveSecret(value string) (string, error) {
+	return DefaultSecretResolver.Resolve(value)
+}
+
+// Register adds or replaces the provider for scheme.
+func (r *SecretResolver) Register(scheme string, provider SecretProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[scheme] = provider
+}
+
+func (r *SecretResolver) Resolve(value string) (string, error) {
+	scheme, ref, ok := r.split(value)
+	if !ok {
+		return value, nil
+	}
+
+	r.mu.Lock()
+	cached, hit := r.cache[value]
+	provider := r.providers[scheme]
+	r.mu.Unlock()
+	if hit && r.now().Before(cached.expires) {
+		return cached.value, nil
+	}
+
+	secret, err := provider(ref)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %v", ErrSecretLookup, scheme, err)
+	}
+
+	r.mu.Lock()
+	r.cache[value] = cachedSecret{value: secret, expires: r.now().Add(r.ttl)}
+	r.mu.Unlock()
+	return secret, nil
+}
+
+func (r *SecretResolver) split(value string) (scheme, ref string, ok bool) {
+	scheme, ref, ok = strings.Cut(value, ":")
+	if !ok {
+		return "", "", false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok = r.providers[scheme]
+	return scheme, ref, ok
+}
+
+// IsSecretReference reports whether value is a file:, cmd: or keyring:
+// reference.
+func IsSecretReference(value string) bool {
+	_, _, ok := DefaultSecretResolver.split(value)
+	return ok
+}
+
+// RejectSecretReferences fails on the first credential holding a file:,
+// cmd: or keyring: reference. Profiles that do not come from the local
+// config files, such as those sent over the API, must pass it before they
+// are connected to or saved: resolving their references would read files
+// and run commands for whoever sent them.
+func (p *Profile) RejectSecretReferences() error {
+	for _, secret := range p.secretFields() {
+		if IsSecretReference(*secret.value) {
+			scheme, _, _ := strings.Cut(*secret.value, ":")
+			return fieldError(secret.field, ErrUntrustedSecretRef, "%s: references are not accepted here", scheme)
+		}
+	}
+	return nil
+}
+
+// validateSecretRef catches references that cannot resolve before anything
+// tries to connect.
+func validateSecretRef(field, value string) error {
+	scheme, ref, ok := DefaultSecretResolver.split(value)
+	if !ok {
+		return nil
+	}
+	if strings.TrimSpace(ref) == "" {
+		return fieldError(field, ErrInvalidSecretRef, "%s: needs a value after the scheme", scheme)
+	}
+	if scheme == "keyring" {
+		if _, _, err := keyringRef(ref); err != nil {
+			return fieldError(field, ErrInvalidSecretRef, "%v", err)
+		}
+	}
+	return nil
+}
+
+func readSecretFile(path string) (string, error) {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// runSecretCommand runs command with the shell and returns its output
+// without the trailing newline. The output is never part of an error.
+func runSecretCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultSecretCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("command timed out after %s", DefaultSecretCommandTimeout)
+		}
+		return "", fmt.Errorf("command failed: %v", err)
+	}
+	secret := strings.TrimRight(stdout.String(), "\r\n")
+	if secret == "" {
+		return "", errors.New("command printed nothing")
+	}
+	return secret, nil
+}
+
+// keyringRef splits service/user. A bare user is looked up under the
+// kassie service.
+func keyringRef(ref string) (service, user string, err error) {
+	service, user, ok := strings.Cut(ref, "/")
+	if !ok {
+		return "kassie", ref, nil
+	}
+	if service == "" || user == "" {
+		return "", "", fmt.Errorf("keyring: %q is not service/user", ref)
+	}
+	return service, user, nil
+}
+
+func readKeyring(ref string) (string, error) {
+	service, user, err := keyringRef(ref)
+	if err != nil {
+		return "", err
+	}
+	return keyring.Get(service, user)
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecretResolverResolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+		unix    bool
+	}{
+		{name: "plain value", value: "literal", want: "literal"},
+		{name: "unknown scheme", value: "http://example.com", want: "http://example.com"},
+		{name: "file", value: "file:" + path, want: "from-file"},
+		{name: "missing file", value: "file:" + path + ".missing", wantErr: true},
+		{name: "command", value: "cmd:echo from-cmd", want: "from-cmd", unix: true},
+		{name: "failing command", value: "cmd:echo leaked; exit 3", wantErr: true, unix: true},
+		{name: "silent command", value: "cmd:true", wantErr: true, unix: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.unix && runtime.GOOS == "windows" {
+				t.Skip("uses sh")
+			}
+			got, err := NewSecretResolver(time.Minute).Resolve(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrSecretLookup) {
+					t.Fatalf("Resolve() error = %v, want %v", err, ErrSecretLookup)
+				}
+				if strings.Contains(err.Error(), "leaked") {
+					t.Errorf("Resolve() error = %q includes the command output", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretResolverCache(t *testing.T) {
+	r := NewSecretResolver(time.Minute)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	calls := 0
+	r.Register("test", func(ref string) (string, error) {
+		calls++
+		if ref == "broken" {
+			return "", errors.New("unavailable")
+		}
+		return ref + "-secret", nil
+	})
+
+	for i := 0; i < 2; i++ {
+		if got, err := r.Resolve("test:db"); err != nil || got != "db-secret" {
+			t.Fatalf("Resolve() = %q, %v", got, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("provider called %d times, want 1 while cached", calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := r.Resolve("test:db"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("provider called %d times, want 2 after the cache expired", calls)
+	}
+
+	r.Resolve("test:broken")
+	r.Resolve("test:broken")
+	if calls != 4 {
+		t.Errorf("provider called %d times, want failures not cached", calls)
+	}
+}
+
+func TestProfileSecretReferences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte("s3cret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Profile{
+		Name:  "prod",
+		Hosts: []string{"h"},
+		Port:  9042,
+		Auth:  &AuthConfig{Username: "app", Password: "file:" + path},
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	resolved, err := p.ResolveSecrets()
+	if err != nil {
+		t.Fatalf("ResolveSecrets() error = %v", err)
+	}
+	if resolved.Auth.Password != "s3cret" || p.Auth.Password != "file:"+path {
+		t.Errorf("resolved = %q, original = %q", resolved.Auth.Password, p.Auth.Password)
+	}
+	if masked := MaskSecrets(&Config{Profiles: []Profile{*p}}); masked.Profiles[0].Auth.Password != "file:"+path {
+		t.Errorf("MaskSecrets() masked the reference: %q", masked.Profiles[0].Auth.Password)
+	}
+
+	if err := p.RejectSecretReferences(); !errors.Is(err, ErrUntrustedSecretRef) {
+		t.Errorf("RejectSecretReferences() error = %v, want %v", err, ErrUntrustedSecretRef)
+	}
+	p.Auth.Password = "s3cret"
+	if err := p.RejectSecretReferences(); err != nil {
+		t.Errorf("RejectSecretReferences() error = %v for a literal password", err)
+	}
+
+	for _, ref := range []string{"cmd:", "file: ", "keyring:kassie/"} {
+		p.Auth.Password = ref
+		if err := p.Validate(); !errors.Is(err, ErrInvalidSecretRef) {
+			t.Errorf("Validate(%q) error = %v, want %v", ref, err, ErrInvalidSecretRef)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sync"
+)
+
+var (
+	ErrInvalidEnvName = errors.New("invalid environment variable name")
+	ErrProfileInUse   = errors.New("profile in use")
+)
+
+var envNameRegex = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+// EnvReference returns the ${NAME} reference stored in place of a secret.
+func EnvReference(name string) (string, error) {
+	if !envNameRegex.MatchString(name) {
+		return "", fmt.Errorf("%w: %q (use upper case letters, digits and _)", ErrInvalidEnvName, name)
+	}
+	return "${" + name + "}", nil
+}
+
+// EnvReferenceName returns NAME when value is exactly a ${NAME} reference.
+func EnvReferenceName(value string) (string, bool) {
+	match := envVarRegex.FindStringSubmatch(value)
+	if match == nil || match[0] != value {
+		return "", false
+	}
+	return match[1], true
+}
+
+// ProfileStore serves the profiles of a loaded config and persists changes
+// to its file. Edits are made to the file's content as written, so ${VAR}
+// references stay unexpanded on disk, while readers see interpolated
+// profiles. Without a loader, changes are kept in memory only.
+type ProfileStore struct {
+	mu     sync.RWMutex
+	cfg    *Config
+	loader *Loader
+	// raw stands in for the file when there is no loader.
+	raw *Config
+}
+
+func NewProfileStore(cfg *Config, loader *Loader) *ProfileStore {
+	s := &ProfileStore{cfg: cfg, loader: loader}
+	if loader == nil {
+		s.raw = cfg.clone()
+	}
+	return s
+}
+
+// GetProfile returns a copy of the named, interpolated profile.
+func (s *ProfileStore) GetProfile(name string) (*Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, err := s.cfg.GetProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	return p.Clone(), nil
+}
+
+func (s *ProfileStore) GetProfiles() []Profile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	profiles := make([]Profile, len(s.cfg.Profiles))
+	for i := range s.cfg.Profiles {
+		profiles[i] = *s.cfg.Profiles[i].Clone()
+	}
+	return profiles
+}
+
+// Reload replaces the served config with cfg, which must be loaded and
+// validated, and reports how its profiles changed. Sessions keep the profile
+// they logged in with; later logins see the new one.
+func (s *ProfileStore) Reload(cfg *Config) ProfileDiff {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	diff := DiffProfiles(s.cfg.Profiles, cfg.Profiles)
+	s.cfg = cfg
+	if s.loader == nil {
+		s.raw = cfg.clone()
+	}
+	return diff
+}
+
+// RawProfile returns the named profile as written in the config file, with
+// ${VAR} references unexpanded.
+func (s *ProfileStore) RawProfile(name string) (*Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	raw, _, err := s.loadRaw()
+	if err != nil {
+		return nil, err
+	}
+	p, err := raw.GetProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	return p.Clone(), nil
+}
+
+// Create adds profile to the file. It only has to be complete once merged
+// with the other layers, so it may extend a profile from another file.
+func (s *ProfileStore) Create(profile Profile) error {
+	return s.modify(profile.Name, func(raw *Config) error {
+		if idx, _ := s.cfg.FindProfile(profile.Name); idx != -1 {
+			return ErrDuplicateProfile
+		}
+		if idx, _ := raw.FindProfile(profile.Name); idx != -1 {
+			return ErrDuplicateProfile
+		}
+		raw.Profiles = append(raw.Profiles, *profile.Clone())
+		return nil
+	})
+}
+
+// Update applies edit to the named profile as written in the file, so the
+// edit sees ${VAR} references rather than their values. Profiles cannot be
+// renamed.
+func (s *ProfileStore) Update(name string, edit func(*Profile) error) error {
+	return s.modify(name, func(raw *Config) error {
+		_, p := raw.FindProfile(name)
+		if p == nil {
+			return ErrProfileNotFound
+		}
+		updated := p.Clone()
+		if err := edit(updated); err != nil {
+			return err
+		}
+		if updated.Name != name {
+			return fmt.Errorf("%w: profiles cannot be renamed", ErrInvalidConfig)
+		}
+		*p = *updated
+		return nil
+	})
+}
+
+// Delete removes the named profile, and clears it as the default profile.
+// Profiles still granted to users by name cannot be removed.
+func (s *ProfileStore) Delete(name string) error {
+	return s.modify(name, func(raw *Config) error {
+		if raw.Server != nil {
+			for _, u := range raw.Server.Users {
+				if slices.Contains(u.Profiles, name) {
+					return fmt.Errorf("%w: granted to user %s", ErrProfileInUse, u.Username)
+				}
+			}
+		}
+		if raw.Defaults.DefaultProfile == name {
+			raw.Defaults.DefaultProfile = ""
+		}
+		return raw.RemoveProfile(name)
+	})
+}
+
+// modify applies change to the file's content, checks that the whole stack
+// still loads with the result in place of the file, so a profile may extend
+// one from another layer, then writes the file and mirrors the named profile
+// in memory.
+func (s *ProfileStore) modify(name string, change func(*Config) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raw, path, err := s.loadRaw()
+	if err != nil {
+		return err
+	}
+	if err := change(raw); err != nil {
+		return err
+	}
+
+	var loaded *Config
+	if s.loader == nil {
+		loaded = raw.clone()
+		if err := loaded.ResolveInheritance(); err != nil {
+			return err
+		}
+		loaded.SetDefaults()
+		if err := InterpolateConfig(loaded); err != nil {
+			return err
+		}
+		if err := loaded.Validate(); err != nil {
+			return err
+		}
+		s.raw = raw
+	} else {
+		if loaded, err = s.loader.loadStackWith(path, raw); err != nil {
+			return err
+		}
+		if err := s.loader.write(path, raw); err != nil {
+			return err
+		}
+	}
+
+	if s.cfg.Defaults.DefaultProfile == name && loaded.Defaults.DefaultProfile == "" {
+		s.cfg.Defaults.DefaultProfile = ""
+	}
+	idx, _ := s.cfg.FindProfile(name)
+	_, p := loaded.FindProfile(name)
+	switch {
+	case p == nil && idx != -1:
+		s.cfg.Profiles = append(s.cfg.Profiles[:idx], s.cfg.Profiles[idx+1:]...)
+	case p != nil && idx == -1:
+		s.cfg.Profiles = append(s.cfg.Profiles, *p)
+	case p != nil:
+		s.cfg.Profiles[idx] = *p
+	}
+	return nil
+}
+
+// loadRaw reads the config file without interpolating it and returns the
+// path to write it back to. When the file does not exist yet, the in-memory
+// config stands in; it was not loaded from disk, so it holds no references
+// to keep. Ephemeral profiles are left out of it.
+func (s *ProfileStore) loadRaw() (*Config, string, error) {
+	if s.loader == nil {
+		return s.raw.clone(), "", nil
+	}
+
+	path, err := s.loader.GetConfigPath()
+	if errors.Is(err, ErrFileNotFound) {
+		raw := s.cfg.clone()
+		raw.Profiles = slices.DeleteFunc(raw.Profiles, func(p Profile) bool {
+			if p.Ephemeral && raw.Defaults.DefaultProfile == p.Name {
+				raw.Defaults.DefaultProfile = ""
+			}
+			return p.Ephemeral
+		})
+		return raw, s.loader.savePath(), nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	raw, err := s.loader.readFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return raw, path, nil
+}
+
+func (l *Loader) readFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFileReadError, err)
+	}
+	return parseConfig(FormatForPath(path), data)
+}
@@ -0,0 +1,305 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const storeTestConfig = `{
+  "version": "1.0",
+  "profiles": [
+    {
+      "name": "prod",
+      "hosts": ["10.0.0.1"],
+      "port": 9042,
+      "auth": {"username": "app", "password": "${STORE_TEST_PASSWORD}"}
+    },
+    {
+      "name": "dev",
+      "hosts": ["127.0.0.1"],
+      "port": 9042
+    }
+  ],
+  "defaults": {"default_profile": "dev"}
+}`
+
+func newTestStore(t *testing.T) (*ProfileStore, string) {
+	t.Helper()
+	t.Setenv("STORE_TEST_PASSWORD", "s3cret")
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(storeTestConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := isolatedLoader(t, path)
+	cfg, err := loader.LoadFromPath(path)
+	if err != nil {
+		t.Fatalf("LoadFromPath() error = %v", err)
+	}
+	return NewProfileStore(cfg, loader), path
+}
+
+// isolatedLoader loads path over system and user files in a temp dir, so
+// the real config files never leak in.
+func isolatedLoader(t *testing.T, path string) *Loader {
+	t.Helper()
+	dir := t.TempDir()
+	loader := NewLoaderWithPath(path)
+	loader.systemPath = filepath.Join(dir, "etc", "config.json")
+	loader.primaryPath = filepath.Join(dir, "home", "config.json")
+	loader.trustPath = filepath.Join(dir, "home", trustFileName)
+	loader.workDir = dir
+	return loader
+}
+
+func TestProfileStoreValidatesStack(t *testing.T) {
+	loader := isolatedLoader(t, writeLayer(t, filepath.Join(t.TempDir(), "config.json"), `{"version": "1.0"}`))
+	writeLayer(t, loader.primaryPath, `{"profiles": [{"name": "base", "abstract": true, "hosts": ["10.0.0.1"], "port": 9042}]}`)
+
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	store := NewProfileStore(cfg, loader)
+
+	if err := store.Create(Profile{Name: "app", Extends: "base", Keyspace: "app"}); err != nil {
+		t.Fatalf("Create() of a profile extending another layer error = %v", err)
+	}
+	p, err := store.GetProfile("app")
+	if err != nil || len(p.Hosts) != 1 || p.Hosts[0] != "10.0.0.1" {
+		t.Errorf("GetProfile() = %+v, %v; want the hosts of base", p, err)
+	}
+	if err := store.Create(Profile{Name: "orphan", Extends: "missing"}); err == nil {
+		t.Error("Create() of a profile extending an unknown one succeeded")
+	}
+
+	if _, err := loader.Load(); err != nil {
+		t.Errorf("stack no longer loads: %v", err)
+	}
+}
+
+func TestProfileStoreKeepsEnvReferences(t *testing.T) {
+	store, path := newTestStore(t)
+
+	err := store.Update("prod", func(p *Profile) error {
+		p.Keyspace = "orders"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cret") {
+		t.Error("saved config contains the interpolated password")
+	}
+	if !strings.Contains(string(data), "${STORE_TEST_PASSWORD}") {
+		t.Error("saved config lost the password reference")
+	}
+
+	p, err := store.GetProfile("prod")
+	if err != nil {
+		t.Fatalf("GetProfile() error = %v", err)
+	}
+	if p.Keyspace != "orders" || p.Auth.Password != "s3cret" {
+		t.Errorf("GetProfile() = keyspace %q password %q, want orders and the interpolated password", p.Keyspace, p.Auth.Password)
+	}
+
+	raw, err := store.RawProfile("prod")
+	if err != nil {
+		t.Fatalf("RawProfile() error = %v", err)
+	}
+	if raw.Auth.Password != "${STORE_TEST_PASSWORD}" {
+		t.Errorf("RawProfile() password = %q", raw.Auth.Password)
+	}
+
+	reloaded, err := NewLoaderWithPath(path).LoadFromPath(path)
+	if err != nil {
+		t.Fatalf("reloading saved config: %v", err)
+	}
+	if _, p := reloaded.FindProfile("prod"); p == nil || p.Keyspace != "orders" {
+		t.Error("update was not persisted")
+	}
+}
+
+func TestProfileStoreCreate(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		wantErr error
+	}{
+		{
+			name:    "new profile",
+			profile: Profile{Name: "staging", Hosts: []string{"10.0.1.1"}, Port: 9042},
+		},
+		{
+			name:    "duplicate name",
+			profile: Profile{Name: "dev", Hosts: []string{"10.0.1.1"}, Port: 9042},
+			wantErr: ErrDuplicateProfile,
+		},
+		{
+			name:    "invalid profile",
+			profile: Profile{Name: "staging", Port: 9042},
+			wantErr: ErrNoHosts,
+		},
+		{
+			name: "unset password variable",
+			profile: Profile{
+				Name:  "staging",
+				Hosts: []string{"10.0.1.1"},
+				Port:  9042,
+				Auth:  &AuthConfig{Username: "app", Password: "${STORE_TEST_UNSET}"},
+			},
+			wantErr: ErrVarNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, path := newTestStore(t)
+
+			err := store.Create(tt.profile)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+
+			reloaded, err := NewLoaderWithPath(path).LoadFromPath(path)
+			if err != nil {
+				t.Fatalf("reloading saved config: %v", err)
+			}
+			_, saved := reloaded.FindProfile(tt.profile.Name)
+			_, mem := store.cfg.FindProfile(tt.profile.Name)
+			if tt.wantErr == nil && (saved == nil || mem == nil) {
+				t.Error("created profile missing from file or memory")
+			}
+			if tt.wantErr != nil && tt.wantErr != ErrDuplicateProfile && (saved != nil || mem != nil) {
+				t.Error("rejected profile was stored")
+			}
+		})
+	}
+}
+
+func TestProfileStoreDelete(t *testing.T) {
+	store, path := newTestStore(t)
+
+	if err := store.Delete("dev"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.GetProfile("dev"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("GetProfile() after delete error = %v", err)
+	}
+	if store.cfg.Defaults.DefaultProfile != "" {
+		t.Errorf("default profile = %q, want cleared", store.cfg.Defaults.DefaultProfile)
+	}
+
+	reloaded, err := NewLoaderWithPath(path).LoadFromPath(path)
+	if err != nil {
+		t.Fatalf("reloading saved config: %v", err)
+	}
+	if len(reloaded.Profiles) != 1 || reloaded.Defaults.DefaultProfile != "" {
+		t.Errorf("saved config = %d profiles, default %q", len(reloaded.Profiles), reloaded.Defaults.DefaultProfile)
+	}
+
+	if err := store.Delete("dev"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("second Delete() error = %v, want %v", err, ErrProfileNotFound)
+	}
+	if err := store.Delete("prod"); !errors.Is(err, ErrNoProfiles) {
+		t.Errorf("deleting the last profile error = %v, want %v", err, ErrNoProfiles)
+	}
+}
+
+func TestProfileStoreSkipsEphemeralProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg := &Config{
+		Profiles: []Profile{{Name: "adhoc", Hosts: []string{"10.0.0.9"}, Port: 9042, Ephemeral: true,
+			Auth: &AuthConfig{Username: "app", Password: "from-stdin"}}},
+		Defaults: DefaultConfig{DefaultProfile: "adhoc"},
+	}
+	cfg.SetDefaults()
+	store := NewProfileStore(cfg, NewLoaderWithPath(path))
+
+	if err := store.Create(Profile{Name: "dev", Hosts: []string{"127.0.0.1"}, Port: 9042}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "adhoc") || strings.Contains(string(data), "from-stdin") {
+		t.Errorf("ephemeral profile written to the config file:\n%s", data)
+	}
+	if _, p := store.cfg.FindProfile("adhoc"); p == nil {
+		t.Error("ephemeral profile dropped from memory")
+	}
+}
+
+func TestProfileStoreRejectsRename(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	err := store.Update("dev", func(p *Profile) error {
+		p.Name = "other"
+		return nil
+	})
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Update() error = %v, want %v", err, ErrInvalidConfig)
+	}
+}
+
+func TestEnvReference(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "CASSANDRA_PASSWORD", want: "${CASSANDRA_PASSWORD}"},
+		{name: "_X1", want: "${_X1}"},
+		{name: "lower", wantErr: true},
+		{name: "1ABC", wantErr: true},
+		{name: "A}B", wantErr: true},
+		{name: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EnvReference(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EnvReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EnvReference() = %q, want %q", got, tt.want)
+			}
+			if tt.wantErr {
+				return
+			}
+			if name, ok := EnvReferenceName(got); !ok || name != tt.name {
+				t.Errorf("EnvReferenceName(%q) = %q, %v", got, name, ok)
+			}
+		})
+	}
+
+	if _, ok := EnvReferenceName("prefix-${X}"); ok {
+		t.Error("EnvReferenceName accepted a value that is not only a reference")
+	}
+}
+
+func TestProfileStoreDeleteGrantedProfile(t *testing.T) {
+	store, _ := newTestStore(t)
+	err := store.loader.write(store.loader.savePath(), &Config{
+		Profiles: store.GetProfiles(),
+		Server:   &ServerConfig{Users: []User{{Username: "alice", Profiles: []string{"dev"}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Delete("dev"); !errors.Is(err, ErrProfileInUse) {
+		t.Errorf("Delete() error = %v, want %v", err, ErrProfileInUse)
+	}
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"errors"
+	"net"
+	"strconv"
+)
+
+var ErrInvalidTunnel = errors.New("invalid tunnel configuration")
+
+// DefaultSSHPort is used when tunnel.host has no port.
+const DefaultSSHPort = 22
+
+// TunnelConfig reaches the cluster through an SSH bastion. Every node,
+// including peers discovered after connecting, is dialed from the bastion.
+type TunnelConfig struct {
+	Host                  string `json:"host"`
+	User                  string `json:"user"`
+	KeyPath               string `json:"key_path,omitempty"`
+	KeyPassphrase         string `json:"key_passphrase,omitempty"`
+	UseAgent              bool   `json:"use_agent,omitempty"`
+	KnownHosts            string `json:"known_hosts,omitempty"`
+	InsecureIgnoreHostKey bool   `json:"insecure_ignore_host_key,omitempty"`
+}
+
+// SOCKS5Config reaches the cluster through a SOCKS5 proxy.
+type SOCKS5Config struct {
+	Address  string `json:"address"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+func (t *TunnelConfig) Validate() error {
+	if t.Host == "" {
+		return fieldError("tunnel.host", ErrInvalidTunnel, "is required")
+	}
+	if _, port, err := net.SplitHostPort(t.Host); err == nil {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fieldError("tunnel.host", ErrInvalidTunnel, "invalid port %q", port)
+		}
+	}
+	if t.User == "" {
+		return fieldError("tunnel.user", ErrInvalidTunnel, "is required")
+	}
+	if t.KeyPath == "" && !t.UseAgent {
+		return fieldError("tunnel.key_path", ErrInvalidTunnel, "is required unless use_agent is set")
+	}
+	if t.KnownHosts != "" && t.InsecureIgnoreHostKey {
+		return fieldError("tunnel.insecure_ignore_host_key", ErrInvalidTunnel, "cannot be combined with known_hosts")
+	}
+	return nil
+}
+
+// Address returns the bastion address with the default SSH port applied.
+func (t *TunnelConfig) Address() string {
+	if _, _, err := net.SplitHostPort(t.Host); err == nil {
+		return t.Host
+	}
+	return net.JoinHostPort(t.Host, strconv.Itoa(DefaultSSHPort))
+}
+
+func (s *SOCKS5Config) Validate() error {
+	if s.Address == "" {
+		return fieldError("socks5.address", ErrInvalidTunnel, "is required")
+	}
+	if _, _, err := net.SplitHostPort(s.Address); err != nil {
+		return fieldError("socks5.address", ErrInvalidTunnel, "must be host:port")
+	}
+	if s.Password != "" && s.Username == "" {
+		return fieldError("socks5.username", ErrInvalidTunnel, "is required with password")
+	}
+	return nil
+}
+
+func (t *TunnelConfig) Clone() *TunnelConfig {
+	clone := *t
+	return &clone
+}
+
+func (s *SOCKS5Config) Clone() *SOCKS5Config {
+	clone := *s
+	return &clone
+}
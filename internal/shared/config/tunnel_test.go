@@ -0,0 +1,66 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProfileValidateTunnel(t *testing.T) {
+	tunnel := func(mod func(*TunnelConfig)) *TunnelConfig {
+		cfg := &TunnelConfig{Host: "bastion.example.com", User: "ops", KeyPath: "~/.ssh/id_ed25519"}
+		if mod != nil {
+			mod(cfg)
+		}
+		return cfg
+	}
+
+	tests := []struct {
+		name   string
+		tunnel *TunnelConfig
+		socks5 *SOCKS5Config
+		field  string
+	}{
+		{name: "key tunnel", tunnel: tunnel(nil)},
+		{name: "agent tunnel", tunnel: tunnel(func(c *TunnelConfig) { c.KeyPath = ""; c.UseAgent = true })},
+		{name: "tunnel with port", tunnel: tunnel(func(c *TunnelConfig) { c.Host = "bastion:2222" })},
+		{name: "socks5", socks5: &SOCKS5Config{Address: "127.0.0.1:1080", Username: "u", Password: "p"}},
+		{name: "missing host", tunnel: tunnel(func(c *TunnelConfig) { c.Host = "" }), field: "tunnel.host"},
+		{name: "bad port", tunnel: tunnel(func(c *TunnelConfig) { c.Host = "bastion:99999" }), field: "tunnel.host"},
+		{name: "missing user", tunnel: tunnel(func(c *TunnelConfig) { c.User = "" }), field: "tunnel.user"},
+		{name: "no credentials", tunnel: tunnel(func(c *TunnelConfig) { c.KeyPath = "" }), field: "tunnel.key_path"},
+		{name: "ignore with known hosts", tunnel: tunnel(func(c *TunnelConfig) { c.KnownHosts = "kh"; c.InsecureIgnoreHostKey = true }), field: "tunnel.insecure_ignore_host_key"},
+		{name: "socks5 without port", socks5: &SOCKS5Config{Address: "proxy"}, field: "socks5.address"},
+		{name: "socks5 password only", socks5: &SOCKS5Config{Address: "proxy:1080", Password: "p"}, field: "socks5.username"},
+		{name: "both", tunnel: tunnel(nil), socks5: &SOCKS5Config{Address: "proxy:1080"}, field: "socks5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Profile{Name: "vpc", Hosts: []string{"10.0.0.1"}, Port: 9042, Tunnel: tt.tunnel, SOCKS5: tt.socks5}
+			err := p.Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) || fieldErr.Field != tt.field || fieldErr.Profile != "vpc" {
+				t.Fatalf("expected error at %s, got %v", tt.field, err)
+			}
+			if !errors.Is(err, ErrInvalidTunnel) {
+				t.Errorf("expected ErrInvalidTunnel, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTunnelConfigAddress(t *testing.T) {
+	if got := (&TunnelConfig{Host: "bastion"}).Address(); got != "bastion:22" {
+		t.Errorf("Address() = %q, want bastion:22", got)
+	}
+	if got := (&TunnelConfig{Host: "bastion:2222"}).Address(); got != "bastion:2222" {
+		t.Errorf("Address() = %q, want bastion:2222", got)
+	}
+}
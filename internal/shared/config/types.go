@@ -1,6 +1,9 @@
 package config
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrProfileNotFound  = errors.New("profile not found")
@@ -11,6 +14,15 @@ var (
 	ErrNoProfiles       = errors.New("no profiles defined")
 	ErrInvalidPageSize  = errors.New("invalid page size")
 	ErrInvalidTimeout   = errors.New("invalid timeout")
+	ErrUserNotFound     = errors.New("user not found")
+	ErrDuplicateUser    = errors.New("duplicate user name")
+	ErrInvalidUser      = errors.New("invalid user")
+	ErrInvalidHash      = errors.New("unsupported password hash")
+	ErrInvalidRole      = errors.New("invalid role")
+	ErrDuplicateRole    = errors.New("duplicate role name")
+	ErrRoleNotFound     = errors.New("role not found")
+	ErrInvalidOIDC      = errors.New("invalid oidc configuration")
+	ErrInvalidAuth      = errors.New("invalid auth configuration")
 )
 
 type Config struct {
@@ -18,20 +30,43 @@ type Config struct {
 	Profiles []Profile     `json:"profiles"`
 	Defaults DefaultConfig `json:"defaults"`
 	Clients  ClientConfig  `json:"clients"`
+	Server   *ServerConfig `json:"server,omitempty"`
 }
 
 type Profile struct {
-	Name     string      `json:"name"`
-	Hosts    []string    `json:"hosts"`
-	Port     int         `json:"port"`
-	Keyspace string      `json:"keyspace,omitempty"`
-	Auth     *AuthConfig `json:"auth,omitempty"`
-	SSL      *SSLConfig  `json:"ssl,omitempty"`
+	Name                string        `json:"name"`
+	Hosts               []string      `json:"hosts"`
+	Port                int           `json:"port"`
+	Keyspace            string        `json:"keyspace,omitempty"`
+	SecureConnectBundle string        `json:"secure_connect_bundle,omitempty"`
+	Auth                *AuthConfig   `json:"auth,omitempty"`
+	SSL                 *SSLConfig    `json:"ssl,omitempty"`
+	Driver              *DriverConfig `json:"driver,omitempty"`
+	Tunnel              *TunnelConfig `json:"tunnel,omitempty"`
+	SOCKS5              *SOCKS5Config `json:"socks5,omitempty"`
+	// Environment, Color and the settings after them change how clients
+	// show the profile, not how they connect to it. PageSize is filled in
+	// from defaults.page_size.
+	Environment     string `json:"environment,omitempty"`
+	Color           string `json:"color,omitempty"`
+	PageSize        int    `json:"page_size,omitempty"`
+	DefaultKeyspace string `json:"default_keyspace,omitempty"`
+	DefaultTable    string `json:"default_table,omitempty"`
+	// Extends names a profile whose settings this one inherits. Abstract
+	// profiles only exist to be extended and cannot be connected to.
+	Extends  string `json:"extends,omitempty"`
+	Abstract bool   `json:"abstract,omitempty"`
+	// Ephemeral profiles are made from command line flags and are never
+	// written to a config file.
+	Ephemeral bool `json:"-"`
 }
 
+// AuthConfig holds database credentials. With Prompt set, each user supplies
+// their own credentials at login instead; Username may then preset the name.
 type AuthConfig struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Prompt   bool   `json:"prompt,omitempty"`
 }
 
 type SSLConfig struct {
@@ -42,6 +77,43 @@ type SSLConfig struct {
 	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
 }
 
+type ServerConfig struct {
+	Users     []User      `json:"users,omitempty"`
+	Roles     []Role      `json:"roles,omitempty"`
+	OIDC      *OIDCConfig `json:"oidc,omitempty"`
+	TokenFile string      `json:"token_file,omitempty"`
+}
+
+type User struct {
+	Username     string   `json:"username"`
+	PasswordHash string   `json:"password_hash"`
+	Profiles     []string `json:"profiles"`
+	Roles        []string `json:"roles,omitempty"`
+}
+
+type OIDCConfig struct {
+	Issuer        string              `json:"issuer"`
+	ClientID      string              `json:"client_id"`
+	ClientSecret  string              `json:"client_secret,omitempty"`
+	RedirectURL   string              `json:"redirect_url"`
+	PostLoginURL  string              `json:"post_login_url,omitempty"`
+	Scopes        []string            `json:"scopes,omitempty"`
+	UsernameClaim string              `json:"username_claim,omitempty"`
+	GroupsClaim   string              `json:"groups_claim,omitempty"`
+	GroupRoles    map[string][]string `json:"group_roles,omitempty"`
+	DefaultRoles  []string            `json:"default_roles,omitempty"`
+}
+
+type Operation string
+
+type Role struct {
+	Name       string      `json:"name"`
+	Operations []Operation `json:"operations"`
+	Profiles   []string    `json:"profiles,omitempty"`
+	Keyspaces  []string    `json:"keyspaces,omitempty"`
+	Tables     []string    `json:"tables,omitempty"`
+}
+
 type DefaultConfig struct {
 	DefaultProfile string `json:"default_profile"`
 	PageSize       int    `json:"page_size"`
@@ -65,44 +137,151 @@ type WebConfig struct {
 
 func (p *Profile) Validate() error {
 	if p.Name == "" {
-		return ErrInvalidConfig
+		return &FieldError{Field: "name", Err: fmt.Errorf("%w: name is required", ErrInvalidConfig)}
 	}
-	if len(p.Hosts) == 0 {
-		return ErrNoHosts
+	if err := p.validateFields(); err != nil {
+		var fieldErr *FieldError
+		if errors.As(err, &fieldErr) {
+			fieldErr.Profile = p.Name
+		}
+		return err
 	}
-	if p.Port < 1 || p.Port > 65535 {
-		return ErrInvalidPort
+	return nil
+}
+
+func (p *Profile) validateFields() error {
+	if !p.UsesBundle() && !p.Abstract {
+		if len(p.Hosts) == 0 {
+			return &FieldError{Field: "hosts", Err: ErrNoHosts}
+		}
+		if p.Port < 1 || p.Port > 65535 {
+			return fieldError("port", ErrInvalidPort, "%d is outside 1-65535", p.Port)
+		}
+	}
+	if p.PromptsForCredentials() && p.Auth.Password != "" {
+		return fieldError("auth.password", ErrInvalidAuth, "must not be set with prompt")
+	}
+	for _, secret := range p.secretFields() {
+		if err := validateSecretRef(secret.field, *secret.value); err != nil {
+			return err
+		}
+	}
+	if err := p.validateDisplay(); err != nil {
+		return err
+	}
+	return p.validateBlocks()
+}
+
+func (p *Profile) validateBlocks() error {
+	if p.UsesBundle() {
+		if err := p.validateBundle(); err != nil {
+			return err
+		}
+	}
+	if p.Driver != nil {
+		if err := p.Driver.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.Tunnel != nil && p.SOCKS5 != nil {
+		return fieldError("socks5", ErrInvalidTunnel, "cannot be combined with tunnel")
+	}
+	if p.Tunnel != nil {
+		if err := p.Tunnel.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.SOCKS5 != nil {
+		if err := p.SOCKS5.Validate(); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+type secretField struct {
+	field string
+	value *string
+}
+
+// secretFields lists the credentials that may hold a file:, cmd: or
+// keyring: reference.
+func (p *Profile) secretFields() []secretField {
+	var fields []secretField
+	if p.Auth != nil {
+		fields = append(fields, secretField{"auth.username", &p.Auth.Username}, secretField{"auth.password", &p.Auth.Password})
+	}
+	if p.Tunnel != nil {
+		fields = append(fields, secretField{"tunnel.key_passphrase", &p.Tunnel.KeyPassphrase})
+	}
+	if p.SOCKS5 != nil {
+		fields = append(fields, secretField{"socks5.password", &p.SOCKS5.Password})
+	}
+	return fields
+}
+
+// ResolveSecrets returns a copy of the profile with the secret references in
+// its credentials replaced by the secrets, using DefaultSecretResolver.
+func (p *Profile) ResolveSecrets() (*Profile, error) {
+	resolved := p.Clone()
+	for _, secret := range resolved.secretFields() {
+		value, err := ResolveSecret(*secret.value)
+		if err != nil {
+			return nil, &FieldError{Profile: p.Name, Field: secret.field, Err: err}
+		}
+		*secret.value = value
+	}
+	return resolved, nil
+}
+
+// PromptsForCredentials reports whether users must supply database
+// credentials when logging in to the profile.
+func (p *Profile) PromptsForCredentials() bool {
+	return p.Auth != nil && p.Auth.Prompt
+}
+
 func (c *Config) Validate() error {
 	if len(c.Profiles) == 0 {
-		return ErrNoProfiles
+		return &FieldError{Field: "profiles", Err: ErrNoProfiles}
 	}
 
 	profileNames := make(map[string]bool)
-	for _, p := range c.Profiles {
+	for i, p := range c.Profiles {
 		if profileNames[p.Name] {
-			return ErrDuplicateProfile
+			return fmt.Errorf("profiles[%d]: %w: %s", i, ErrDuplicateProfile, p.Name)
 		}
 		profileNames[p.Name] = true
 
 		if err := p.Validate(); err != nil {
+			if p.Name == "" {
+				return fmt.Errorf("profiles[%d]: %w", i, err)
+			}
+			var fieldErr *FieldError
+			if errors.As(err, &fieldErr) {
+				fieldErr.Extends = c.ExtendsChain(p.Name)
+			}
 			return err
 		}
 	}
 
-	if c.Defaults.PageSize < 1 || c.Defaults.PageSize > 10000 {
-		return ErrInvalidPageSize
+	if _, p := c.FindProfile(c.Defaults.DefaultProfile); p != nil && p.Abstract {
+		return fieldError("defaults.default_profile", ErrAbstractProfile, "%s can only be extended", p.Name)
+	}
+
+	if c.Defaults.PageSize < 1 || c.Defaults.PageSize > DefaultMaxPageSize {
+		return fieldError("defaults.page_size", ErrInvalidPageSize, "%d is outside 1-%d", c.Defaults.PageSize, DefaultMaxPageSize)
 	}
 
 	if c.Defaults.TimeoutMs < 100 || c.Defaults.TimeoutMs > 300000 {
-		return ErrInvalidTimeout
+		return fieldError("defaults.timeout_ms", ErrInvalidTimeout, "%d is outside 100-300000", c.Defaults.TimeoutMs)
 	}
 
 	if c.Clients.Web.DefaultPort < 1 || c.Clients.Web.DefaultPort > 65535 {
-		return ErrInvalidPort
+		return fieldError("clients.web.default_port", ErrInvalidPort, "%d is outside 1-65535", c.Clients.Web.DefaultPort)
+	}
+
+	if err := c.validateUsers(profileNames); err != nil {
+		return err
 	}
 
 	return nil
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -135,12 +136,32 @@ func TestProfileValidate(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name: "prompted auth",
+			profile: Profile{
+				Name:  "test",
+				Hosts: []string{"localhost"},
+				Port:  9042,
+				Auth:  &AuthConfig{Username: "analyst", Prompt: true},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "prompted auth with stored password",
+			profile: Profile{
+				Name:  "test",
+				Hosts: []string{"localhost"},
+				Port:  9042,
+				Auth:  &AuthConfig{Password: "pass", Prompt: true},
+			},
+			wantErr: ErrInvalidAuth,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := tt.profile.Validate()
-			if err != tt.wantErr {
+			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("Profile.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -425,7 +446,7 @@ func TestConfigValidate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := tt.config.Validate()
-			if err != tt.wantErr {
+			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AllProfiles grants a user access to every configured profile.
+const AllProfiles = "*"
+
+var passwordHashPrefixes = []string{"$2a$", "$2b$", "$2y$", "$argon2id$"}
+
+func (u *User) Validate() error {
+	if u.Username == "" {
+		return ErrInvalidUser
+	}
+	if strings.HasPrefix(u.Username, ExternalUserPrefix) {
+		return fmt.Errorf("%w: %s: names starting with %s are reserved for single sign-on", ErrInvalidUser, u.Username, ExternalUserPrefix)
+	}
+
+	supported := false
+	for _, prefix := range passwordHashPrefixes {
+		if strings.HasPrefix(u.PasswordHash, prefix) {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("%w for user %s", ErrInvalidHash, u.Username)
+	}
+
+	return nil
+}
+
+func (u *User) RoleNames() []string {
+	if len(u.Roles) == 0 {
+		return []string{DefaultRole}
+	}
+	return u.Roles
+}
+
+func (u *User) CanUseProfile(name string) bool {
+	for _, p := range u.Profiles {
+		if p == AllProfiles || p == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ServerConfig) clone() *ServerConfig {
+	clone := &ServerConfig{
+		Users:     make([]User, len(s.Users)),
+		TokenFile: s.TokenFile,
+	}
+
+	for i, u := range s.Users {
+		clone.Users[i] = User{
+			Username:     u.Username,
+			PasswordHash: u.PasswordHash,
+			Profiles:     append([]string(nil), u.Profiles...),
+			Roles:        append([]string(nil), u.Roles...),
+		}
+	}
+
+	for _, r := range s.Roles {
+		clone.Roles = append(clone.Roles, Role{
+			Name:       r.Name,
+			Operations: append([]Operation(nil), r.Operations...),
+			Profiles:   append([]string(nil), r.Profiles...),
+			Keyspaces:  append([]string(nil), r.Keyspaces...),
+			Tables:     append([]string(nil), r.Tables...),
+		})
+	}
+
+	if s.OIDC != nil {
+		clone.OIDC = s.OIDC.clone()
+	}
+
+	return clone
+}
+
+func (c *Config) HasUsers() bool {
+	return c.Server != nil && len(c.Server.Users) > 0
+}
+
+func (c *Config) GetUser(username string) (*User, error) {
+	if c.Server == nil {
+		return nil, ErrUserNotFound
+	}
+	for i := range c.Server.Users {
+		if c.Server.Users[i].Username == username {
+			return &c.Server.Users[i], nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (c *Config) validateUsers(profileNames map[string]bool) error {
+	if c.Server == nil {
+		return nil
+	}
+
+	if err := c.Server.validateRoles(); err != nil {
+		return err
+	}
+
+	usernames := make(map[string]bool)
+	for i := range c.Server.Users {
+		u := &c.Server.Users[i]
+		if err := u.Validate(); err != nil {
+			return err
+		}
+		if usernames[u.Username] {
+			return ErrDuplicateUser
+		}
+		usernames[u.Username] = true
+
+		for _, p := range u.Profiles {
+			if p != AllProfiles && !profileNames[p] {
+				return fmt.Errorf("%w: user %s references unknown profile %s", ErrProfileNotFound, u.Username, p)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+const testBcryptHash = "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"
+
+func validUserConfig(users ...User) Config {
+	return Config{
+		Version: "1.0",
+		Profiles: []Profile{
+			{Name: "dev", Hosts: []string{"localhost"}, Port: 9042},
+			{Name: "prod", Hosts: []string{"prod-1"}, Port: 9042},
+		},
+		Defaults: DefaultConfig{PageSize: 100, TimeoutMs: 5000},
+		Clients:  ClientConfig{Web: WebConfig{DefaultPort: 8080}},
+		Server:   &ServerConfig{Users: users},
+	}
+}
+
+func TestUserValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    User
+		wantErr error
+	}{
+		{
+			name:    "bcrypt hash",
+			user:    User{Username: "alice", PasswordHash: testBcryptHash},
+			wantErr: nil,
+		},
+		{
+			name:    "argon2id hash",
+			user:    User{Username: "bob", PasswordHash: "$argon2id$v=19$m=65536,t=3,p=4$c2FsdHNhbHQ$aGFzaGhhc2g"},
+			wantErr: nil,
+		},
+		{
+			name:    "empty username",
+			user:    User{PasswordHash: testBcryptHash},
+			wantErr: ErrInvalidUser,
+		},
+		{
+			name:    "single sign-on namespace",
+			user:    User{Username: "sso:alice", PasswordHash: testBcryptHash},
+			wantErr: ErrInvalidUser,
+		},
+		{
+			name:    "plaintext password",
+			user:    User{Username: "carol", PasswordHash: "hunter2"},
+			wantErr: ErrInvalidHash,
+		},
+		{
+			name:    "missing hash",
+			user:    User{Username: "dave"},
+			wantErr: ErrInvalidHash,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.user.Validate()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("User.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUserCanUseProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles []string
+		profile  string
+		want     bool
+	}{
+		{name: "explicit profile", profiles: []string{"dev"}, profile: "dev", want: true},
+		{name: "other profile", profiles: []string{"dev"}, profile: "prod", want: false},
+		{name: "wildcard", profiles: []string{AllProfiles}, profile: "prod", want: true},
+		{name: "no profiles", profiles: nil, profile: "dev", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := User{Username: "alice", PasswordHash: testBcryptHash, Profiles: tt.profiles}
+			if got := u.CanUseProfile(tt.profile); got != tt.want {
+				t.Errorf("CanUseProfile(%q) = %v, want %v", tt.profile, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigValidateUsers(t *testing.T) {
+	tests := []struct {
+		name    string
+		users   []User
+		wantErr error
+	}{
+		{
+			name:    "valid users",
+			users:   []User{{Username: "alice", PasswordHash: testBcryptHash, Profiles: []string{"dev", "prod"}}, {Username: "bob", PasswordHash: testBcryptHash, Profiles: []string{AllProfiles}}},
+			wantErr: nil,
+		},
+		{
+			name:    "duplicate user",
+			users:   []User{{Username: "alice", PasswordHash: testBcryptHash}, {Username: "alice", PasswordHash: testBcryptHash}},
+			wantErr: ErrDuplicateUser,
+		},
+		{
+			name:    "unknown profile",
+			users:   []User{{Username: "alice", PasswordHash: testBcryptHash, Profiles: []string{"staging"}}},
+			wantErr: ErrProfileNotFound,
+		},
+		{
+			name:    "invalid hash",
+			users:   []User{{Username: "alice", PasswordHash: "secret"}},
+			wantErr: ErrInvalidHash,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validUserConfig(tt.users...)
+			err := cfg.Validate()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigGetUser(t *testing.T) {
+	cfg := validUserConfig(User{Username: "alice", PasswordHash: testBcryptHash, Profiles: []string{"dev"}})
+
+	if !cfg.HasUsers() {
+		t.Fatal("expected HasUsers to be true")
+	}
+
+	user, err := cfg.GetUser("alice")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.Username != "alice" {
+		t.Errorf("expected alice, got %s", user.Username)
+	}
+
+	if _, err := cfg.GetUser("mallory"); err != ErrUserNotFound {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+
+	empty := Config{}
+	if empty.HasUsers() {
+		t.Error("expected HasUsers to be false without server block")
+	}
+	if _, err := empty.GetUser("alice"); err != ErrUserNotFound {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+}
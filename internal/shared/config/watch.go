@@ -0,0 +1,126 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io/fs"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Watcher polls the files of a config stack and reports when their content
+// changes. Polling works the same on every filesystem and with editors that
+// replace the file instead of writing to it. The stack is listed again on
+// every poll, so a file that appears or disappears is a change too. A change
+// is reported once the new content is seen on two polls in a row, so a file
+// caught mid-write is not loaded.
+type Watcher struct {
+	layers   func() []string
+	interval time.Duration
+	current  [sha256.Size]byte
+	pending  [sha256.Size]byte
+}
+
+// NewWatcher watches the files layers returns, such as Loader.Layers.
+func NewWatcher(layers func() []string, interval time.Duration) *Watcher {
+	w := &Watcher{layers: layers, interval: interval}
+	w.current, _ = w.sum()
+	w.pending = w.current
+	return w
+}
+
+// Watch calls onChange after each change to the files until ctx is done.
+func (w *Watcher) Watch(ctx context.Context, onChange func()) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if w.poll() {
+				onChange()
+			}
+		}
+	}
+}
+
+func (w *Watcher) poll() bool {
+	sum, err := w.sum()
+	if err != nil {
+		return false
+	}
+	if sum == w.current {
+		w.pending = sum
+		return false
+	}
+	if sum != w.pending {
+		w.pending = sum
+		return false
+	}
+	w.current = sum
+	return true
+}
+
+// sum hashes the path and content of every file in the stack. A missing
+// file hashes as empty content, so deleting one is seen as a change rather
+// than stopping reloads.
+func (w *Watcher) sum() ([sha256.Size]byte, error) {
+	h := sha256.New()
+	for _, path := range w.layers() {
+		data, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return [sha256.Size]byte{}, err
+		}
+		h.Write([]byte(path))
+		h.Write([]byte{0})
+		sum := sha256.Sum256(data)
+		h.Write(sum[:])
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// ProfileDiff names the profiles that differ between two configs.
+type ProfileDiff struct {
+	Added   []string
+	Changed []string
+	Removed []string
+}
+
+func (d ProfileDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Changed) == 0 && len(d.Removed) == 0
+}
+
+func DiffProfiles(old, updated []Profile) ProfileDiff {
+	before := make(map[string]*Profile, len(old))
+	for i := range old {
+		before[old[i].Name] = &old[i]
+	}
+
+	var diff ProfileDiff
+	for i := range updated {
+		p := &updated[i]
+		prev, ok := before[p.Name]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, p.Name)
+		case !reflect.DeepEqual(prev, p):
+			diff.Changed = append(diff.Changed, p.Name)
+		}
+		delete(before, p.Name)
+	}
+	for name := range before {
+		diff.Removed = append(diff.Removed, name)
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Changed)
+	sort.Strings(diff.Removed)
+	return diff
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatcherPoll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"version": "1"}`)
+	w := NewWatcher(func() []string { return []string{path} }, time.Hour)
+
+	if w.poll() {
+		t.Fatal("reported a change for an unchanged file")
+	}
+
+	write(`{"version": "2"}`)
+	if w.poll() {
+		t.Fatal("reported a change before the content settled")
+	}
+	if !w.poll() {
+		t.Fatal("expected the settled change to be reported")
+	}
+	if w.poll() {
+		t.Fatal("reported the same change twice")
+	}
+
+	write(`{"vers`)
+	w.poll()
+	write(`{"version": "3"}`)
+	if w.poll() {
+		t.Fatal("reported a change while the content was still moving")
+	}
+	if !w.poll() {
+		t.Fatal("expected the final content to be reported")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if w.poll() {
+		t.Fatal("reported a removal before it settled")
+	}
+	if !w.poll() {
+		t.Fatal("expected the removed file to be reported")
+	}
+
+	write(`{"version": "3"}`)
+	w.poll()
+	if !w.poll() {
+		t.Fatal("expected the file coming back to be reported")
+	}
+}
+
+func TestWatcherStackChanges(t *testing.T) {
+	dir := t.TempDir()
+	primary := filepath.Join(dir, "config.json")
+	project := filepath.Join(dir, ".kassie.json")
+	for _, path := range []string{primary, project} {
+		if err := os.WriteFile(path, []byte(`{}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	layers := []string{primary}
+	w := NewWatcher(func() []string { return layers }, time.Hour)
+
+	// A file that joins the stack after startup is watched from then on.
+	layers = []string{primary, project}
+	w.poll()
+	if !w.poll() {
+		t.Fatal("expected the added file to be reported")
+	}
+	if err := os.WriteFile(project, []byte(`{"version": "1"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w.poll()
+	if !w.poll() {
+		t.Fatal("expected an edit to the added file to be reported")
+	}
+
+	layers = []string{primary}
+	w.poll()
+	if !w.poll() {
+		t.Fatal("expected the file leaving the stack to be reported")
+	}
+}
+
+func TestWatcherWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changed := make(chan struct{}, 1)
+	go NewWatcher(func() []string { return []string{path} }, 5*time.Millisecond).Watch(ctx, func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+
+	if err := os.WriteFile(path, []byte("b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("change was not reported")
+	}
+}
+
+func TestDiffProfiles(t *testing.T) {
+	old := []Profile{
+		{Name: "dev", Hosts: []string{"127.0.0.1"}, Port: 9042},
+		{Name: "prod", Hosts: []string{"10.0.0.1"}, Port: 9042},
+		{Name: "test", Hosts: []string{"10.0.2.1"}, Port: 9042},
+	}
+	updated := []Profile{
+		{Name: "dev", Hosts: []string{"127.0.0.1"}, Port: 9042},
+		{Name: "prod", Hosts: []string{"10.0.0.1"}, Port: 9042, Keyspace: "orders"},
+		{Name: "staging", Hosts: []string{"10.0.1.1"}, Port: 9042},
+	}
+
+	got := DiffProfiles(old, updated)
+	want := ProfileDiff{Added: []string{"staging"}, Changed: []string{"prod"}, Removed: []string{"test"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffProfiles() = %+v, want %+v", got, want)
+	}
+
+	if diff := DiffProfiles(old, old); !diff.Empty() {
+		t.Errorf("DiffProfiles() of equal profiles = %+v", diff)
+	}
+}
+
+func TestProfileStoreReload(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	cfg := &Config{Profiles: []Profile{{Name: "dev", Hosts: []string{"127.0.0.2"}, Port: 9042}}}
+	diff := store.Reload(cfg)
+	want := ProfileDiff{Changed: []string{"dev"}, Removed: []string{"prod"}}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("Reload() = %+v, want %+v", diff, want)
+	}
+
+	p, err := store.GetProfile("dev")
+	if err != nil || p.Hosts[0] != "127.0.0.2" {
+		t.Errorf("GetProfile() = %+v, %v; want the reloaded profile", p, err)
+	}
+	if _, err := store.GetProfile("prod"); err == nil {
+		t.Error("expected the removed profile to be gone")
+	}
+}
@@ -4,9 +4,15 @@ import "context"
 
 type contextKey string
 
+// LocalKeyMetadata carries an embedded server's local key, which lets the
+// process that started the server manage profiles without logging in.
+const LocalKeyMetadata = "x-kassie-local-key"
+
 const (
 	SessionIDKey contextKey = "session_id"
 	ProfileKey   contextKey = "profile"
+	UserKey      contextKey = "user"
+	TokenIDKey   contextKey = "token_id"
 )
 
 func WithSessionID(ctx context.Context, sessionID string) context.Context {
@@ -17,6 +23,14 @@ func WithProfile(ctx context.Context, profile string) context.Context {
 	return context.WithValue(ctx, ProfileKey, profile)
 }
 
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, UserKey, user)
+}
+
+func WithTokenID(ctx context.Context, tokenID string) context.Context {
+	return context.WithValue(ctx, TokenIDKey, tokenID)
+}
+
 func GetSessionID(ctx context.Context) (string, bool) {
 	val := ctx.Value(SessionIDKey)
 	if val == nil {
@@ -34,3 +48,21 @@ func GetProfile(ctx context.Context) (string, bool) {
 	profile, ok := val.(string)
 	return profile, ok
 }
+
+func GetUser(ctx context.Context) (string, bool) {
+	val := ctx.Value(UserKey)
+	if val == nil {
+		return "", false
+	}
+	user, ok := val.(string)
+	return user, ok
+}
+
+func GetTokenID(ctx context.Context) (string, bool) {
+	val := ctx.Value(TokenIDKey)
+	if val == nil {
+		return "", false
+	}
+	tokenID, ok := val.(string)
+	return tokenID, ok
+}
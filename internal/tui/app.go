@@ -18,6 +18,7 @@ type App struct {
 	connection views.ConnectionView
 	explorer   views.ExplorerView
 	help       views.HelpView
+	largeData  views.LargeDataView
 }
 
 func NewApp(client *client.Client) *App {
@@ -29,6 +30,7 @@ func NewApp(client *client.Client) *App {
 		connection: views.NewConnectionView(theme),
 		explorer:   views.NewExplorerView(theme),
 		help:       views.NewHelpView(""),
+		largeData:  views.NewLargeDataView(theme),
 	}
 }
 
@@ -45,23 +47,45 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.state.Profile = m.Profile
 		a.state.Status = "Connected"
 		a.state.View = ViewExplorer
-		a.explorer.SetProfile(m.Profile)
+		a.explorer.SetProfile(m.Info)
 		var cmd tea.Cmd
 		a.explorer, cmd = a.explorer.Reload(a.client)
 		return a, cmd
 	case views.ProfileLoadedMsg:
 		a.state.Profile = m.Profile
 		a.state.View = ViewExplorer
-		a.explorer.SetProfile(m.Profile)
+		a.explorer.SetProfile(m.Info)
 		var cmd tea.Cmd
 		a.explorer, cmd = a.explorer.Reload(a.client)
 		return a, cmd
+	case views.HealthMsg, views.HealthTickMsg:
+		var cmd tea.Cmd
+		a.explorer, cmd = a.explorer.Update(msg, a.client)
+		return a, cmd
+	case views.ShowLargeDataMsg:
+		a.state.View = ViewLargeData
+		var cmd tea.Cmd
+		a.largeData, cmd = a.largeData.Load(a.client)
+		return a, cmd
+	case views.CloseLargeDataMsg:
+		a.state.View = ViewExplorer
+		return a, nil
+	case views.OpenPartitionMsg:
+		a.state.View = ViewExplorer
+		var cmd tea.Cmd
+		a.explorer, cmd = a.explorer.OpenPartition(a.client, m.Keyspace, m.Table, m.Where)
+		return a, cmd
 	case views.ShowHelpMsg:
 		a.updateHelp()
 		a.state.PreviousView = a.state.View
 		a.state.View = ViewHelp
 		return a, nil
 	case tea.KeyMsg:
+		if a.state.View == ViewConnection && a.connection.IsEditing() {
+			var cmd tea.Cmd
+			a.connection, cmd = a.connection.Update(msg, a.client, a.state.Width, a.state.Height)
+			return a, cmd
+		}
 		if m.String() == "?" {
 			a.updateHelp()
 			a.state.PreviousView = a.state.View
@@ -72,6 +96,10 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.state.View = a.state.PreviousView
 			return a, nil
 		}
+		if a.state.View == ViewLargeData && (m.String() == "q" || m.String() == "esc") {
+			a.state.View = ViewExplorer
+			return a, nil
+		}
 		if m.String() == "q" {
 			return a, tea.Quit
 		}
@@ -97,6 +125,12 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a, cmd
 	}
 
+	if a.state.View == ViewLargeData {
+		var cmd tea.Cmd
+		a.largeData, cmd = a.largeData.Update(msg, a.client)
+		return a, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		if msg.String() == "ctrl+c" {
@@ -115,6 +149,8 @@ func (a *App) View() string {
 		return a.explorer.View(a.state.Width, a.state.Height)
 	case ViewHelp:
 		return a.help.View(a.state.Width, a.state.Height)
+	case ViewLargeData:
+		return a.largeData.View(a.state.Width, a.state.Height)
 	default:
 		return a.renderPlaceholder("Unknown view")
 	}
@@ -167,6 +203,7 @@ func (a *App) updateHelp() {
 		"  " + keyStyle.Render("t") + "                   Toggle inspector view (Table/JSON)",
 		"  " + keyStyle.Render("Ctrl+C") + "              Copy to clipboard (inspector)",
 		"  " + keyStyle.Render("Ctrl+E") + "              Export data to JSON file",
+		"  " + keyStyle.Render("L") + "                   ScyllaDB large partitions/rows/cells report",
 		"  " + keyStyle.Render("?") + "                   Show/Hide this help screen",
 		"  " + keyStyle.Render("q") + "                   Back / Quit application",
 		"",
@@ -95,6 +95,14 @@ func NewDataGrid(theme styles.Theme, schemaCache *cache.SchemaCache) DataGrid {
 	}
 }
 
+// SetPageSize sets how many rows each fetch asks for; sizes below one are
+// ignored.
+func (g *DataGrid) SetPageSize(size int32) {
+	if size > 0 {
+		g.pageSize = size
+	}
+}
+
 func (g DataGrid) Init() tea.Cmd {
 	return nil
 }
@@ -120,6 +128,21 @@ func (g DataGrid) LoadTable(c *client.Client, keyspace, table string) (DataGrid,
 	)
 }
 
+func (g DataGrid) LoadTableWithFilter(c *client.Client, keyspace, table, where string) (DataGrid, tea.Cmd) {
+	if where == "" {
+		return g.LoadTable(c, keyspace, table)
+	}
+
+	g, _ = g.LoadTable(c, keyspace, table)
+	g.filter = where
+	g.status = fmt.Sprintf("Filtering %s.%s...", keyspace, table)
+
+	return g, tea.Batch(
+		g.fetchSchemaCmd(c, keyspace, table),
+		g.fetchFilterCmd(c, keyspace, table, where, g.pageSize),
+	)
+}
+
 func (g DataGrid) ApplyFilter(c *client.Client, where string) (DataGrid, tea.Cmd) {
 	if g.keyspace == "" || g.table == "" {
 		return g, nil
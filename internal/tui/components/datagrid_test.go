@@ -351,6 +351,30 @@ func TestDataGrid_CacheInvalidationOnLoadTable(t *testing.T) {
 	}
 }
 
+func TestDataGrid_LoadTableWithFilter(t *testing.T) {
+	g := createTestGrid()
+	g.rows = createTestRows(5)
+	g.selected = 3
+
+	g, cmd := g.LoadTableWithFilter(nil, "ks", "events", `"id" = 42`)
+
+	if cmd == nil {
+		t.Fatal("expected fetch command")
+	}
+	if g.Keyspace() != "ks" || g.Table() != "events" {
+		t.Errorf("expected ks.events, got %s.%s", g.Keyspace(), g.Table())
+	}
+	if g.Filter() != `"id" = 42` {
+		t.Errorf("expected filter to be set, got %q", g.Filter())
+	}
+	if g.rows != nil || g.selected != 0 {
+		t.Error("expected rows and selection to be reset")
+	}
+	if !g.loading {
+		t.Error("expected grid to be loading")
+	}
+}
+
 func TestDataGrid_SearchMultipleColumns(t *testing.T) {
 	g := createTestGrid()
 	g.rows = []rowData{
@@ -523,3 +547,15 @@ func TestPad(t *testing.T) {
 		})
 	}
 }
+
+func TestDataGrid_SetPageSize(t *testing.T) {
+	grid := createTestGrid()
+	grid.SetPageSize(0)
+	if grid.pageSize != 50 {
+		t.Errorf("pageSize = %d, want the default kept", grid.pageSize)
+	}
+	grid.SetPageSize(500)
+	if grid.pageSize != 500 {
+		t.Errorf("pageSize = %d, want 500", grid.pageSize)
+	}
+}
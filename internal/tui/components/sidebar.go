@@ -30,6 +30,7 @@ type Sidebar struct {
 	searchQuery         string
 	showSystemKeyspaces bool
 	filteredMapping     []itemMapping
+	openKeyspace        string
 }
 
 type itemMapping struct {
@@ -80,6 +81,11 @@ func NewSidebar(theme styles.Theme) Sidebar {
 	}
 }
 
+// SetOpenKeyspace names a keyspace to expand once the keyspaces load.
+func (s *Sidebar) SetOpenKeyspace(keyspace string) {
+	s.openKeyspace = keyspace
+}
+
 func (s Sidebar) Init(c *client.Client) tea.Cmd {
 	return s.fetchKeyspacesCmd(c)
 }
@@ -90,6 +96,10 @@ func (s Sidebar) ActivateSearch() (Sidebar, tea.Cmd) {
 	return s, nil
 }
 
+func (s Sidebar) IsSearchActive() bool {
+	return s.searchActive
+}
+
 func (s Sidebar) Update(msg tea.Msg, c *client.Client) (Sidebar, tea.Cmd) {
 	var cmd tea.Cmd
 
@@ -154,6 +164,11 @@ func (s Sidebar) Update(msg tea.Msg, c *client.Client) (Sidebar, tea.Cmd) {
 		for _, ks := range m.Keyspaces {
 			s.keyspaces = append(s.keyspaces, keyspaceNode{name: ks})
 		}
+		for i, ks := range s.keyspaces {
+			if ks.name == s.openKeyspace {
+				return s.toggleKeyspace(c, i, ks.name)
+			}
+		}
 	case tablesMsg:
 		s.applyTables(m.Keyspace, m.Tables)
 	case sidebarErrMsg:
@@ -2,19 +2,36 @@ package components
 
 import (
 	"fmt"
+	"strings"
 
+	pb "github.com/KashifKhn/kassie/api/gen/go"
 	"github.com/KashifKhn/kassie/internal/tui/styles"
 	"github.com/charmbracelet/lipgloss"
 )
 
 type StatusBar struct {
-	theme styles.Theme
+	theme       styles.Theme
+	health      *pb.ConnectionHealth
+	environment string
+	color       string
 }
 
 func NewStatusBar(theme styles.Theme) StatusBar {
 	return StatusBar{theme: theme}
 }
 
+// SetHealth sets the connection health shown next to the profile; nil hides it.
+func (s *StatusBar) SetHealth(health *pb.ConnectionHealth) {
+	s.health = health
+}
+
+// SetTint fills the bar with the profile's color and labels it with its
+// environment; empty values leave the bar plain.
+func (s *StatusBar) SetTint(environment, color string) {
+	s.environment = environment
+	s.color = color
+}
+
 func (s StatusBar) View(width int, profile, keyspace, table, status string) string {
 	left := profile
 	if keyspace != "" && table != "" {
@@ -27,5 +44,45 @@ func (s StatusBar) View(width int, profile, keyspace, table, status string) stri
 	}
 
 	content := fmt.Sprintf("%s │ %s", left, status)
-	return lipgloss.NewStyle().Width(width).Render(s.theme.Status.Render(content))
+	if label := healthLabel(s.health); label != "" {
+		content = fmt.Sprintf("%s │ %s │ %s", left, label, status)
+	}
+
+	if s.environment != "" {
+		content = EnvironmentLabel(s.environment) + " │ " + content
+	}
+	if s.color != "" {
+		return s.theme.Tint(s.color).Width(width).Render(content)
+	}
+
+	style := s.theme.Status
+	if s.health != nil && s.health.State == pb.ConnectionState_CONNECTION_STATE_DOWN {
+		style = s.theme.Error
+	}
+	return lipgloss.NewStyle().Width(width).Render(style.Render(content))
+}
+
+func healthLabel(health *pb.ConnectionHealth) string {
+	if health == nil {
+		return ""
+	}
+
+	switch health.State {
+	case pb.ConnectionState_CONNECTION_STATE_HEALTHY:
+		return fmt.Sprintf("● %dms", health.LatencyMs)
+	case pb.ConnectionState_CONNECTION_STATE_DEGRADED:
+		return fmt.Sprintf("◐ degraded %dms", health.LatencyMs)
+	case pb.ConnectionState_CONNECTION_STATE_DOWN:
+		if health.LastError != "" {
+			return "○ down: " + health.LastError
+		}
+		return "○ down"
+	default:
+		return ""
+	}
+}
+
+// EnvironmentLabel is the tag shown for a profile's environment, such as PROD.
+func EnvironmentLabel(environment string) string {
+	return strings.ToUpper(environment)
 }
@@ -0,0 +1,44 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/tui/styles"
+)
+
+func TestHealthLabel(t *testing.T) {
+	tests := []struct {
+		name   string
+		health *pb.ConnectionHealth
+		want   string
+	}{
+		{name: "unknown", health: nil, want: ""},
+		{name: "unspecified", health: &pb.ConnectionHealth{}, want: ""},
+		{name: "healthy", health: &pb.ConnectionHealth{State: pb.ConnectionState_CONNECTION_STATE_HEALTHY, LatencyMs: 3}, want: "● 3ms"},
+		{name: "degraded", health: &pb.ConnectionHealth{State: pb.ConnectionState_CONNECTION_STATE_DEGRADED, LatencyMs: 1500}, want: "◐ degraded 1500ms"},
+		{name: "down", health: &pb.ConnectionHealth{State: pb.ConnectionState_CONNECTION_STATE_DOWN, LastError: "no hosts available"}, want: "○ down: no hosts available"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := healthLabel(tt.health); got != tt.want {
+				t.Errorf("healthLabel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusBarTint(t *testing.T) {
+	bar := NewStatusBar(styles.DefaultTheme())
+	if got := bar.View(80, "prod", "", "", "Ready"); strings.Contains(got, "PROD") {
+		t.Errorf("View() = %q, want no environment label", got)
+	}
+
+	bar.SetTint("prod", "#c62828")
+	got := bar.View(80, "prod", "app", "users", "Ready")
+	if !strings.Contains(got, "PROD │ prod › app › users") {
+		t.Errorf("View() = %q, want the environment label first", got)
+	}
+}
@@ -6,6 +6,7 @@ const (
 	ViewConnection View = iota
 	ViewExplorer
 	ViewHelp
+	ViewLargeData
 )
 
 type AppState struct {
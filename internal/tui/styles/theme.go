@@ -1,6 +1,11 @@
 package styles
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
 
 type Theme struct {
 	Title      lipgloss.Style
@@ -41,3 +46,22 @@ func DefaultTheme() Theme {
 		Selected:   base.Background(lipgloss.Color("24")).Foreground(lipgloss.Color("255")).Bold(true),
 	}
 }
+
+// Tint is the style of a bar filled with a profile's #rrggbb color, with
+// black or white text, whichever reads better on it.
+func (t Theme) Tint(color string) lipgloss.Style {
+	text := "231"
+	if isLight(color) {
+		text = "16"
+	}
+	return lipgloss.NewStyle().Background(lipgloss.Color(color)).Foreground(lipgloss.Color(text)).Bold(true)
+}
+
+func isLight(color string) bool {
+	rgb, err := strconv.ParseUint(strings.TrimPrefix(color, "#"), 16, 32)
+	if err != nil {
+		return false
+	}
+	r, g, b := rgb>>16&0xff, rgb>>8&0xff, rgb&0xff
+	return 299*r+587*g+114*b > 150000
+}
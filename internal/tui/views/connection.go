@@ -2,30 +2,55 @@ package views
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	pb "github.com/KashifKhn/kassie/api/gen/go"
 	"github.com/KashifKhn/kassie/internal/client"
+	"github.com/KashifKhn/kassie/internal/tui/components"
 	"github.com/KashifKhn/kassie/internal/tui/styles"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type tickMsg time.Time
 
 type ConnectedMsg struct {
 	Profile string
+	Info    *pb.ProfileInfo
 }
 
 type ProfileLoadedMsg struct {
 	Profile string
+	Info    *pb.ProfileInfo
 }
 
 type connectionErrMsg struct {
 	Err error
 }
 
+type connectionTestMsg struct {
+	Profile string
+	Result  *pb.TestConnectionResponse
+	Err     error
+}
+
+type profileLoadedForEditMsg struct {
+	Profile string
+	Result  *pb.GetProfileResponse
+	Err     error
+}
+
+type profileSavedMsg struct {
+	Status string
+	Err    error
+}
+
 type ConnectionView struct {
 	theme         styles.Theme
 	profiles      []string
@@ -36,16 +61,66 @@ type ConnectionView struct {
 	spinnerFrame  int
 	lastErrorTime time.Time
 	retryCount    int
+
+	credentials    bool
+	dbCredentials  bool
+	credForTest    bool
+	credFocus      int
+	userInput      textinput.Model
+	passInput      textinput.Model
+	pendingProfile string
+	serverUser     string
+	dbUsers        map[string]string
+	prompted       map[string]bool
+	environments   map[string]string
+	colors         map[string]string
+
+	testProfile string
+	testResult  *pb.TestConnectionResponse
+
+	form          *profileForm
+	confirmDelete string
+	notice        string
+
+	// Deleting or saving a prod profile waits for its name to be typed.
+	confirmTyped bool
+	confirmInput textinput.Model
+	pendingSave  *pb.ProfileSpec
 }
 
 func NewConnectionView(theme styles.Theme) ConnectionView {
+	userInput := textinput.New()
+	userInput.Placeholder = "username"
+	userInput.Prompt = "User:     "
+	userInput.CharLimit = 128
+	userInput.Width = 30
+
+	passInput := textinput.New()
+	passInput.Placeholder = "password"
+	passInput.Prompt = "Password: "
+	passInput.EchoMode = textinput.EchoPassword
+	passInput.CharLimit = 256
+	passInput.Width = 30
+
+	confirmInput := textinput.New()
+	confirmInput.Prompt = "> "
+	confirmInput.CharLimit = 128
+	confirmInput.Width = 30
+
 	return ConnectionView{
-		theme:   theme,
-		status:  "Fetching profiles...",
-		loading: true,
+		theme:        theme,
+		status:       "Fetching profiles...",
+		loading:      true,
+		userInput:    userInput,
+		passInput:    passInput,
+		confirmInput: confirmInput,
 	}
 }
 
+func (v ConnectionView) IsEditing() bool {
+	return v.credentials || v.form != nil || v.confirmDelete != "" || v.confirmTyped
+}
+
 func (v ConnectionView) Init(c *client.Client) tea.Cmd {
 	return tea.Batch(
 		v.fetchProfilesCmd(c),
@@ -63,16 +138,76 @@ func (v ConnectionView) Update(msg tea.Msg, c *client.Client, width, height int)
 		}
 		return v, nil
 	case tea.KeyMsg:
+		if v.credentials {
+			return v.updateCredentials(m, c)
+		}
+		if v.confirmTyped {
+			return v.updateConfirm(m, c)
+		}
+		if v.form != nil {
+			return v.updateForm(m, c)
+		}
+		if v.confirmDelete != "" {
+			profile := v.confirmDelete
+			v.confirmDelete = ""
+			if m.String() != "y" {
+				v.status = "Delete cancelled"
+				return v, nil
+			}
+			v.status = fmt.Sprintf("Deleting %s...", profile)
+			v.loading = true
+			return v, tea.Batch(v.deleteProfileCmd(c, profile), v.tickCmd())
+		}
 		switch m.String() {
 		case "j", "down":
 			if len(v.profiles) > 0 {
 				v.selected = min(v.selected+1, len(v.profiles)-1)
 			}
+			v.testResult = nil
 		case "k", "up":
 			v.selected = max(v.selected-1, 0)
+			v.testResult = nil
+		case "esc":
+			v.testResult = nil
+		case "t":
+			if !v.loading && len(v.profiles) > 0 {
+				profile := v.profiles[v.selected]
+				if v.prompted[profile] {
+					v = v.openCredentials(profile, true)
+					v.credForTest = true
+					return v, nil
+				}
+				return v.startTest(c, profile)
+			}
+		case "a":
+			if !v.loading {
+				v.testResult = nil
+				v.form = newProfileForm("", nil, false)
+			}
+		case "e":
+			if !v.loading && len(v.profiles) > 0 {
+				profile := v.profiles[v.selected]
+				v.status = fmt.Sprintf("Loading %s...", profile)
+				v.loading = true
+				return v, tea.Batch(v.getProfileCmd(c, profile), v.tickCmd())
+			}
+		case "d":
+			if !v.loading && len(v.profiles) > 0 {
+				v.confirmDelete = v.profiles[v.selected]
+				v.testResult = nil
+				if v.isProd(v.confirmDelete) {
+					v = v.openConfirm()
+					v.status = fmt.Sprintf("%s is a prod profile: type its name to delete it", v.confirmDelete)
+					return v, nil
+				}
+				v.status = fmt.Sprintf("Delete profile %s? (y/n)", v.confirmDelete)
+			}
 		case "enter":
 			if v.ready && len(v.profiles) > 0 {
 				profile := v.profiles[v.selected]
+				if v.prompted[profile] {
+					return v.openCredentials(profile, true), nil
+				}
 				v.status = fmt.Sprintf("Connecting to %s...", profile)
 				v.loading = true
 				v.retryCount = 0
@@ -91,17 +226,75 @@ func (v ConnectionView) Update(msg tea.Msg, c *client.Client, width, height int)
 		v.loading = false
 		v.ready = true
 		v.profiles = m.Profiles
+		v.prompted = m.Prompted
+		v.dbUsers = m.DBUsers
+		v.environments = m.Environments
+		v.colors = m.Colors
+		v.selected = min(v.selected, max(len(m.Profiles)-1, 0))
 		if len(m.Profiles) == 0 {
 			v.status = "No profiles found"
 			v.ready = false
+		} else if v.notice != "" {
+			v.status = v.notice
 		} else {
 			v.status = "Select a profile and press Enter"
 		}
+		v.notice = ""
 	case connectionErrMsg:
 		v.loading = false
 		v.ready = true
 		v.lastErrorTime = time.Now()
 		v.status = parseError(m.Err)
+		if status.Code(m.Err) == codes.Unauthenticated && len(v.profiles) > 0 {
+			return v.openCredentials(v.profiles[v.selected], false), nil
+		}
+	case connectionTestMsg:
+		v.loading = false
+		v.ready = true
+		if m.Err != nil {
+			v.lastErrorTime = time.Now()
+			v.status = parseError(m.Err)
+			if status.Code(m.Err) == codes.Unauthenticated {
+				v = v.openCredentials(m.Profile, false)
+				v.credForTest = true
+			}
+			return v, nil
+		}
+		v.testProfile = m.Profile
+		v.testResult = m.Result
+		if m.Result.Ok {
+			v.status = fmt.Sprintf("All checks passed for %s", m.Profile)
+		} else {
+			v.status = fmt.Sprintf("Connection test failed for %s", m.Profile)
+		}
+	case profileLoadedForEditMsg:
+		v.loading = false
+		v.ready = true
+		if m.Err != nil {
+			v.lastErrorTime = time.Now()
+			v.status = parseError(m.Err)
+			return v, nil
+		}
+		v.testResult = nil
+		v.form = newProfileForm(m.Profile, m.Result.Profile, m.Result.PasswordLiteral)
+		v.status = fmt.Sprintf("Editing %s", m.Profile)
+	case profileSavedMsg:
+		v.loading = false
+		if m.Err != nil {
+			v.ready = true
+			v.lastErrorTime = time.Now()
+			if v.form != nil {
+				v.form.err = statusMessage(m.Err)
+				return v, nil
+			}
+			v.status = statusMessage(m.Err)
+			return v, nil
+		}
+		v.form = nil
+		v.notice = m.Status
+		v.status = "Reloading profiles..."
+		v.loading = true
+		return v, tea.Batch(v.fetchProfilesCmd(c), v.tickCmd())
 	case ProfileLoadedMsg:
 		v.status = fmt.Sprintf("Using profile: %s", m.Profile)
 		v.ready = true
@@ -110,6 +303,196 @@ func (v ConnectionView) Update(msg tea.Msg, c *client.Client, width, height int)
 	return v, nil
 }
 
+func (v ConnectionView) startTest(c *client.Client, profile string) (ConnectionView, tea.Cmd) {
+	v.status = fmt.Sprintf("Testing connection to %s...", profile)
+	v.loading = true
+	v.testResult = nil
+	return v, tea.Batch(v.testCmd(c, profile), v.tickCmd())
+}
+
+// openCredentials shows the sign-in form, either for the server user or, with
+// database set, for the database credentials a prompted profile requires.
+func (v ConnectionView) openCredentials(profile string, database bool) ConnectionView {
+	v.credentials = true
+	v.dbCredentials = database
+	v.pendingProfile = profile
+	if database {
+		v.userInput.SetValue(v.dbUsers[profile])
+	} else {
+		v.userInput.SetValue(v.serverUser)
+	}
+	v.passInput.SetValue("")
+	if v.userInput.Value() == "" {
+		v.credFocus = 0
+		v.userInput.Focus()
+		v.passInput.Blur()
+	} else {
+		v.credFocus = 1
+		v.userInput.Blur()
+		v.passInput.Focus()
+	}
+	return v
+}
+
+func (v ConnectionView) closeCredentials() ConnectionView {
+	v.credentials = false
+	v.credForTest = false
+	v.userInput.Blur()
+	v.passInput.Blur()
+	v.passInput.SetValue("")
+	return v
+}
+
+func (v ConnectionView) updateCredentials(msg tea.KeyMsg, c *client.Client) (ConnectionView, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		v = v.closeCredentials()
+		v.status = "Sign in cancelled"
+		return v, nil
+	case "tab", "shift+tab", "up", "down":
+		v.credFocus = 1 - v.credFocus
+		if v.credFocus == 0 {
+			v.passInput.Blur()
+			v.userInput.Focus()
+		} else {
+			v.userInput.Blur()
+			v.passInput.Focus()
+		}
+		return v, nil
+	case "enter":
+		username := strings.TrimSpace(v.userInput.Value())
+		if v.credFocus == 0 || v.passInput.Value() == "" {
+			if username != "" {
+				v.credFocus = 1
+				v.userInput.Blur()
+				v.passInput.Focus()
+			}
+			return v, nil
+		}
+		profile := v.pendingProfile
+		forTest := v.credForTest
+		if v.dbCredentials {
+			c.SetDatabaseCredentials(username, v.passInput.Value())
+			if v.dbUsers == nil {
+				v.dbUsers = make(map[string]string)
+			}
+			v.dbUsers[profile] = username
+		} else {
+			c.SetCredentials(username, v.passInput.Value())
+			v.serverUser = username
+		}
+		v = v.closeCredentials()
+		if forTest {
+			return v.startTest(c, profile)
+		}
+		v.status = fmt.Sprintf("Signing in to %s as %s...", profile, username)
+		v.loading = true
+		return v, tea.Batch(v.loginCmd(c, profile), v.tickCmd())
+	}
+
+	var cmd tea.Cmd
+	if v.credFocus == 0 {
+		v.userInput, cmd = v.userInput.Update(msg)
+	} else {
+		v.passInput, cmd = v.passInput.Update(msg)
+	}
+	return v, cmd
+}
+
+func (v ConnectionView) updateForm(msg tea.KeyMsg, c *client.Client) (ConnectionView, tea.Cmd) {
+	if msg.String() == "esc" {
+		v.form = nil
+		v.status = "Edit cancelled"
+		return v, nil
+	}
+	if v.loading {
+		return v, nil
+	}
+
+	cmd, save := v.form.Update(msg)
+	if !save {
+		return v, cmd
+	}
+
+	spec, err := v.form.Spec()
+	if err != nil {
+		v.form.err = err.Error()
+		return v, nil
+	}
+	v.form.err = ""
+	if v.isProd(v.form.editing) {
+		v.pendingSave = spec
+		v = v.openConfirm()
+		v.status = fmt.Sprintf("%s is a prod profile: type its name to save it", v.form.editing)
+		return v, nil
+	}
+	v.status = fmt.Sprintf("Saving %s...", spec.Name)
+	v.loading = true
+	return v, tea.Batch(v.saveProfileCmd(c, v.form.editing, spec), v.tickCmd())
+}
+
+func (v ConnectionView) isProd(profile string) bool {
+	return v.environments[profile] == "prod"
+}
+
+func (v ConnectionView) openConfirm() ConnectionView {
+	v.confirmTyped = true
+	v.confirmInput.SetValue("")
+	v.confirmInput.Focus()
+	return v
+}
+
+// confirmTarget is the profile whose name must be typed: the one being
+// saved, else the one being deleted.
+func (v ConnectionView) confirmTarget() string {
+	if v.pendingSave != nil {
+		return v.form.editing
+	}
+	return v.confirmDelete
+}
+
+func (v ConnectionView) closeConfirm() ConnectionView {
+	v.confirmTyped = false
+	v.confirmInput.Blur()
+	v.confirmInput.SetValue("")
+	v.pendingSave = nil
+	v.confirmDelete = ""
+	return v
+}
+
+func (v ConnectionView) updateConfirm(msg tea.KeyMsg, c *client.Client) (ConnectionView, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		saving := v.pendingSave != nil
+		v = v.closeConfirm()
+		if saving {
+			v.status = "Save cancelled"
+		} else {
+			v.status = "Delete cancelled"
+		}
+		return v, nil
+	case "enter":
+		profile := v.confirmTarget()
+		if strings.TrimSpace(v.confirmInput.Value()) != profile {
+			v.status = fmt.Sprintf("Name does not match; type %s or press esc", profile)
+			return v, nil
+		}
+		spec := v.pendingSave
+		v = v.closeConfirm()
+		v.loading = true
+		if spec != nil {
+			v.status = fmt.Sprintf("Saving %s...", spec.Name)
+			return v, tea.Batch(v.saveProfileCmd(c, v.form.editing, spec), v.tickCmd())
+		}
+		v.status = fmt.Sprintf("Deleting %s...", profile)
+		return v, tea.Batch(v.deleteProfileCmd(c, profile), v.tickCmd())
+	}
+
+	var cmd tea.Cmd
+	v.confirmInput, cmd = v.confirmInput.Update(msg)
+	return v, cmd
+}
+
 func (v ConnectionView) tickCmd() tea.Cmd {
 	return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
 		return tickMsg(t)
@@ -183,11 +566,42 @@ func (v ConnectionView) View(width, height int) string {
 			prefix = "▶ "
 			style = selectedProfileStyle
 		}
-		items = append(items, style.Render(prefix+p))
+		item := style.Render(prefix + p)
+		if env, color := v.environments[p], v.colors[p]; env != "" && color != "" {
+			item += " " + v.theme.Tint(color).Padding(0, 1).Render(components.EnvironmentLabel(env))
+		}
+		items = append(items, item)
 	}
 
 	var profileSection string
-	if len(items) == 0 {
+	if v.confirmTyped {
+		action := "delete"
+		if v.pendingSave != nil {
+			action = "save"
+		}
+		form := lipgloss.JoinVertical(
+			lipgloss.Left,
+			selectedProfileStyle.Render(fmt.Sprintf("Type %s to %s this prod profile", v.confirmTarget(), action)),
+			"",
+			v.confirmInput.View(),
+		)
+		profileSection = profileBoxStyle.Render(form)
+	} else if v.form != nil {
+		profileSection = profileBoxStyle.Render(v.form.View(selectedProfileStyle))
+	} else if v.credentials {
+		heading := "Sign in to " + v.pendingProfile
+		if v.dbCredentials {
+			heading = "Database credentials for " + v.pendingProfile
+		}
+		form := lipgloss.JoinVertical(
+			lipgloss.Left,
+			selectedProfileStyle.Render(heading),
+			"",
+			v.userInput.View(),
+			v.passInput.View(),
+		)
+		profileSection = profileBoxStyle.Render(form)
+	} else if len(items) == 0 {
 		profileSection = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("240")).
 			Italic(true).
@@ -197,6 +611,15 @@ func (v ConnectionView) View(width, height int) string {
 		profileSection = profileBoxStyle.Render(list)
 	}
 
+	contentWidth := 50
+	if width < 60 {
+		contentWidth = width - 10
+	}
+
+	if v.testResult != nil && !v.credentials && v.form == nil {
+		profileSection = lipgloss.JoinVertical(lipgloss.Left, profileSection, "", v.renderTestResult(contentWidth))
+	}
+
 	divider := dividerStyle.Render("─────────────────────────────")
 
 	helpText := lipgloss.NewStyle().
@@ -204,22 +627,25 @@ func (v ConnectionView) View(width, height int) string {
 		Align(lipgloss.Center)
 
 	var helpStr string
-	if !v.ready && !v.loading {
-		helpStr = "j/k navigate • r retry • q quit"
+	if v.confirmTyped {
+		helpStr = "enter confirm • esc cancel"
+	} else if v.form != nil {
+		helpStr = "tab next field • space toggle • enter save • esc cancel"
+	} else if v.confirmDelete != "" {
+		helpStr = "y delete • any other key cancels"
+	} else if v.credentials {
+		helpStr = "tab switch field • enter sign in • esc cancel"
+	} else if !v.ready && !v.loading {
+		helpStr = "j/k navigate • r retry • t test • a/e/d profiles • q quit"
 		if v.retryCount > 0 {
-			helpStr = fmt.Sprintf("j/k navigate • r retry (%d) • q quit", v.retryCount)
+			helpStr = fmt.Sprintf("j/k navigate • r retry (%d) • t test • a/e/d profiles • q quit", v.retryCount)
 		}
 	} else {
-		helpStr = "j/k navigate • enter connect • q quit"
+		helpStr = "j/k navigate • enter connect • t test • a add • e edit • d delete • q quit"
 	}
 
 	helpRendered := helpText.Render(helpStr)
 
-	contentWidth := 50
-	if width < 60 {
-		contentWidth = width - 10
-	}
-
 	container := lipgloss.NewStyle().
 		Width(contentWidth).
 		Align(lipgloss.Center)
@@ -245,7 +671,11 @@ func (v ConnectionView) View(width, height int) string {
 }
 
 type profilesMsg struct {
-	Profiles []string
+	Profiles     []string
+	Prompted     map[string]bool
+	DBUsers      map[string]string
+	Environments map[string]string
+	Colors       map[string]string
 }
 
 func (v ConnectionView) fetchProfilesCmd(c *client.Client) tea.Cmd {
@@ -258,12 +688,24 @@ func (v ConnectionView) fetchProfilesCmd(c *client.Client) tea.Cmd {
 			return connectionErrMsg{Err: err}
 		}
 
-		items := make([]string, 0, len(profiles))
+		msg := profilesMsg{
+			Profiles:     make([]string, 0, len(profiles)),
+			Prompted:     make(map[string]bool),
+			DBUsers:      make(map[string]string),
+			Environments: make(map[string]string),
+			Colors:       make(map[string]string),
+		}
 		for _, p := range profiles {
-			items = append(items, p.Name)
+			msg.Profiles = append(msg.Profiles, p.Name)
+			msg.Environments[p.Name] = p.Environment
+			msg.Colors[p.Name] = p.Color
+			if p.CredentialsRequired {
+				msg.Prompted[p.Name] = true
+				msg.DBUsers[p.Name] = p.DefaultDbUsername
+			}
 		}
 
-		return profilesMsg{Profiles: items}
+		return msg
 	}
 }
 
@@ -272,15 +714,106 @@ func (v ConnectionView) loginCmd(c *client.Client, profile string) tea.Cmd {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		_, err := c.Login(ctx, profile)
+		info, err := c.Login(ctx, profile)
 		if err != nil {
 			return connectionErrMsg{Err: err}
 		}
+		if info == nil {
+			info = &pb.ProfileInfo{Name: profile}
+		}
 
-		return ConnectedMsg{Profile: profile}
+		return ConnectedMsg{Profile: profile, Info: info}
 	}
 }
 
+func (v ConnectionView) testCmd(c *client.Client, profile string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		result, err := c.TestConnection(ctx, profile, "")
+		return connectionTestMsg{Profile: profile, Result: result, Err: err}
+	}
+}
+
+func (v ConnectionView) getProfileCmd(c *client.Client, profile string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		result, err := c.GetProfile(ctx, profile)
+		return profileLoadedForEditMsg{Profile: profile, Result: result, Err: err}
+	}
+}
+
+// saveProfileCmd creates spec, or updates the profile being edited.
+func (v ConnectionView) saveProfileCmd(c *client.Client, editing string, spec *pb.ProfileSpec) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var err error
+		if editing == "" {
+			err = c.CreateProfile(ctx, spec)
+		} else {
+			err = c.UpdateProfile(ctx, editing, spec, false)
+		}
+		if err != nil {
+			return profileSavedMsg{Err: err}
+		}
+		return profileSavedMsg{Status: fmt.Sprintf("Saved profile %s", spec.Name)}
+	}
+}
+
+func (v ConnectionView) deleteProfileCmd(c *client.Client, profile string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := c.DeleteProfile(ctx, profile); err != nil {
+			return profileSavedMsg{Err: err}
+		}
+		return profileSavedMsg{Status: fmt.Sprintf("Deleted profile %s", profile)}
+	}
+}
+
+// renderTestResult lists the checks of the last connection test, with the
+// hint for each failed one.
+func (v ConnectionView) renderTestResult(width int) string {
+	passStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("46"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	skipStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("226"))
+
+	truncate := func(s string) string {
+		runes := []rune(s)
+		if len(runes) > width && width > 3 {
+			return string(runes[:width-3]) + "..."
+		}
+		return s
+	}
+
+	lines := []string{lipgloss.NewStyle().Bold(true).Render("Connection test: " + v.testProfile)}
+	for _, check := range v.testResult.Checks {
+		label := check.Stage
+		if check.Host != "" {
+			label += " " + check.Host
+		}
+		switch check.Status {
+		case pb.CheckStatus_CHECK_STATUS_PASSED:
+			lines = append(lines, passStyle.Render(truncate(fmt.Sprintf("✓ %s (%dms) %s", label, check.LatencyMs, check.Detail))))
+		case pb.CheckStatus_CHECK_STATUS_FAILED:
+			lines = append(lines, failStyle.Render(truncate(fmt.Sprintf("✗ %s (%dms) %s", label, check.LatencyMs, check.Detail))))
+			if check.Hint != "" {
+				lines = append(lines, hintStyle.Render(truncate("  → "+check.Hint)))
+			}
+		default:
+			lines = append(lines, skipStyle.Render(truncate(fmt.Sprintf("- %s %s", label, check.Detail))))
+		}
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
 func (v ConnectionView) loadExistingProfile(c *client.Client) tea.Cmd {
 	return func() tea.Msg {
 		if c == nil {
@@ -290,7 +823,19 @@ func (v ConnectionView) loadExistingProfile(c *client.Client) tea.Cmd {
 		if profile == "" {
 			return nil
 		}
-		return ProfileLoadedMsg{Profile: profile}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		info := &pb.ProfileInfo{Name: profile}
+		if profiles, err := c.GetProfiles(ctx); err == nil {
+			for _, p := range profiles {
+				if p.Name == profile {
+					info = p
+				}
+			}
+		}
+		return ProfileLoadedMsg{Profile: profile, Info: info}
 	}
 }
 
@@ -308,6 +853,16 @@ func max(a, b int) int {
 	return b
 }
 
+// statusMessage returns the server's message for a failed call, without the
+// client's wrapping.
+func statusMessage(err error) string {
+	var se interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &se) {
+		return se.GRPCStatus().Message()
+	}
+	return err.Error()
+}
+
 func parseError(err error) string {
 	if err == nil {
 		return "Unknown error"
@@ -1,9 +1,11 @@
 package views
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	pb "github.com/KashifKhn/kassie/api/gen/go"
 	"github.com/KashifKhn/kassie/internal/client"
 	"github.com/KashifKhn/kassie/internal/tui/cache"
 	"github.com/KashifKhn/kassie/internal/tui/components"
@@ -12,6 +14,9 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
+// healthPollInterval is how often the status bar refreshes connection health.
+const healthPollInterval = 15 * time.Second
+
 type CopySuccessMsg struct{}
 
 type CopyErrorMsg struct {
@@ -28,10 +33,12 @@ type ExplorerView struct {
 	status           components.StatusBar
 	active           pane
 	profile          string
+	info             *pb.ProfileInfo
 	message          string
 	schemaCache      *cache.SchemaCache
 	viewMode         viewMode
 	previousViewMode viewMode
+	healthPoll       int
 }
 
 type pane int
@@ -72,10 +79,24 @@ func (v ExplorerView) Reload(c *client.Client) (ExplorerView, tea.Cmd) {
 	v.inspect = components.NewInspector(v.theme)
 	v.filter = components.NewFilterBar(v.theme)
 	v.active = paneSidebar
+	v.status.SetHealth(nil)
+
+	v.grid.SetPageSize(v.info.GetPageSize())
+	v.sidebar.SetOpenKeyspace(v.info.GetDefaultKeyspace())
+	var openTable tea.Cmd
+	if keyspace, table := v.info.GetDefaultKeyspace(), v.info.GetDefaultTable(); keyspace != "" && table != "" {
+		v.grid, openTable = v.grid.LoadTable(c, keyspace, table)
+		v.active = paneGrid
+	}
+
+	// Each reload starts a new poll loop; ticks from earlier ones are dropped.
+	v.healthPoll++
 
 	return v, tea.Batch(
 		v.sidebar.Init(c),
 		v.grid.Init(),
+		openTable,
+		fetchHealth(c, v.healthPoll),
 	)
 }
 
@@ -97,6 +118,21 @@ func (v ExplorerView) Update(msg tea.Msg, c *client.Client) (ExplorerView, tea.C
 	case clearMessageMsg:
 		v.message = ""
 		return v, nil
+	case HealthMsg:
+		if m.poll != v.healthPoll {
+			return v, nil
+		}
+		if m.err == nil {
+			v.status.SetHealth(m.health)
+		}
+		return v, tea.Tick(healthPollInterval, func(time.Time) tea.Msg {
+			return HealthTickMsg{poll: m.poll}
+		})
+	case HealthTickMsg:
+		if m.poll != v.healthPoll {
+			return v, nil
+		}
+		return v, fetchHealth(c, m.poll)
 	case components.TableSelectedMsg:
 		var cmd tea.Cmd
 		v.grid, cmd = v.grid.LoadTable(c, m.Keyspace, m.Table)
@@ -250,7 +286,13 @@ func (v ExplorerView) View(width, height int) string {
 		filterView = v.filter.View(width)
 	}
 
-	contentHeight := height - filterHeight - 1
+	header := v.header(width)
+	headerHeight := 0
+	if header != "" {
+		headerHeight = 1
+	}
+
+	contentHeight := height - filterHeight - headerHeight - 1
 	if contentHeight < 1 {
 		contentHeight = height
 	}
@@ -309,6 +351,9 @@ func (v ExplorerView) View(width, height int) string {
 	status := v.status.View(width, v.profile, v.grid.Keyspace(), v.grid.Table(), statusText+" | "+statusHint)
 
 	parts := []string{row}
+	if header != "" {
+		parts = []string{header, row}
+	}
 	if filterView != "" {
 		parts = append(parts, filterView)
 	}
@@ -379,6 +424,10 @@ func (v ExplorerView) handleNavigation(msg tea.Msg, cmd tea.Cmd) (ExplorerView,
 		} else if v.active == paneGrid {
 			v.filter = v.filter.Activate(v.grid.Filter())
 		}
+	case "L":
+		if !v.sidebar.IsSearchActive() {
+			return v, tea.Batch(cmd, func() tea.Msg { return ShowLargeDataMsg{} })
+		}
 	case "?":
 		return v, tea.Batch(cmd, func() tea.Msg { return ShowHelpMsg{} })
 	}
@@ -399,8 +448,36 @@ func (v ExplorerView) paneLabel() string {
 	}
 }
 
-func (v *ExplorerView) SetProfile(profile string) {
-	v.profile = profile
+func (v ExplorerView) OpenPartition(c *client.Client, keyspace, table, where string) (ExplorerView, tea.Cmd) {
+	v.filter = v.filter.Deactivate()
+	v.active = paneGrid
+	if v.viewMode == viewModeInspectorOnly {
+		v.viewMode = v.previousViewMode
+		v.inspect.SetFullscreen(false)
+	}
+
+	var cmd tea.Cmd
+	v.grid, cmd = v.grid.LoadTableWithFilter(c, keyspace, table, where)
+	return v, cmd
+}
+
+func (v *ExplorerView) SetProfile(info *pb.ProfileInfo) {
+	v.profile = info.GetName()
+	v.info = info
+	v.status.SetTint(info.GetEnvironment(), info.GetColor())
+}
+
+// header is a bar in the profile's color naming it and its environment, so
+// that a prod session is hard to mistake. Profiles without a color have none.
+func (v ExplorerView) header(width int) string {
+	if v.info.GetColor() == "" {
+		return ""
+	}
+	label := v.profile
+	if env := v.info.GetEnvironment(); env != "" {
+		label = components.EnvironmentLabel(env) + " │ " + label
+	}
+	return v.theme.Tint(v.info.GetColor()).Width(width).Render(" " + label)
 }
 
 type ShowHelpMsg struct{}
@@ -414,6 +491,28 @@ func maxInt(a, b int) int {
 
 type clearMessageMsg struct{}
 
+// HealthMsg and HealthTickMsg drive the status bar's health polling and must
+// reach the explorer whichever view is shown.
+type HealthMsg struct {
+	poll   int
+	health *pb.ConnectionHealth
+	err    error
+}
+
+type HealthTickMsg struct {
+	poll int
+}
+
+func fetchHealth(c *client.Client, poll int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		health, err := c.GetConnectionHealth(ctx)
+		return HealthMsg{poll: poll, health: health, err: err}
+	}
+}
+
 func (v ExplorerView) clearMessageAfter(seconds int) tea.Cmd {
 	return tea.Tick(time.Duration(seconds)*time.Second, func(t time.Time) tea.Msg {
 		return clearMessageMsg{}
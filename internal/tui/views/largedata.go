@@ -0,0 +1,233 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/client"
+	"github.com/KashifKhn/kassie/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type ShowLargeDataMsg struct{}
+
+type CloseLargeDataMsg struct{}
+
+type OpenPartitionMsg struct {
+	Keyspace string
+	Table    string
+	Where    string
+}
+
+type largeDataMsg struct {
+	Report *pb.GetLargeDataReportResponse
+}
+
+type largeDataErrMsg struct {
+	Err error
+}
+
+type LargeDataView struct {
+	theme    styles.Theme
+	entries  []*pb.LargeDataEntry
+	selected int
+	loading  bool
+	status   string
+	version  string
+}
+
+func NewLargeDataView(theme styles.Theme) LargeDataView {
+	return LargeDataView{
+		theme:  theme,
+		status: "Press r to load the large data report",
+	}
+}
+
+func (v LargeDataView) Load(c *client.Client) (LargeDataView, tea.Cmd) {
+	v.loading = true
+	v.status = "Loading large partitions, rows and cells..."
+	return v, v.fetchReportCmd(c)
+}
+
+func (v LargeDataView) Update(msg tea.Msg, c *client.Client) (LargeDataView, tea.Cmd) {
+	switch m := msg.(type) {
+	case largeDataMsg:
+		v.loading = false
+		v.version = m.Report.Version
+		v.entries = nil
+		for _, t := range m.Report.Tables {
+			v.entries = append(v.entries, t.Entries...)
+		}
+		v.selected = 0
+		if len(v.entries) == 0 {
+			v.status = "No large partitions, rows or cells recorded"
+		} else {
+			v.status = fmt.Sprintf("%d offenders in %d tables", len(v.entries), len(m.Report.Tables))
+		}
+	case largeDataErrMsg:
+		v.loading = false
+		v.status = fmt.Sprintf("Error: %s", m.Err)
+	case tea.KeyMsg:
+		switch m.String() {
+		case "j", "down":
+			if len(v.entries) > 0 {
+				v.selected = min(v.selected+1, len(v.entries)-1)
+			}
+		case "k", "up":
+			v.selected = max(v.selected-1, 0)
+		case "g":
+			v.selected = 0
+		case "G":
+			if len(v.entries) > 0 {
+				v.selected = len(v.entries) - 1
+			}
+		case "r":
+			if !v.loading {
+				return v.Load(c)
+			}
+		case "esc", "q":
+			return v, func() tea.Msg { return CloseLargeDataMsg{} }
+		case "enter":
+			if len(v.entries) == 0 {
+				return v, nil
+			}
+			entry := v.entries[v.selected]
+			if entry.WhereClause == "" {
+				v.status = fmt.Sprintf("Cannot map partition key %q of %s.%s to a filter", entry.PartitionKey, entry.Keyspace, entry.Table)
+				return v, nil
+			}
+			return v, func() tea.Msg {
+				return OpenPartitionMsg{Keyspace: entry.Keyspace, Table: entry.Table, Where: entry.WhereClause}
+			}
+		}
+	}
+
+	return v, nil
+}
+
+func (v LargeDataView) View(width, height int) string {
+	if width <= 0 || height <= 0 {
+		return ""
+	}
+
+	title := "Large Data Report"
+	if v.version != "" {
+		title = fmt.Sprintf("Large Data Report — ScyllaDB %s", v.version)
+	}
+
+	lines := []string{v.theme.Title.Render(title), ""}
+
+	// The padding takes two lines, and the title, the status, the help
+	// and the blank lines around them five more.
+	visible := height - 7
+	if visible < 1 {
+		visible = 1
+	}
+
+	var body []string
+	var lastTable string
+	selectedLine := 0
+	for i, e := range v.entries {
+		table := e.Keyspace + "." + e.Table
+		if table != lastTable {
+			body = append(body, v.theme.Header.Render(table))
+			lastTable = table
+		}
+
+		line := fmt.Sprintf("  %-9s %10s  %s", largeDataKindLabel(e.Kind), formatBytes(e.SizeBytes), largeDataKey(e))
+		line = truncateLine(line, width-2)
+		if i == v.selected {
+			line = v.theme.Selected.Render(line)
+			selectedLine = len(body)
+		}
+		body = append(body, line)
+	}
+
+	scroll := 0
+	if selectedLine >= visible {
+		scroll = selectedLine - visible + 1
+	}
+	lines = append(lines, body[scroll:min(scroll+visible, len(body))]...)
+
+	if len(v.entries) == 0 && !v.loading {
+		lines = append(lines, v.theme.Dim.Render("  Nothing to show"))
+	}
+
+	status := v.status
+	if v.loading {
+		status = "⠋ " + status
+	}
+
+	lines = append(lines, "", v.theme.Status.Render(status))
+	lines = append(lines, v.theme.Dim.Render("j/k navigate • enter open partition • r refresh • esc back"))
+
+	return lipgloss.NewStyle().Width(width).Height(height).Padding(1, 2).Render(strings.Join(lines, "\n"))
+}
+
+func (v LargeDataView) fetchReportCmd(c *client.Client) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		report, err := c.GetLargeDataReport(ctx, "", "", 0)
+		if err != nil {
+			return largeDataErrMsg{Err: err}
+		}
+		return largeDataMsg{Report: report}
+	}
+}
+
+func largeDataKindLabel(kind pb.LargeDataKind) string {
+	switch kind {
+	case pb.LargeDataKind_LARGE_DATA_KIND_PARTITION:
+		return "partition"
+	case pb.LargeDataKind_LARGE_DATA_KIND_ROW:
+		return "row"
+	case pb.LargeDataKind_LARGE_DATA_KIND_CELL:
+		return "cell"
+	default:
+		return "unknown"
+	}
+}
+
+func largeDataKey(e *pb.LargeDataEntry) string {
+	key := "pk=" + e.PartitionKey
+	if e.ClusteringKey != "" {
+		key += " ck=" + e.ClusteringKey
+	}
+	if e.ColumnName != "" {
+		key += " col=" + e.ColumnName
+	}
+	if e.Rows > 0 {
+		key += fmt.Sprintf(" (%d)", e.Rows)
+	}
+	return key
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func truncateLine(s string, width int) string {
+	if width <= 3 || lipgloss.Width(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	if len(runes) > width-3 {
+		runes = runes[:width-3]
+	}
+	return string(runes) + "..."
+}
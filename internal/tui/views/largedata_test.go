@@ -0,0 +1,46 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/KashifKhn/kassie/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestLargeDataViewScrollsToSelection(t *testing.T) {
+	report := &pb.GetLargeDataReportResponse{}
+	for _, table := range []string{"users", "orders", "events"} {
+		entries := make([]*pb.LargeDataEntry, 4)
+		for i := range entries {
+			entries[i] = &pb.LargeDataEntry{
+				Keyspace:     "app",
+				Table:        table,
+				Kind:         pb.LargeDataKind_LARGE_DATA_KIND_PARTITION,
+				PartitionKey: fmt.Sprintf("%s-%d", table, i),
+				SizeBytes:    int64(200<<20 - i),
+			}
+		}
+		report.Tables = append(report.Tables, &pb.LargeDataTable{Keyspace: "app", Table: table, Entries: entries})
+	}
+
+	v := NewLargeDataView(styles.DefaultTheme())
+	v, _ = v.Update(largeDataMsg{Report: report}, nil)
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("G")}, nil)
+
+	const height = 12
+	out := v.View(80, height)
+	if !strings.Contains(out, "pk=events-3") {
+		t.Errorf("selected entry is not shown:\n%s", out)
+	}
+	if got := strings.Count(out, "\n") + 1; got > height {
+		t.Errorf("View() rendered %d lines, want at most %d:\n%s", got, height, out)
+	}
+
+	v, _ = v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")}, nil)
+	if out := v.View(80, height); !strings.Contains(out, "app.users") || !strings.Contains(out, "pk=users-0") {
+		t.Errorf("first entry is not shown after g:\n%s", out)
+	}
+}
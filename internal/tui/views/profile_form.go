@@ -0,0 +1,219 @@
+package views
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	pb "github.com/KashifKhn/kassie/api/gen/go"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	fieldName = iota
+	fieldHosts
+	fieldPort
+	fieldKeyspace
+	fieldUsername
+	fieldPasswordEnv
+	fieldBundle
+	fieldSSLCA
+	fieldPrompt
+	fieldSSL
+	fieldCount
+)
+
+const defaultCQLPort = 9042
+
+// profileForm edits the settings of one profile. Settings it does not show,
+// such as client certificates, are carried over from the loaded profile.
+type profileForm struct {
+	editing         string
+	base            *pb.ProfileSpec
+	passwordLiteral bool
+	inputs          []textinput.Model
+	prompt          bool
+	ssl             bool
+	focus           int
+	err             string
+}
+
+// newProfileForm opens the form for base, or for a new profile when editing
+// is empty.
+func newProfileForm(editing string, base *pb.ProfileSpec, passwordLiteral bool) *profileForm {
+	if base == nil {
+		base = &pb.ProfileSpec{Port: defaultCQLPort}
+	}
+
+	f := &profileForm{
+		editing:         editing,
+		base:            base,
+		passwordLiteral: passwordLiteral,
+		prompt:          base.Prompt,
+		ssl:             base.SslEnabled,
+	}
+
+	port := ""
+	if base.Port != 0 {
+		port = strconv.Itoa(int(base.Port))
+	}
+
+	fields := []struct {
+		prompt, placeholder, value string
+	}{
+		fieldName:        {"Name:         ", "staging", base.Name},
+		fieldHosts:       {"Hosts:        ", "10.0.0.1,10.0.0.2", strings.Join(base.Hosts, ",")},
+		fieldPort:        {"Port:         ", "9042", port},
+		fieldKeyspace:    {"Keyspace:     ", "optional", base.Keyspace},
+		fieldUsername:    {"Username:     ", "optional", base.Username},
+		fieldPasswordEnv: {"Password env: ", "CASSANDRA_PASSWORD", base.PasswordEnv},
+		fieldBundle:      {"Astra bundle: ", "secure-connect.zip", base.SecureConnectBundle},
+		fieldSSLCA:       {"SSL CA:       ", "optional", base.SslCaPath},
+	}
+	if passwordLiteral {
+		fields[fieldPasswordEnv].placeholder = "keep stored password"
+	}
+	for _, field := range fields {
+		input := textinput.New()
+		input.Prompt = field.prompt
+		input.Placeholder = field.placeholder
+		input.CharLimit = 512
+		input.Width = 30
+		input.SetValue(field.value)
+		f.inputs = append(f.inputs, input)
+	}
+
+	if editing != "" {
+		f.focus = fieldHosts
+	}
+	f.inputs[f.focus].Focus()
+	return f
+}
+
+// Update handles a key and reports whether the form should be saved.
+func (f *profileForm) Update(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch msg.String() {
+	case "enter":
+		return nil, true
+	case "tab", "down":
+		f.move(1)
+		return nil, false
+	case "shift+tab", "up":
+		f.move(-1)
+		return nil, false
+	case " ":
+		switch f.focus {
+		case fieldPrompt:
+			f.prompt = !f.prompt
+			return nil, false
+		case fieldSSL:
+			f.ssl = !f.ssl
+			return nil, false
+		}
+	}
+
+	if f.focus >= len(f.inputs) {
+		return nil, false
+	}
+	var cmd tea.Cmd
+	f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+	return cmd, false
+}
+
+func (f *profileForm) move(delta int) {
+	if f.focus < len(f.inputs) {
+		f.inputs[f.focus].Blur()
+	}
+	for {
+		f.focus = (f.focus + delta + fieldCount) % fieldCount
+		// A profile's name cannot change once created.
+		if f.focus != fieldName || f.editing == "" {
+			break
+		}
+	}
+	if f.focus < len(f.inputs) {
+		f.inputs[f.focus].Focus()
+	}
+}
+
+func (f *profileForm) value(field int) string {
+	return strings.TrimSpace(f.inputs[field].Value())
+}
+
+// Spec returns the profile the form describes.
+func (f *profileForm) Spec() (*pb.ProfileSpec, error) {
+	spec := proto.Clone(f.base).(*pb.ProfileSpec)
+	spec.Name = f.value(fieldName)
+	if f.editing != "" {
+		spec.Name = f.editing
+	}
+	if spec.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	spec.Hosts = nil
+	for _, host := range strings.Split(f.value(fieldHosts), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			spec.Hosts = append(spec.Hosts, host)
+		}
+	}
+
+	spec.SecureConnectBundle = f.value(fieldBundle)
+	spec.Port = 0
+	if port := f.value(fieldPort); port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, fmt.Errorf("port must be a number")
+		}
+		spec.Port = int32(n)
+	} else if spec.SecureConnectBundle == "" {
+		spec.Port = defaultCQLPort
+	}
+
+	spec.Keyspace = f.value(fieldKeyspace)
+	spec.Username = f.value(fieldUsername)
+	spec.PasswordEnv = f.value(fieldPasswordEnv)
+	spec.SslCaPath = f.value(fieldSSLCA)
+	spec.Prompt = f.prompt
+	spec.SslEnabled = f.ssl
+	return spec, nil
+}
+
+func (f *profileForm) View(headingStyle lipgloss.Style) string {
+	heading := "New profile"
+	if f.editing != "" {
+		heading = "Edit " + f.editing
+	}
+
+	focused := lipgloss.NewStyle().Foreground(lipgloss.Color("51"))
+	checkbox := func(field int, label string, on bool) string {
+		box := "[ ] "
+		if on {
+			box = "[x] "
+		}
+		line := box + label
+		if f.focus == field {
+			return focused.Render(line)
+		}
+		return line
+	}
+
+	lines := []string{headingStyle.Render(heading), ""}
+	for i := range f.inputs {
+		if i == fieldName && f.editing != "" {
+			continue
+		}
+		lines = append(lines, f.inputs[i].View())
+	}
+	lines = append(lines,
+		checkbox(fieldPrompt, "Ask for credentials at login", f.prompt),
+		checkbox(fieldSSL, "Use TLS", f.ssl),
+	)
+	if f.err != "" {
+		lines = append(lines, "", lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(f.err))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
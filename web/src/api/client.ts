@@ -3,7 +3,7 @@ import type { AxiosInstance, InternalAxiosRequestConfig } from 'axios';
 import type { ApiError } from './types';
 import { useAuthStore } from '@/stores/authStore';
 
-const BASE_URL = import.meta.env.VITE_API_URL || '/api/v1';
+export const BASE_URL = import.meta.env.VITE_API_URL || '/api/v1';
 
 export const apiClient: AxiosInstance = axios.create({
   baseURL: BASE_URL,
@@ -84,9 +84,9 @@ apiClient.interceptors.response.use(
           refreshToken,
         });
 
-        const { accessToken, expiresAt } = response.data;
+        const { accessToken, refreshToken: rotatedToken, expiresAt } = response.data;
         const { setTokens } = useAuthStore.getState();
-        setTokens(accessToken, refreshToken, expiresAt);
+        setTokens(accessToken, rotatedToken || refreshToken, expiresAt);
 
         if (originalRequest.headers) {
           originalRequest.headers.Authorization = `Bearer ${accessToken}`;
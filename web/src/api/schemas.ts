@@ -6,10 +6,21 @@ export const ProfileInfoSchema = z.object({
   port: z.number(),
   keyspace: z.string().optional(),
   sslEnabled: z.boolean(),
+  credentialsRequired: z.boolean().optional(),
+  defaultDbUsername: z.string().optional(),
+  environment: z.string().optional(),
+  color: z.string().optional(),
+  pageSize: z.number().optional(),
+  defaultKeyspace: z.string().optional(),
+  defaultTable: z.string().optional(),
 });
 
 export const LoginRequestSchema = z.object({
   profile: z.string(),
+  username: z.string().optional(),
+  password: z.string().optional(),
+  dbUsername: z.string().optional(),
+  dbPassword: z.string().optional(),
 });
 
 export const LoginResponseSchema = z.object({
@@ -25,6 +36,7 @@ export const RefreshRequestSchema = z.object({
 
 export const RefreshResponseSchema = z.object({
   accessToken: z.string(),
+  refreshToken: z.string(),
   expiresAt: z.coerce.number().transform((val) => val * 1000),
 });
 
@@ -36,6 +48,8 @@ export const GetProfilesRequestSchema = z.object({});
 
 export const GetProfilesResponseSchema = z.object({
   profiles: z.array(ProfileInfoSchema),
+  authRequired: z.boolean().optional(),
+  ssoEnabled: z.boolean().optional(),
 });
 
 export const KeyspaceSchema = z.object({
@@ -4,10 +4,21 @@ export interface ProfileInfo {
   port: number;
   keyspace?: string;
   sslEnabled: boolean;
+  credentialsRequired?: boolean;
+  defaultDbUsername?: string;
+  environment?: string;
+  color?: string;
+  pageSize?: number;
+  defaultKeyspace?: string;
+  defaultTable?: string;
 }
 
 export interface LoginRequest {
   profile: string;
+  username?: string;
+  password?: string;
+  dbUsername?: string;
+  dbPassword?: string;
 }
 
 export interface LoginResponse {
@@ -23,6 +34,7 @@ export interface RefreshRequest {
 
 export interface RefreshResponse {
   accessToken: string;
+  refreshToken: string;
   expiresAt: number;
 }
 
@@ -34,6 +46,8 @@ export type GetProfilesRequest = Record<string, never>;
 
 export interface GetProfilesResponse {
   profiles: ProfileInfo[];
+  authRequired?: boolean;
+  ssoEnabled?: boolean;
 }
 
 export interface Keyspace {
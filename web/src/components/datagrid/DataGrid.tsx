@@ -4,6 +4,7 @@ import { List } from 'react-window';
 import { ChevronLeft, ChevronRight, Loader2, AlertCircle, Database } from 'lucide-react';
 import { dataApi, queryKeys, schemaApi } from '@/api/queries';
 import { useUiStore } from '@/stores/uiStore';
+import { useAuthStore } from '@/stores/authStore';
 import type { Row, CellValue } from '@/api/types';
 
 interface DataGridProps {
@@ -19,7 +20,9 @@ export function DataGrid({
   whereClause,
   onRowSelect,
 }: DataGridProps) {
-  const { pageSize } = useUiStore();
+  const { pageSize: defaultPageSize } = useUiStore();
+  const { profile } = useAuthStore();
+  const pageSize = profile?.pageSize || defaultPageSize;
   const [cursorId, setCursorId] = useState<string | null>(null);
   const [allRows, setAllRows] = useState<Row[]>([]);
   const [hasMore, setHasMore] = useState(false);
@@ -1,6 +1,7 @@
 import { Moon, Sun, Monitor, Menu, PanelRight, LogOut, Database } from 'lucide-react';
 import { useUiStore } from '@/stores/uiStore';
 import { useAuthStore } from '@/stores/authStore';
+import { EnvironmentBadge } from '@/components/statusbar/EnvironmentBadge';
 
 interface HeaderProps {
   onLogout?: () => void;
@@ -31,7 +32,8 @@ export function Header({ onLogout }: HeaderProps) {
       className="h-full flex items-center justify-between px-6"
       style={{
         background: 'var(--bg-elevated)',
-        borderBottom: '1px solid var(--border-primary)'
+        borderBottom: '1px solid var(--border-primary)',
+        borderTop: profile?.color ? `4px solid ${profile.color}` : undefined,
       }}
     >
       <div className="flex items-center gap-6">
@@ -65,6 +67,7 @@ export function Header({ onLogout }: HeaderProps) {
               <span className="font-mono text-sm" style={{ color: 'var(--text-tertiary)' }}>
                 {profile.name}
               </span>
+              <EnvironmentBadge profile={profile} />
             </>
           )}
         </div>
@@ -7,9 +7,10 @@ interface LayoutProps {
   sidebar: ReactNode;
   main: ReactNode;
   inspector: ReactNode;
+  statusBar?: ReactNode;
 }
 
-export function Layout({ header, sidebar, main, inspector }: LayoutProps) {
+export function Layout({ header, sidebar, main, inspector, statusBar }: LayoutProps) {
   const { sidebarCollapsed, inspectorCollapsed } = useUiStore();
 
   return (
@@ -76,6 +77,8 @@ export function Layout({ header, sidebar, main, inspector }: LayoutProps) {
           )}
         </Group>
       </div>
+
+      {statusBar && <footer className="h-7 flex-shrink-0">{statusBar}</footer>}
     </div>
   );
 }
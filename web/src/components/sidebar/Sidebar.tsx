@@ -7,7 +7,7 @@ import { useUiStore } from '@/stores/uiStore';
 export function Sidebar() {
   const { selectedKeyspace, selectedTable, setSelectedKeyspace, setSelectedTable } = useUiStore();
   const [expandedKeyspaces, setExpandedKeyspaces] = useState<Set<string>>(
-    new Set()
+    () => new Set(selectedKeyspace ? [selectedKeyspace] : [])
   );
 
   const { data: keyspacesData, isLoading } = useQuery({
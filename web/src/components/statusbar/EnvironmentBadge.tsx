@@ -0,0 +1,19 @@
+import { textOn } from '@/lib/utils';
+import type { ProfileInfo } from '@/api/types';
+
+interface EnvironmentBadgeProps {
+  profile: ProfileInfo;
+}
+
+export function EnvironmentBadge({ profile }: EnvironmentBadgeProps) {
+  if (!profile.environment || !profile.color) return null;
+
+  return (
+    <span
+      className="font-mono text-xs font-bold px-2 py-0.5 rounded"
+      style={{ background: profile.color, color: textOn(profile.color) }}
+    >
+      {profile.environment.toUpperCase()}
+    </span>
+  );
+}
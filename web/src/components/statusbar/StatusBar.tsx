@@ -0,0 +1,32 @@
+import { useAuthStore } from '@/stores/authStore';
+import { useUiStore } from '@/stores/uiStore';
+import { textOn } from '@/lib/utils';
+
+// StatusBar shows where the explorer is, filled with the profile's color so
+// that a prod session is hard to mistake.
+export function StatusBar() {
+  const { profile } = useAuthStore();
+  const { selectedKeyspace, selectedTable } = useUiStore();
+
+  if (!profile) return null;
+
+  const path = [profile.name, selectedKeyspace, selectedKeyspace && selectedTable]
+    .filter(Boolean)
+    .join(' › ');
+  const style = profile.color
+    ? { background: profile.color, color: textOn(profile.color) }
+    : {
+        background: 'var(--bg-elevated)',
+        color: 'var(--text-secondary)',
+        borderTop: '1px solid var(--border-primary)',
+      };
+
+  return (
+    <div className="h-full flex items-center gap-3 px-6 font-mono text-xs" style={style}>
+      {profile.environment && (
+        <span className="font-bold">{profile.environment.toUpperCase()}</span>
+      )}
+      <span>{path}</span>
+    </div>
+  );
+}
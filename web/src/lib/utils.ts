@@ -4,3 +4,10 @@ import { twMerge } from "tailwind-merge"
 export function cn(...inputs: ClassValue[]) {
   return twMerge(clsx(inputs))
 }
+
+// textOn returns black or white, whichever reads better on a #rrggbb color.
+export function textOn(color: string) {
+  const rgb = parseInt(color.slice(1), 16)
+  const luma = 299 * ((rgb >> 16) & 0xff) + 587 * ((rgb >> 8) & 0xff) + 114 * (rgb & 0xff)
+  return luma > 150000 ? "#000000" : "#ffffff"
+}
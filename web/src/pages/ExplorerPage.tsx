@@ -8,6 +8,7 @@ import { Sidebar } from '@/components/sidebar/Sidebar';
 import { FilterBar } from '@/components/filterbar/FilterBar';
 import { DataGrid } from '@/components/datagrid/DataGrid';
 import { Inspector } from '@/components/inspector/Inspector';
+import { StatusBar } from '@/components/statusbar/StatusBar';
 import { useUiStore } from '@/stores/uiStore';
 import { useAuthStore } from '@/stores/authStore';
 import { useToastStore } from '@/stores/toastStore';
@@ -126,6 +127,7 @@ export function ExplorerPage() {
         </div>
       }
       inspector={<Inspector row={selectedRow} />}
+      statusBar={<StatusBar />}
     />
   );
 }
@@ -1,17 +1,25 @@
-import { useState } from 'react';
+import { useEffect, useState } from 'react';
 import { useNavigate } from 'react-router-dom';
 import { useQuery, useMutation } from '@tanstack/react-query';
 import { Database, Loader2, Server, Shield } from 'lucide-react';
+import { BASE_URL } from '@/api/client';
 import { sessionApi } from '@/api/queries';
 import { useAuthStore } from '@/stores/authStore';
+import { useUiStore } from '@/stores/uiStore';
 import { useToastStore } from '@/stores/toastStore';
 import type { ProfileInfo } from '@/api/types';
 
 export function LoginPage() {
   const navigate = useNavigate();
   const { setTokens, setProfile } = useAuthStore();
+  const { openProfileDefaults } = useUiStore();
   const { success, error } = useToastStore();
   const [selectedProfile, setSelectedProfile] = useState<string>('');
+  const [username, setUsername] = useState<string>('');
+  const [password, setPassword] = useState<string>('');
+  const [dbProfile, setDbProfile] = useState<ProfileInfo | null>(null);
+  const [dbUsername, setDbUsername] = useState<string>('');
+  const [dbPassword, setDbPassword] = useState<string>('');
 
   const { data: profilesData, isLoading: loadingProfiles } = useQuery({
     queryKey: ['profiles'],
@@ -21,8 +29,10 @@ export function LoginPage() {
   const loginMutation = useMutation({
     mutationFn: sessionApi.login,
     onSuccess: (data) => {
+      setDbPassword('');
       setTokens(data.accessToken, data.refreshToken, data.expiresAt);
       setProfile(data.profile);
+      openProfileDefaults(data.profile);
       success(`Connected to ${data.profile.name}`);
       
       queueMicrotask(() => {
@@ -34,10 +44,66 @@ export function LoginPage() {
     },
   });
 
+  const authRequired = profilesData?.authRequired ?? false;
+  const ssoEnabled = profilesData?.ssoEnabled ?? false;
+
+  // The server finishes single sign-on by redirecting here with the tokens in
+  // the URL fragment, which never leaves the browser.
+  useEffect(() => {
+    if (!profilesData || !window.location.hash) return;
+
+    const params = new URLSearchParams(window.location.hash.slice(1));
+    const accessToken = params.get('access_token');
+    const ssoError = params.get('error');
+    if (!accessToken && !ssoError) return;
+
+    window.history.replaceState(null, '', window.location.pathname + window.location.search);
+
+    if (ssoError) {
+      error(`Single sign-on failed: ${ssoError}`);
+      return;
+    }
+
+    const profile = profilesData.profiles.find((p) => p.name === params.get('profile'));
+    if (!accessToken || !profile) {
+      error('Single sign-on returned an unknown profile');
+      return;
+    }
+
+    setTokens(accessToken, params.get('refresh_token') ?? '', Number(params.get('expires_at')) * 1000);
+    setProfile(profile);
+    openProfileDefaults(profile);
+    success(`Connected to ${profile.name}`);
+    navigate('/explorer');
+  }, [profilesData, setTokens, setProfile, openProfileDefaults, success, error, navigate]);
+
   const handleLogin = (profile: ProfileInfo) => {
+    // Profiles with auth.prompt take the user's own database credentials,
+    // which are sent with this login only and never stored.
+    if (profile.credentialsRequired && dbProfile?.name !== profile.name) {
+      setDbProfile(profile);
+      setDbUsername(profile.defaultDbUsername ?? '');
+      setDbPassword('');
+      return;
+    }
+    if (profile.credentialsRequired && (!dbUsername || !dbPassword)) {
+      error('Enter your database username and password');
+      return;
+    }
+    if (ssoEnabled && !username && !password && !profile.credentialsRequired) {
+      setSelectedProfile(profile.name);
+      window.location.assign(`${BASE_URL}/auth/oidc/login?profile=${encodeURIComponent(profile.name)}`);
+      return;
+    }
+    if (authRequired && (!username || !password)) {
+      error('Enter your username and password first');
+      return;
+    }
     setSelectedProfile(profile.name);
     loginMutation.mutate({
       profile: profile.name,
+      ...(authRequired ? { username, password } : {}),
+      ...(profile.credentialsRequired ? { dbUsername, dbPassword } : {}),
     });
   };
 
@@ -130,6 +196,119 @@ export function LoginPage() {
           </div>
         </div>
 
+        {authRequired && (
+          <div
+            className="rounded-2xl p-6 space-y-4"
+            style={{
+              background: 'var(--bg-secondary)',
+              border: '2px solid var(--border-primary)'
+            }}
+          >
+            <p className="font-mono text-sm uppercase tracking-widest" style={{ color: 'var(--text-secondary)' }}>
+              Sign in
+            </p>
+            {ssoEnabled && (
+              <p className="text-sm font-sans" style={{ color: 'var(--text-tertiary)' }}>
+                Leave both fields empty and pick a profile to sign in with your organization's SSO.
+              </p>
+            )}
+            <input
+              type="text"
+              autoComplete="username"
+              placeholder="Username"
+              value={username}
+              onChange={(e) => setUsername(e.target.value)}
+              className="w-full rounded-lg px-4 py-3 font-mono text-base outline-none"
+              style={{
+                background: 'var(--bg-elevated)',
+                border: '1px solid var(--border-primary)',
+                color: 'var(--text-primary)'
+              }}
+            />
+            <input
+              type="password"
+              autoComplete="current-password"
+              placeholder="Password"
+              value={password}
+              onChange={(e) => setPassword(e.target.value)}
+              className="w-full rounded-lg px-4 py-3 font-mono text-base outline-none"
+              style={{
+                background: 'var(--bg-elevated)',
+                border: '1px solid var(--border-primary)',
+                color: 'var(--text-primary)'
+              }}
+            />
+          </div>
+        )}
+
+        {dbProfile && (
+          <form
+            className="rounded-2xl p-6 space-y-4"
+            style={{
+              background: 'var(--bg-secondary)',
+              border: '2px solid var(--accent-primary)'
+            }}
+            onSubmit={(e) => {
+              e.preventDefault();
+              handleLogin(dbProfile);
+            }}
+          >
+            <p className="font-mono text-sm uppercase tracking-widest" style={{ color: 'var(--text-secondary)' }}>
+              Database credentials for {dbProfile.name}
+            </p>
+            <p className="text-sm font-sans" style={{ color: 'var(--text-tertiary)' }}>
+              This profile connects with your own database role. Your password is used for this session only.
+            </p>
+            <input
+              type="text"
+              autoComplete="off"
+              placeholder="Database username"
+              value={dbUsername}
+              onChange={(e) => setDbUsername(e.target.value)}
+              className="w-full rounded-lg px-4 py-3 font-mono text-base outline-none"
+              style={{
+                background: 'var(--bg-elevated)',
+                border: '1px solid var(--border-primary)',
+                color: 'var(--text-primary)'
+              }}
+            />
+            <input
+              type="password"
+              autoComplete="off"
+              placeholder="Database password"
+              value={dbPassword}
+              onChange={(e) => setDbPassword(e.target.value)}
+              className="w-full rounded-lg px-4 py-3 font-mono text-base outline-none"
+              style={{
+                background: 'var(--bg-elevated)',
+                border: '1px solid var(--border-primary)',
+                color: 'var(--text-primary)'
+              }}
+            />
+            <div className="flex gap-3">
+              <button
+                type="submit"
+                disabled={loginMutation.isPending}
+                className="rounded-lg px-5 py-2 font-mono text-sm disabled:opacity-50"
+                style={{ background: 'var(--accent-primary)', color: 'white' }}
+              >
+                Connect
+              </button>
+              <button
+                type="button"
+                onClick={() => {
+                  setDbProfile(null);
+                  setDbPassword('');
+                }}
+                className="rounded-lg px-5 py-2 font-mono text-sm"
+                style={{ border: '1px solid var(--border-primary)', color: 'var(--text-secondary)' }}
+              >
+                Cancel
+              </button>
+            </div>
+          </form>
+        )}
+
         {/* Profiles - MUCH LARGER CARDS */}
         <div className="space-y-5">
           {profiles.length === 0 ? (
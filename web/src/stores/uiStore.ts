@@ -1,5 +1,6 @@
 import { create } from 'zustand';
 import { persist } from 'zustand/middleware';
+import type { ProfileInfo } from '@/api/types';
 
 type Theme = 'light' | 'dark' | 'system';
 
@@ -23,6 +24,7 @@ interface UiActions {
   setPageSize: (size: number) => void;
   setSelectedKeyspace: (keyspace: string | null) => void;
   setSelectedTable: (table: string | null) => void;
+  openProfileDefaults: (profile: ProfileInfo) => void;
 }
 
 const initialState: UiState = {
@@ -76,6 +78,13 @@ export const useUiStore = create<UiState & UiActions>()(
       setSelectedTable: (table) => {
         set({ selectedTable: table });
       },
+
+      openProfileDefaults: (profile) => {
+        set({
+          selectedKeyspace: profile.defaultKeyspace || null,
+          selectedTable: (profile.defaultKeyspace && profile.defaultTable) || null,
+        });
+      },
     }),
     {
       name: 'ui-storage',